@@ -0,0 +1,235 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+
+	"github.com/YLivay/gote/input"
+	"github.com/YLivay/gote/logging"
+	"github.com/YLivay/gote/view"
+)
+
+// runCLI runs the gote command with the given command line arguments, not
+// including the program's name, until the user quits it.
+func runCLI(args []string) error {
+	ctx, cancelCtx := context.WithCancel(context.Background())
+
+	cleanupOsSignals := setupOsSignals(ctx, cancelCtx)
+	defer cleanupOsSignals()
+
+	config, err := view.ParseFlags(args, os.Stderr)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return fmt.Errorf("failed to parse arguments: %w", err)
+	}
+	if config.LogFormat != logging.Text {
+		// The log package's logger writes through the default one.
+		slog.SetDefault(slog.New(logging.NewHandler(os.Stderr, config.LogFormat)).With("component", "main"))
+	}
+
+	var debugLog io.Writer = io.Discard
+	if config.DebugLog {
+		path, err := debugLogPath()
+		if err != nil {
+			return err
+		}
+		l, err := openRotatingLog(path, int64(config.DebugLogMaxMB)<<20)
+		if err != nil {
+			return fmt.Errorf("failed to open debug log: %w", err)
+		}
+		defer l.Close()
+		debugLog = l
+	}
+
+	if config.SpillDir != "" {
+		if err := os.MkdirAll(config.SpillDir, 0o700); err != nil {
+			return fmt.Errorf("failed to create spill directory: %w", err)
+		}
+	}
+
+	// The inputs log what happens to them while they are read through the
+	// same loggers as the buffer, which keep them for the debug panel rather
+	// than writing them over the screen. Without the screen, they are written
+	// to stderr as well.
+	headless := config.NoTUI || (!config.TUI && !isTerminal(os.Stdout))
+	logRing := logging.NewRing(view.LogRingSize)
+	logOut := io.MultiWriter(debugLog, logRing)
+	if headless {
+		logOut = io.MultiWriter(debugLog, os.Stderr)
+	}
+	logger := logging.New(logOut, config.LogFormat, config.LogLevels)
+	input.RemoveOrphanedSpillFiles(config.SpillDir, logger.Named("spool"))
+
+	notices := &input.Notices{}
+	reader, spool, cleanupReader, err := input.Open(input.Options{
+		Inputs:          config.Inputs,
+		Listen:          config.Listen,
+		Journal:         config.Journal,
+		JournalUnits:    config.JournalUnits,
+		JournalPriority: config.JournalPriority,
+		FollowObject:    config.FollowObject,
+		NoRotated:       config.NoRotated,
+		Delimiter:       config.Delimiter,
+		Encoding:        config.Encoding,
+		MaxSpill:        int64(config.MaxSpillMB) << 20,
+		SpillPolicy:     config.SpillPolicy,
+		SpillDir:        config.SpillDir,
+	}, logger, notices)
+	if err != nil {
+		return fmt.Errorf("failed to prepare reader: %w", err)
+	}
+	defer cleanupReader()
+
+	if spool != nil && config.SpillPolicy != input.SpillPause {
+		// Dropping old input replaces the spill file, which a window falls
+		// back to where it can't drop it in place, so it must be followed by
+		// name.
+		config.FollowName = true
+	}
+
+	if headless {
+		err := view.RunHeadless(ctx, reader, spool, config, debugLog, os.Stdout)
+		if err != nil && !errors.Is(err, context.Canceled) {
+			return fmt.Errorf("failed to print records: %w", err)
+		}
+		return nil
+	}
+
+	application := view.NewApplication(reader, true, config)
+	application.SetSpool(spool, notices)
+	application.SetLog(debugLog, logRing)
+	if spool == nil && !config.NoResume && len(config.Inputs) == 1 && config.Inputs[0] != "-" {
+		// Only the offsets of a regular file read directly stay the same
+		// the next time it is read.
+		if info, err := reader.Stat(); err == nil && info.Mode().IsRegular() {
+			if path, err := filepath.Abs(config.Inputs[0]); err == nil {
+				application.SetInputPath(path)
+			}
+		}
+	}
+	if err = application.Run(ctx, cancelCtx); err != nil && err != context.Canceled {
+		return fmt.Errorf("failed to run application: %w", err)
+	}
+	// The marked records are printed once the screen is closed, so they are
+	// left on the terminal or can be piped onward.
+	if err := application.PrintMarks(os.Stdout); err != nil {
+		return fmt.Errorf("failed to print marked records: %w", err)
+	}
+
+	// go func() {
+	// 	for {
+	// 		// Update screen
+	// 		screen.Show()
+
+	// 		// Poll event
+	// 		ev := screen.PollEvent()
+
+	// 		// Process event
+	// 		switch ev := ev.(type) {
+	// 		case *tcell.EventResize:
+	// 			screen.Sync()
+	// 		case *tcell.EventKey:
+	// 			if ev.Key() == tcell.KeyEscape || ev.Key() == tcell.KeyCtrlC || ev.Rune() == 'q' {
+	// 				cancelCtx()
+	// 				return
+	// 			}
+	// 		}
+	// 	}
+	// }()
+
+	// <-ctx.Done()
+
+	// // Check if os.Stdin is a tty. If it isn't, we need to initialize a new one for user input.
+	// tty, cleanupTty, err := ensureTty()
+	// if err != nil {
+	// 	return errors.New("Failed to ensure tty: " + err.Error())
+	// }
+	// defer cleanupTty()
+
+	// go func() {
+	// 	// Read keys from the tty and send them to the program
+	// 	for {
+	// 		r, err := tty.ReadRune()
+	// 		if err != nil {
+	// 			log.Println("Failed to read from /dev/tty:", err)
+	// 			return
+	// 		}
+	// 		log.Println("Read rune:", r, string(r))
+
+	// 		switch r {
+	// 		case 'q':
+	// 			cancelCtx()
+	// 			return
+	// 		}
+	// 	}
+	// }()
+
+	// // p := tea.NewProgram(AppState{reader: reader}, tea.WithContext(ctx))
+	// // if _, err := p.Run(); err != nil {
+	// // 	log.Fatalln(err.Error())
+	// // }
+
+	// // Sleep for a bit
+	// select {
+	// case <-time.After(30 * time.Second):
+	// case <-ctx.Done():
+	// 	log.Println("Sleep interrupted")
+	// }
+
+	// b := make([]byte, 10)
+	// reader.Seek(0, io.SeekStart)
+	// _, err = reader.Read(b)
+	// if err != nil {
+	// 	log.Println("Failed to read file:", err)
+	// }
+	// log.Println(string(b))
+	return nil
+}
+
+func setupOsSignals(ctx context.Context, cancelCtx context.CancelFunc) (cleanup func()) {
+	// Catch ctrl+c signal and make it close the context instead of immediately
+	// exiting. This allows us to do some cleanup.
+	signalChan := make(chan os.Signal, 1)
+	signal.Notify(signalChan, os.Interrupt)
+
+	cleanup = func() {
+		signal.Stop(signalChan)
+		cancelCtx()
+	}
+
+	go func() {
+		for {
+			select {
+			case <-signalChan:
+				if view.InterruptsIgnored() {
+					// A command gote runs on the terminal was
+					// interrupted instead.
+					continue
+				}
+				log.Println("Ctrl+C pressed")
+				cancelCtx()
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return cleanup
+}
+
+// isTerminal reports whether the given file is a terminal.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// The name of the debug log within the cache directory. When it is rotated,
+// the previous one is kept with a ".1" suffix.
+const debugLogFname = "debug.log"
+
+// debugLogPath returns the path the debug log is written to, in the user's
+// cache directory.
+func debugLogPath() (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to determine user cache directory: %w", err)
+	}
+	return filepath.Join(cacheDir, "gote", debugLogFname), nil
+}
+
+// rotatingLog is a log file that is rotated once it would grow beyond its
+// maximum size: it is renamed with a ".1" suffix, replacing the one rotated
+// before it, and a new one is started.
+type rotatingLog struct {
+	mu   sync.Mutex
+	path string
+	// The most bytes the file may hold, or 0 for unlimited.
+	maxSize int64
+	f       *os.File
+	size    int64
+}
+
+// openRotatingLog opens the log file at the given path for appending, creating
+// it and its directory if needed.
+func openRotatingLog(path string, maxSize int64) (*rotatingLog, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create log directory: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &rotatingLog{path: path, maxSize: maxSize, f: f, size: info.Size()}, nil
+}
+
+func (l *rotatingLog) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.f == nil {
+		return 0, os.ErrClosed
+	}
+	if l.maxSize > 0 && l.size > 0 && l.size+int64(len(p)) > l.maxSize {
+		if err := l.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := l.f.Write(p)
+	l.size += int64(n)
+	return n, err
+}
+
+// rotate moves the log file aside and starts a new one in its place.
+func (l *rotatingLog) rotate() error {
+	l.f.Close()
+	l.f = nil
+	if err := os.Rename(l.path, l.path+".1"); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	f, err := os.OpenFile(l.path, os.O_CREATE|os.O_TRUNC|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	l.f, l.size = f, 0
+	return nil
+}
+
+// Close closes the log file. Writing to it afterwards fails.
+func (l *rotatingLog) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.f == nil {
+		return nil
+	}
+	err := l.f.Close()
+	l.f = nil
+	return err
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRotatingLog_RotatesWhenFull(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gote", debugLogFname)
+	l, err := openRotatingLog(path, 10)
+	assert.NoError(t, err)
+	defer l.Close()
+
+	for _, line := range []string{"first\n", "second\n", "third\n"} {
+		_, err := l.Write([]byte(line))
+		assert.NoError(t, err)
+	}
+
+	contents, _ := os.ReadFile(path)
+	assert.EqualValues(t, "third\n", string(contents))
+	rotated, _ := os.ReadFile(path + ".1")
+	assert.EqualValues(t, "second\n", string(rotated))
+}
+
+func TestRotatingLog_AppendsToExistingLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), debugLogFname)
+	assert.NoError(t, os.WriteFile(path, []byte("before\n"), 0644))
+
+	l, err := openRotatingLog(path, 0)
+	assert.NoError(t, err)
+	_, err = l.Write([]byte("after\n"))
+	assert.NoError(t, err)
+	assert.NoError(t, l.Close())
+
+	contents, _ := os.ReadFile(path)
+	assert.EqualValues(t, "before\nafter\n", string(contents))
+	_, err = l.Write([]byte("closed\n"))
+	assert.ErrorIs(t, err, os.ErrClosed)
+}
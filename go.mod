@@ -3,10 +3,15 @@ module github.com/YLivay/gote
 go 1.22
 
 require (
+	github.com/fsnotify/fsnotify v1.7.0
 	github.com/gdamore/tcell/v2 v2.7.3
+	github.com/gorilla/websocket v1.5.3
 	github.com/itchyny/gojq v0.12.15
+	github.com/klauspost/compress v1.17.11
 	github.com/rivo/uniseg v0.4.7
 	github.com/stretchr/testify v1.8.4
+	github.com/yuin/gopher-lua v1.1.1
+	golang.org/x/text v0.14.0
 )
 
 require (
@@ -18,6 +23,5 @@ require (
 	github.com/pmezard/go-difflib v1.0.0 // indirect
 	golang.org/x/sys v0.18.0 // indirect
 	golang.org/x/term v0.17.0 // indirect
-	golang.org/x/text v0.14.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
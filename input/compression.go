@@ -0,0 +1,79 @@
+package input
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// compression is a format inputs may be compressed in, detected by the magic
+// bytes they start with.
+type compression struct {
+	name  string
+	magic []byte
+	// newReader returns a reader of the decompressed contents of r.
+	newReader func(r io.Reader) (io.Reader, error)
+}
+
+var compressions = []*compression{
+	{
+		name:  "gzip",
+		magic: []byte{0x1f, 0x8b},
+		newReader: func(r io.Reader) (io.Reader, error) {
+			return gzip.NewReader(r)
+		},
+	},
+	{
+		name:  "bzip2",
+		magic: []byte("BZh"),
+		newReader: func(r io.Reader) (io.Reader, error) {
+			return bzip2.NewReader(r), nil
+		},
+	},
+	{
+		name:  "zstd",
+		magic: []byte{0x28, 0xb5, 0x2f, 0xfd},
+		newReader: func(r io.Reader) (io.Reader, error) {
+			// A single goroutine decodes synchronously, so the decoder holds
+			// on to nothing that needs closing.
+			return zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+		},
+	},
+}
+
+// maxMagicLen is the most bytes the magic bytes of a compression format span.
+const maxMagicLen = 4
+
+// detectCompression returns the compression format of an input that starts with
+// the given bytes, or nil if it isn't compressed in a known format.
+func detectCompression(start []byte) *compression {
+	for _, c := range compressions {
+		if bytes.HasPrefix(start, c.magic) {
+			return c
+		}
+	}
+	return nil
+}
+
+// decompressInput peeks at the start of an input that can't be seeked to detect
+// whether it is compressed. It returns a reader of its decompressed contents and
+// the format it was compressed in, or a reader of the input as it is and nil if
+// it isn't compressed.
+func decompressInput(input io.Reader) (io.Reader, *compression, error) {
+	buffered := bufio.NewReader(input)
+	start, _ := buffered.Peek(maxMagicLen)
+	c := detectCompression(start)
+	if c == nil {
+		return buffered, nil, nil
+	}
+
+	decompressed, err := c.newReader(buffered)
+	if err != nil {
+		return nil, nil, err
+	}
+	return decompressed, c, nil
+}
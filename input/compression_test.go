@@ -0,0 +1,52 @@
+package input
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecompressInput_DetectsFormatsByMagicBytes(t *testing.T) {
+	contents := `{"a":1}` + "\n"
+
+	var gzipped bytes.Buffer
+	gw := gzip.NewWriter(&gzipped)
+	gw.Write([]byte(contents))
+	gw.Close()
+
+	zw, err := zstd.NewWriter(nil)
+	assert.NoError(t, err)
+	zstded := zw.EncodeAll([]byte(contents), nil)
+
+	// The same contents, as compressed by the bzip2 command.
+	bzipped := []byte{
+		0x42, 0x5a, 0x68, 0x39, 0x31, 0x41, 0x59, 0x26, 0x53, 0x59, 0xba, 0xc6,
+		0x4d, 0xdb, 0x00, 0x00, 0x03, 0x59, 0x80, 0x00, 0x10, 0x10, 0x00, 0x20,
+		0x10, 0x20, 0x00, 0x00, 0x0a, 0x20, 0x00, 0x22, 0x03, 0x65, 0x08, 0x60,
+		0x11, 0x4a, 0x1f, 0x17, 0x72, 0x45, 0x38, 0x50, 0x90, 0xba, 0xc6, 0x4d,
+		0xdb,
+	}
+
+	for name, input := range map[string][]byte{
+		"gzip":  gzipped.Bytes(),
+		"zstd":  zstded,
+		"bzip2": bzipped,
+		"":      []byte(contents),
+	} {
+		r, c, err := decompressInput(bytes.NewReader(input))
+		assert.NoError(t, err, name)
+		if name == "" {
+			assert.Nil(t, c)
+		} else if assert.NotNil(t, c, name) {
+			assert.EqualValues(t, name, c.name)
+		}
+
+		decompressed, err := io.ReadAll(r)
+		assert.NoError(t, err, name)
+		assert.EqualValues(t, contents, string(decompressed), name)
+	}
+}
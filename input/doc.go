@@ -0,0 +1,15 @@
+// Package input opens what gote reads, and turns inputs that can't be seeked
+// into files that can, so the view package's Buffer can read them around the
+// screen in both directions.
+//
+// Open opens regular files directly. Stdin and pipes, several files merged
+// into one input, inputs received over the network, streamed from a URL or
+// WebSocket, downloaded from object storage, tailed over SSH, consumed from
+// Kafka or read from the systemd journal, and inputs that are compressed or
+// transcoded, are copied by a Spool into a temporary spill file instead, which
+// is read as it grows. Its SpillPolicy decides what happens once the spill file
+// is full.
+//
+// What happens to the inputs while they are read is logged with the logging
+// package, and what fails is passed on to whoever shows it through Notices.
+package input
@@ -0,0 +1,39 @@
+package input
+
+import (
+	"bytes"
+	"io"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+// transcodeInput returns a reader of the input transcoded from the given
+// encoding to UTF-8. A byte order mark at the start of the input overrides the
+// encoding.
+func transcodeInput(input io.Reader, enc encoding.Encoding) io.Reader {
+	return transform.NewReader(input, unicode.BOMOverride(enc.NewDecoder()))
+}
+
+// The byte order marks of UTF-16 inputs.
+var (
+	utf16LEBOM = []byte{0xff, 0xfe}
+	utf16BEBOM = []byte{0xfe, 0xff}
+)
+
+// maxBOMLen is the most bytes a byte order mark spans.
+const maxBOMLen = 3
+
+// encodingOfBOM returns the encoding hinted at by the byte order mark an input
+// starts with, or nil if it has none or it is UTF-8, which needs no
+// transcoding.
+func encodingOfBOM(start []byte) encoding.Encoding {
+	switch {
+	case bytes.HasPrefix(start, utf16LEBOM):
+		return unicode.UTF16(unicode.LittleEndian, unicode.ExpectBOM)
+	case bytes.HasPrefix(start, utf16BEBOM):
+		return unicode.UTF16(unicode.BigEndian, unicode.ExpectBOM)
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package input
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/text/encoding/htmlindex"
+)
+
+func TestTranscodeInput_ConvertsToUTF8(t *testing.T) {
+	contents := `{"name":"café"}` + "\n"
+
+	for name, input := range map[string][]byte{
+		"latin1": []byte("{\"name\":\"caf\xe9\"}\n"),
+		"utf-16le": {
+			'{', 0, '"', 0, 'n', 0, 'a', 0, 'm', 0, 'e', 0, '"', 0, ':', 0,
+			'"', 0, 'c', 0, 'a', 0, 'f', 0, 0xe9, 0, '"', 0, '}', 0, '\n', 0,
+		},
+		// A byte order mark overrides the encoding that was asked for.
+		"utf-16be": {
+			0xff, 0xfe,
+			'{', 0, '"', 0, 'n', 0, 'a', 0, 'm', 0, 'e', 0, '"', 0, ':', 0,
+			'"', 0, 'c', 0, 'a', 0, 'f', 0, 0xe9, 0, '"', 0, '}', 0, '\n', 0,
+		},
+	} {
+		enc, err := htmlindex.Get(name)
+		if !assert.NoError(t, err, name) {
+			continue
+		}
+
+		transcoded, err := io.ReadAll(transcodeInput(bytes.NewReader(input), enc))
+		assert.NoError(t, err, name)
+		assert.EqualValues(t, contents, string(transcoded), name)
+	}
+}
+
+func TestEncodingOfBOM_HintsAtUTF16(t *testing.T) {
+	assert.Nil(t, encodingOfBOM(nil))
+	assert.Nil(t, encodingOfBOM([]byte("{}")))
+	assert.Nil(t, encodingOfBOM([]byte("\xef\xbb\xbf{}")))
+
+	for _, start := range [][]byte{{0xff, 0xfe, '{', 0}, {0xfe, 0xff, 0, '{'}} {
+		enc := encodingOfBOM(start)
+		if assert.NotNil(t, enc) {
+			transcoded, err := io.ReadAll(transcodeInput(bytes.NewReader(start), enc))
+			assert.NoError(t, err)
+			assert.EqualValues(t, "{", string(transcoded))
+		}
+	}
+}
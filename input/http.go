@@ -0,0 +1,233 @@
+package input
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/YLivay/gote/logging"
+)
+
+// The delays before reconnecting to an HTTP input. The delay doubles with every
+// attempt that receives no records, up to the maximum, and is reset once one
+// does. Server-sent events may change the default.
+const (
+	defaultReconnectDelay = time.Second
+	maxReconnectDelay     = 30 * time.Second
+)
+
+// isURLInput returns true if the given input is an HTTP or HTTPS URL to stream
+// records from.
+func isURLInput(input string) bool {
+	return strings.HasPrefix(input, "http://") || strings.HasPrefix(input, "https://")
+}
+
+// httpInput streams records from an HTTP URL into one input, which is followed
+// like stdin is. The response is read either as server-sent events, each of
+// which is a record, or as records that end with the delimiter, like NDJSON.
+//
+// When the response ends or fails, the URL is requested again after a delay
+// that backs off while it keeps failing. Server-sent events are resumed from
+// the last event ID they gave.
+type httpInput struct {
+	url    string
+	delim  []byte
+	logger *logging.Logger
+
+	pr *io.PipeReader
+	pw *io.PipeWriter
+
+	// How long to wait before reconnecting. Server-sent events may set it.
+	delay time.Duration
+	// The ID of the last server-sent event, sent when reconnecting so the
+	// events continue from it.
+	lastEventID string
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// newHTTPInput starts streaming records from the given URL. Records are split
+// with the given delimiter, or newlines if it is empty. Failures to stream them
+// are logged to the given logger.
+func newHTTPInput(url string, delim []byte, logger *logging.Logger) *httpInput {
+	if len(delim) == 0 {
+		delim = []byte{'\n'}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	h := &httpInput{
+		url:    url,
+		delim:  delim,
+		logger: logger,
+		delay:  defaultReconnectDelay,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	h.pr, h.pw = io.Pipe()
+
+	go h.run(ctx)
+	return h
+}
+
+// Read implements io.Reader.
+func (h *httpInput) Read(p []byte) (int, error) {
+	n, err := h.pr.Read(p)
+	if errors.Is(err, io.ErrClosedPipe) {
+		// Closing the HTTP input ends it.
+		err = io.EOF
+	}
+	return n, err
+}
+
+// Close stops streaming records.
+func (h *httpInput) Close() error {
+	h.cancel()
+	// Whoever read the HTTP input may have stopped, so unblock writes to it.
+	h.pr.Close()
+	<-h.done
+	return nil
+}
+
+// run streams the records of the URL into the input, reconnecting whenever
+// the response ends, until the context is done or the input is closed.
+func (h *httpInput) run(ctx context.Context) {
+	defer close(h.done)
+	defer h.pw.Close()
+
+	reconnect(ctx, h.url, h.logger, func() time.Duration { return h.delay }, h.stream)
+}
+
+// reconnect calls stream to stream the records of the given URL again whenever
+// it returns, until the context is done or the input it streams into is
+// closed. Between calls, it waits for the delay, which doubles while stream
+// receives no records. Failures and reconnects are logged to the given logger
+// rather than stderr, which the viewer may be drawing on.
+func reconnect(ctx context.Context, url string, logger *logging.Logger, delay func() time.Duration, stream func(context.Context) (received bool, err error)) {
+	failures := 0
+	for {
+		received, err := stream(ctx)
+		if errors.Is(err, io.ErrClosedPipe) || ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			logger.Warn("failed to stream", url+":", err.Error())
+		}
+
+		if received {
+			failures = 0
+		}
+		wait := delay()
+		for i := 0; i < failures && wait < maxReconnectDelay; i++ {
+			wait = min(wait*2, maxReconnectDelay)
+		}
+		failures++
+		logger.Info("reconnecting to", url, "in", wait)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+// stream requests the URL once and copies the records of its response into the
+// input, returning whether any were.
+func (h *httpInput) stream(ctx context.Context) (received bool, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, h.url, nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Accept", "text/event-stream, application/x-ndjson;q=0.9, */*;q=0.8")
+	if h.lastEventID != "" {
+		req.Header.Set("Last-Event-ID", h.lastEventID)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return false, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mediaType == "text/event-stream" {
+		return h.copyEvents(resp.Body)
+	}
+	return h.copyRecords(resp.Body)
+}
+
+// copyRecords copies the records of the given body into the input. Its last
+// record is copied when it ends, even if it doesn't end with the delimiter.
+func (h *httpInput) copyRecords(body io.Reader) (received bool, err error) {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(nil, maxNetworkRecordLen)
+	scanner.Split(splitOn(h.delim))
+	for scanner.Scan() {
+		if _, err := h.pw.Write(append(scanner.Bytes(), h.delim...)); err != nil {
+			return received, err
+		}
+		received = true
+	}
+	return received, scanner.Err()
+}
+
+// copyEvents copies the data of the server-sent events of the given body into
+// the input, an event per record. An event that the body ends in the middle of
+// is dropped.
+func (h *httpInput) copyEvents(body io.Reader) (received bool, err error) {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(nil, maxNetworkRecordLen)
+
+	var data []byte
+	hasData := false
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			// An empty line ends the event, which is only a record if it
+			// has data.
+			if len(data) > 0 {
+				if _, err := h.pw.Write(append(data, h.delim...)); err != nil {
+					return received, err
+				}
+				received = true
+			}
+			data, hasData = data[:0], false
+			continue
+		}
+		if line[0] == ':' {
+			// A comment, usually sent to keep the connection alive.
+			continue
+		}
+
+		field, value, _ := bytes.Cut(line, []byte{':'})
+		value = bytes.TrimPrefix(value, []byte{' '})
+		switch string(field) {
+		case "data":
+			if hasData {
+				data = append(data, '\n')
+			}
+			data = append(data, value...)
+			hasData = true
+		case "id":
+			if !bytes.ContainsRune(value, 0) {
+				h.lastEventID = string(value)
+			}
+		case "retry":
+			if ms, err := strconv.Atoi(string(value)); err == nil && ms > 0 {
+				h.delay = time.Duration(ms) * time.Millisecond
+			}
+		}
+	}
+	return received, scanner.Err()
+}
@@ -0,0 +1,68 @@
+package input
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/YLivay/gote/logging"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTTPInput_StreamsRecords(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) > 1 {
+			<-r.Context().Done()
+			return
+		}
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		fmt.Fprint(w, "{\"a\":1}\n{\"b\":2}")
+	}))
+	defer server.Close()
+
+	h := newHTTPInput(server.URL, nil, logging.New(io.Discard, logging.Text, logging.Levels{}))
+	defer h.Close()
+
+	r := bufio.NewReader(h)
+	for _, expected := range []string{"{\"a\":1}\n", "{\"b\":2}\n"} {
+		line, err := r.ReadString('\n')
+		assert.NoError(t, err)
+		assert.EqualValues(t, expected, line)
+	}
+}
+
+func TestHTTPInput_ResumesServerSentEvents(t *testing.T) {
+	var requests atomic.Int32
+	var lastEventID atomic.Value
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		switch requests.Add(1) {
+		case 1:
+			fmt.Fprint(w, "retry: 10\n\nid: 1\ndata: one\n\n: keep alive\ndata: two\ndata: lines\n\ndata: partial")
+		case 2:
+			lastEventID.Store(r.Header.Get("Last-Event-ID"))
+			fmt.Fprint(w, "data: three\n\n")
+		default:
+			<-r.Context().Done()
+		}
+	}))
+	defer server.Close()
+
+	h := newHTTPInput(server.URL, nil, logging.New(io.Discard, logging.Text, logging.Levels{}))
+	defer h.Close()
+
+	// Each event is a record, and the event the response ended in the middle
+	// of is dropped.
+	r := bufio.NewReader(h)
+	for _, expected := range []string{"one\n", "two\n", "lines\n", "three\n"} {
+		line, err := r.ReadString('\n')
+		assert.NoError(t, err)
+		assert.EqualValues(t, expected, line)
+	}
+	assert.EqualValues(t, "1", lastEventID.Load())
+}
@@ -0,0 +1,171 @@
+package input
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/YLivay/gote/logging"
+)
+
+// ParseJournalPriority parses the value of the -journal-priority flag, a
+// severity name or number, or a range of them like "err..warning", as
+// journalctl takes it.
+func ParseJournalPriority(value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	for _, bound := range strings.SplitN(value, "..", 2) {
+		if n, err := strconv.Atoi(bound); err == nil && n >= 0 && n < len(syslogSeverities) {
+			continue
+		}
+		if !slices.Contains(syslogSeverities, bound) {
+			return "", fmt.Errorf("invalid journal priority %q, expected one of %s or a range of them", value, strings.Join(syslogSeverities, ", "))
+		}
+	}
+	return value, nil
+}
+
+// journalctlArgs returns the arguments journalctl is run with to follow the
+// entries of the given units, or of all of them if there are none, with the
+// given priority, or any if it is empty.
+func journalctlArgs(units []string, priority string) []string {
+	args := []string{"--output=json", "--follow", "--no-tail", "--no-pager"}
+	for _, unit := range units {
+		args = append(args, "--unit="+unit)
+	}
+	if priority != "" {
+		args = append(args, "--priority="+priority)
+	}
+	return args
+}
+
+// journalInput reads the entries of the systemd journal into one input, which
+// is followed like stdin is. Each entry is a JSON record of its fields, with
+// its priority also given as a level name.
+//
+// The journal's files are read through journalctl, which knows their format.
+type journalInput struct {
+	delim  []byte
+	logger *logging.Logger
+
+	cmd    *exec.Cmd
+	stdout io.ReadCloser
+
+	pr *io.PipeReader
+	pw *io.PipeWriter
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// newJournalInput starts reading the entries of the given units with the given
+// priority, as filtered by journalctlArgs. Records end with the given
+// delimiter, or newlines if it is empty. What journalctl writes to its stderr,
+// and its failure, are logged to the given logger.
+func newJournalInput(units []string, priority string, delim []byte, logger *logging.Logger) (*journalInput, error) {
+	if len(delim) == 0 {
+		delim = []byte{'\n'}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	j := &journalInput{
+		delim:  delim,
+		logger: logger,
+		cmd:    exec.CommandContext(ctx, "journalctl", journalctlArgs(units, priority)...),
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	j.pr, j.pw = io.Pipe()
+
+	var err error
+	if j.stdout, err = j.cmd.StdoutPipe(); err != nil {
+		cancel()
+		return nil, err
+	}
+	j.cmd.Stderr = &logWriter{logger: logger.Named("stderr")}
+	if err := j.cmd.Start(); err != nil {
+		cancel()
+		return nil, fmt.Errorf("failed to run journalctl: %w", err)
+	}
+	logger.Info("reading the journal with journalctl", strings.Join(j.cmd.Args[1:], " "))
+
+	go j.run(ctx)
+	return j, nil
+}
+
+// Read implements io.Reader.
+func (j *journalInput) Read(p []byte) (int, error) {
+	n, err := j.pr.Read(p)
+	if errors.Is(err, io.ErrClosedPipe) {
+		// Closing the journal input ends it.
+		err = io.EOF
+	}
+	return n, err
+}
+
+// Close stops reading the journal.
+func (j *journalInput) Close() error {
+	j.cancel()
+	// Whoever read the journal input may have stopped, so unblock writes to
+	// it.
+	j.pr.Close()
+	<-j.done
+	return nil
+}
+
+// run copies the entries journalctl writes into the input, until it exits or
+// the input is closed.
+func (j *journalInput) run(ctx context.Context) {
+	defer close(j.done)
+	defer j.pw.Close()
+
+	scanner := bufio.NewScanner(j.stdout)
+	scanner.Buffer(nil, maxNetworkRecordLen)
+	var writeErr error
+	for scanner.Scan() {
+		record := append(journalRecord(scanner.Bytes()), j.delim...)
+		if _, writeErr = j.pw.Write(record); writeErr != nil {
+			break
+		}
+	}
+
+	// journalctl follows the journal until it is stopped, so it only exits
+	// on its own if it failed.
+	stopped := ctx.Err() != nil || writeErr != nil
+	j.cancel()
+	if err := j.cmd.Wait(); err != nil && !stopped {
+		j.logger.Error("journalctl failed:", err.Error())
+	}
+}
+
+// journalRecord returns the record of the given journal entry, as journalctl
+// writes it in JSON, with a level field that names its PRIORITY field, so it is
+// recognized like the levels of other records.
+func journalRecord(entry []byte) []byte {
+	var fields struct {
+		Priority string `json:"PRIORITY"`
+	}
+	if err := json.Unmarshal(entry, &fields); err != nil {
+		return entry
+	}
+	priority, err := strconv.Atoi(fields.Priority)
+	if err != nil || priority < 0 || priority >= len(syslogSeverities) {
+		return entry
+	}
+
+	rest := bytes.TrimLeft(entry[1:], " ")
+	record := fmt.Appendf(nil, `{"level":%q`, syslogSeverities[priority])
+	if len(rest) > 0 && rest[0] != '}' {
+		record = append(record, ',')
+	}
+	return append(record, rest...)
+}
@@ -0,0 +1,61 @@
+package input
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/YLivay/gote/logging"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJournalRecord_NamesThePriorityAsALevel(t *testing.T) {
+	record := journalRecord([]byte(`{"MESSAGE":"disk full","PRIORITY":"3","_SYSTEMD_UNIT":"app.service"}`))
+	assert.EqualValues(t, `{"level":"err","MESSAGE":"disk full","PRIORITY":"3","_SYSTEMD_UNIT":"app.service"}`, string(record))
+
+	// Entries without a priority are left alone.
+	for _, entry := range []string{`{"MESSAGE":"hi"}`, `{"PRIORITY":"9"}`, `not json`} {
+		assert.EqualValues(t, entry, string(journalRecord([]byte(entry))))
+	}
+}
+
+func TestJournalctlArgs_FilterUnitsAndPriority(t *testing.T) {
+	assert.EqualValues(t,
+		[]string{"--output=json", "--follow", "--no-tail", "--no-pager", "--unit=a.service", "--unit=b.service", "--priority=emerg..warning"},
+		journalctlArgs([]string{"a.service", "b.service"}, "emerg..warning"))
+
+	for _, valid := range []string{"", "err", "3", "0..warning"} {
+		_, err := ParseJournalPriority(valid)
+		assert.NoError(t, err, valid)
+	}
+	for _, invalid := range []string{"loud", "8", "err..", "err..warning..info"} {
+		_, err := ParseJournalPriority(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestJournalInput_LogsWhatJournalctlWritesToItsStderr(t *testing.T) {
+	// A fake journalctl that writes an entry and a warning, and fails.
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "journalctl"), []byte(`#!/bin/sh
+echo '{"MESSAGE":"hi","PRIORITY":"6"}'
+echo "no journal files were found" >&2
+exit 1
+`), 0755))
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	logs := logging.NewRing(10)
+	j, err := newJournalInput(nil, "", nil, logging.New(logs, logging.Text, logging.Levels{}))
+	assert.NoError(t, err)
+	defer j.Close()
+
+	line, err := bufio.NewReader(j).ReadString('\n')
+	assert.NoError(t, err)
+	assert.EqualValues(t, `{"level":"info","MESSAGE":"hi","PRIORITY":"6"}`+"\n", line)
+	<-j.done
+	text := strings.Join(logs.Last(10), "\n")
+	assert.Contains(t, text, "[stderr] no journal files were found")
+	assert.Contains(t, text, "journalctl failed: exit status 1")
+}
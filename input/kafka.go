@@ -0,0 +1,248 @@
+package input
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/YLivay/gote/logging"
+)
+
+// isKafkaInput returns true if the given input is the URL of a Kafka topic to
+// consume, like kafka://broker:9092/topic.
+func isKafkaInput(input string) bool {
+	return strings.HasPrefix(input, "kafka://")
+}
+
+// kafkaInput consumes the messages of a Kafka topic into one input, which is
+// followed like stdin is. Each message is a record.
+//
+// Messages are consumed through kcat, which speaks Kafka's protocol. When it
+// exits, it is run again like an HTTP input is reconnected to. A partition
+// that was given continues from the message after the last one consumed.
+type kafkaInput struct {
+	url       string
+	brokers   string
+	topic     string
+	partition int
+	// The offset consumption starts from, as kcat takes it: beginning, end,
+	// a number, or a negative number relative to the end.
+	offset string
+
+	delim  []byte
+	logger *logging.Logger
+
+	pr *io.PipeReader
+	pw *io.PipeWriter
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// newKafkaInput starts consuming the topic at the given URL, which has the form
+// kafka://broker[,broker...]/topic?partition=N&offset=O. Without a partition,
+// all of the topic's partitions are consumed. The offset is earliest, latest
+// (the default), a number, or a negative number of messages before the latest.
+// Records end with the given delimiter, or newlines if it is empty. Failures to
+// consume them are logged to the given logger.
+func newKafkaInput(rawURL string, delim []byte, logger *logging.Logger) (*kafkaInput, error) {
+	if len(delim) == 0 {
+		delim = []byte{'\n'}
+	}
+
+	// Several brokers are separated with commas, which url.Parse doesn't
+	// allow in the host, so the URL is split by hand.
+	rest, _ := strings.CutPrefix(rawURL, "kafka://")
+	rest, rawQuery, _ := strings.Cut(rest, "?")
+	brokers, topic, _ := strings.Cut(rest, "/")
+	if brokers == "" || topic == "" || strings.Contains(topic, "/") {
+		return nil, fmt.Errorf("invalid Kafka URL %q: expected kafka://broker[,broker...]/topic", rawURL)
+	}
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Kafka URL %q: %w", rawURL, err)
+	}
+
+	k := &kafkaInput{
+		url:       rawURL,
+		brokers:   brokers,
+		topic:     topic,
+		partition: -1,
+		offset:    "end",
+		delim:     delim,
+		logger:    logger,
+		done:      make(chan struct{}),
+	}
+	if value := query.Get("partition"); value != "" {
+		if k.partition, err = strconv.Atoi(value); err != nil || k.partition < 0 {
+			return nil, fmt.Errorf("invalid Kafka partition %q", value)
+		}
+	}
+	switch value := query.Get("offset"); value {
+	case "", "latest":
+	case "earliest":
+		k.offset = "beginning"
+	default:
+		if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+			return nil, fmt.Errorf("invalid Kafka offset %q, expected earliest, latest or a number", value)
+		}
+		k.offset = value
+	}
+
+	if _, err := exec.LookPath("kcat"); err != nil {
+		return nil, errors.New("consuming Kafka topics requires kcat to be installed")
+	}
+
+	var ctx context.Context
+	ctx, k.cancel = context.WithCancel(context.Background())
+	k.pr, k.pw = io.Pipe()
+
+	go k.run(ctx)
+	return k, nil
+}
+
+// Read implements io.Reader.
+func (k *kafkaInput) Read(p []byte) (int, error) {
+	n, err := k.pr.Read(p)
+	if errors.Is(err, io.ErrClosedPipe) {
+		// Closing the Kafka input ends it.
+		err = io.EOF
+	}
+	return n, err
+}
+
+// Close stops consuming the topic.
+func (k *kafkaInput) Close() error {
+	k.cancel()
+	// Whoever read the Kafka input may have stopped, so unblock writes to it.
+	k.pr.Close()
+	<-k.done
+	return nil
+}
+
+// run consumes the topic into the input, running kcat again whenever it exits,
+// until the context is done or the input is closed.
+func (k *kafkaInput) run(ctx context.Context) {
+	defer close(k.done)
+	defer k.pw.Close()
+
+	reconnect(ctx, k.url, k.logger, func() time.Duration { return defaultReconnectDelay }, k.consume)
+}
+
+// kcatArgs returns the arguments kcat is run with to consume the topic from the
+// current offset, writing each message as a JSON envelope on its own line.
+func (k *kafkaInput) kcatArgs() []string {
+	args := []string{"-C", "-J", "-u", "-q", "-b", k.brokers, "-t", k.topic, "-o", k.offset}
+	if k.partition >= 0 {
+		args = append(args, "-p", strconv.Itoa(k.partition))
+	}
+	return args
+}
+
+// consume runs kcat once and copies the messages it consumes into the input,
+// returning whether any were.
+func (k *kafkaInput) consume(ctx context.Context) (received bool, err error) {
+	cmd := exec.CommandContext(ctx, "kcat", k.kcatArgs()...)
+	cmd.Stderr = &logWriter{logger: k.logger.Named("stderr")}
+	cmd.WaitDelay = time.Second
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return false, err
+	}
+	if err := cmd.Start(); err != nil {
+		return false, fmt.Errorf("failed to run kcat: %w", err)
+	}
+
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(nil, maxNetworkRecordLen)
+	var writeErr error
+	for scanner.Scan() {
+		record, offset, ok := kafkaRecord(scanner.Bytes())
+		if !ok {
+			continue
+		}
+		if _, writeErr = k.pw.Write(append(record, k.delim...)); writeErr != nil {
+			cmd.Process.Kill()
+			break
+		}
+		received = true
+		if k.partition >= 0 {
+			k.offset = strconv.FormatInt(offset+1, 10)
+		}
+	}
+
+	waitErr := cmd.Wait()
+	if writeErr != nil {
+		return received, writeErr
+	}
+	if waitErr != nil {
+		return received, fmt.Errorf("kcat failed: %w", waitErr)
+	}
+	return received, nil
+}
+
+// kafkaMessage is the envelope kcat writes a consumed message in.
+type kafkaMessage struct {
+	Topic     string  `json:"topic"`
+	Partition int     `json:"partition"`
+	Offset    int64   `json:"offset"`
+	Timestamp int64   `json:"ts"`
+	Key       *string `json:"key"`
+	Payload   *string `json:"payload"`
+}
+
+// kafkaRecord returns the record of the message in the given envelope, along
+// with its offset. A payload that is a JSON object is the record, with the
+// message's topic, partition, offset, timestamp and key added to it in a kafka
+// field. Other payloads are the message field of a record.
+func kafkaRecord(envelope []byte) (record []byte, offset int64, ok bool) {
+	var msg kafkaMessage
+	if err := json.Unmarshal(envelope, &msg); err != nil {
+		return nil, 0, false
+	}
+
+	meta := struct {
+		Topic     string  `json:"topic"`
+		Partition int     `json:"partition"`
+		Offset    int64   `json:"offset"`
+		Timestamp int64   `json:"ts,omitempty"`
+		Key       *string `json:"key,omitempty"`
+	}{msg.Topic, msg.Partition, msg.Offset, msg.Timestamp, msg.Key}
+	metaJSON, err := marshalRecord(meta)
+	if err != nil {
+		return nil, 0, false
+	}
+	record = fmt.Appendf(nil, `{"kafka":%s`, metaJSON)
+
+	var payload []byte
+	if msg.Payload != nil {
+		payload = bytes.TrimSpace([]byte(*msg.Payload))
+	}
+	// The payload may be indented over several lines, which would be split
+	// into several records, so it is compacted.
+	var compact bytes.Buffer
+	if len(payload) > 0 && payload[0] == '{' && json.Compact(&compact, payload) == nil {
+		rest := compact.Bytes()[1:]
+		if rest[0] != '}' {
+			record = append(record, ',')
+		}
+		return append(record, rest...), msg.Offset, true
+	}
+
+	message, _ := marshalRecord(string(payload))
+	if msg.Payload == nil {
+		message = []byte("null")
+	}
+	record = append(record, `,"message":`...)
+	record = append(record, message...)
+	return append(record, '}'), msg.Offset, true
+}
@@ -0,0 +1,69 @@
+package input
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/YLivay/gote/logging"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKafkaRecord_AddsTheMessageToItsPayload(t *testing.T) {
+	for envelope, expected := range map[string]string{
+		`{"topic":"t","partition":1,"offset":5,"tstype":"create","ts":1700000000000,"broker":1,"key":"k","payload":"{\n  \"level\": \"warn\"\n}"}`: `{"kafka":{"topic":"t","partition":1,"offset":5,"ts":1700000000000,"key":"k"},"level":"warn"}`,
+		`{"topic":"t","partition":0,"offset":6,"key":null,"payload":"{}"}`:                                                                         `{"kafka":{"topic":"t","partition":0,"offset":6}}`,
+		`{"topic":"t","partition":0,"offset":7,"key":null,"payload":"plain <text>"}`:                                                               `{"kafka":{"topic":"t","partition":0,"offset":7},"message":"plain <text>"}`,
+		`{"topic":"t","partition":0,"offset":8,"key":"gone","payload":null}`:                                                                       `{"kafka":{"topic":"t","partition":0,"offset":8,"key":"gone"},"message":null}`,
+	} {
+		record, offset, ok := kafkaRecord([]byte(envelope))
+		assert.True(t, ok, envelope)
+		assert.EqualValues(t, expected, string(record), envelope)
+		assert.Positive(t, offset)
+	}
+
+	_, _, ok := kafkaRecord([]byte("% Reached end of topic"))
+	assert.False(t, ok)
+}
+
+func TestKafkaInput_ContinuesAfterTheLastOffset(t *testing.T) {
+	// A fake kcat that consumes two messages and exits.
+	dir := t.TempDir()
+	runs := filepath.Join(dir, "runs")
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "kcat"), []byte(`#!/bin/sh
+echo "$*" >> "`+runs+`"
+n=$(wc -l < "`+runs+`")
+echo '{"topic":"events","partition":2,"offset":'$((n*2))',"key":null,"payload":"one"}'
+echo '{"topic":"events","partition":2,"offset":'$((n*2+1))',"key":null,"payload":"two"}'
+`), 0755))
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	k, err := newKafkaInput("kafka://a:9092,b:9092/events?partition=2&offset=earliest", nil, logging.New(io.Discard, logging.Text, logging.Levels{}))
+	assert.NoError(t, err)
+	defer k.Close()
+
+	r := bufio.NewReader(k)
+	for i := 0; i < 4; i++ {
+		_, err := r.ReadString('\n')
+		assert.NoError(t, err)
+	}
+	assert.Eventually(t, func() bool {
+		ran, _ := os.ReadFile(runs)
+		return strings.Count(string(ran), "\n") >= 2
+	}, 5*time.Second, 10*time.Millisecond)
+
+	ran, err := os.ReadFile(runs)
+	assert.NoError(t, err)
+	lines := strings.Split(string(ran), "\n")
+	assert.EqualValues(t, "-C -J -u -q -b a:9092,b:9092 -t events -o beginning -p 2", lines[0])
+	assert.EqualValues(t, "-C -J -u -q -b a:9092,b:9092 -t events -o 4 -p 2", lines[1])
+
+	for _, invalid := range []string{"kafka://broker", "kafka:///topic", "kafka://b/t?partition=x", "kafka://b/t?offset=soon"} {
+		_, err := newKafkaInput(invalid, nil, logging.New(io.Discard, logging.Text, logging.Levels{}))
+		assert.Error(t, err, invalid)
+	}
+}
@@ -0,0 +1,271 @@
+package input
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/YLivay/gote/logging"
+)
+
+// maxNetworkRecordLen bounds how much of a record sent over a TCP connection
+// is buffered while waiting for its delimiter. A connection that sends a
+// longer record is closed.
+const maxNetworkRecordLen = 16 << 20
+
+// maxDatagramLen is the largest UDP datagram that can be received.
+const maxDatagramLen = 64 << 10
+
+// ParseListenAddress parses the value of the -listen flag, a URL like
+// tcp://:5140 or udp://localhost:5140, into the network and address to listen
+// on. Prefixing the scheme with "syslog+", or using syslog:// for UDP, listens
+// for syslog messages.
+func ParseListenAddress(value string) (network, address string, syslog bool, err error) {
+	u, err := url.Parse(value)
+	if err != nil {
+		return "", "", false, fmt.Errorf("invalid listen address %q: %w", value, err)
+	}
+	network = u.Scheme
+	if network == "syslog" {
+		network, syslog = "udp", true
+	} else if after, ok := strings.CutPrefix(network, "syslog+"); ok {
+		network, syslog = after, true
+	}
+	switch network {
+	case "tcp", "tcp4", "tcp6", "udp", "udp4", "udp6":
+	default:
+		return "", "", false, fmt.Errorf("invalid listen address %q: the scheme must be tcp, udp, syslog+tcp or syslog+udp", value)
+	}
+	if u.Host == "" {
+		return "", "", false, fmt.Errorf("invalid listen address %q: missing the address to listen on", value)
+	}
+	return network, u.Host, syslog, nil
+}
+
+// networkInput accepts records sent over the network into one input, which is
+// followed like stdin is. Each line of a TCP connection, and each UDP datagram,
+// is a record. When receiving syslog messages, each is parsed into a JSON
+// record.
+//
+// Records are only added once they are complete, so records of different
+// connections sent at the same time don't mix. The last record of a TCP
+// connection that doesn't end with the delimiter is added when it closes.
+type networkInput struct {
+	delim  []byte
+	syslog bool
+
+	pr *io.PipeReader
+	pw *io.PipeWriter
+	// Serializes writes to the pipe between the connections.
+	writeMu sync.Mutex
+
+	// One of these is set, depending on the network listened on.
+	listener   net.Listener
+	packetConn net.PacketConn
+
+	mu     sync.Mutex
+	conns  map[net.Conn]struct{}
+	closed bool
+
+	wg sync.WaitGroup
+
+	logger *logging.Logger
+}
+
+// listenInput starts accepting records, or syslog messages if syslog is true,
+// on the given network address. Records are split with the given delimiter, or
+// newlines if it is empty. Failures to receive them are logged to the given
+// logger.
+func listenInput(network, address string, syslog bool, delim []byte, logger *logging.Logger) (*networkInput, error) {
+	if len(delim) == 0 {
+		delim = []byte{'\n'}
+	}
+
+	n := &networkInput{
+		delim:  delim,
+		syslog: syslog,
+		conns:  make(map[net.Conn]struct{}),
+		logger: logger,
+	}
+	n.pr, n.pw = io.Pipe()
+
+	var err error
+	switch network {
+	case "udp", "udp4", "udp6":
+		if n.packetConn, err = net.ListenPacket(network, address); err != nil {
+			return nil, err
+		}
+		n.wg.Add(1)
+		go n.receive()
+	default:
+		if n.listener, err = net.Listen(network, address); err != nil {
+			return nil, err
+		}
+		n.wg.Add(1)
+		go n.accept()
+	}
+	logger.Info("listening for records on", network, n.Addr().String())
+	return n, nil
+}
+
+// Addr returns the address records are accepted on.
+func (n *networkInput) Addr() net.Addr {
+	if n.packetConn != nil {
+		return n.packetConn.LocalAddr()
+	}
+	return n.listener.Addr()
+}
+
+// Read implements io.Reader.
+func (n *networkInput) Read(p []byte) (int, error) {
+	read, err := n.pr.Read(p)
+	if errors.Is(err, io.ErrClosedPipe) {
+		// Closing the network input ends it.
+		err = io.EOF
+	}
+	return read, err
+}
+
+// Close stops accepting records and closes the open connections.
+func (n *networkInput) Close() error {
+	n.mu.Lock()
+	n.closed = true
+	var err error
+	if n.packetConn != nil {
+		err = n.packetConn.Close()
+	} else {
+		err = n.listener.Close()
+	}
+	for conn := range n.conns {
+		conn.Close()
+	}
+	n.mu.Unlock()
+
+	// Whoever read the network input may have stopped, so unblock writes to it.
+	n.pr.Close()
+	n.wg.Wait()
+	n.pw.Close()
+	return err
+}
+
+// write adds the given record, which ends with the delimiter, to the input.
+func (n *networkInput) write(record []byte) error {
+	n.writeMu.Lock()
+	defer n.writeMu.Unlock()
+
+	_, err := n.pw.Write(record)
+	return err
+}
+
+// accept serves the TCP connections made to the listener until it is closed.
+func (n *networkInput) accept() {
+	defer n.wg.Done()
+
+	for {
+		conn, err := n.listener.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				n.logger.Named("accept").Warn("failed to accept a connection:", err.Error())
+			}
+			return
+		}
+
+		n.mu.Lock()
+		if n.closed {
+			n.mu.Unlock()
+			conn.Close()
+			return
+		}
+		n.conns[conn] = struct{}{}
+		n.wg.Add(1)
+		n.mu.Unlock()
+
+		go n.serve(conn)
+	}
+}
+
+// serve copies the records sent over the given connection into the input,
+// until it is closed.
+func (n *networkInput) serve(conn net.Conn) {
+	defer n.wg.Done()
+	defer func() {
+		n.mu.Lock()
+		delete(n.conns, conn)
+		n.mu.Unlock()
+		conn.Close()
+	}()
+
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(nil, maxNetworkRecordLen)
+	if n.syslog {
+		scanner.Split(splitSyslog)
+	} else {
+		scanner.Split(splitOn(n.delim))
+	}
+	for scanner.Scan() {
+		record := scanner.Bytes()
+		if n.syslog {
+			if len(record) == 0 {
+				continue
+			}
+			record = parseSyslog(record)
+		}
+		if err := n.write(append(record, n.delim...)); err != nil {
+			return
+		}
+	}
+	if err := scanner.Err(); err != nil && !errors.Is(err, net.ErrClosed) {
+		n.logger.Named("serve").Warn("failed to read records from", conn.RemoteAddr().String()+":", err.Error())
+	}
+}
+
+// receive copies the UDP datagrams sent to the input into it, until it is
+// closed.
+func (n *networkInput) receive() {
+	defer n.wg.Done()
+
+	buf := make([]byte, maxDatagramLen)
+	for {
+		size, _, err := n.packetConn.ReadFrom(buf)
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				n.logger.Named("receive").Warn("failed to receive a datagram:", err.Error())
+			}
+			return
+		}
+		if size == 0 {
+			continue
+		}
+
+		record := buf[:size:size]
+		if n.syslog {
+			record = parseSyslog(record)
+		}
+		if !bytes.HasSuffix(record, n.delim) {
+			record = append(record, n.delim...)
+		}
+		if err := n.write(record); err != nil {
+			return
+		}
+	}
+}
+
+// splitOn returns a bufio.SplitFunc that splits on the given delimiter, and
+// doesn't include it in the tokens.
+func splitOn(delim []byte) bufio.SplitFunc {
+	return func(data []byte, atEOF bool) (int, []byte, error) {
+		if i := bytes.Index(data, delim); i >= 0 {
+			return i + len(delim), data[:i], nil
+		}
+		if atEOF && len(data) > 0 {
+			return len(data), data, nil
+		}
+		return 0, nil, nil
+	}
+}
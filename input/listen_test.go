@@ -0,0 +1,145 @@
+package input
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/YLivay/gote/logging"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseListenAddress(t *testing.T) {
+	for value, expected := range map[string]struct {
+		network, address string
+		syslog           bool
+	}{
+		"tcp://:5140":            {"tcp", ":5140", false},
+		"udp://localhost:5140":   {"udp", "localhost:5140", false},
+		"syslog://:514":          {"udp", ":514", true},
+		"syslog+tcp://[::1]:514": {"tcp", "[::1]:514", true},
+	} {
+		network, address, syslog, err := ParseListenAddress(value)
+		assert.NoError(t, err, value)
+		assert.EqualValues(t, expected.network, network, value)
+		assert.EqualValues(t, expected.address, address, value)
+		assert.EqualValues(t, expected.syslog, syslog, value)
+	}
+
+	for _, value := range []string{":5140", "http://:5140", "tcp://", "tcp:5140", "syslog+http://:514"} {
+		_, _, _, err := ParseListenAddress(value)
+		assert.Error(t, err, value)
+	}
+}
+
+// readLines reads the lines of the given network input into the returned
+// function, which returns the next one.
+func readLines(n *networkInput) func() string {
+	lines := make(chan string, 10)
+	go func() {
+		r := bufio.NewReader(n)
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				close(lines)
+				return
+			}
+			lines <- line
+		}
+	}()
+	return func() string {
+		select {
+		case line := <-lines:
+			return line
+		case <-time.After(5 * time.Second):
+			return "timed out"
+		}
+	}
+}
+
+func TestNetworkInput_AcceptsLinesOfTCPConnections(t *testing.T) {
+	n, err := listenInput("tcp", "127.0.0.1:0", false, nil, logging.New(io.Discard, logging.Text, logging.Levels{}))
+	assert.NoError(t, err)
+	defer n.Close()
+	next := readLines(n)
+
+	a, err := net.Dial("tcp", n.Addr().String())
+	assert.NoError(t, err)
+	b, err := net.Dial("tcp", n.Addr().String())
+	assert.NoError(t, err)
+
+	_, err = a.Write([]byte("one\ntw"))
+	assert.NoError(t, err)
+	assert.EqualValues(t, "one\n", next())
+
+	// Lines of different connections don't mix, so the partial line waits
+	// until it is complete.
+	_, err = b.Write([]byte("three\n"))
+	assert.NoError(t, err)
+	assert.EqualValues(t, "three\n", next())
+	_, err = a.Write([]byte("o\n"))
+	assert.NoError(t, err)
+	assert.EqualValues(t, "two\n", next())
+
+	// The last line is added when the connection closes.
+	_, err = b.Write([]byte("four"))
+	assert.NoError(t, err)
+	assert.NoError(t, b.Close())
+	assert.EqualValues(t, "four\n", next())
+
+	assert.NoError(t, n.Close())
+	assert.NoError(t, a.Close())
+}
+
+func TestNetworkInput_LogsTheAddressItListensOn(t *testing.T) {
+	logs := logging.NewRing(10)
+	n, err := listenInput("tcp", "127.0.0.1:0", false, nil, logging.New(logs, logging.Text, logging.Levels{}).Named("listen"))
+	assert.NoError(t, err)
+	defer n.Close()
+
+	last := logs.Last(1)
+	if assert.Len(t, last, 1) {
+		assert.Contains(t, last[0], "[listen] listening for records on tcp "+n.Addr().String())
+	}
+}
+
+func TestNetworkInput_AcceptsUDPDatagrams(t *testing.T) {
+	n, err := listenInput("udp", "127.0.0.1:0", false, nil, logging.New(io.Discard, logging.Text, logging.Levels{}))
+	assert.NoError(t, err)
+	defer n.Close()
+	next := readLines(n)
+
+	conn, err := net.Dial("udp", n.Addr().String())
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	_, err = conn.Write([]byte("one"))
+	assert.NoError(t, err)
+	assert.EqualValues(t, "one\n", next())
+
+	_, err = conn.Write([]byte("two\nthree\n"))
+	assert.NoError(t, err)
+	assert.EqualValues(t, "two\n", next())
+	assert.EqualValues(t, "three\n", next())
+
+	assert.NoError(t, n.Close())
+}
+
+func TestNetworkInput_ParsesSyslogMessagesOverTCP(t *testing.T) {
+	n, err := listenInput("tcp", "127.0.0.1:0", true, nil, logging.New(io.Discard, logging.Text, logging.Levels{}))
+	assert.NoError(t, err)
+	defer n.Close()
+	next := readLines(n)
+
+	conn, err := net.Dial("tcp", n.Addr().String())
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	// Messages may be framed by their length or end with a newline.
+	_, err = conn.Write([]byte("<13>Feb  5 17:32:18 web su: hi\n29 <11>1 - - app - - - two\nlines"))
+	assert.NoError(t, err)
+	assert.EqualValues(t, `{"level":"notice","facility":"user","time":"Feb  5 17:32:18","host":"web","app":"su","message":"hi"}`+"\n", next())
+	assert.EqualValues(t, `{"level":"err","facility":"user","app":"app","message":"two\nlines"}`+"\n", next())
+}
@@ -0,0 +1,448 @@
+package input
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/YLivay/gote/logging"
+	"github.com/YLivay/gote/reader"
+	"github.com/fsnotify/fsnotify"
+)
+
+// isMergedInput returns true if the given inputs are read by merging the files
+// they match, rather than reading a single file: if there are several of them,
+// or the one given is a directory or a glob pattern.
+func isMergedInput(inputs []string) bool {
+	if len(inputs) != 1 {
+		return len(inputs) > 1
+	}
+	if inputs[0] == "-" || isRemoteInput(inputs[0]) {
+		return false
+	}
+	if isGlobPattern(inputs[0]) {
+		return true
+	}
+	info, err := os.Stat(inputs[0])
+	return err == nil && info.IsDir()
+}
+
+// isRemoteInput returns true if the given input is the URL of a stream or
+// object to read from, rather than a file.
+func isRemoteInput(input string) bool {
+	return isURLInput(input) || isWebSocketInput(input) || isObjectInput(input) || isSSHInput(input) || isKafkaInput(input)
+}
+
+// isGlobPattern returns true if the given name has the special characters of a
+// glob pattern. Shells expand patterns before passing them on, so a name with
+// them was either quoted or matched nothing yet.
+func isGlobPattern(name string) bool {
+	return strings.ContainsAny(name, "*?[")
+}
+
+// expandInputs returns the files the given inputs match, oldest first, so
+// rotated logs are read in the order they were written. Directories match the
+// regular files in them, and glob patterns the files they match, which may be
+// none yet. Other inputs must be existing files.
+func expandInputs(inputs []string) ([]string, error) {
+	type match struct {
+		name string
+		info os.FileInfo
+	}
+	var matches []match
+	add := func(name string) error {
+		info, err := os.Stat(name)
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		for _, m := range matches {
+			if os.SameFile(m.info, info) {
+				return nil
+			}
+		}
+		matches = append(matches, match{name, info})
+		return nil
+	}
+
+	for _, input := range inputs {
+		if input == "-" {
+			return nil, errors.New("stdin can't be merged with other inputs")
+		}
+		if isRemoteInput(input) {
+			return nil, errors.New("URLs can't be merged with other inputs")
+		}
+
+		var names []string
+		if isGlobPattern(input) {
+			var err error
+			if names, err = filepath.Glob(input); err != nil {
+				return nil, fmt.Errorf("invalid pattern %q: %w", input, err)
+			}
+		} else if info, err := os.Stat(input); err != nil {
+			return nil, err
+		} else if info.IsDir() {
+			entries, err := os.ReadDir(input)
+			if err != nil {
+				return nil, err
+			}
+			for _, entry := range entries {
+				names = append(names, filepath.Join(input, entry.Name()))
+			}
+		} else {
+			names = []string{input}
+		}
+
+		for _, name := range names {
+			// Files may be removed between listing and opening them.
+			if err := add(name); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return nil, err
+			}
+		}
+	}
+
+	slices.SortStableFunc(matches, func(a, b match) int {
+		return a.info.ModTime().Compare(b.info.ModTime())
+	})
+	names := make([]string, len(matches))
+	for i, m := range matches {
+		names[i] = m.name
+	}
+	return names, nil
+}
+
+// rotatedSuffix matches what rotating a log appends to its name: a number, as
+// in app.log.1, or a date, as in app.log-20240101, optionally followed by the
+// extension of the compression format it was compressed in.
+var rotatedSuffix = regexp.MustCompile(`^[.-][0-9]+(\.(gz|bz2|zst))?$`)
+
+// rotatedFiles returns the files the given file was rotated into, which are
+// next to it and have its name with a rotatedSuffix, oldest first.
+func rotatedFiles(name string) ([]string, error) {
+	entries, err := os.ReadDir(filepath.Dir(name))
+	if err != nil {
+		return nil, err
+	}
+
+	base := filepath.Base(name)
+	var rotated []string
+	for _, entry := range entries {
+		suffix, ok := strings.CutPrefix(entry.Name(), base)
+		if ok && rotatedSuffix.MatchString(suffix) {
+			rotated = append(rotated, filepath.Join(filepath.Dir(name), entry.Name()))
+		}
+	}
+	if len(rotated) == 0 {
+		return nil, nil
+	}
+	return expandInputs(rotated)
+}
+
+// mergePollInterval is how often the files of a merged input are checked for
+// new lines, and its inputs for new files, when there are no notifications of
+// them changing.
+const mergePollInterval = time.Second
+
+// mergedFile is one of the files of a merged input.
+type mergedFile struct {
+	f       *os.File
+	info    os.FileInfo
+	scanner *reader.ForwardsLineScanner
+	// The source the file is read as.
+	source int
+	// If true, the file is compressed, so it is decompressed whole instead of
+	// followed. Rotated logs are compressed once they are done being written.
+	compressed bool
+	// If true, the compressed file was already copied into the merged input.
+	copied bool
+}
+
+// mergedInput merges the lines of the files that its inputs match into one
+// input, which is followed like stdin is: the files are read oldest first, and
+// then the lines written to any of them, or to new files that the inputs match,
+// are added as they are written.
+//
+// What the files held when they were opened is read whole, one file after
+// another in the order of their modification times, rather than interleaved
+// by the timestamps of the records. That suits rotated logs, which were
+// written one after another, but the history of files written at the same
+// time is shown file by file.
+//
+// Lines are only added once they are complete, so lines of different files
+// written at the same time don't mix. The last line of a file that doesn't end
+// with the delimiter is added once it does.
+type mergedInput struct {
+	inputs []string
+	delim  []byte
+	logger *logging.Logger
+
+	pr *io.PipeReader
+	pw *io.PipeWriter
+
+	files []*mergedFile
+	// Tells which of the files each line was copied from.
+	sources *inputSources
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// newMergedInput starts merging the files the given inputs match. Lines are
+// read with the given delimiter, or newlines if it is empty. Failures to read
+// the files are logged to the given logger.
+func newMergedInput(inputs []string, delim []byte, logger *logging.Logger) (*mergedInput, error) {
+	if len(delim) == 0 {
+		delim = []byte{'\n'}
+	}
+
+	names, err := expandInputs(inputs)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	m := &mergedInput{
+		inputs:  inputs,
+		delim:   delim,
+		logger:  logger,
+		sources: &inputSources{},
+		cancel:  cancel,
+		done:    make(chan struct{}),
+	}
+	m.pr, m.pw = io.Pipe()
+
+	for _, name := range names {
+		if err := m.addFile(name); err != nil {
+			m.closeFiles()
+			cancel()
+			return nil, err
+		}
+	}
+	logger.Info("merging", len(m.files), "input files")
+
+	go m.run(ctx)
+	return m, nil
+}
+
+// Read implements io.Reader.
+func (m *mergedInput) Read(p []byte) (int, error) {
+	n, err := m.pr.Read(p)
+	if errors.Is(err, io.ErrClosedPipe) {
+		// Closing the merged input ends it.
+		err = io.EOF
+	}
+	return n, err
+}
+
+// Close stops merging the input files and closes them.
+func (m *mergedInput) Close() error {
+	m.cancel()
+	// Whoever read the merged input may have stopped, so unblock writes to it.
+	m.pr.Close()
+	<-m.done
+	return m.closeFiles()
+}
+
+func (m *mergedInput) closeFiles() error {
+	var errs []error
+	for _, file := range m.files {
+		if file.scanner != nil {
+			file.scanner.Close()
+		}
+		errs = append(errs, file.f.Close())
+	}
+	m.files = nil
+	return errors.Join(errs...)
+}
+
+// addFile opens the given file to be merged, unless it is already.
+func (m *mergedInput) addFile(name string) error {
+	// Files are told apart by their identity rather than their names, so a
+	// file that replaced one that was rotated away is a new file.
+	info, err := os.Stat(name)
+	if err != nil {
+		return err
+	}
+	for _, file := range m.files {
+		if os.SameFile(file.info, info) {
+			return nil
+		}
+	}
+
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	if info, err = f.Stat(); err != nil {
+		f.Close()
+		return err
+	}
+
+	file := &mergedFile{f: f, info: info, source: m.sources.add(name)}
+	start := make([]byte, maxMagicLen)
+	n, _ := f.ReadAt(start, 0)
+	if c := detectCompression(start[:n]); c != nil {
+		m.logger.Named("addFile").Info("merged input file", name, "is compressed with", c.name)
+		file.compressed = true
+	} else {
+		file.scanner = reader.NewForwardsLineScanner(f, 0, reader.WithDelimiter(m.delim))
+	}
+	m.files = append(m.files, file)
+	return nil
+}
+
+// run copies the lines of the files into the merged input, and then follows
+// the files and the inputs for more, until the context is done or the merged
+// input is closed.
+func (m *mergedInput) run(ctx context.Context) {
+	defer close(m.done)
+	defer m.pw.Close()
+
+	changed := m.watch(ctx)
+	for {
+		if err := m.copyFiles(); err != nil {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-changed:
+		case <-time.After(mergePollInterval):
+		}
+
+		// New files may match the inputs now. Files that were given by name
+		// may be gone, like rotated files that were deleted, which doesn't
+		// stop the others from being expanded.
+		for _, input := range m.inputs {
+			names, err := expandInputs([]string{input})
+			if err != nil {
+				if !errors.Is(err, os.ErrNotExist) {
+					m.logger.Named("run").Warn("failed to expand merged input", input+":", err.Error())
+				}
+				continue
+			}
+			for _, name := range names {
+				if err := m.addFile(name); err != nil && !errors.Is(err, os.ErrNotExist) {
+					m.logger.Named("run").Warn("failed to open merged input file", name+":", err.Error())
+				}
+			}
+		}
+	}
+}
+
+// copyFiles copies what was written to the files since they were last copied
+// into the merged input. It only fails if the merged input was closed.
+func (m *mergedInput) copyFiles() error {
+	for _, file := range m.files {
+		var err error
+		if file.compressed {
+			err = m.copyCompressed(file)
+		} else {
+			err = m.copyLines(file)
+		}
+		if errors.Is(err, io.ErrClosedPipe) {
+			return err
+		}
+		if err != nil {
+			m.logger.Named("copyFiles").Warn("failed to merge input file", file.f.Name()+":", err.Error())
+		}
+	}
+	return nil
+}
+
+// copyLines copies the complete lines written to the given file since it was
+// last copied into the merged input.
+func (m *mergedInput) copyLines(file *mergedFile) error {
+	for file.scanner.Scan() {
+		line := append(file.scanner.Bytes(), m.delim...)
+		m.sources.copied(file.source, len(line))
+		if _, err := m.pw.Write(line); err != nil {
+			return err
+		}
+	}
+	return file.scanner.Err()
+}
+
+// copyCompressed copies the decompressed contents of the given file into the
+// merged input, unless they already were.
+func (m *mergedInput) copyCompressed(file *mergedFile) error {
+	if file.copied {
+		return nil
+	}
+	file.copied = true
+
+	input, _, err := decompressInput(io.NewSectionReader(file.f, 0, file.info.Size()))
+	if err != nil {
+		return err
+	}
+	contents, readErr := io.ReadAll(input)
+	if len(contents) > 0 && !bytes.HasSuffix(contents, m.delim) {
+		contents = append(contents, m.delim...)
+	}
+	m.sources.copied(file.source, len(contents))
+	if _, err := m.pw.Write(contents); err != nil {
+		return err
+	}
+	return readErr
+}
+
+// watch returns a channel that is signaled when files in the directories of the
+// inputs change. It is nil if notifications can't be set up, in which case the
+// inputs are polled.
+func (m *mergedInput) watch(ctx context.Context) <-chan struct{} {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		m.logger.Named("watch").Warn("failed to watch merged inputs, polling them instead:", err.Error())
+		return nil
+	}
+
+	for _, input := range m.inputs {
+		dir := filepath.Dir(input)
+		if info, err := os.Stat(input); err == nil && info.IsDir() {
+			dir = input
+		}
+		if err := watcher.Add(dir); err != nil {
+			m.logger.Named("watch").Warn("failed to watch", dir+", polling it instead:", err.Error())
+		}
+	}
+
+	changed := make(chan struct{}, 1)
+	go func() {
+		defer watcher.Close()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case ev, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if !ev.Has(fsnotify.Write) && !ev.Has(fsnotify.Create) {
+					continue
+				}
+				// Collapse changes that happen before the next wait into one.
+				select {
+				case changed <- struct{}{}:
+				default:
+				}
+			case _, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+			}
+		}
+	}()
+	return changed
+}
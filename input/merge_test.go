@@ -0,0 +1,167 @@
+package input
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/YLivay/gote/logging"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpandInputs_MatchesOldestFirst(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	for i, name := range []string{"new.log", "old.log", "notes.txt"} {
+		path := filepath.Join(dir, name)
+		assert.NoError(t, os.WriteFile(path, []byte("x\n"), 0644))
+		mtime := now.Add(-time.Duration(i) * time.Hour)
+		assert.NoError(t, os.Chtimes(path, mtime, mtime))
+	}
+	assert.NoError(t, os.Mkdir(filepath.Join(dir, "sub"), 0755))
+
+	names, err := expandInputs([]string{filepath.Join(dir, "*.log")})
+	assert.NoError(t, err)
+	assert.EqualValues(t, []string{filepath.Join(dir, "old.log"), filepath.Join(dir, "new.log")}, names)
+
+	// Directories match the files in them, and files matched twice are
+	// only read once.
+	names, err = expandInputs([]string{dir, filepath.Join(dir, "new.log")})
+	assert.NoError(t, err)
+	assert.EqualValues(t, []string{
+		filepath.Join(dir, "notes.txt"),
+		filepath.Join(dir, "old.log"),
+		filepath.Join(dir, "new.log"),
+	}, names)
+
+	_, err = expandInputs([]string{filepath.Join(dir, "missing.log")})
+	assert.ErrorIs(t, err, os.ErrNotExist)
+	_, err = expandInputs([]string{dir, "-"})
+	assert.Error(t, err)
+
+	assert.True(t, isMergedInput([]string{dir}))
+	assert.True(t, isMergedInput([]string{filepath.Join(dir, "*.log")}))
+	assert.True(t, isMergedInput([]string{"a", "b"}))
+	assert.False(t, isMergedInput([]string{filepath.Join(dir, "new.log")}))
+	assert.False(t, isMergedInput([]string{"-"}))
+}
+
+func TestMergedInput_FollowsFilesAndNewMatches(t *testing.T) {
+	dir := t.TempDir()
+	first := filepath.Join(dir, "a.log")
+	assert.NoError(t, os.WriteFile(first, []byte("one\ntwo\nthr"), 0644))
+
+	m, err := newMergedInput([]string{filepath.Join(dir, "*.log")}, nil, logging.New(io.Discard, logging.Text, logging.Levels{}))
+	assert.NoError(t, err)
+	defer m.Close()
+
+	lines := make(chan string, 10)
+	go func() {
+		r := bufio.NewReader(m)
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				close(lines)
+				return
+			}
+			lines <- line
+		}
+	}()
+	next := func() string {
+		select {
+		case line := <-lines:
+			return line
+		case <-time.After(5 * time.Second):
+			return "timed out"
+		}
+	}
+
+	assert.EqualValues(t, "one\n", next())
+	assert.EqualValues(t, "two\n", next())
+
+	// New files that match are merged in as they are created.
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "b.log"), []byte("four\n"), 0644))
+	assert.EqualValues(t, "four\n", next())
+
+	// The partial line is merged once it is complete.
+	f, err := os.OpenFile(first, os.O_APPEND|os.O_WRONLY, 0644)
+	assert.NoError(t, err)
+	_, err = f.WriteString("ee\n")
+	assert.NoError(t, err)
+	assert.NoError(t, f.Close())
+	assert.EqualValues(t, "three\n", next())
+
+	assert.NoError(t, m.Close())
+}
+
+func TestRotatedFiles_FindsRotationsOldestFirst(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	for i, name := range []string{"app.log", "app.log.1", "app.log.2.gz", "app.log-20240101", "app.log.bak", "app.log.1.swp", "other.log.1"} {
+		path := filepath.Join(dir, name)
+		assert.NoError(t, os.WriteFile(path, []byte("x\n"), 0644))
+		mtime := now.Add(-time.Duration(i) * time.Hour)
+		assert.NoError(t, os.Chtimes(path, mtime, mtime))
+	}
+
+	rotated, err := rotatedFiles(filepath.Join(dir, "app.log"))
+	assert.NoError(t, err)
+	assert.EqualValues(t, []string{
+		filepath.Join(dir, "app.log-20240101"),
+		filepath.Join(dir, "app.log.2.gz"),
+		filepath.Join(dir, "app.log.1"),
+	}, rotated)
+
+	rotated, err = rotatedFiles(filepath.Join(dir, "other.log"))
+	assert.NoError(t, err)
+	assert.EqualValues(t, []string{filepath.Join(dir, "other.log.1")}, rotated)
+
+	rotated, err = rotatedFiles(filepath.Join(dir, "app.log.1"))
+	assert.NoError(t, err)
+	assert.Empty(t, rotated)
+}
+
+func TestMergedInput_ReadsRotatedFilesAsOneHistory(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "app.log")
+	now := time.Now()
+
+	var gzipped bytes.Buffer
+	gw := gzip.NewWriter(&gzipped)
+	gw.Write([]byte("one\n"))
+	gw.Close()
+	for i, file := range []struct {
+		suffix   string
+		contents []byte
+	}{{".2.gz", gzipped.Bytes()}, {".1", []byte("two\n")}, {"", []byte("three\n")}} {
+		assert.NoError(t, os.WriteFile(name+file.suffix, file.contents, 0644))
+		mtime := now.Add(time.Duration(i-3) * time.Hour)
+		assert.NoError(t, os.Chtimes(name+file.suffix, mtime, mtime))
+	}
+
+	rotated, err := rotatedFiles(name)
+	assert.NoError(t, err)
+	m, err := newMergedInput(append(rotated, name), nil, logging.New(io.Discard, logging.Text, logging.Levels{}))
+	assert.NoError(t, err)
+	defer m.Close()
+
+	r := bufio.NewReader(m)
+	for _, expected := range []string{"one\n", "two\n", "three\n"} {
+		line, err := r.ReadString('\n')
+		assert.NoError(t, err)
+		assert.EqualValues(t, expected, line)
+	}
+
+	// When the log is rotated again, the history continues in the new file
+	// at its path.
+	assert.NoError(t, os.Rename(name, name+".0"))
+	assert.NoError(t, os.WriteFile(name, []byte("four\n"), 0644))
+	line, err := r.ReadString('\n')
+	assert.NoError(t, err)
+	assert.EqualValues(t, "four\n", line)
+}
@@ -0,0 +1,49 @@
+package input
+
+import "sync"
+
+// Notices passes what fails while the inputs are read on to whoever shows it,
+// like the status bar of the terminal UI. The inputs are started before it is,
+// so the notices posted before it is attached are kept until it is, and
+// without it, failures are only logged by the inputs. A nil Notices drops the
+// notices.
+type Notices struct {
+	mu      sync.Mutex
+	post    func(text string, err bool)
+	pending []notice
+}
+
+type notice struct {
+	text string
+	err  bool
+}
+
+// Notify passes on the given text, which tells of a failure if err is true,
+// once the notices are attached.
+func (n *Notices) Notify(text string, err bool) {
+	if n == nil {
+		return
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.post == nil {
+		n.pending = append(n.pending, notice{text: text, err: err})
+		return
+	}
+	n.post(text, err)
+}
+
+// Attach passes the notices on to the given function, the pending ones first.
+// It must be safe to call from any goroutine.
+func (n *Notices) Attach(post func(text string, err bool)) {
+	if n == nil {
+		return
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.post = post
+	for _, no := range n.pending {
+		post(no.text, no.err)
+	}
+	n.pending = nil
+}
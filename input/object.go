@@ -0,0 +1,343 @@
+package input
+
+import (
+	"cmp"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/YLivay/gote/logging"
+)
+
+// objectPollInterval is how often a followed object is checked for bytes
+// appended to it.
+const objectPollInterval = 5 * time.Second
+
+// gcsTokenLifetime is how long a GCS access token is used before another is
+// gotten. Tokens gcloud prints expire after an hour.
+const gcsTokenLifetime = 30 * time.Minute
+
+// emptySHA256 is the hex SHA-256 hash of an empty request body.
+const emptySHA256 = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// isObjectInput returns true if the given input is the URL of an S3 or GCS
+// object, like s3://bucket/key or gs://bucket/key.
+func isObjectInput(input string) bool {
+	return strings.HasPrefix(input, "s3://") || strings.HasPrefix(input, "gs://")
+}
+
+// objectInput downloads an object of S3 or GCS into one input. When followed,
+// the object is polled for bytes appended to it, which are range-read into the
+// input as they are.
+//
+// Objects are requested over HTTP. S3 requests are signed with the credentials
+// in the AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN
+// environment variables, and GCS requests with the token in
+// GOOGLE_OAUTH_ACCESS_TOKEN or one gcloud prints. Without credentials, the
+// object must be public.
+type objectInput struct {
+	url    string
+	scheme string
+	bucket string
+	key    string
+	follow bool
+
+	logger  *logging.Logger
+	notices *Notices
+
+	// The token GCS requests are authorized with, and when it was gotten.
+	// Tokens expire, so it is gotten again once it is old.
+	token     string
+	tokenTime time.Time
+
+	pr *io.PipeReader
+	pw *io.PipeWriter
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// newObjectInput starts downloading the object at the given URL, and following
+// it if follow is true. It fails if the object can't be read. Failures to
+// download it afterwards are logged to the given logger and told of in the
+// given notices.
+func newObjectInput(rawURL string, follow bool, logger *logging.Logger, notices *Notices) (*objectInput, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid object URL %q: %w", rawURL, err)
+	}
+	key := strings.TrimPrefix(u.Path, "/")
+	if u.Host == "" || key == "" {
+		return nil, fmt.Errorf("invalid object URL %q: expected %s://bucket/key", rawURL, u.Scheme)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	o := &objectInput{
+		url:     rawURL,
+		scheme:  u.Scheme,
+		bucket:  u.Host,
+		key:     key,
+		follow:  follow,
+		logger:  logger,
+		notices: notices,
+		cancel:  cancel,
+		done:    make(chan struct{}),
+	}
+	o.pr, o.pw = io.Pipe()
+
+	// The object is requested right away, so an object that is missing or
+	// forbidden fails the input.
+	resp, err := o.get(ctx, 0)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	logger.Info("downloading", rawURL)
+
+	go o.run(ctx, resp)
+	return o, nil
+}
+
+// Read implements io.Reader.
+func (o *objectInput) Read(p []byte) (int, error) {
+	n, err := o.pr.Read(p)
+	if errors.Is(err, io.ErrClosedPipe) {
+		// Closing the object input ends it.
+		err = io.EOF
+	}
+	return n, err
+}
+
+// Close stops downloading the object.
+func (o *objectInput) Close() error {
+	o.cancel()
+	// Whoever read the object input may have stopped, so unblock writes to it.
+	o.pr.Close()
+	<-o.done
+	return nil
+}
+
+// run copies the body of the given response into the input, and then, if the
+// object is followed, what is appended to the object, until the context is
+// done or the input is closed.
+func (o *objectInput) run(ctx context.Context, resp *http.Response) {
+	defer close(o.done)
+	defer o.pw.Close()
+
+	n, err := io.Copy(o.pw, resp.Body)
+	resp.Body.Close()
+	offset := n
+	for {
+		if errors.Is(err, io.ErrClosedPipe) || ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			o.logger.Warn("failed to download", o.url+":", err.Error())
+			o.notices.Notify("failed to download "+o.url+": "+err.Error(), true)
+		}
+		if !o.follow {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(objectPollInterval):
+		}
+
+		resp, err = o.get(ctx, offset)
+		if resp != nil {
+			n, err = io.Copy(o.pw, resp.Body)
+			resp.Body.Close()
+			offset += n
+		}
+	}
+}
+
+// get requests the object from the given offset. The response is nil if the
+// object has nothing past the offset.
+func (o *objectInput) get(ctx context.Context, offset int64) (*http.Response, error) {
+	var req *http.Request
+	var err error
+	if o.scheme == "s3" {
+		req, err = o.s3Request(ctx, offset)
+	} else {
+		req, err = o.gcsRequest(ctx, offset)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if offset > 0 && resp.StatusCode == http.StatusRequestedRangeNotSatisfiable {
+		resp.Body.Close()
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusPartialContent {
+		resp.Body.Close()
+		return nil, fmt.Errorf("failed to get %s: unexpected status %s", o.url, resp.Status)
+	}
+	return resp, nil
+}
+
+// gcsRequest returns the request of the GCS object from the given offset.
+func (o *objectInput) gcsRequest(ctx context.Context, offset int64) (*http.Request, error) {
+	endpoint := "https://storage.googleapis.com"
+	if host := os.Getenv("STORAGE_EMULATOR_HOST"); host != "" {
+		endpoint = strings.TrimSuffix(host, "/")
+		if !strings.Contains(endpoint, "://") {
+			endpoint = "http://" + endpoint
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint+"/"+o.bucket+"/"+escapeObjectKey(o.key), nil)
+	if err != nil {
+		return nil, err
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+	if time.Since(o.tokenTime) > gcsTokenLifetime {
+		o.token, o.tokenTime = o.gcsAccessToken(), time.Now()
+	}
+	if o.token != "" {
+		req.Header.Set("Authorization", "Bearer "+o.token)
+	}
+	return req, nil
+}
+
+// gcsAccessToken returns the token GCS requests are authorized with, or an
+// empty string if there is none.
+func (o *objectInput) gcsAccessToken() string {
+	if token := os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN"); token != "" {
+		return token
+	}
+	if _, err := exec.LookPath("gcloud"); err != nil {
+		return ""
+	}
+	out, err := exec.Command("gcloud", "auth", "print-access-token").Output()
+	if err != nil {
+		o.logger.Warn("failed to get an access token from gcloud:", err.Error())
+		o.notices.Notify("failed to get an access token from gcloud: "+err.Error(), true)
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// s3Request returns the signed request of the S3 object from the given offset.
+// The endpoint is AWS's, in the region of AWS_REGION, unless another is given
+// in AWS_ENDPOINT_URL_S3 or AWS_ENDPOINT_URL, where buckets are addressed in
+// the path.
+func (o *objectInput) s3Request(ctx context.Context, offset int64) (*http.Request, error) {
+	region := cmp.Or(os.Getenv("AWS_REGION"), os.Getenv("AWS_DEFAULT_REGION"), "us-east-1")
+	target := fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", o.bucket, region, escapeObjectKey(o.key))
+	if endpoint := cmp.Or(os.Getenv("AWS_ENDPOINT_URL_S3"), os.Getenv("AWS_ENDPOINT_URL")); endpoint != "" {
+		target = strings.TrimSuffix(endpoint, "/") + "/" + o.bucket + "/" + escapeObjectKey(o.key)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, err
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	accessKey, secretKey := os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY")
+	if accessKey != "" && secretKey != "" {
+		if token := os.Getenv("AWS_SESSION_TOKEN"); token != "" {
+			req.Header.Set("X-Amz-Security-Token", token)
+		}
+		signS3Request(req, accessKey, secretKey, region, time.Now())
+	}
+	return req, nil
+}
+
+// escapeObjectKey escapes the given object key for a URL path, leaving its
+// slashes as they are. Everything but unreserved characters is escaped, as
+// signed S3 requests require.
+func escapeObjectKey(key string) string {
+	var escaped strings.Builder
+	for _, c := range []byte(key) {
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9', strings.IndexByte("-._~/", c) >= 0:
+			escaped.WriteByte(c)
+		default:
+			fmt.Fprintf(&escaped, "%%%02X", c)
+		}
+	}
+	return escaped.String()
+}
+
+// signS3Request signs the given request, which has no body, with AWS Signature
+// Version 4. The host, the range and the x-amz- headers are signed.
+func signS3Request(req *http.Request, accessKey, secretKey, region string, now time.Time) {
+	date := now.UTC().Format("20060102T150405Z")
+	req.Header.Set("X-Amz-Date", date)
+	req.Header.Set("X-Amz-Content-Sha256", emptySHA256)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		name = strings.ToLower(name)
+		if name == "range" || strings.HasPrefix(name, "x-amz-") {
+			headers[name] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		emptySHA256,
+	}, "\n")
+
+	scope := date[:8] + "/" + region + "/s3/aws4_request"
+	hash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + date + "\n" + scope + "\n" + hex.EncodeToString(hash[:])
+
+	key := []byte("AWS4" + secretKey)
+	for _, part := range []string{date[:8], region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		accessKey, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,282 @@
+package input
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/YLivay/gote/logging"
+	"golang.org/x/text/encoding"
+)
+
+// Options describe the input to open.
+type Options struct {
+	// The files to read, "-" for stdin, or the URL of a remote input. Several
+	// files, or glob patterns, are merged into one input.
+	Inputs []string
+	// If set, the input is received over the network on this address
+	// instead, as ParseListenAddress takes it.
+	Listen string
+	// If true, the input is read from the systemd journal instead, of the
+	// given units, or all of them if there are none, at or above the given
+	// priority, as ParseJournalPriority returns it.
+	Journal         bool
+	JournalUnits    []string
+	JournalPriority string
+	// If true, an object downloaded from object storage is followed as it
+	// grows.
+	FollowObject bool
+	// If true, the files a single input file was rotated into aren't merged
+	// with it.
+	NoRotated bool
+	// The delimiter records are separated by in inputs that are assembled
+	// from records, or newlines if it is empty.
+	Delimiter []byte
+	// The encoding to transcode the input from, or nil to read it as UTF-8.
+	Encoding encoding.Encoding
+	// The most bytes spilled into the spill file before the spill policy
+	// applies, or 0 for unlimited.
+	MaxSpill    int64
+	SpillPolicy SpillPolicy
+	// The directory spill files are created in, or the default directory
+	// for temporary files if it is empty.
+	SpillDir string
+}
+
+// Open opens the input described by the given options for reading. If the
+// input can't be seeked, several files are merged into it, or it is received
+// over the network, streamed from a URL or WebSocket, downloaded from object
+// storage, tailed over SSH, consumed from Kafka, or read from the journal, it
+// is spooled into a temporary spill file of at most the given size, and the
+// spool is returned along with the file. What happens to the input while it is
+// read is logged to the given logger, and what fails is told of in the given
+// notices. The returned cleanup function closes the input and removes the
+// spill file.
+func Open(options Options, logger *logging.Logger, notices *Notices) (reader *os.File, spool *Spool, cleanup func(), err error) {
+	maxSpill, policy, enc := options.MaxSpill, options.SpillPolicy, options.Encoding
+
+	// As resources are created in this function, accumulate functions to clean
+	// them up in this slice.
+	var deferredCleanups []func()
+	cleanup = func() {
+		// Invoke deferredCleanups in reverse order.
+		for i := len(deferredCleanups) - 1; i >= 0; i-- {
+			deferredCleanups[i]()
+		}
+	}
+
+	inputs := options.Inputs
+	isFile := options.Listen == "" && !options.Journal && !isMergedInput(inputs) &&
+		inputs[0] != "-" && !isRemoteInput(inputs[0])
+	if isFile && !options.NoRotated {
+		// The history of a log continues in the files it was rotated into,
+		// so they are merged with it.
+		if rotated, err := rotatedFiles(inputs[0]); err != nil {
+			log.Println("Failed to look for rotated input files:", err)
+		} else if len(rotated) > 0 {
+			log.Println("Reading", len(rotated), "rotated input files before the input")
+			inputs = append(rotated, inputs[0])
+		}
+	}
+
+	var input io.Reader
+	var start []byte
+	var seekable bool
+	var sources *inputSources
+	// Inputs that are assembled from records, of several files, sent over
+	// the network, streamed from a URL or WebSocket, downloaded from object
+	// storage, tailed over SSH, consumed from Kafka, or read from the journal,
+	// are spooled like other inputs that can't be seeked.
+	assembled := options.Listen != "" || options.Journal || isMergedInput(inputs) || isRemoteInput(inputs[0])
+	switch {
+	case options.Journal:
+		j, err := newJournalInput(options.JournalUnits, options.JournalPriority, options.Delimiter, logger.Named("journal"))
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		deferredCleanups = append(deferredCleanups, func() { j.Close() })
+		input = j
+	case options.Listen != "":
+		network, address, syslog, err := ParseListenAddress(options.Listen)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		n, err := listenInput(network, address, syslog, options.Delimiter, logger.Named("listen"))
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to listen: %w", err)
+		}
+		deferredCleanups = append(deferredCleanups, func() { n.Close() })
+		input = n
+	case isMergedInput(inputs):
+		// The merged files are decompressed one by one.
+		m, err := newMergedInput(inputs, options.Delimiter, logger.Named("merge"))
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to merge inputs: %w", err)
+		}
+		deferredCleanups = append(deferredCleanups, func() { m.Close() })
+		input = m
+		sources = m.sources
+	case isURLInput(inputs[0]):
+		h := newHTTPInput(inputs[0], options.Delimiter, logger.Named("http"))
+		deferredCleanups = append(deferredCleanups, func() { h.Close() })
+		input = h
+	case isWebSocketInput(inputs[0]):
+		w := newWebSocketInput(inputs[0], options.Delimiter, logger.Named("websocket"))
+		deferredCleanups = append(deferredCleanups, func() { w.Close() })
+		input = w
+	case isSSHInput(inputs[0]):
+		s, err := newSSHInput(inputs[0], logger.Named("ssh"))
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		deferredCleanups = append(deferredCleanups, func() { s.Close() })
+		input = s
+	case isKafkaInput(inputs[0]):
+		k, err := newKafkaInput(inputs[0], options.Delimiter, logger.Named("kafka"))
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		deferredCleanups = append(deferredCleanups, func() { k.Close() })
+		input = k
+	case isObjectInput(inputs[0]):
+		o, err := newObjectInput(inputs[0], options.FollowObject, logger.Named("object"), notices)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to download object: %w", err)
+		}
+		deferredCleanups = append(deferredCleanups, func() { o.Close() })
+
+		// Objects are often compressed, which is detected as it is for
+		// other inputs that can't be seeked.
+		var c *compression
+		if input, c, err = decompressInput(o); err != nil {
+			cleanup()
+			return nil, nil, nil, fmt.Errorf("failed to decompress object: %w", err)
+		}
+		if c != nil {
+			log.Println("Object is compressed with", c.name)
+		}
+	case inputs[0] == "-":
+		reader = os.Stdin
+	default:
+		reader, err = os.Open(inputs[0])
+		if err != nil {
+			return nil, nil, nil, errors.New("Failed to open file for reading: " + err.Error())
+		}
+
+		fileToClose := reader
+		deferredCleanups = append(deferredCleanups, func() { fileToClose.Close() })
+	}
+
+	// Test if the file is seekable without changing the current position
+	var pos int64
+	if !assembled {
+		input = reader
+		pos, err = reader.Seek(0, io.SeekCurrent)
+		seekable = err == nil
+	}
+	switch {
+	case assembled:
+		// The records the input is assembled from are never compressed.
+	case seekable:
+		// Compressed files can be seeked, but not their contents, so they
+		// are decompressed through a temporary file like other inputs that
+		// can't be seeked.
+		start = make([]byte, max(maxMagicLen, maxBOMLen))
+		n, _ := reader.ReadAt(start, pos)
+		start = start[:n]
+		if c := detectCompression(start); c != nil {
+			log.Println("Input is compressed with", c.name)
+			if input, err = c.newReader(bufio.NewReader(reader)); err != nil {
+				cleanup()
+				return nil, nil, nil, fmt.Errorf("failed to decompress %s input: %w", c.name, err)
+			}
+			seekable = false
+		}
+	default:
+		var c *compression
+		if input, c, err = decompressInput(reader); err != nil {
+			cleanup()
+			return nil, nil, nil, fmt.Errorf("failed to decompress input: %w", err)
+		}
+		if c != nil {
+			log.Println("Input is compressed with", c.name)
+		}
+	}
+
+	if enc == nil && !assembled {
+		// Without an encoding to read the input in, a UTF-16 byte order
+		// mark at its start hints at one. Assembled inputs may not have
+		// anything to peek at yet, so they aren't waited for.
+		if !seekable {
+			buffered := bufio.NewReader(input)
+			start, _ = buffered.Peek(maxBOMLen)
+			input = buffered
+		}
+		if enc = encodingOfBOM(start); enc != nil {
+			log.Println("Input starts with a UTF-16 byte order mark")
+		}
+	}
+
+	if enc != nil {
+		// Records are scanned as UTF-8, and their offsets are into the
+		// transcoded input, so it goes through a temporary file too.
+		input = transcodeInput(input, enc)
+		seekable = false
+	}
+
+	if !seekable {
+		// If the file is not seekable we need to pipe it through a temporary file first.
+		// This is the case for stdin or other special files like sockets or pipes.
+		log.Println("Input is not seekable, piping through a temporary file")
+		tempWriter, err := NewSpillFile(options.SpillDir, logger.Named("spool"))
+		if err != nil {
+			cleanup()
+			return nil, nil, nil, errors.New("Failed to create temporary file: " + err.Error())
+		}
+
+		tempFname := tempWriter.Name()
+		log.Println("Using temporary file:", tempFname)
+
+		// Pipe the input to the temporary file asyncronously
+		spool = NewSpool(input, tempWriter, maxSpill, policy, logger.Named("spool"))
+		if enc == nil {
+			// Transcoding changes the offsets of the lines, so the
+			// sources of the records can't be told by them.
+			spool.sources = sources
+		}
+
+		// Open the new tempfile again for reading.
+		reader, err = os.Open(tempFname)
+		if err != nil {
+			spool.Close()
+			cleanup()
+			return nil, nil, nil, errors.New("Failed to open temporary file for reading: " + err.Error())
+		}
+
+		deferredCleanups = append(deferredCleanups, func() {
+			log.Println("Disposing temporary file:", tempFname)
+
+			if err := spool.Close(); err != nil {
+				log.Println("Failed to close the writer end of the temporary file:", err)
+			}
+
+			if err := reader.Close(); err != nil {
+				if !strings.HasSuffix(err.Error(), "file already closed") {
+					log.Println("Failed to close the reader end of the temporary file:", err)
+				}
+			}
+
+			if err := os.Remove(tempFname); err != nil {
+				if !os.IsNotExist(err) {
+					log.Println("Failed to remove temporary file:", err)
+				}
+			}
+		})
+	}
+
+	return reader, spool, cleanup, nil
+}
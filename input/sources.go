@@ -0,0 +1,105 @@
+package input
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// inputSources tells which of the files merged into an input each of its lines
+// was copied from. Files of the same name, like a file and the files it was
+// rotated into, are one source.
+type inputSources struct {
+	mu sync.Mutex
+	// The names of the sources, in the order they were added.
+	names []string
+	// Where the lines of each run of lines copied from the same source start,
+	// in the order they were copied.
+	spans []sourceSpan
+	// The bytes copied into the merged input so far.
+	size int64
+}
+
+type sourceSpan struct {
+	offset int64
+	source int
+}
+
+// add returns the source the given file is read as, adding it if it is new.
+func (s *inputSources) add(file string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	name := filepath.Base(file)
+	for i, other := range s.names {
+		if suffix, ok := strings.CutPrefix(name, other); ok && (suffix == "" || rotatedSuffix.MatchString(suffix)) {
+			return i
+		}
+		// Rotated files are read before the file they were rotated from,
+		// which names them all.
+		if suffix, ok := strings.CutPrefix(other, name); ok && rotatedSuffix.MatchString(suffix) {
+			s.names[i] = name
+			return i
+		}
+	}
+	s.names = append(s.names, name)
+	return len(s.names) - 1
+}
+
+// copied records that n bytes of the given source are copied into the merged
+// input next. It must be called before they are, so they are never read before
+// their source is known.
+func (s *inputSources) copied(source int, n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if last := len(s.spans) - 1; last < 0 || s.spans[last].source != source {
+		s.spans = append(s.spans, sourceSpan{offset: s.size, source: source})
+	}
+	s.size += int64(n)
+}
+
+// at returns the source of the line at the given offset of the merged input,
+// or false if nothing was copied there.
+func (s *inputSources) at(offset int64) (int, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if offset < 0 || offset >= s.size {
+		return 0, false
+	}
+	i := sort.Search(len(s.spans), func(i int) bool { return s.spans[i].offset > offset }) - 1
+	if i < 0 {
+		return 0, false
+	}
+	return s.spans[i].source, true
+}
+
+// list returns the names of the sources.
+func (s *inputSources) list() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]string(nil), s.names...)
+}
+
+// Sources returns the names of the files the input is merged from, or nil if
+// it isn't merged from several files. Files of the same name, like a file and
+// the files it was rotated into, are one source.
+func (s *Spool) Sources() []string {
+	if s == nil || s.sources == nil {
+		return nil
+	}
+	return s.sources.list()
+}
+
+// SourceAt returns the index into Sources of the source of the record at the
+// given offset of the spill file, or false if the input wasn't merged from
+// several files.
+func (s *Spool) SourceAt(offset int64) (int, bool) {
+	if s == nil || s.sources == nil || offset < 0 {
+		return 0, false
+	}
+	return s.sources.at(offset + s.dropped.Load())
+}
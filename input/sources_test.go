@@ -0,0 +1,31 @@
+package input
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInputSources_TellsTheSourcesOfOffsets(t *testing.T) {
+	s := &inputSources{}
+	rotated := s.add("/var/log/app.log.1")
+	other := s.add("/var/log/db.log")
+	assert.EqualValues(t, rotated, s.add("/var/log/app.log"))
+	assert.EqualValues(t, rotated, s.add("/var/log/app.log.2.gz"))
+	assert.EqualValues(t, []string{"app.log", "db.log"}, s.list())
+
+	s.copied(rotated, 10)
+	s.copied(rotated, 5)
+	s.copied(other, 7)
+	s.copied(rotated, 3)
+
+	for offset, want := range map[int64]int{0: rotated, 14: rotated, 15: other, 21: other, 22: rotated, 24: rotated} {
+		source, ok := s.at(offset)
+		assert.True(t, ok, "offset %d", offset)
+		assert.EqualValues(t, want, source, "offset %d", offset)
+	}
+	_, ok := s.at(25)
+	assert.False(t, ok)
+	_, ok = s.at(-1)
+	assert.False(t, ok)
+}
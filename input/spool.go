@@ -0,0 +1,413 @@
+package input
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/YLivay/gote/logging"
+)
+
+// spoolChunkSize is the most bytes read from the input at once.
+const spoolChunkSize = 64 << 10
+
+// spillFilePattern is the pattern of the names of spill files, as
+// os.CreateTemp takes it.
+const spillFilePattern = "gote.tmp"
+
+// spillOrphanAge is how old a spill file must be before it may be removed as
+// an orphan. A spill file is locked right after it is created, and younger
+// ones may not have been locked yet.
+const spillOrphanAge = time.Minute
+
+// spoolQueueLen is how many chunks may be read from the input before they are
+// written to the spill file. Once it is full, the input is not read until the
+// writer catches up, which blocks whoever writes into it.
+const spoolQueueLen = 16
+
+// SpillPolicy selects what is done when the spill file reaches its maximum
+// size.
+type SpillPolicy int
+
+const (
+	// Stop reading the input for good, so whoever writes into it blocks.
+	// Nothing is ever dropped from the spill file, so it stays full and the
+	// input is never read again.
+	SpillPause SpillPolicy = iota
+	// Drop the oldest half of the spill file to make room for new input.
+	SpillDropOldest
+	// Keep a window of the newest input, freeing the disk space of the
+	// oldest quarter of the spill file whenever it is full. Unlike dropping
+	// the oldest half, the spill file is never replaced, so the input's
+	// offsets stay the same.
+	SpillWindow
+)
+
+// ErrInputPaused tells that the spool stopped reading the input before it
+// ended, so not all of it can be read from the spill file.
+var ErrInputPaused = errors.New("stopped reading the input before it ended, because the spill file is full or can't be written to")
+
+// ParseSpillPolicy parses the value of the -spill-policy flag.
+func ParseSpillPolicy(value string) (SpillPolicy, error) {
+	switch value {
+	case "pause":
+		return SpillPause, nil
+	case "drop-oldest":
+		return SpillDropOldest, nil
+	case "window":
+		return SpillWindow, nil
+	}
+	return SpillPause, fmt.Errorf("unknown spill policy %q, expected pause, drop-oldest or window", value)
+}
+
+// Spool copies an input that can't be seeked, like stdin, into a temporary
+// spill file that can. The input is read into a bounded queue, so a slow disk
+// pushes back on the input instead of buffering it in memory.
+type Spool struct {
+	// The path of the spill file. When the oldest input is dropped, the file
+	// at this path is replaced, so it has to be followed by name.
+	name string
+
+	// The most bytes the spill file may hold, or 0 for unlimited.
+	maxSize int64
+	policy  SpillPolicy
+
+	queue chan []byte
+
+	// Guards the file being written to, which is replaced when the oldest
+	// input is dropped.
+	mu     sync.Mutex
+	w      *os.File
+	closed bool
+	// Closed once the spool is closed, so the input isn't queued anymore.
+	stop chan struct{}
+
+	// Bytes read from the input but not yet written to the spill file.
+	pending atomic.Int64
+	// Bytes in the spill file, after the start of its window.
+	size atomic.Int64
+	// Where the window of the spill file starts. The bytes before it were
+	// dropped and read as zeros.
+	start atomic.Int64
+	// Bytes dropped from the start of the spill file.
+	dropped atomic.Int64
+	// If true, the spill file is full and the input is not read anymore.
+	paused atomic.Bool
+	// Receives a value after chunks are written to the spill file, and is
+	// closed once no more are.
+	changed chan struct{}
+
+	// The files the input is merged from, or nil if it isn't merged.
+	sources *inputSources
+
+	logger *logging.Logger
+}
+
+// NewSpool starts copying the input into the given spill file, which it
+// takes ownership of. What happens to the input is logged to the given logger.
+func NewSpool(input io.Reader, w *os.File, maxSize int64, policy SpillPolicy, logger *logging.Logger) *Spool {
+	s := &Spool{
+		name:    w.Name(),
+		maxSize: maxSize,
+		policy:  policy,
+		queue:   make(chan []byte, spoolQueueLen),
+		w:       w,
+		stop:    make(chan struct{}),
+		changed: make(chan struct{}, 1),
+		logger:  logger,
+	}
+	go s.read(input)
+	go s.write()
+	return s
+}
+
+// read reads the input into the queue until it ends or the spool is closed.
+func (s *Spool) read(input io.Reader) {
+	defer close(s.queue)
+	logger := s.logger.Named("read")
+
+	for {
+		chunk := make([]byte, spoolChunkSize)
+		n, err := input.Read(chunk)
+		if n > 0 {
+			s.pending.Add(int64(n))
+			select {
+			case s.queue <- chunk[:n]:
+			case <-s.stop:
+				// The queue isn't written anymore, like when the
+				// spill file is full.
+				return
+			}
+		}
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				logger.Error("failed to read input:", err.Error())
+			}
+			return
+		}
+	}
+}
+
+// write writes the queued chunks to the spill file, enforcing its maximum
+// size.
+func (s *Spool) write() {
+	defer close(s.changed)
+	logger := s.logger.Named("write")
+
+	for chunk := range s.queue {
+		if s.maxSize > 0 && s.size.Load()+int64(len(chunk)) > s.maxSize {
+			if s.policy == SpillPause {
+				// Leave the queue full, so the reader stops reading the
+				// input.
+				logger.Warn("spill file is full, pausing input")
+				s.paused.Store(true)
+				return
+			}
+			if s.policy == SpillWindow {
+				err := s.slideWindow()
+				if errors.Is(err, errors.ErrUnsupported) {
+					// Holes can't be punched in the spill file, so
+					// old input is dropped by replacing it instead.
+					logger.Warn("spill file can't drop old input in place, dropping its oldest half instead")
+					s.policy = SpillDropOldest
+				} else if err != nil {
+					logger.Error("failed to drop old input:", err.Error())
+					return
+				}
+			}
+			if s.policy == SpillDropOldest {
+				if err := s.dropOldest(); err != nil {
+					logger.Error("failed to drop old input:", err.Error())
+					return
+				}
+			}
+		}
+
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			return
+		}
+		_, err := s.w.Write(chunk)
+		s.mu.Unlock()
+
+		s.pending.Add(-int64(len(chunk)))
+		s.size.Add(int64(len(chunk)))
+		if err != nil {
+			// The disk may be full, so the input stops being read like
+			// it does when the spill file is.
+			logger.Error("failed to copy input to temporary file, pausing input:", err.Error())
+			s.paused.Store(true)
+			return
+		}
+
+		select {
+		case s.changed <- struct{}{}:
+		default:
+		}
+	}
+
+	logger.Info("input closed")
+}
+
+// dropOldest replaces the spill file with one holding only its newest half,
+// starting at a line.
+func (s *Spool) dropOldest() error {
+	old, err := os.Open(s.name)
+	if err != nil {
+		return err
+	}
+	defer old.Close()
+
+	// Keep whole lines, starting after the first newline past the half.
+	from, err := nextLineStart(old, s.size.Load()-s.maxSize/2)
+	if err != nil {
+		return err
+	}
+
+	replacement, err := NewSpillFile(filepath.Dir(s.name), s.logger.Named("dropOldest"))
+	if err != nil {
+		return err
+	}
+	_, err = old.Seek(from, io.SeekStart)
+	if err == nil {
+		_, err = io.Copy(replacement, old)
+	}
+	if err == nil {
+		err = os.Rename(replacement.Name(), s.name)
+	}
+	if err != nil {
+		replacement.Close()
+		os.Remove(replacement.Name())
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return replacement.Close()
+	}
+	s.w.Close()
+	s.w = replacement
+	s.dropped.Add(from)
+	s.size.Add(-from)
+	s.logger.Named("dropOldest").Info("spill file is full, dropped", from, "bytes of old input")
+	return nil
+}
+
+// slideWindow drops the oldest quarter of the spill file's window, starting the
+// window at a line. The bytes dropped are punched out of the spill file, which
+// frees their disk space but leaves the offsets of the bytes after them as
+// they were.
+func (s *Spool) slideWindow() error {
+	r, err := os.Open(s.name)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	start := s.start.Load()
+	from, err := nextLineStart(r, start+s.size.Load()-s.maxSize*3/4)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil
+	}
+	if err := punchHole(s.w, start, from-start); err != nil {
+		return err
+	}
+	s.start.Store(from)
+	s.dropped.Add(from - start)
+	s.size.Add(start - from)
+	s.logger.Named("slideWindow").Info("spill file is full, dropped", from-start, "bytes of old input")
+	return nil
+}
+
+// nextLineStart returns the position of the first line in the given file that
+// starts at or after the given position. If no newline follows the position,
+// it returns the end of the file.
+func nextLineStart(f *os.File, pos int64) (int64, error) {
+	if _, err := f.Seek(pos, io.SeekStart); err != nil {
+		return 0, err
+	}
+	buf := make([]byte, spoolChunkSize)
+	for {
+		n, err := f.Read(buf)
+		if i := bytes.IndexByte(buf[:n], '\n'); i >= 0 {
+			return pos + int64(i) + 1, nil
+		}
+		pos += int64(n)
+		if err != nil {
+			// No newline to start at, so nothing is kept.
+			return pos, nil
+		}
+	}
+}
+
+// Start returns where the window of the spill file starts. The input before it
+// was dropped.
+func (s *Spool) Start() int64 {
+	return s.start.Load()
+}
+
+// NewSpillFile creates a spill file in the given directory, or the default
+// directory for temporary files if it is empty. The file is locked while it is
+// open, so other instances can tell it isn't orphaned. Failing to lock it is
+// logged to the given logger.
+func NewSpillFile(dir string, logger *logging.Logger) (*os.File, error) {
+	f, err := os.CreateTemp(dir, spillFilePattern)
+	if err != nil {
+		return nil, err
+	}
+	if err := lockSpillFile(f); err != nil && !errors.Is(err, errors.ErrUnsupported) {
+		logger.Warn("failed to lock spill file:", err.Error())
+	}
+	return f, nil
+}
+
+// RemoveOrphanedSpillFiles removes the spill files in the given directory, or
+// the default directory for temporary files if it is empty, that instances
+// which crashed or were killed left behind. Spill files that are still locked
+// are in use and are kept. The removed ones are logged to the given logger.
+func RemoveOrphanedSpillFiles(dir string, logger *logging.Logger) {
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	names, err := filepath.Glob(filepath.Join(dir, spillFilePattern+"*"))
+	if err != nil {
+		return
+	}
+
+	removed := 0
+	for _, name := range names {
+		info, err := os.Lstat(name)
+		if err != nil || !info.Mode().IsRegular() || time.Since(info.ModTime()) < spillOrphanAge {
+			continue
+		}
+		f, err := os.Open(name)
+		if err != nil {
+			continue
+		}
+		// The lock is released along with the file, which is removed
+		// anyway.
+		if lockSpillFile(f) == nil && os.Remove(name) == nil {
+			removed++
+		}
+		f.Close()
+	}
+	if removed > 0 {
+		logger.Info("removed", removed, "orphaned spill files from", dir)
+	}
+}
+
+// Changed returns a channel that receives a value after input is written to
+// the spill file, and is closed once the spool stops writing to it, like when
+// the input ended. Values are dropped while one is waiting to be received.
+func (s *Spool) Changed() <-chan struct{} {
+	return s.changed
+}
+
+// Close stops writing to the spill file and closes it. The input may still be
+// read until it ends.
+func (s *Spool) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	close(s.stop)
+	return s.w.Close()
+}
+
+// SpoolStatus is a snapshot of how far behind the input the spill file is.
+type SpoolStatus struct {
+	// Bytes read from the input but not yet written to the spill file.
+	Pending int64
+	// Bytes dropped from the start of the spill file.
+	Dropped int64
+	// If true, the spill file is full and the input is not read anymore.
+	Paused bool
+}
+
+// Status returns a snapshot of the spool's progress.
+func (s *Spool) Status() SpoolStatus {
+	return SpoolStatus{
+		Pending: s.pending.Load(),
+		Dropped: s.dropped.Load(),
+		Paused:  s.paused.Load(),
+	}
+}
@@ -0,0 +1,23 @@
+//go:build linux
+
+package input
+
+import (
+	"os"
+	"syscall"
+)
+
+// Flags of fallocate, which the syscall package doesn't have.
+const (
+	fallocKeepSize  = 0x1
+	fallocPunchHole = 0x2
+)
+
+// punchHole frees the disk space of the given range of the given file, which is
+// read as zeros afterwards. The size of the file stays the same.
+func punchHole(f *os.File, offset, length int64) error {
+	if length <= 0 {
+		return nil
+	}
+	return syscall.Fallocate(int(f.Fd()), fallocKeepSize|fallocPunchHole, offset, length)
+}
@@ -0,0 +1,12 @@
+//go:build !unix
+
+package input
+
+import (
+	"errors"
+	"os"
+)
+
+func lockSpillFile(f *os.File) error {
+	return errors.ErrUnsupported
+}
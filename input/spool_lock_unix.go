@@ -0,0 +1,14 @@
+//go:build unix
+
+package input
+
+import (
+	"os"
+	"syscall"
+)
+
+// lockSpillFile takes an exclusive lock of the given spill file, which is held
+// until the file is closed, or fails if another process holds it.
+func lockSpillFile(f *os.File) error {
+	return syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+}
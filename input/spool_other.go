@@ -0,0 +1,12 @@
+//go:build !linux
+
+package input
+
+import (
+	"errors"
+	"os"
+)
+
+func punchHole(f *os.File, offset, length int64) error {
+	return errors.ErrUnsupported
+}
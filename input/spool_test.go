@@ -0,0 +1,157 @@
+package input
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/YLivay/gote/logging"
+	"github.com/stretchr/testify/assert"
+)
+
+func createSpillFile(t *testing.T) *os.File {
+	w, err := os.CreateTemp(t.TempDir(), "gote.tmp")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	return w
+}
+
+func TestInputSpool_CopiesInput(t *testing.T) {
+	w := createSpillFile(t)
+	spool := NewSpool(strings.NewReader("hello\nyou\n"), w, 0, SpillPause, logging.New(io.Discard, logging.Text, logging.Levels{}))
+	defer spool.Close()
+
+	assert.Eventually(t, func() bool {
+		contents, _ := os.ReadFile(w.Name())
+		return string(contents) == "hello\nyou\n"
+	}, time.Second, 5*time.Millisecond)
+	assert.EqualValues(t, SpoolStatus{}, spool.Status())
+}
+
+func TestInputSpool_PausesWhenFull(t *testing.T) {
+	input, inputWriter := io.Pipe()
+	defer inputWriter.Close()
+
+	w := createSpillFile(t)
+	spool := NewSpool(input, w, 10, SpillPause, logging.New(io.Discard, logging.Text, logging.Levels{}))
+	defer spool.Close()
+
+	_, err := inputWriter.Write([]byte("hello\n"))
+	assert.NoError(t, err)
+	_, err = inputWriter.Write([]byte("you there\n"))
+	assert.NoError(t, err)
+
+	assert.Eventually(t, func() bool { return spool.Status().Paused }, time.Second, 5*time.Millisecond)
+	assert.EqualValues(t, SpoolStatus{Pending: 10, Paused: true}, spool.Status())
+	contents, _ := os.ReadFile(w.Name())
+	assert.EqualValues(t, "hello\n", string(contents))
+
+	// Once the queue is full too, the input is read no further, until
+	// closing the spool stops queuing it.
+	go func() {
+		for i := 0; i <= spoolQueueLen; i++ {
+			inputWriter.Write([]byte("more\n"))
+		}
+	}()
+	assert.Eventually(t, func() bool { return len(spool.queue) == spoolQueueLen }, time.Second, 5*time.Millisecond)
+	assert.NoError(t, spool.Close())
+	assert.Eventually(t, func() bool {
+		for {
+			select {
+			case _, ok := <-spool.queue:
+				if !ok {
+					return true
+				}
+			default:
+				return false
+			}
+		}
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestInputSpool_DropsOldestLines(t *testing.T) {
+	input, inputWriter := io.Pipe()
+	defer inputWriter.Close()
+
+	w := createSpillFile(t)
+	logs := logging.NewRing(10)
+	spool := NewSpool(input, w, 20, SpillDropOldest, logging.New(logs, logging.Text, logging.Levels{}))
+	defer spool.Close()
+
+	for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		_, err := inputWriter.Write([]byte(line))
+		assert.NoError(t, err)
+	}
+
+	// Only the lines from the newest half are kept, and new input is written
+	// after them in the file that replaced the spill file.
+	assert.Eventually(t, func() bool {
+		contents, _ := os.ReadFile(w.Name())
+		return string(contents) == "third\nfourth\n"
+	}, time.Second, 5*time.Millisecond)
+	assert.EqualValues(t, SpoolStatus{Dropped: int64(len("first\nsecond\n"))}, spool.Status())
+	// What was dropped is logged rather than written over the screen.
+	assert.Contains(t, strings.Join(logs.Last(10), "\n"), "[dropOldest] spill file is full, dropped 13 bytes")
+}
+
+func TestInputSpool_KeepsWindowOfNewestLines(t *testing.T) {
+	input, inputWriter := io.Pipe()
+	defer inputWriter.Close()
+
+	w := createSpillFile(t)
+	spool := NewSpool(input, w, 20, SpillWindow, logging.New(io.Discard, logging.Text, logging.Levels{}))
+	defer spool.Close()
+
+	for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		_, err := inputWriter.Write([]byte(line))
+		assert.NoError(t, err)
+	}
+
+	// The oldest line is zeroed out of the spill file, which keeps the
+	// offsets of the lines after it.
+	assert.Eventually(t, func() bool {
+		contents, _ := os.ReadFile(w.Name())
+		return strings.HasSuffix(string(contents), "third\nfourth\n")
+	}, time.Second, 5*time.Millisecond)
+	contents, _ := os.ReadFile(w.Name())
+	if string(contents) == "third\nfourth\n" {
+		t.Skip("holes can't be punched in the spill file, so it was replaced")
+	}
+	assert.EqualValues(t, "\x00\x00\x00\x00\x00\x00second\nthird\nfourth\n", string(contents))
+	assert.EqualValues(t, len("first\n"), spool.Start())
+	assert.EqualValues(t, SpoolStatus{Dropped: int64(len("first\n"))}, spool.Status())
+}
+
+func TestRemoveOrphanedSpillFiles(t *testing.T) {
+	dir := t.TempDir()
+	old := time.Now().Add(-time.Hour)
+
+	inUse, err := NewSpillFile(dir, logging.New(io.Discard, logging.Text, logging.Levels{}))
+	assert.NoError(t, err)
+	defer inUse.Close()
+	assert.NoError(t, os.Chtimes(inUse.Name(), old, old))
+
+	orphaned, err := os.CreateTemp(dir, spillFilePattern)
+	assert.NoError(t, err)
+	assert.NoError(t, orphaned.Close())
+	assert.NoError(t, os.Chtimes(orphaned.Name(), old, old))
+
+	// Spill files that were just created may not be locked yet.
+	young, err := os.CreateTemp(dir, spillFilePattern)
+	assert.NoError(t, err)
+	assert.NoError(t, young.Close())
+
+	logs := logging.NewRing(10)
+	RemoveOrphanedSpillFiles(dir, logging.New(logs, logging.Text, logging.Levels{}).Named("spool"))
+
+	assert.FileExists(t, inUse.Name())
+	assert.NoFileExists(t, orphaned.Name())
+	assert.FileExists(t, young.Name())
+	last := logs.Last(1)
+	if assert.Len(t, last, 1) {
+		assert.Contains(t, last[0], "[spool] removed 1 orphaned spill files from "+dir)
+	}
+}
@@ -0,0 +1,186 @@
+package input
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os/exec"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/YLivay/gote/logging"
+)
+
+// isSSHInput returns true if the given input is the URL of a file on a remote
+// host to read over SSH, like ssh://user@host/var/log/app.log.
+func isSSHInput(input string) bool {
+	return strings.HasPrefix(input, "ssh://")
+}
+
+// sshInput reads a file on a remote host into one input, which is followed like
+// stdin is. The file is tailed by a command run over SSH, so it isn't copied
+// before it can be viewed.
+//
+// When the connection drops, it is reconnected to like an HTTP input is, and
+// reading continues from where it stopped.
+type sshInput struct {
+	url string
+	// The arguments of ssh before the remote command: the options, port,
+	// user and host.
+	args []string
+	path string
+
+	logger *logging.Logger
+
+	// How many bytes of the file were read, which reading continues after
+	// when reconnecting.
+	offset int64
+
+	pr *io.PipeReader
+	pw *io.PipeWriter
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// newSSHInput starts reading the file at the given URL. Failures to read it are
+// logged to the given logger.
+func newSSHInput(rawURL string, logger *logging.Logger) (*sshInput, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SSH URL %q: %w", rawURL, err)
+	}
+	if u.Hostname() == "" || u.Path == "" || u.Path == "/" {
+		return nil, fmt.Errorf("invalid SSH URL %q: expected ssh://[user@]host[:port]/path", rawURL)
+	}
+
+	// Batch mode fails instead of prompting for passwords, which can't be
+	// typed into the viewer, and keepalives tell when the connection drops.
+	args := []string{"-o", "BatchMode=yes", "-o", "ServerAliveInterval=15", "-o", "ServerAliveCountMax=3"}
+	if port := u.Port(); port != "" {
+		args = append(args, "-p", port)
+	}
+	if u.User != nil {
+		args = append(args, "-l", u.User.Username())
+	}
+	args = append(args, u.Hostname())
+
+	// A path under ~/ is in the remote user's home directory.
+	path := u.Path
+	if strings.HasPrefix(path, "/~/") {
+		path = path[1:]
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &sshInput{
+		url:    rawURL,
+		args:   args,
+		path:   path,
+		logger: logger,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	s.pr, s.pw = io.Pipe()
+
+	go s.run(ctx)
+	return s, nil
+}
+
+// Read implements io.Reader.
+func (s *sshInput) Read(p []byte) (int, error) {
+	n, err := s.pr.Read(p)
+	if errors.Is(err, io.ErrClosedPipe) {
+		// Closing the SSH input ends it.
+		err = io.EOF
+	}
+	return n, err
+}
+
+// Close stops reading the remote file.
+func (s *sshInput) Close() error {
+	s.cancel()
+	// Whoever read the SSH input may have stopped, so unblock writes to it.
+	s.pr.Close()
+	<-s.done
+	return nil
+}
+
+// run reads the remote file into the input, reconnecting whenever the
+// connection drops, until the context is done or the input is closed.
+func (s *sshInput) run(ctx context.Context) {
+	defer close(s.done)
+	defer s.pw.Close()
+
+	reconnect(ctx, s.url, s.logger, func() time.Duration { return defaultReconnectDelay }, s.tail)
+}
+
+// remoteCommand returns the command that is run on the remote host to tail the
+// file from the current offset. tail -F keeps reading the file at the path when
+// it is rotated.
+func (s *sshInput) remoteCommand() string {
+	path := shellQuote(s.path)
+	if rest, ok := strings.CutPrefix(s.path, "~/"); ok {
+		path = `"$HOME"/` + shellQuote(rest)
+	}
+	return fmt.Sprintf("tail -c +%d -F %s", s.offset+1, path)
+}
+
+// tail connects to the remote host once and copies what the remote command
+// writes into the input, returning whether anything was.
+func (s *sshInput) tail(ctx context.Context) (received bool, err error) {
+	stdout := &countingWriter{w: s.pw}
+	cmd := exec.CommandContext(ctx, "ssh", slices.Concat(s.args, []string{"--", s.remoteCommand()})...)
+	cmd.Stdout = stdout
+	cmd.Stderr = &logWriter{logger: s.logger.Named("stderr")}
+	// Processes ssh leaves behind may hold its output open after it is
+	// stopped, which isn't waited for.
+	cmd.WaitDelay = time.Second
+
+	err = cmd.Run()
+	s.offset += stdout.n
+	if err != nil {
+		return stdout.n > 0, fmt.Errorf("ssh failed: %w", err)
+	}
+	return stdout.n > 0, nil
+}
+
+// countingWriter counts the bytes written through it.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// logWriter logs the lines written to it as warnings, which keeps what a
+// command writes to its stderr off the screen the viewer draws on.
+type logWriter struct {
+	logger *logging.Logger
+	buf    []byte
+}
+
+func (l *logWriter) Write(p []byte) (int, error) {
+	l.buf = append(l.buf, p...)
+	for {
+		line, rest, ok := bytes.Cut(l.buf, []byte{'\n'})
+		if !ok {
+			break
+		}
+		l.logger.Warn(string(line))
+		l.buf = rest
+	}
+	return len(p), nil
+}
+
+// shellQuote quotes the given string for a POSIX shell.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,64 @@
+package input
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/YLivay/gote/logging"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSSHInput_ContinuesFromItsOffsetWhenReconnecting(t *testing.T) {
+	// A fake ssh that runs the remote command locally, and drops the
+	// connection after a moment.
+	dir := t.TempDir()
+	commands := filepath.Join(dir, "commands")
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "ssh"), []byte(`#!/bin/sh
+while [ "$1" != "--" ]; do shift; done
+echo "$2" >> "`+commands+`"
+echo "connected to host" >&2
+exec timeout 0.5 sh -c "$2"
+`), 0755))
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	name := filepath.Join(dir, "app's.log")
+	assert.NoError(t, os.WriteFile(name, []byte("one\n"), 0644))
+
+	logs := logging.NewRing(10)
+	s, err := newSSHInput("ssh://user@host:2222"+name, logging.New(logs, logging.Text, logging.Levels{}))
+	assert.NoError(t, err)
+	defer s.Close()
+	assert.EqualValues(t, []string{"-o", "BatchMode=yes", "-o", "ServerAliveInterval=15", "-o", "ServerAliveCountMax=3", "-p", "2222", "-l", "user", "host"}, s.args)
+
+	r := bufio.NewReader(s)
+	line, err := r.ReadString('\n')
+	assert.NoError(t, err)
+	assert.EqualValues(t, "one\n", line)
+
+	assert.Eventually(t, func() bool {
+		ran, _ := os.ReadFile(commands)
+		return strings.Count(string(ran), "\n") >= 2
+	}, 5*time.Second, 10*time.Millisecond)
+
+	f, err := os.OpenFile(name, os.O_APPEND|os.O_WRONLY, 0644)
+	assert.NoError(t, err)
+	_, err = f.WriteString("two\n")
+	assert.NoError(t, err)
+	assert.NoError(t, f.Close())
+	line, err = r.ReadString('\n')
+	assert.NoError(t, err)
+	assert.EqualValues(t, "two\n", line)
+
+	ran, err := os.ReadFile(commands)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "tail -c +1 -F '"+strings.ReplaceAll(name, "'", `'\''`)+"'", strings.Split(string(ran), "\n")[0])
+	assert.Contains(t, string(ran), "tail -c +5 -F")
+
+	// What ssh writes to its stderr is logged rather than written to the
+	// screen.
+	assert.Contains(t, strings.Join(logs.Last(1), "\n"), "[stderr] connected to host")
+}
@@ -0,0 +1,210 @@
+package input
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// syslogSeverities are the names of the syslog severities, by their number.
+// Journal entries and syslog messages are tagged with them.
+var syslogSeverities = []string{"emerg", "alert", "crit", "err", "warning", "notice", "info", "debug"}
+
+// syslogFacilities are the names of the syslog facilities, by their number.
+var syslogFacilities = []string{
+	"kern", "user", "mail", "daemon", "auth", "syslog", "lpr", "news",
+	"uucp", "cron", "authpriv", "ftp", "ntp", "security", "console", "solaris-cron",
+	"local0", "local1", "local2", "local3", "local4", "local5", "local6", "local7",
+}
+
+// syslogRecord is the record a syslog message is parsed into. The fields that
+// the message doesn't have are left out.
+type syslogRecord struct {
+	Level          string                       `json:"level,omitempty"`
+	Facility       string                       `json:"facility,omitempty"`
+	Time           string                       `json:"time,omitempty"`
+	Host           string                       `json:"host,omitempty"`
+	App            string                       `json:"app,omitempty"`
+	PID            string                       `json:"pid,omitempty"`
+	MsgID          string                       `json:"msgid,omitempty"`
+	StructuredData map[string]map[string]string `json:"structured_data,omitempty"`
+	Message        string                       `json:"message"`
+}
+
+// parseSyslog parses the given syslog message, in the format of either RFC 5424
+// or RFC 3164, into a JSON record. Whatever can't be parsed is left in the
+// message field.
+func parseSyslog(msg []byte) []byte {
+	msg = bytes.TrimRight(msg, "\r\n\x00")
+
+	var record syslogRecord
+	rest, ok := parseSyslogPriority(string(msg), &record)
+	if !ok {
+		record.Message = string(msg)
+	} else if version, after, _ := strings.Cut(rest, " "); version == "1" {
+		parseRFC5424(after, &record)
+	} else {
+		parseRFC3164(rest, &record)
+	}
+
+	data, err := marshalRecord(record)
+	if err != nil {
+		return msg
+	}
+	return data
+}
+
+// marshalRecord encodes the given value as JSON for a record. Records are shown
+// as they were sent, so HTML in them isn't escaped like json.Marshal does.
+func marshalRecord(v any) ([]byte, error) {
+	var data bytes.Buffer
+	encoder := json.NewEncoder(&data)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(data.Bytes(), []byte{'\n'}), nil
+}
+
+// parseSyslogPriority parses the priority a syslog message starts with, like
+// <34>, into the level and facility of the given record, and returns the rest
+// of the message.
+func parseSyslogPriority(msg string, record *syslogRecord) (string, bool) {
+	if !strings.HasPrefix(msg, "<") {
+		return msg, false
+	}
+	end := strings.IndexByte(msg, '>')
+	if end < 2 || end > 4 {
+		return msg, false
+	}
+	priority, err := strconv.Atoi(msg[1:end])
+	if err != nil || priority < 0 || priority >= len(syslogFacilities)*8 {
+		return msg, false
+	}
+	record.Level = syslogSeverities[priority%8]
+	record.Facility = syslogFacilities[priority/8]
+	return msg[end+1:], true
+}
+
+// parseRFC5424 parses what follows the priority and version of an RFC 5424
+// message: "TIMESTAMP HOSTNAME APP-NAME PROCID MSGID STRUCTURED-DATA MSG", where
+// "-" stands for fields that are missing.
+func parseRFC5424(msg string, record *syslogRecord) {
+	for _, field := range []*string{&record.Time, &record.Host, &record.App, &record.PID, &record.MsgID} {
+		var value string
+		value, msg, _ = strings.Cut(msg, " ")
+		if value != "-" {
+			*field = value
+		}
+	}
+
+	if strings.HasPrefix(msg, "-") {
+		msg = strings.TrimPrefix(msg[1:], " ")
+	} else if strings.HasPrefix(msg, "[") {
+		record.StructuredData, msg = parseStructuredData(msg)
+	}
+	// The message may start with a byte order mark to tell it is UTF-8.
+	record.Message = strings.TrimPrefix(msg, "\ufeff")
+}
+
+// parseStructuredData parses the structured data elements at the start of an
+// RFC 5424 message, like [id param="value"], and returns the rest of it.
+func parseStructuredData(msg string) (map[string]map[string]string, string) {
+	data := make(map[string]map[string]string)
+	for strings.HasPrefix(msg, "[") {
+		var id string
+		end := strings.IndexAny(msg, " ]")
+		if end < 0 {
+			break
+		}
+		id, msg = msg[1:end], msg[end:]
+		params := make(map[string]string)
+		data[id] = params
+
+		for strings.HasPrefix(msg, " ") {
+			name, after, ok := strings.Cut(msg[1:], `="`)
+			if !ok {
+				return data, msg
+			}
+			// Values escape quotes, backslashes and closing brackets
+			// with a backslash.
+			var value strings.Builder
+			i := 0
+			for ; i < len(after) && after[i] != '"'; i++ {
+				if after[i] == '\\' && i+1 < len(after) && strings.IndexByte(`"\]`, after[i+1]) >= 0 {
+					i++
+				}
+				value.WriteByte(after[i])
+			}
+			if i == len(after) {
+				return data, ""
+			}
+			params[name] = value.String()
+			msg = after[i+1:]
+		}
+		if !strings.HasPrefix(msg, "]") {
+			return data, msg
+		}
+		msg = msg[1:]
+	}
+	return data, strings.TrimPrefix(msg, " ")
+}
+
+// parseRFC3164 parses what follows the priority of a BSD syslog message:
+// "Mmm dd hh:mm:ss HOSTNAME TAG[PID]: MSG". Messages logged locally often
+// leave out the hostname, and the tag may be missing too.
+func parseRFC3164(msg string, record *syslogRecord) {
+	if len(msg) > len(time.Stamp) {
+		if _, err := time.Parse(time.Stamp, msg[:len(time.Stamp)]); err == nil {
+			record.Time = msg[:len(time.Stamp)]
+			msg = strings.TrimPrefix(msg[len(time.Stamp):], " ")
+		}
+	}
+
+	token, rest, _ := strings.Cut(msg, " ")
+	if record.Time != "" && !isSyslogTag(token) {
+		record.Host = token
+		msg = rest
+		token, rest, _ = strings.Cut(msg, " ")
+	}
+	if isSyslogTag(token) {
+		tag := strings.TrimSuffix(token, ":")
+		if app, pid, ok := strings.Cut(tag, "["); ok {
+			record.App, record.PID = app, strings.TrimSuffix(pid, "]")
+		} else {
+			record.App = tag
+		}
+		msg = rest
+	}
+	record.Message = msg
+}
+
+// isSyslogTag returns true if the given token of a BSD syslog message looks like
+// its tag, which ends with a colon, like "sshd[42]:".
+func isSyslogTag(token string) bool {
+	return len(token) > 1 && strings.HasSuffix(token, ":")
+}
+
+// splitSyslog is a bufio.SplitFunc that splits the syslog messages sent over a
+// TCP connection. Messages are either framed by their length, like
+// "11 <34>1 - - -", or end with a newline, as RFC 6587 describes.
+func splitSyslog(data []byte, atEOF bool) (int, []byte, error) {
+	if len(data) > 0 && data[0] >= '1' && data[0] <= '9' {
+		if space := bytes.IndexByte(data, ' '); space > 0 {
+			if length, err := strconv.Atoi(string(data[:space])); err == nil {
+				if end := space + 1 + length; end <= len(data) {
+					return end, data[space+1 : end], nil
+				}
+				if !atEOF {
+					return 0, nil, nil
+				}
+			}
+		} else if !atEOF && len(data) < 10 {
+			return 0, nil, nil
+		}
+	}
+	return bufio.ScanLines(data, atEOF)
+}
@@ -0,0 +1,36 @@
+package input
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSyslog(t *testing.T) {
+	for msg, expected := range map[string]string{
+		// RFC 5424, with structured data and a byte order mark.
+		`<165>1 2003-10-11T22:14:15.003Z mymachine.example.com evntslog - ID47 [exampleSDID@32473 iut="3" eventSource="Appl\]ication"][x@1] ` + "\ufeff" + `An application event`: `{"level":"notice","facility":"local4","time":"2003-10-11T22:14:15.003Z","host":"mymachine.example.com","app":"evntslog","msgid":"ID47","structured_data":{"exampleSDID@32473":{"eventSource":"Appl]ication","iut":"3"},"x@1":{}},"message":"An application event"}`,
+		"<34>1 2003-10-11T22:14:15.003Z host su 42 - - 'su root' failed\n": `{"level":"crit","facility":"auth","time":"2003-10-11T22:14:15.003Z","host":"host","app":"su","pid":"42","message":"'su root' failed"}`,
+		// RFC 3164, with and without the hostname and tag.
+		"<34>Oct 11 22:14:15 mymachine su[230]: 'su root' failed": `{"level":"crit","facility":"auth","time":"Oct 11 22:14:15","host":"mymachine","app":"su","pid":"230","message":"'su root' failed"}`,
+		"<30>Oct  1 02:03:04 sshd: started":                       `{"level":"info","facility":"daemon","time":"Oct  1 02:03:04","app":"sshd","message":"started"}`,
+		"<14>just a message":                                      `{"level":"info","facility":"user","message":"just a message"}`,
+		// Messages that aren't syslog are kept whole.
+		"no priority": `{"message":"no priority"}`,
+		"<999>nope":   `{"message":"<999>nope"}`,
+	} {
+		assert.EqualValues(t, expected, string(parseSyslog([]byte(msg))), msg)
+	}
+}
+
+func TestSplitSyslog_FramesByLengthOrNewline(t *testing.T) {
+	scanner := bufio.NewScanner(strings.NewReader("5 <1>a\n<2>b\n9 <3>c\nd\n  \n<4>e"))
+	scanner.Split(splitSyslog)
+	var messages []string
+	for scanner.Scan() {
+		messages = append(messages, scanner.Text())
+	}
+	assert.EqualValues(t, []string{"<1>a\n", "<2>b", "<3>c\nd\n  ", "", "<4>e"}, messages)
+}
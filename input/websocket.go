@@ -0,0 +1,156 @@
+package input
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/YLivay/gote/logging"
+	"github.com/gorilla/websocket"
+)
+
+// How often a WebSocket input is pinged, and how long it may go without a pong
+// or a message before the connection is considered dead and reconnected.
+const (
+	webSocketPingInterval = 30 * time.Second
+	webSocketPongWait     = 60 * time.Second
+)
+
+// isWebSocketInput returns true if the given input is a ws:// or wss:// URL to
+// receive records from.
+func isWebSocketInput(input string) bool {
+	return strings.HasPrefix(input, "ws://") || strings.HasPrefix(input, "wss://")
+}
+
+// webSocketInput receives records from a WebSocket into one input, which is
+// followed like stdin is. Each text message is a record.
+//
+// The connection is kept alive with pings, and when it closes or stops
+// answering them, it is reconnected to like an HTTP input is.
+type webSocketInput struct {
+	url    string
+	delim  []byte
+	logger *logging.Logger
+
+	pr *io.PipeReader
+	pw *io.PipeWriter
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// newWebSocketInput starts receiving records from the given URL. Records end
+// with the given delimiter, or newlines if it is empty. Failures to receive them
+// are logged to the given logger.
+func newWebSocketInput(url string, delim []byte, logger *logging.Logger) *webSocketInput {
+	if len(delim) == 0 {
+		delim = []byte{'\n'}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	w := &webSocketInput{
+		url:    url,
+		delim:  delim,
+		logger: logger,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	w.pr, w.pw = io.Pipe()
+
+	go w.run(ctx)
+	return w
+}
+
+// Read implements io.Reader.
+func (w *webSocketInput) Read(p []byte) (int, error) {
+	n, err := w.pr.Read(p)
+	if errors.Is(err, io.ErrClosedPipe) {
+		// Closing the WebSocket input ends it.
+		err = io.EOF
+	}
+	return n, err
+}
+
+// Close stops receiving records.
+func (w *webSocketInput) Close() error {
+	w.cancel()
+	// Whoever read the WebSocket input may have stopped, so unblock writes to
+	// it.
+	w.pr.Close()
+	<-w.done
+	return nil
+}
+
+// run receives the records of the WebSocket into the input, reconnecting
+// whenever the connection closes, until the context is done or the input is
+// closed.
+func (w *webSocketInput) run(ctx context.Context) {
+	defer close(w.done)
+	defer w.pw.Close()
+
+	reconnect(ctx, w.url, w.logger, func() time.Duration { return defaultReconnectDelay }, w.receive)
+}
+
+// receive connects to the WebSocket once and copies the text messages it sends
+// into the input, returning whether any were.
+func (w *webSocketInput) receive(ctx context.Context) (received bool, err error) {
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, w.url, nil)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+
+	// Reads only fail when the connection does, so it is closed to stop
+	// them when the context is done.
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		ticker := time.NewTicker(webSocketPingInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				conn.Close()
+				return
+			case <-stop:
+				return
+			case <-ticker.C:
+				deadline := time.Now().Add(webSocketPingInterval)
+				if err := conn.WriteControl(websocket.PingMessage, nil, deadline); err != nil {
+					return
+				}
+			}
+		}
+	}()
+
+	conn.SetReadDeadline(time.Now().Add(webSocketPongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(webSocketPongWait))
+	})
+
+	for {
+		kind, message, err := conn.ReadMessage()
+		if err != nil {
+			if websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				err = nil
+			}
+			return received, err
+		}
+		conn.SetReadDeadline(time.Now().Add(webSocketPongWait))
+		if kind != websocket.TextMessage || len(message) == 0 {
+			continue
+		}
+
+		if !bytes.HasSuffix(message, w.delim) {
+			message = append(message, w.delim...)
+		}
+		if _, err := w.pw.Write(message); err != nil {
+			return received, err
+		}
+		received = true
+	}
+}
@@ -0,0 +1,52 @@
+package input
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/YLivay/gote/logging"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebSocketInput_ReceivesTextMessagesAndReconnects(t *testing.T) {
+	var connections atomic.Int32
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		switch connections.Add(1) {
+		case 1:
+			conn.WriteMessage(websocket.TextMessage, []byte("one"))
+			conn.WriteMessage(websocket.BinaryMessage, []byte("ignored"))
+			conn.WriteMessage(websocket.TextMessage, []byte("two\n"))
+			conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+		case 2:
+			conn.WriteMessage(websocket.TextMessage, []byte("three"))
+			fallthrough
+		default:
+			// Wait for the input to close the connection.
+			conn.ReadMessage()
+		}
+	}))
+	defer server.Close()
+
+	w := newWebSocketInput("ws"+strings.TrimPrefix(server.URL, "http"), nil, logging.New(io.Discard, logging.Text, logging.Levels{}))
+	defer w.Close()
+
+	r := bufio.NewReader(w)
+	for _, expected := range []string{"one\n", "two\n", "three\n"} {
+		line, err := r.ReadString('\n')
+		assert.NoError(t, err)
+		assert.EqualValues(t, expected, line)
+	}
+}
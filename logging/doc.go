@@ -0,0 +1,9 @@
+// Package logging writes gote's diagnostics, as lines of text, JSON objects or
+// key=value pairs.
+//
+// A Logger tags what it logs with the name of the component it is about, like
+// "buffer.fwdReadLoop" for a part of the buffer, and the Levels it is created
+// with drop the messages below the level set for their component. A Ring keeps
+// the most recent lines written to it, so what was logged can be shown rather
+// than written over the screen.
+package logging
@@ -0,0 +1,182 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// Format is the format gote writes its own diagnostics in.
+type Format int
+
+const (
+	// Plain lines of text, prefixed with the time.
+	Text Format = iota
+	// A JSON object per line, which gote can read itself.
+	JSON
+	// Lines of key=value pairs.
+	KeyValue
+)
+
+// ParseFormat parses a log format as given on the command line.
+func ParseFormat(value string) (Format, error) {
+	switch value {
+	case "text":
+		return Text, nil
+	case "json":
+		return JSON, nil
+	case "logfmt":
+		return KeyValue, nil
+	}
+	return Text, fmt.Errorf("unknown log format %q, expected text, json or logfmt", value)
+}
+
+// NewHandler returns a handler that writes structured records to w in the
+// given format, which must not be Text.
+func NewHandler(w io.Writer, format Format) slog.Handler {
+	opts := &slog.HandlerOptions{Level: slog.LevelDebug}
+	if format == JSON {
+		return slog.NewJSONHandler(w, opts)
+	}
+	return slog.NewTextHandler(w, opts)
+}
+
+// Logger writes gote's diagnostics, tagged with the name of the component they
+// are about, like "buffer.fwdReadLoop". Messages below the level set for their
+// component are dropped.
+type Logger struct {
+	name   string
+	levels Levels
+	// Messages are written by text if the format is Text, and otherwise
+	// by structured.
+	text       *log.Logger
+	structured slog.Handler
+}
+
+// New returns a logger of no component, which writes to w in the given
+// format. Its components are named with Named.
+func New(w io.Writer, format Format, levels Levels) *Logger {
+	l := &Logger{levels: levels}
+	if format == Text {
+		l.text = log.New(w, "", log.Ltime|log.Lmicroseconds)
+	} else {
+		l.structured = NewHandler(w, format)
+	}
+	return l
+}
+
+// Named returns a logger for the given part of the logger's component, which is
+// named after both, like "buffer.fwdReadLoop" for the "fwdReadLoop" part of the
+// "buffer" component.
+func (l *Logger) Named(name string) *Logger {
+	child := *l
+	if l.name != "" {
+		child.name = l.name + "." + name
+	} else {
+		child.name = name
+	}
+	return &child
+}
+
+// Debug logs a message about the inner workings of the component, which are
+// only of interest when debugging it. Its operands are formatted like
+// log.Println formats them.
+func (l *Logger) Debug(v ...any) {
+	l.log(slog.LevelDebug, v)
+}
+
+// Info logs a message about something notable the component did.
+func (l *Logger) Info(v ...any) {
+	l.log(slog.LevelInfo, v)
+}
+
+// Warn logs a message about something that went wrong, but that the component
+// worked around.
+func (l *Logger) Warn(v ...any) {
+	l.log(slog.LevelWarn, v)
+}
+
+// Error logs a message about something that went wrong.
+func (l *Logger) Error(v ...any) {
+	l.log(slog.LevelError, v)
+}
+
+func (l *Logger) log(level slog.Level, v []any) {
+	if level < l.levels.of(l.name) {
+		return
+	}
+	msg := strings.TrimSuffix(fmt.Sprintln(v...), "\n")
+
+	if l.text != nil {
+		l.text.Printf("%-5s [%s] %s", level, l.name, msg)
+		return
+	}
+	r := slog.NewRecord(time.Now(), level, msg, 0)
+	if component, _, _ := strings.Cut(l.name, "."); component != "" {
+		r.AddAttrs(slog.String("component", component))
+		if component != l.name {
+			r.AddAttrs(slog.String("scope", l.name))
+		}
+	}
+	l.structured.Handle(context.Background(), r)
+}
+
+// Levels are the least levels of the messages that are logged, by the
+// component they are about. The zero value logs messages of level info and
+// above about every component.
+type Levels struct {
+	base       slog.Level
+	components map[string]slog.Level
+}
+
+// ParseLevels parses log levels as given on the command line: a comma
+// separated list of the level of all components and of the levels of specific
+// ones, like "warn,buffer=info,buffer.fwdReadLoop=debug".
+func ParseLevels(value string) (Levels, error) {
+	var levels Levels
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		component, name, ok := strings.Cut(part, "=")
+		if !ok {
+			component, name = "", part
+		}
+		var level slog.Level
+		if err := level.UnmarshalText([]byte(name)); err != nil {
+			return Levels{}, fmt.Errorf("unknown log level %q, expected debug, info, warn or error", name)
+		}
+
+		if component == "" {
+			levels.base = level
+			continue
+		}
+		if levels.components == nil {
+			levels.components = make(map[string]slog.Level)
+		}
+		levels.components[component] = level
+	}
+	return levels, nil
+}
+
+// of returns the level of the component with the given name, which is the one
+// set for it, or else for the nearest component it is a part of.
+func (l Levels) of(name string) slog.Level {
+	for name != "" {
+		if level, ok := l.components[name]; ok {
+			return level
+		}
+		i := strings.LastIndexByte(name, '.')
+		if i < 0 {
+			break
+		}
+		name = name[:i]
+	}
+	return l.base
+}
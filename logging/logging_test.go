@@ -0,0 +1,82 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogger_WritesJSONWithComponents(t *testing.T) {
+	var out bytes.Buffer
+	logger := New(&out, JSON, Levels{}).Named("buffer")
+	logger.Named("fwdReadLoop").Info("EOF in follow mode")
+	logger.Error("failed to close input:", "file already closed")
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	assert.Len(t, lines, 2)
+
+	var first map[string]any
+	assert.NoError(t, json.Unmarshal([]byte(lines[0]), &first))
+	assert.EqualValues(t, "EOF in follow mode", first["msg"])
+	assert.EqualValues(t, "INFO", first["level"])
+	assert.EqualValues(t, "buffer", first["component"])
+	assert.EqualValues(t, "buffer.fwdReadLoop", first["scope"])
+	assert.Contains(t, first, "time")
+
+	var second map[string]any
+	assert.NoError(t, json.Unmarshal([]byte(lines[1]), &second))
+	assert.EqualValues(t, "failed to close input: file already closed", second["msg"])
+	assert.EqualValues(t, "ERROR", second["level"])
+	assert.NotContains(t, second, "scope")
+}
+
+func TestLogger_WritesKeyValuePairs(t *testing.T) {
+	var out bytes.Buffer
+	logger := New(&out, KeyValue, Levels{})
+	logger.Named("buffer").Named("readRecord").Info("line at", 10, "looks binary")
+
+	assert.Contains(t, out.String(), `level=INFO msg="line at 10 looks binary" component=buffer scope=buffer.readRecord`)
+}
+
+func TestLogger_WritesText(t *testing.T) {
+	var out bytes.Buffer
+	logger := New(&out, Text, Levels{})
+	logger.Named("buffer").Named("readRecord").Warn("line looks binary")
+
+	assert.True(t, strings.HasSuffix(out.String(), " WARN  [buffer.readRecord] line looks binary\n"), out.String())
+}
+
+func TestLogger_DropsMessagesBelowTheirComponentsLevel(t *testing.T) {
+	levels, err := ParseLevels("warn, buffer=info, buffer.fwdReadLoop=debug")
+	assert.NoError(t, err)
+
+	var out bytes.Buffer
+	logger := New(&out, Text, levels)
+	logger.Named("application").Info("dropped")
+	logger.Named("application").Warn("kept 1")
+	logger.Named("buffer").Named("bkdReadLoop").Debug("dropped")
+	logger.Named("buffer").Named("bkdReadLoop").Info("kept 2")
+	logger.Named("buffer").Named("fwdReadLoop").Debug("kept 3")
+
+	assert.NotContains(t, out.String(), "dropped")
+	assert.EqualValues(t, 3, strings.Count(out.String(), "kept"))
+}
+
+func TestParseLogLevels(t *testing.T) {
+	levels, err := ParseLevels("info")
+	assert.NoError(t, err)
+	assert.EqualValues(t, Levels{base: slog.LevelInfo}, levels)
+	assert.EqualValues(t, slog.LevelInfo, levels.of("buffer.fwdReadLoop"))
+
+	levels, err = ParseLevels("debug,buffer=error")
+	assert.NoError(t, err)
+	assert.EqualValues(t, slog.LevelDebug, levels.of("application"))
+	assert.EqualValues(t, slog.LevelError, levels.of("buffer.fwdReadLoop"))
+
+	_, err = ParseLevels("buffer=loud")
+	assert.Error(t, err)
+}
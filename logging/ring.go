@@ -0,0 +1,47 @@
+package logging
+
+import (
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Ring keeps the most recent lines written to it, forgetting the oldest ones
+// once it holds its maximum.
+type Ring struct {
+	mu    sync.Mutex
+	lines []string
+	// Where the next line is stored once the ring is full, which is where the
+	// oldest one is.
+	next int
+	max  int
+}
+
+// NewRing returns a ring that keeps up to the given number of lines.
+func NewRing(max int) *Ring {
+	return &Ring{max: max}
+}
+
+func (r *Ring) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, line := range strings.Split(strings.TrimSuffix(string(p), "\n"), "\n") {
+		if len(r.lines) < r.max {
+			r.lines = append(r.lines, line)
+			continue
+		}
+		r.lines[r.next] = line
+		r.next = (r.next + 1) % r.max
+	}
+	return len(p), nil
+}
+
+// Last returns up to the given number of the most recent lines, oldest first.
+func (r *Ring) Last(n int) []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ordered := slices.Concat(r.lines[r.next:], r.lines[:r.next])
+	return ordered[max(len(ordered)-n, 0):]
+}
@@ -0,0 +1,23 @@
+package logging
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRing_KeepsTheMostRecentLines(t *testing.T) {
+	ring := NewRing(3)
+	assert.Empty(t, ring.Last(10))
+
+	for i := 1; i <= 4; i++ {
+		fmt.Fprintf(ring, "line %d\n", i)
+	}
+	assert.EqualValues(t, []string{"line 2", "line 3", "line 4"}, ring.Last(10))
+	assert.EqualValues(t, []string{"line 3", "line 4"}, ring.Last(2))
+
+	// A message of several lines is kept as its lines.
+	fmt.Fprint(ring, "line 5\nline 6\n")
+	assert.EqualValues(t, []string{"line 4", "line 5", "line 6"}, ring.Last(10))
+}
@@ -2,21 +2,63 @@ package reader
 
 import (
 	"bytes"
-	"errors"
 	"fmt"
 	"io"
+	"slices"
+	"sync"
 )
 
 // ErrUseAfterClose is returned when the scanner is used after Close() was called.
 var ErrUseAfterClose = fmt.Errorf("scanner used after Close()")
 
+// BackwardsLineScanner reads lines from a reader backwards, from a position
+// towards the start of the reader. It reads with ReadAt and has no position of
+// its own in the reader, so the reader may be shared with other scanners.
 type BackwardsLineScanner struct {
-	reader      io.ReadSeeker
+	reader      io.ReaderAt
 	nextPos     int64
 	chunkSize   int
 	chunks      []*readChunk
 	nextNewLine int
 	lastErr     error
+
+	// The delimiter lines end with.
+	delim []byte
+	// If true, the end of the delimiter is also its start, like with --, so
+	// delimiters may overlap one another.
+	overlaps bool
+	// Where the delimiters that overlap one another, which endsLine last
+	// split, start, and the ones of them that end lines, in order.
+	chainStart int64
+	chainEnds  []int64
+
+	// The most bytes of a line that are kept, or 0 to keep whole lines.
+	maxLineLen int
+	// Returns where the reader starts, if it isn't at 0.
+	start func() int64
+	// The number of bytes at the end of the line being read that were
+	// dropped because the line is too long.
+	dropped int
+	// The length of the last line read, including the bytes that weren't
+	// kept.
+	lineLen int
+	// If true, the line being read ends with a carriage return that was in
+	// the dropped bytes.
+	droppedCR bool
+	// If true, the last line read ended with \r\n rather than just \n.
+	crlf bool
+	// If true, only the start of the last line read was kept.
+	truncated bool
+
+	// The last line read by Scan, and where it starts.
+	line []byte
+	pos  int64
+	// If true, the line at the start of the reader was read, so there are no
+	// more lines to scan.
+	atStart bool
+	// If true, nothing was left before the position to read a line from, so
+	// the last line ReadLine returned isn't one.
+	exhausted bool
 }
 
 type readChunk struct {
@@ -24,45 +66,134 @@ type readChunk struct {
 	len int
 }
 
-func NewBackwardsLineScanner(reader io.ReadSeeker, chunkSize int, seekAndWhence ...int64) (*BackwardsLineScanner, error) {
-	var seek, whence int64
-	switch len(seekAndWhence) {
-	case 0:
-		seek = 0
-		whence = io.SeekEnd
-	case 1:
-		seek = seekAndWhence[0]
-		if seek >= 0 {
-			whence = io.SeekStart
-		} else {
-			whence = io.SeekEnd
-			seek = -seek
-		}
-	case 2:
-		seek = seekAndWhence[0]
-		whence = seekAndWhence[1]
-	default:
-		panic("Too many arguments")
+// chunkPool holds the buffers of chunks that scanners are done with, so reading
+// backwards through a large file doesn't allocate a new buffer for every chunk.
+var chunkPool sync.Pool
+
+// getChunkBuf returns a buffer of the given size for a chunk, reusing one from
+// the pool if it is large enough.
+func getChunkBuf(size int) []byte {
+	if buf, ok := chunkPool.Get().(*[]byte); ok && cap(*buf) >= size {
+		return (*buf)[:size]
 	}
+	return make([]byte, size)
+}
 
-	pos, err := reader.Seek(int64(seek), int(whence))
-	if err != nil {
-		return nil, err
+// putChunkBuf returns the buffer of a chunk to the pool. Nothing may refer to
+// it afterwards.
+func putChunkBuf(buf []byte) {
+	if cap(buf) == 0 {
+		return
 	}
+	buf = buf[:cap(buf)]
+	chunkPool.Put(&buf)
+}
 
+// releaseChunks returns the buffers of the given chunks to the pool.
+func releaseChunks(chunks []*readChunk) {
+	for _, chunk := range chunks {
+		putChunkBuf(chunk.buf)
+		chunk.buf = nil
+	}
+}
+
+// NewBackwardsLineScanner creates a scanner that reads the lines before the
+// given position in the reader.
+//
+// Only the bytes before the position are ever read, so the reader may grow
+// while it is scanned, like a log that is being written: what is written after
+// the position is ignored. If it shrinks instead, ReadLine fails with
+// io.ErrUnexpectedEOF once it gets to the bytes that are gone.
+func NewBackwardsLineScanner(reader io.ReaderAt, pos int64, opts ...Option) (*BackwardsLineScanner, error) {
+	if pos < 0 {
+		return nil, fmt.Errorf("invalid position %d", pos)
+	}
+
+	o := newOptions(opts)
 	scanner := &BackwardsLineScanner{
 		reader:      reader,
 		nextPos:     pos,
-		chunkSize:   chunkSize,
+		chunkSize:   o.chunkSize,
 		chunks:      make([]*readChunk, 0),
 		nextNewLine: -1,
 		lastErr:     nil,
+		delim:       o.delim,
+		overlaps:    overlapsItself(o.delim),
+		maxLineLen:  o.maxLineLen,
+		start:       o.start,
 	}
 
 	return scanner, nil
 }
 
+// LineLen returns the length of the last line read, without its line ending.
+// It is longer than what ReadLine returned if only the start of the line was
+// kept.
+func (s *BackwardsLineScanner) LineLen() int {
+	return s.lineLen
+}
+
+// Truncated returns true if the last line read was longer than the most bytes
+// of a line that are kept, so only its start was returned. The rest of it is
+// skipped rather than held, so a line without a delimiter in sight doesn't
+// grow the scanner's memory without bound.
+func (s *BackwardsLineScanner) Truncated() bool {
+	return s.truncated
+}
+
+// CRLF returns true if the last line read ended with \r\n, so its line ending
+// is two bytes long rather than one. The carriage return of the last line of
+// the file is dropped too, even without a newline after it.
+func (s *BackwardsLineScanner) CRLF() bool {
+	return s.crlf
+}
+
+// Scan advances the scanner to the line before the last one it read, which is
+// then returned by Bytes and Text, like ForwardsLineScanner.Scan does in the
+// other direction. It returns false after the line at the start of the reader
+// was read, or on an error, which Err returns.
+func (s *BackwardsLineScanner) Scan() bool {
+	s.line = nil
+	if s.atStart {
+		return false
+	}
+
+	line, pos, err := s.ReadLine()
+	if s.exhausted || (err != nil && err != io.EOF) {
+		return false
+	}
+	s.line, s.pos = line, pos
+	return true
+}
+
+// Bytes returns the last line read by Scan, without its line ending.
+func (s *BackwardsLineScanner) Bytes() []byte {
+	return s.line
+}
+
+// Text returns the last line read by Scan as a string, without its line ending.
+func (s *BackwardsLineScanner) Text() string {
+	return string(s.line)
+}
+
+// Pos returns the position in the reader where the last line read by Scan
+// starts.
+func (s *BackwardsLineScanner) Pos() int64 {
+	return s.pos
+}
+
+// Err returns the error that stopped the scanner, or nil if it only reached the
+// start of the reader or wasn't stopped.
+func (s *BackwardsLineScanner) Err() error {
+	if s.lastErr == io.EOF {
+		return nil
+	}
+	return s.lastErr
+}
+
+// Close releases the buffers of the scanner. It can't be used afterwards.
 func (s *BackwardsLineScanner) Close() error {
+	releaseChunks(s.chunks)
 	s.chunks = nil
 	s.lastErr = ErrUseAfterClose
 	return nil
@@ -71,8 +202,10 @@ func (s *BackwardsLineScanner) Close() error {
 // ReadLine reads the next line from the file, starting from the current
 // position. It returns the line, the position in the file where the line
 // starts, and an error if any occured. If the end of the file is reached, the
-// error will be io.EOF. If a non io.EOF error has occured, no line data will be
-// returned and the position will be -1.
+// error will be io.EOF. If nothing is left before the position, like when the
+// reader is empty, an empty line is returned along with io.EOF, which Scan
+// doesn't take for a line. If a non io.EOF error has occured, no line data will
+// be returned and the position will be -1.
 func (s *BackwardsLineScanner) ReadLine() ([]byte, int64, error) {
 	var err error
 
@@ -93,62 +226,91 @@ func (s *BackwardsLineScanner) ReadLine() ([]byte, int64, error) {
 
 	numChunks := len(s.chunks)
 
-	// If the last chunk started with a
+	// Nothing is left before the position, so there is no line to read.
 	if numChunks == 0 {
-		return []byte{}, 0, io.EOF
+		s.atStart, s.exhausted = true, true
+		return []byte{}, s.nextPos, io.EOF
 	}
 
 	curChunk := s.chunks[numChunks-1]
 	var nlIdx int
 	if s.nextNewLine == -1 {
-		nlIdx = bytes.LastIndexByte(curChunk.buf, '\n')
+		var findErr error
+		if nlIdx, findErr = s.findDelimiter(); findErr != nil {
+			s.lastErr = findErr
+			return nil, -1, findErr
+		}
 	} else {
 		nlIdx = s.nextNewLine
 	}
 
-	// If we found a newline or we reached the start of the file, start
+	// If we found a delimiter or we reached the start of the file, start
 	// constructing the result line from our buffers.
 	if nlIdx != -1 || err == io.EOF {
+		// The line starts after the delimiter, which may continue into the
+		// chunks read before the current one.
+		start := 0
+		if nlIdx != -1 {
+			start = nlIdx + len(s.delim)
+		}
+
 		// Calculate the length of the line so we can allocate a buffer for it
 		// once without reallocations.
-
-		// The first chunk is partial. We only consider the bytes after the newline.
-		lineLen := curChunk.len - nlIdx - 1
-		// The rest of the chunks are full, so we consider all of their bytes.
-		for i := numChunks - 2; i >= 0; i-- {
-			lineLen += s.chunks[i].len
+		lineLen := -start
+		for _, chunk := range s.chunks {
+			lineLen += chunk.len
+		}
+		s.lineLen = lineLen + s.dropped
+		if s.dropped > 0 {
+			s.crlf = s.droppedCR
+		} else {
+			s.crlf = lineLen > 0 && s.endsWithCR()
+		}
+		if s.crlf {
+			// Get rid of the carriage return of a \r\n line ending.
+			s.lineLen--
+			lineLen = min(lineLen, s.lineLen)
 		}
+		s.dropped = 0
+		if s.maxLineLen > 0 {
+			// Copying stops when the line is full, keeping only its start.
+			lineLen = min(lineLen, s.maxLineLen)
+		}
+		s.truncated = lineLen < s.lineLen
 		line := make([]byte, lineLen)
 
-		// Copy the bytes from the chunks into the result line.
-		written := copy(line, curChunk.buf[nlIdx+1:curChunk.len]) // Note, the first chunk is partial.
-		// The rest of the chunks are full.
-		for i := numChunks - 2; i >= 0; i-- {
-			written += copy(line[written:], s.chunks[i].buf[:s.chunks[i].len])
+		// Copy the bytes from the chunks into the result line, in the order
+		// they are in the file and skipping the ones before the line.
+		written, skip := 0, start
+		for i := numChunks - 1; i >= 0 && written < lineLen; i-- {
+			chunk := s.chunks[i].buf[:s.chunks[i].len]
+			if skip >= len(chunk) {
+				skip -= len(chunk)
+				continue
+			}
+			written += copy(line[written:], chunk[skip:])
+			skip = 0
 		}
 
-		// Cleanup to prep for the next read.
-		s.chunks = make([]*readChunk, 0)
+		// Cleanup to prep for the next read. The line was copied out of the
+		// chunks, so their buffers can be reused.
+		releaseChunks(s.chunks[:numChunks-1])
+		s.chunks = s.chunks[:0]
 
 		if nlIdx != -1 {
 			// We need to save the bytes before the new line in curChunk.buf. These are
 			// the end of the NEXT line we'll be reading.
-			var remainingChunk *readChunk
-			if nlIdx > 0 {
-				remainingChunk = &readChunk{
-					buf: curChunk.buf[:nlIdx],
-					len: nlIdx,
-				}
-			} else {
-				remainingChunk = &readChunk{
-					buf: []byte{},
-					len: 0,
-				}
+			curChunk.len = nlIdx
+			s.chunks = append(s.chunks, curChunk)
+			var findErr error
+			if s.nextNewLine, findErr = s.findDelimiter(); findErr != nil {
+				// The line was read, so the error is returned along
+				// with the next one.
+				s.lastErr = findErr
+				s.nextNewLine = -1
 			}
-
-			s.chunks = append(s.chunks, remainingChunk)
-			s.nextNewLine = bytes.LastIndexByte(remainingChunk.buf, '\n')
 		} else {
+			releaseChunks([]*readChunk{curChunk})
 			s.nextNewLine = -1
 		}
 
@@ -157,82 +319,226 @@ func (s *BackwardsLineScanner) ReadLine() ([]byte, int64, error) {
 			err = nil
 		}
 
-		lineStartedAt := s.nextPos + int64(nlIdx) + 1
+		lineStartedAt := s.nextPos + int64(start)
+		if err == io.EOF {
+			s.atStart = true
+		}
 
 		return line, lineStartedAt, err
 	}
 
+	s.dropLineEnd()
 	return s.ReadLine()
 }
 
-func (s *BackwardsLineScanner) readMore() (int, error) {
-	if s.lastErr != nil {
-		return 0, s.lastErr
+// dropLineEnd drops the chunks at the end of the line being read that are not
+// needed to keep its start, so a long line doesn't have to be held whole.
+func (s *BackwardsLineScanner) dropLineEnd() {
+	if s.maxLineLen <= 0 {
+		return
 	}
 
-	buf := make([]byte, s.chunkSize)
-	result, err := ReadBackwardsFrom(s.reader, s.nextPos, buf)
-	n := result.N
-
-	// In case of a partial read, try reading the remaining bytes.
-	leftToRead := result.LeftToRead
-	if leftToRead > 0 {
-		// If no data is returned at all for a few consecutive reads, we stop
-		// trying and return io.ErrNoProgress.
-		emptyReads := 0
-		maxEmptyReads := 10
-		nPart := 0
-		for leftToRead > 0 {
-			nPart, err = s.reader.Read(buf[n : n+leftToRead])
+	// The chunks are in reverse order, so the first one holds the end of the
+	// line. It can go once the ones read after it hold enough of the line.
+	// The last one is read up to the start of the line, so it is never
+	// dropped. Its first bytes may still be the end of the delimiter before
+	// the line, so they don't count towards what is kept.
+	rest := 1 - len(s.delim)
+	for _, chunk := range s.chunks[1:] {
+		rest += chunk.len
+	}
+	if len(s.chunks) > 1 && rest >= s.maxLineLen && s.dropped == 0 {
+		// The end of the line is about to be dropped, so remember whether it
+		// ends with a carriage return.
+		s.droppedCR = s.endsWithCR()
+	}
+	for len(s.chunks) > 1 && rest >= s.maxLineLen {
+		s.dropped += s.chunks[0].len
+		releaseChunks(s.chunks[:1])
+		s.chunks = s.chunks[1:]
+		if len(s.chunks) > 1 {
+			rest -= s.chunks[0].len
+		}
+	}
+}
 
-			n += nPart
-			leftToRead -= nPart
+// endsWithCR returns true if the delimiter is a newline and the bytes read so
+// far end with a carriage return, which the caller must know belong to the line
+// being read.
+func (s *BackwardsLineScanner) endsWithCR() bool {
+	if !bytes.Equal(s.delim, newline) {
+		return false
+	}
+	for _, chunk := range s.chunks {
+		if chunk.len > 0 {
+			return chunk.buf[chunk.len-1] == '\r'
+		}
+	}
+	return false
+}
 
+// findDelimiter returns the offset in the last chunk of the last delimiter
+// that starts in it and ends a line, or -1 if there is none. The delimiter may
+// continue into the chunks read before it, which come after it in the file.
+func (s *BackwardsLineScanner) findDelimiter() (int, error) {
+	cur := s.chunks[len(s.chunks)-1]
+	end := cur.len
+	for {
+		i := bytes.LastIndexByte(cur.buf[:end], s.delim[0])
+		if i == -1 {
+			return -1, nil
+		}
+		if s.delimiterAt(i) {
+			ends, err := s.endsLine(s.nextPos + int64(i))
 			if err != nil {
-				break
+				return -1, err
 			}
-
-			// Sanity check for bad readers.
-			if leftToRead < 0 {
-				err = io.ErrShortBuffer
-				break
+			if ends {
+				return i, nil
 			}
+		}
+		end = i
+	}
+}
 
-			if nPart == 0 {
-				emptyReads++
-				if emptyReads >= maxEmptyReads {
-					err = io.ErrNoProgress
-					break
+// endsLine returns true if the delimiter at the given position in the reader
+// ends a line. The forwards scanner ends a line at the first delimiter it
+// finds and looks for the next one after it, so of the delimiters that overlap
+// one another, only the first one, the first one after it, and so on, end
+// lines. Where they start is found by reading the bytes before them.
+func (s *BackwardsLineScanner) endsLine(pos int64) (bool, error) {
+	if !s.overlaps {
+		return true, nil
+	}
+	if n := len(s.chainEnds); n > 0 && pos >= s.chainStart && pos <= s.chainEnds[n-1] {
+		// The delimiters were already split, after the ones scanned before.
+		_, ends := slices.BinarySearch(s.chainEnds, pos)
+		return ends, nil
+	}
+
+	var start int64
+	if s.start != nil {
+		start = s.start()
+	}
+	delimLen := int64(len(s.delim))
+
+	// buf holds the bytes from the position from up to the end of the
+	// delimiter at pos.
+	from, buf := pos, slices.Clone(s.delim)
+	first := pos
+	for {
+		// Look for the first delimiter that overlaps the first one found so
+		// far, reading the bytes before them if they weren't yet.
+		lo := max(first-delimLen+1, start)
+		if lo < from {
+			readFrom := max(min(lo, from-int64(s.chunkSize)), start)
+			before := make([]byte, from-readFrom)
+			n, err := s.reader.ReadAt(before, readFrom)
+			if n < len(before) {
+				if err == nil || err == io.EOF {
+					err = io.ErrUnexpectedEOF
 				}
-			} else {
-				emptyReads = 0
+				return false, fmt.Errorf("expected to read %d bytes at %d, but only read %d: %w", len(before), readFrom, n, err)
 			}
+			from, buf = readFrom, append(before, buf...)
+		}
+
+		overlapping := int64(-1)
+		for i := lo; i < first; i++ {
+			if bytes.HasPrefix(buf[i-from:], s.delim) {
+				overlapping = i
+				break
+			}
+		}
+		if overlapping == -1 {
+			break
+		}
+		first = overlapping
+	}
+
+	// Split the delimiters like the forwards scanner does, from the first.
+	s.chainStart, s.chainEnds = first, s.chainEnds[:0]
+	for i := first; i <= pos; {
+		if bytes.HasPrefix(buf[i-from:], s.delim) {
+			s.chainEnds = append(s.chainEnds, i)
+			i += delimLen
+		} else {
+			i++
 		}
 	}
+	return s.chainEnds[len(s.chainEnds)-1] == pos, nil
+}
+
+// overlapsItself returns true if the end of the given delimiter is also its
+// start, so delimiters may overlap one another.
+func overlapsItself(delim []byte) bool {
+	for i := 1; i < len(delim); i++ {
+		if bytes.HasPrefix(delim, delim[i:]) {
+			return true
+		}
+	}
+	return false
+}
+
+// delimiterAt returns true if the delimiter starts at the given offset in the
+// last chunk.
+func (s *BackwardsLineScanner) delimiterAt(offset int) bool {
+	matched := 0
+	for i := len(s.chunks) - 1; i >= 0 && matched < len(s.delim); i-- {
+		chunk := s.chunks[i].buf[offset:s.chunks[i].len]
+		n := min(len(chunk), len(s.delim)-matched)
+		if !bytes.Equal(chunk[:n], s.delim[matched:matched+n]) {
+			return false
+		}
+		matched += n
+		offset = 0
+	}
+	return matched == len(s.delim)
+}
+
+func (s *BackwardsLineScanner) readMore() (int, error) {
+	if s.lastErr != nil {
+		return 0, s.lastErr
+	}
+
+	var start int64
+	if s.start != nil {
+		start = s.start()
+	}
+	if s.nextPos <= start {
+		// What is left before the position was dropped.
+		return 0, io.EOF
+	}
 
-	s.nextPos = result.NextPos
+	buf := getChunkBuf(s.chunkSize)
+	toRead := int(min(int64(s.chunkSize), s.nextPos-start))
+	from := s.nextPos - int64(toRead)
+
+	// ReadAt only reads less than asked for along with an error.
+	n, err := s.reader.ReadAt(buf[:toRead], from)
+	if n < toRead {
+		putChunkBuf(buf)
+
+		// EOFs are not supported because it means the file got shorter after
+		// the scanner was initialized. This read is basically undefined
+		// behavior.
+		if err == nil || err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return 0, fmt.Errorf("expected to read %d bytes at %d, but only read %d: %w", toRead, from, n, err)
+	}
+
+	s.nextPos = from
 
 	s.chunks = append(s.chunks, &readChunk{
 		buf: buf,
 		len: n,
 	})
 
-	// EOFs are not supported because it means the file got shorter after the
-	// reader was initialized. This read is basically undefined behavior.
-	if err == io.EOF {
-		return n, io.ErrUnexpectedEOF
-	}
-
 	// If we reached the start of the file.
-	if s.nextPos == 0 {
+	if s.nextPos == start {
 		return n, io.EOF
-	} else if leftToRead > 0 {
-		if err == nil {
-			err = errors.New("no error was returned")
-		}
-
-		return n, fmt.Errorf("expected to read %d bytes, but only read %d: %w", s.chunkSize, n, err)
 	}
 
-	return n, err
+	return n, nil
 }
@@ -1,16 +1,21 @@
 package reader
 
 import (
+	"fmt"
 	"io"
+	"math/rand"
+	"os"
+	"slices"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 )
 
 func TestBackwardsLineScanner_ReadsSingleLine_SingleChunk(t *testing.T) {
-	f, _ := createTestFile(t, "hello", 0, io.SeekEnd)
+	f, size := createTestFile(t, "hello", 0, io.SeekEnd)
 
-	s, err := NewBackwardsLineScanner(f, 1024)
+	s, err := NewBackwardsLineScanner(f, size)
 	assert.NoError(t, err)
 
 	bytes, pos, err := s.ReadLine()
@@ -20,9 +25,9 @@ func TestBackwardsLineScanner_ReadsSingleLine_SingleChunk(t *testing.T) {
 }
 
 func TestBackwardsLineScanner_ReadsSingleLine_TwoChunks(t *testing.T) {
-	f, _ := createTestFile(t, "hello", 0, io.SeekEnd)
+	f, size := createTestFile(t, "hello", 0, io.SeekEnd)
 
-	s, err := NewBackwardsLineScanner(f, 3)
+	s, err := NewBackwardsLineScanner(f, size, WithChunkSize(3))
 	assert.NoError(t, err)
 
 	bytes, pos, err := s.ReadLine()
@@ -32,9 +37,9 @@ func TestBackwardsLineScanner_ReadsSingleLine_TwoChunks(t *testing.T) {
 }
 
 func TestBackwardsLineScanner_ReadsSingleLine_ThreeChunks(t *testing.T) {
-	f, _ := createTestFile(t, "hello", 0, io.SeekEnd)
+	f, size := createTestFile(t, "hello", 0, io.SeekEnd)
 
-	s, err := NewBackwardsLineScanner(f, 2)
+	s, err := NewBackwardsLineScanner(f, size, WithChunkSize(2))
 	assert.NoError(t, err)
 
 	bytes, pos, err := s.ReadLine()
@@ -44,9 +49,9 @@ func TestBackwardsLineScanner_ReadsSingleLine_ThreeChunks(t *testing.T) {
 }
 
 func TestBackwardsLineScanner_ReadsSingleLine_ManyChunks(t *testing.T) {
-	f, _ := createTestFile(t, "hello", 0, io.SeekEnd)
+	f, size := createTestFile(t, "hello", 0, io.SeekEnd)
 
-	s, err := NewBackwardsLineScanner(f, 1)
+	s, err := NewBackwardsLineScanner(f, size, WithChunkSize(1))
 	assert.NoError(t, err)
 
 	bytes, pos, err := s.ReadLine()
@@ -56,9 +61,9 @@ func TestBackwardsLineScanner_ReadsSingleLine_ManyChunks(t *testing.T) {
 }
 
 func TestBackwardsLine_ReadsOneLine(t *testing.T) {
-	f, _ := createTestFile(t, "hi\nhello", 0, io.SeekEnd)
+	f, size := createTestFile(t, "hi\nhello", 0, io.SeekEnd)
 
-	s, err := NewBackwardsLineScanner(f, 1024)
+	s, err := NewBackwardsLineScanner(f, size)
 	assert.NoError(t, err)
 
 	bytes, pos, err := s.ReadLine()
@@ -68,9 +73,9 @@ func TestBackwardsLine_ReadsOneLine(t *testing.T) {
 }
 
 func TestBackwardsLine_ReadsEmptyLine(t *testing.T) {
-	f, _ := createTestFile(t, "hello\n", 0, io.SeekEnd)
+	f, size := createTestFile(t, "hello\n", 0, io.SeekEnd)
 
-	s, err := NewBackwardsLineScanner(f, 1024)
+	s, err := NewBackwardsLineScanner(f, size)
 	assert.NoError(t, err)
 
 	bytes, pos, err := s.ReadLine()
@@ -80,9 +85,9 @@ func TestBackwardsLine_ReadsEmptyLine(t *testing.T) {
 }
 
 func TestBackwardsLine_ReadsOneLine_WithoutLastNewLine(t *testing.T) {
-	f, _ := createTestFile(t, "\nhello", 0, io.SeekEnd)
+	f, size := createTestFile(t, "\nhello", 0, io.SeekEnd)
 
-	s, err := NewBackwardsLineScanner(f, 1024)
+	s, err := NewBackwardsLineScanner(f, size)
 	assert.NoError(t, err)
 
 	bytes, pos, err := s.ReadLine()
@@ -92,9 +97,9 @@ func TestBackwardsLine_ReadsOneLine_WithoutLastNewLine(t *testing.T) {
 }
 
 func TestBackwardsLine_ReadsTwoLines_SingleChunk(t *testing.T) {
-	f, _ := createTestFile(t, "hi\nhello", 0, io.SeekEnd)
+	f, size := createTestFile(t, "hi\nhello", 0, io.SeekEnd)
 
-	s, err := NewBackwardsLineScanner(f, 1024)
+	s, err := NewBackwardsLineScanner(f, size)
 	assert.NoError(t, err)
 
 	bytes, pos, err := s.ReadLine()
@@ -108,9 +113,9 @@ func TestBackwardsLine_ReadsTwoLines_SingleChunk(t *testing.T) {
 }
 
 func TestBackwardsLine_ReadsTwoLines_SingleChunk_PerLine(t *testing.T) {
-	f, _ := createTestFile(t, "hi\nhello", 0, io.SeekEnd)
+	f, size := createTestFile(t, "hi\nhello", 0, io.SeekEnd)
 
-	s, err := NewBackwardsLineScanner(f, 5)
+	s, err := NewBackwardsLineScanner(f, size, WithChunkSize(5))
 	assert.NoError(t, err)
 
 	bytes, pos, err := s.ReadLine()
@@ -126,9 +131,9 @@ func TestBackwardsLine_ReadsTwoLines_SingleChunk_PerLine(t *testing.T) {
 // TestBackwardsLine_ReadsTwoLines_NewLineOnBorder tests that the scanner can
 // read two lines when the newline is on the border of two chunks.
 func TestBackwardsLine_ReadsTwoLines_NewLineOnBorder(t *testing.T) {
-	f, _ := createTestFile(t, "hi\nheyo", 0, io.SeekEnd)
+	f, size := createTestFile(t, "hi\nheyo", 0, io.SeekEnd)
 
-	s, err := NewBackwardsLineScanner(f, 5)
+	s, err := NewBackwardsLineScanner(f, size, WithChunkSize(5))
 	assert.NoError(t, err)
 
 	bytes, pos, err := s.ReadLine()
@@ -142,9 +147,9 @@ func TestBackwardsLine_ReadsTwoLines_NewLineOnBorder(t *testing.T) {
 }
 
 func TestBackwardsLine_ReadsTwoLines_SharedChunk(t *testing.T) {
-	f, _ := createTestFile(t, "hii\nhello", 0, io.SeekEnd)
+	f, size := createTestFile(t, "hii\nhello", 0, io.SeekEnd)
 
-	s, err := NewBackwardsLineScanner(f, 4)
+	s, err := NewBackwardsLineScanner(f, size, WithChunkSize(4))
 	assert.NoError(t, err)
 
 	bytes, pos, err := s.ReadLine()
@@ -158,9 +163,9 @@ func TestBackwardsLine_ReadsTwoLines_SharedChunk(t *testing.T) {
 }
 
 func TestBackwardsLine_ReadsTwoLines_SecondIsEmpty(t *testing.T) {
-	f, _ := createTestFile(t, "\nhello", 0, io.SeekEnd)
+	f, size := createTestFile(t, "\nhello", 0, io.SeekEnd)
 
-	s, err := NewBackwardsLineScanner(f, 1024)
+	s, err := NewBackwardsLineScanner(f, size)
 	assert.NoError(t, err)
 
 	bytes, pos, err := s.ReadLine()
@@ -174,9 +179,9 @@ func TestBackwardsLine_ReadsTwoLines_SecondIsEmpty(t *testing.T) {
 }
 
 func TestBackwardsLine_ReadPastEOF(t *testing.T) {
-	f, _ := createTestFile(t, "hello", 0, io.SeekEnd)
+	f, size := createTestFile(t, "hello", 0, io.SeekEnd)
 
-	s, err := NewBackwardsLineScanner(f, 1024)
+	s, err := NewBackwardsLineScanner(f, size)
 	assert.NoError(t, err)
 
 	bytes, pos, err := s.ReadLine()
@@ -190,9 +195,9 @@ func TestBackwardsLine_ReadPastEOF(t *testing.T) {
 }
 
 func TestBackwardsLine_ReadPastEOF_NewLineBoundary(t *testing.T) {
-	f, _ := createTestFile(t, "\nhello", 0, io.SeekEnd)
+	f, size := createTestFile(t, "\nhello", 0, io.SeekEnd)
 
-	s, err := NewBackwardsLineScanner(f, 1024)
+	s, err := NewBackwardsLineScanner(f, size)
 	assert.NoError(t, err)
 
 	bytes, pos, err := s.ReadLine()
@@ -208,3 +213,319 @@ func TestBackwardsLine_ReadPastEOF_NewLineBoundary(t *testing.T) {
 	assert.EqualValues(t, "", bytes)
 	assert.EqualValues(t, 0, pos)
 }
+
+func TestBackwardsLine_KeepsStartOfLongLines(t *testing.T) {
+	f, size := createTestFile(t, "hello\n0123456789abcdef\nyou\n", 0, io.SeekEnd)
+
+	s, err := NewBackwardsLineScanner(f, size, WithChunkSize(3), WithMaxLineLen(5))
+	assert.NoError(t, err)
+
+	bytes, _, err := s.ReadLine()
+	assert.NoError(t, err)
+	assert.EqualValues(t, "", bytes)
+
+	bytes, pos, err := s.ReadLine()
+	assert.NoError(t, err)
+	assert.EqualValues(t, "you", bytes)
+	assert.EqualValues(t, 3, s.LineLen())
+	assert.False(t, s.Truncated())
+	assert.EqualValues(t, 23, pos)
+
+	bytes, pos, err = s.ReadLine()
+	assert.NoError(t, err)
+	assert.EqualValues(t, "01234", bytes)
+	assert.EqualValues(t, 16, s.LineLen())
+	assert.True(t, s.Truncated())
+	assert.EqualValues(t, 6, pos)
+
+	bytes, pos, err = s.ReadLine()
+	assert.ErrorIs(t, err, io.EOF)
+	assert.EqualValues(t, "hello", bytes)
+	assert.EqualValues(t, 0, pos)
+}
+
+func TestBackwardsLine_StripsCarriageReturns(t *testing.T) {
+	f, size := createTestFile(t, "hello\r\n0123456789\r\nyou\r\nend\r", 0, io.SeekEnd)
+
+	// The carriage return of the long line is among the bytes that are
+	// dropped.
+	s, err := NewBackwardsLineScanner(f, size, WithChunkSize(3), WithMaxLineLen(5))
+	assert.NoError(t, err)
+
+	for _, expected := range []struct {
+		line string
+		pos  int
+	}{{"end", 24}, {"you", 19}, {"01234", 7}, {"hello", 0}} {
+		bytes, pos, err := s.ReadLine()
+		if expected.pos == 0 {
+			assert.ErrorIs(t, err, io.EOF)
+		} else {
+			assert.NoError(t, err)
+		}
+		assert.EqualValues(t, expected.line, bytes)
+		assert.EqualValues(t, expected.pos, pos)
+		assert.True(t, s.CRLF())
+	}
+	assert.EqualValues(t, 5, s.LineLen())
+}
+
+func TestBackwardsLine_SplitsOnDelimiter(t *testing.T) {
+	// The delimiter straddles the chunks it is read in.
+	f, size := createTestFile(t, "one<>two\n<>three", 0, io.SeekEnd)
+
+	s, err := NewBackwardsLineScanner(f, size, WithChunkSize(3), WithDelimiter([]byte("<>")))
+	assert.NoError(t, err)
+
+	bytes, pos, err := s.ReadLine()
+	assert.NoError(t, err)
+	assert.EqualValues(t, "three", bytes)
+	assert.EqualValues(t, 11, pos)
+
+	bytes, pos, err = s.ReadLine()
+	assert.NoError(t, err)
+	assert.EqualValues(t, "two\n", bytes)
+	assert.EqualValues(t, 5, pos)
+
+	bytes, pos, err = s.ReadLine()
+	assert.ErrorIs(t, err, io.EOF)
+	assert.EqualValues(t, "one", bytes)
+	assert.EqualValues(t, 0, pos)
+}
+
+func TestBackwardsLine_ReturnedLinesSurviveChunkReuse(t *testing.T) {
+	contents := ""
+	expected := []string{}
+	for i := 0; i < 50; i++ {
+		line := strings.Repeat(string(rune('a'+i%26)), i%7)
+		contents += line + "\n"
+		expected = append([]string{line}, expected...)
+	}
+	f, size := createTestFile(t, contents, 0, io.SeekEnd)
+
+	s, err := NewBackwardsLineScanner(f, size, WithChunkSize(4))
+	assert.NoError(t, err)
+
+	// The trailing newline ends an empty last line.
+	_, _, err = s.ReadLine()
+	assert.NoError(t, err)
+
+	// The chunks the lines were read from are reused for the lines after
+	// them, which must not change the lines that were already returned.
+	lines := [][]byte{}
+	for err == nil {
+		var line []byte
+		line, _, err = s.ReadLine()
+		lines = append(lines, line)
+	}
+	assert.ErrorIs(t, err, io.EOF)
+	assert.NoError(t, s.Close())
+
+	assert.Len(t, lines, len(expected))
+	for i, line := range lines {
+		assert.EqualValues(t, expected[i], line)
+	}
+}
+
+func TestBackwardsLine_IgnoresGrowthPastItsStart(t *testing.T) {
+	f, size := createTestFile(t, "hello\nyou\n", 0, io.SeekEnd)
+
+	s, err := NewBackwardsLineScanner(f, size, WithChunkSize(2))
+	assert.NoError(t, err)
+
+	bytes, _, err := s.ReadLine()
+	assert.NoError(t, err)
+	assert.EqualValues(t, "", bytes)
+
+	// Lines written while scanning come after where the scanner started.
+	appendToTestFile(t, f, "there\n")
+
+	bytes, pos, err := s.ReadLine()
+	assert.NoError(t, err)
+	assert.EqualValues(t, "you", bytes)
+	assert.EqualValues(t, 6, pos)
+
+	bytes, pos, err = s.ReadLine()
+	assert.ErrorIs(t, err, io.EOF)
+	assert.EqualValues(t, "hello", bytes)
+	assert.EqualValues(t, 0, pos)
+}
+
+func TestBackwardsLine_StopsAtTheStart(t *testing.T) {
+	f, size := createTestFile(t, "one\ntwo\nthree", 0, io.SeekEnd)
+
+	var start int64
+	s, err := NewBackwardsLineScanner(f, size, WithChunkSize(3), WithStart(func() int64 { return start }))
+	assert.NoError(t, err)
+
+	bytes, pos, err := s.ReadLine()
+	assert.NoError(t, err)
+	assert.EqualValues(t, "three", bytes)
+	assert.EqualValues(t, 8, pos)
+
+	// The lines before the start were dropped while scanning.
+	start = 4
+	bytes, pos, err = s.ReadLine()
+	assert.ErrorIs(t, err, io.EOF)
+	assert.EqualValues(t, "two", bytes)
+	assert.EqualValues(t, 4, pos)
+}
+
+func TestBackwardsLine_FailsWhenTruncated(t *testing.T) {
+	f, size := createTestFile(t, "hello\nyou\n", 0, io.SeekEnd)
+
+	s, err := NewBackwardsLineScanner(f, size, WithChunkSize(2))
+	assert.NoError(t, err)
+
+	assert.NoError(t, os.Truncate(f.Name(), 0))
+
+	_, pos, err := s.ReadLine()
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	assert.EqualValues(t, -1, pos)
+}
+
+func TestBackwardsLine_ErrIgnoresReachingTheStart(t *testing.T) {
+	f, size := createTestFile(t, "hello\n", 0, io.SeekEnd)
+
+	s, err := NewBackwardsLineScanner(f, size)
+	assert.NoError(t, err)
+
+	_, _, err = s.ReadLine()
+	assert.NoError(t, err)
+	_, _, err = s.ReadLine()
+	assert.ErrorIs(t, err, io.EOF)
+	assert.NoError(t, s.Err())
+
+	assert.NoError(t, s.Close())
+	_, _, err = s.ReadLine()
+	assert.ErrorIs(t, err, ErrUseAfterClose)
+	assert.ErrorIs(t, s.Err(), ErrUseAfterClose)
+}
+
+func TestBackwardsLine_ScansNothingBeforeTheStart(t *testing.T) {
+	f, _ := createTestFile(t, "", 0, io.SeekEnd)
+
+	s, err := NewBackwardsLineScanner(f, 0)
+	assert.NoError(t, err)
+	assert.False(t, s.Scan())
+	assert.False(t, s.Scan())
+	assert.NoError(t, s.Err())
+
+	// Nor is there anything to scan at the start of a reader that isn't
+	// empty.
+	f, _ = createTestFile(t, "hello\n", 0, io.SeekEnd)
+	s, err = NewBackwardsLineScanner(f, 0)
+	assert.NoError(t, err)
+	assert.False(t, s.Scan())
+	assert.NoError(t, s.Err())
+}
+
+func TestBackwardsLine_ScansLikeTheForwardsScanner(t *testing.T) {
+	f, size := createTestFile(t, "hello\n\nyou\nthere", 0, io.SeekEnd)
+
+	bkd, err := NewBackwardsLineScanner(f, size, WithChunkSize(3))
+	assert.NoError(t, err)
+	fwd := NewForwardsLineScanner(f, 0)
+	appendToTestFile(t, f, "\n")
+
+	// The scanners read the same lines, in opposite orders.
+	scan := func(s LineScanner) (lines []string, positions []int64) {
+		for s.Scan() {
+			lines = append(lines, s.Text())
+			positions = append(positions, s.Pos())
+		}
+		assert.NoError(t, s.Err())
+		assert.NoError(t, s.Close())
+		return lines, positions
+	}
+
+	lines, positions := scan(bkd)
+	assert.EqualValues(t, []string{"there", "you", "", "hello"}, lines)
+	assert.EqualValues(t, []int64{11, 7, 6, 0}, positions)
+
+	lines, positions = scan(fwd)
+	assert.EqualValues(t, []string{"hello", "", "you", "there"}, lines)
+	assert.EqualValues(t, []int64{0, 6, 7, 11}, positions)
+}
+
+func TestBackwardsLine_SplitsLikeTheForwardsScanner(t *testing.T) {
+	// The lines of random inputs, with delimiters that are split across
+	// chunks, overlap themselves and end lines that are too long to keep.
+	rnd := rand.New(rand.NewSource(1))
+	delims := []string{"\n", "-", "--", "ab", "aba", "-x-"}
+	for i := 0; i < 200; i++ {
+		input := make([]byte, rnd.Intn(40))
+		for j := range input {
+			input[j] = "-xab\r\n"[rnd.Intn(6)]
+		}
+		f, size := createTestFile(t, string(input), 0, io.SeekEnd)
+
+		for _, delim := range delims {
+			for chunkSize := 1; chunkSize <= 5; chunkSize++ {
+				for maxLineLen := 0; maxLineLen <= 6; maxLineLen++ {
+					name := fmt.Sprintf("%q split on %q, chunks of %d, lines of at most %d", input, delim, chunkSize, maxLineLen)
+					opts := []Option{WithDelimiter([]byte(delim)), WithChunkSize(chunkSize), WithMaxLineLen(maxLineLen)}
+					type line struct {
+						text            string
+						pos             int64
+						len             int
+						truncated, crlf bool
+					}
+					scan := func(s LineScanner) []line {
+						lines := []line{}
+						for s.Scan() {
+							lines = append(lines, line{s.Text(), s.Pos(), s.LineLen(), s.Truncated(), s.CRLF()})
+						}
+						assert.NoError(t, s.Err(), name)
+						return lines
+					}
+
+					bkd, err := NewBackwardsLineScanner(f, size, opts...)
+					assert.NoError(t, err)
+					backwards := scan(bkd)
+					// The forwards scanner doesn't read the partial line at
+					// the end, which the backwards one reads first.
+					if len(backwards) > 0 {
+						backwards = backwards[1:]
+					}
+					slices.Reverse(backwards)
+
+					forwards := scan(NewForwardsLineScanner(f, 0, opts...))
+					if !assert.EqualValues(t, forwards, backwards, name) {
+						return
+					}
+				}
+			}
+		}
+	}
+}
+
+// readerAtFunc adapts a function to io.ReaderAt.
+type readerAtFunc func(p []byte, off int64) (int, error)
+
+func (f readerAtFunc) ReadAt(p []byte, off int64) (int, error) {
+	return f(p, off)
+}
+
+func TestBackwardsLine_BoundsMemoryOfLongLines(t *testing.T) {
+	long := strings.Repeat("x", 1<<20)
+	f, size := createTestFile(t, "hello\n"+long, 0, io.SeekEnd)
+
+	// Watch how many chunks the scanner holds as it reads the long line.
+	var s *BackwardsLineScanner
+	held := 0
+	r := readerAtFunc(func(p []byte, off int64) (int, error) {
+		held = max(held, len(s.chunks))
+		return f.ReadAt(p, off)
+	})
+
+	s, err := NewBackwardsLineScanner(r, size, WithChunkSize(16), WithMaxLineLen(40))
+	assert.NoError(t, err)
+
+	bytes, pos, err := s.ReadLine()
+	assert.NoError(t, err)
+	assert.EqualValues(t, long[:40], bytes)
+	assert.EqualValues(t, 6, pos)
+	assert.EqualValues(t, len(long), s.LineLen())
+	assert.True(t, s.Truncated())
+	assert.LessOrEqual(t, held, 4)
+}
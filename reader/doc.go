@@ -0,0 +1,19 @@
+// Package reader reads lines from files that may be too large to load whole,
+// in either direction from any position.
+//
+// A ForwardsLineScanner reads the lines after a position and a
+// BackwardsLineScanner reads the lines before it, and both are LineScanners
+// that are scanned like a bufio.Scanner is. Both read with ReadAt, so one
+// file, or a MappedFile of it, can be shared by any number of scanners. They are
+// created with Options for the delimiter lines end with and the most bytes of a
+// line they keep, report the position every line starts at, and are released
+// with Close. Errors other than reaching the end of the input are returned by
+// Err.
+//
+// A ForwardsLineScanner can also follow a reader that is being written to, like
+// tail -f, waiting at its end for signals that more was written WithFollow.
+//
+// A LineIndex maps line numbers to the positions of the lines in a file. It can
+// be written out and read back when the file is opened again, instead of being
+// built again.
+package reader
@@ -3,55 +3,217 @@ package reader
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"io"
 )
 
+// forwardsPieceLen is the most bytes of a line the scanner buffers at once.
+// Longer lines are handed over in pieces of this size.
+const forwardsPieceLen = 64 * 1024
+
+// newline is the delimiter lines end with unless another one is set.
+var newline = []byte{'\n'}
+
+// ForwardsLineScanner reads lines from a reader, from a position towards its
+// end. It reads with ReadAt and has no position of its own in the reader, so
+// the reader may be shared with other scanners.
 type ForwardsLineScanner struct {
-	*bufio.Scanner
+	scanner     *bufio.Scanner
 	r           io.Reader
 	token       []byte
 	isCarryOver bool
+
+	// The delimiter lines end with.
+	delim []byte
+	// The end of a partial line at EOF that may be the start of a delimiter
+	// that isn't fully written yet. It is scanned again along with what is
+	// read after it.
+	held []byte
+
+	// The most bytes of a line that are kept, or 0 to keep whole lines.
+	maxLineLen int
+	// The length of the current line, including the bytes that weren't kept.
+	lineLen int
+	// The last byte of the pieces of the current line scanned so far.
+	lastByte byte
+	// If true, the last scanned line ended with \r\n rather than just \n.
+	crlf bool
+	// Set by the split function when the token it returned is a piece of a
+	// line that is too long to buffer whole, and not a partial line at EOF.
+	isPiece bool
+
+	// The position in the reader of the last scanned line, and of the line
+	// after it.
+	pos     int64
+	nextPos int64
+
+	// If true, Close was called and the scanner can't be used.
+	closed bool
+
+	// If followCtx is set, Scan waits at the end of the reader for values from
+	// followChanged, and followErr is set to the context's error when it is
+	// done.
+	followCtx     context.Context
+	followChanged <-chan struct{}
+	followErr     error
 }
 
-func NewForwardsLineScanner(reader io.Reader) *ForwardsLineScanner {
+// NewForwardsLineScanner creates a scanner that reads lines from the given
+// reader, starting at the given position.
+//
+// When it gets to the end of the reader Scan returns false, and a partial line
+// at the end is held until the rest of it is written. Scanning again continues
+// with what was written to the reader since.
+func NewForwardsLineScanner(reader io.ReaderAt, pos int64, opts ...Option) *ForwardsLineScanner {
+	o := newOptions(opts)
 	scanner := &ForwardsLineScanner{
-		r:           reader,
+		r:           &readerFrom{r: reader, pos: pos, end: o.end},
 		token:       make([]byte, 0),
 		isCarryOver: false,
+		delim:       o.delim,
+		maxLineLen:  o.maxLineLen,
+		pos:         pos,
+		nextPos:     pos,
+
+		followCtx:     o.followCtx,
+		followChanged: o.followChanged,
 	}
 	scanner.initInternalScanner()
 	return scanner
 }
 
 func (s *ForwardsLineScanner) initInternalScanner() {
-	scanner := bufio.NewScanner(s.r)
-	scanner.Split(scanLines)
-	s.Scanner = scanner
+	r := s.r
+	if len(s.held) > 0 {
+		r = io.MultiReader(bytes.NewReader(s.held), s.r)
+		s.held = nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 4096), forwardsPieceLen)
+	scanner.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+		s.isPiece = false
+		advance, token, err := scanLines(data, atEOF, s.delim)
+		if token == nil && err == nil && len(data) >= forwardsPieceLen {
+			// The line doesn't fit in the buffer, so hand it over in pieces.
+			// The end of the buffer may be the start of a delimiter, so it is
+			// left for the next piece.
+			s.isPiece = true
+			n := len(data) - len(s.delim) + 1
+			return n, data[:n], nil
+		}
+		if atEOF && token != nil && len(s.delim) > 1 && !bytes.HasSuffix(token, s.delim) {
+			// The end of a partial line may be the start of a delimiter, so
+			// it is held for the next internal scanner.
+			keep := max(len(token)-len(s.delim)+1, 0)
+			s.held = bytes.Clone(token[keep:])
+			token = token[:keep]
+		}
+		return advance, token, err
+	})
+	s.scanner = scanner
 }
 
+// Scan advances the scanner to the next line, which is then returned by Bytes
+// and Text. It returns false when it gets to the end of the reader or an error,
+// which Err returns. If the scanner follows the reader, it waits at the end of
+// the reader for more lines instead.
 func (s *ForwardsLineScanner) Scan() bool {
-	res := s.Scanner.Scan()
+	for {
+		if s.scan() {
+			return true
+		}
+		if s.followCtx == nil || s.Err() != nil || !s.waitForMore() {
+			return false
+		}
+	}
+}
 
-	// Make sure to reset our token if we're not carrying over.
-	if !s.isCarryOver {
-		s.token = nil
+// waitForMore waits for the reader to grow when following it. It returns false
+// if following stopped instead.
+func (s *ForwardsLineScanner) waitForMore() bool {
+	select {
+	case _, ok := <-s.followChanged:
+		return ok
+	case <-s.followCtx.Done():
+		s.followErr = s.followCtx.Err()
+		return false
 	}
+}
 
-	// The scanner may reach an actual EOF if it is the very first read
-	// attempt of this scanner, or if the previous read ended EXACTLY on EOF
-	// (which means the current one read 0 bytes).
-	if !res && s.Scanner.Err() == nil {
-		s.initInternalScanner()
+// scan scans the next line, returning false at the end of the reader.
+func (s *ForwardsLineScanner) scan() bool {
+	if s.closed {
 		return false
 	}
 
-	// TODO: figure out if we have to check s.Scanner.Err() first.
-	bytes := s.Scanner.Bytes()
-	if len(bytes) != 0 {
+	for {
+		res := s.scanner.Scan()
+
+		// Make sure to reset our token if we're not carrying over.
+		if !s.isCarryOver {
+			s.token = nil
+			s.lineLen = 0
+			s.lastByte = 0
+		}
+
+		// The scanner may reach an actual EOF if it is the very first read
+		// attempt of this scanner, or if the previous read ended EXACTLY on EOF
+		// (which means the current one read 0 bytes).
+		if !res {
+			if s.scanner.Err() == nil {
+				s.initInternalScanner()
+			}
+			return false
+		}
+
+		piece := s.scanner.Bytes()
+		complete := bytes.HasSuffix(piece, s.delim)
+		if complete {
+			// Get rid of the delimiter at the end.
+			piece = piece[:len(piece)-len(s.delim)]
+		}
+		s.lineLen += len(piece)
+
+		kept := piece
+		if s.maxLineLen > 0 {
+			kept = kept[:min(len(kept), max(s.maxLineLen-len(s.token), 0))]
+		}
 		if s.isCarryOver {
-			s.token = append(s.token, bytes...)
+			s.token = append(s.token, kept...)
+		} else if complete {
+			s.token = kept
 		} else {
-			s.token = bytes
+			// The scanner's buffer is reused for the rest of the line, so
+			// keep a copy.
+			s.token = append([]byte(nil), kept...)
+		}
+
+		if len(piece) > 0 {
+			s.lastByte = piece[len(piece)-1]
+		}
+
+		if complete {
+			// Get rid of the carriage return of a \r\n line ending too. It may
+			// have been scanned with an earlier piece of the line.
+			s.crlf = bytes.Equal(s.delim, newline) && s.lineLen > 0 && s.lastByte == '\r'
+			if s.crlf {
+				s.lineLen--
+				s.token = s.token[:min(len(s.token), s.lineLen)]
+			}
+			s.pos = s.nextPos
+			s.nextPos += int64(s.lineLen + len(s.delim))
+			if s.crlf {
+				s.nextPos++
+			}
+			s.isCarryOver = false
+			return true
+		}
+
+		s.isCarryOver = true
+		if s.isPiece {
+			// The rest of the line is yet to be scanned.
+			continue
 		}
 
 		// If we encountered a partial token (doesn't end with a newline) it
@@ -59,23 +221,38 @@ func (s *ForwardsLineScanner) Scan() bool {
 		//
 		// In order to read past this EOF we need to reinitialize the scanner,
 		// and save the partial token for the next scan.
-		if bytes[len(bytes)-1] != '\n' {
-			s.isCarryOver = true
-			s.initInternalScanner()
+		s.initInternalScanner()
 
-			// We need to emulate the behavior of bufio.Scanner.Scan() which
-			// returns false when it reaches EOF.
-			return false
-		} else {
-			s.isCarryOver = false
-			// Get rid of the newline character at the end.
-			s.token = s.token[:len(s.token)-1]
-		}
+		// We need to emulate the behavior of bufio.Scanner.Scan() which
+		// returns false when it reaches EOF.
+		return false
+	}
+}
+
+// Err returns the error that stopped the scanner, or nil if it only got to the
+// end of the reader. A scanner that follows the reader returns the error of its
+// context once it is done.
+func (s *ForwardsLineScanner) Err() error {
+	if s.closed {
+		return ErrUseAfterClose
+	}
+	if err := s.scanner.Err(); err != nil {
+		return err
 	}
+	return s.followErr
+}
 
-	return true
+// Close releases the buffers of the scanner. It can't be used afterwards.
+func (s *ForwardsLineScanner) Close() error {
+	s.closed = true
+	s.scanner = nil
+	s.token = nil
+	s.held = nil
+	return nil
 }
 
+// Bytes returns the last scanned line, without its line ending. The bytes may
+// be overwritten by the next call to Scan.
 func (s *ForwardsLineScanner) Bytes() []byte {
 	if s.isCarryOver {
 		return nil
@@ -84,6 +261,7 @@ func (s *ForwardsLineScanner) Bytes() []byte {
 	return s.token
 }
 
+// Text returns the last scanned line as a string, without its line ending.
 func (s *ForwardsLineScanner) Text() string {
 	if s.isCarryOver {
 		return ""
@@ -92,17 +270,59 @@ func (s *ForwardsLineScanner) Text() string {
 	return string(s.token)
 }
 
-// Modified from bufio.ScanLines to make not drop carriage returns and also
-// return the newline character itself. This lets us differentiate between a
-// line that is returned because it has a newline character and a line that is
-// returned because it reached EOF.
-func scanLines(data []byte, atEOF bool) (advance int, token []byte, err error) {
+// LineLen returns the length of the last scanned line, without its line
+// ending. It is longer than what Bytes returns if only the start of the line was
+// kept.
+func (s *ForwardsLineScanner) LineLen() int {
+	if s.isCarryOver {
+		return 0
+	}
+
+	return s.lineLen
+}
+
+// Truncated returns true if the last scanned line was longer than the most
+// bytes of a line that are kept, so only its start is returned by Bytes.
+func (s *ForwardsLineScanner) Truncated() bool {
+	if s.isCarryOver {
+		return false
+	}
+
+	return len(s.token) < s.lineLen
+}
+
+// CRLF returns true if the last scanned line ended with \r\n, so its line
+// ending is two bytes long rather than one.
+func (s *ForwardsLineScanner) CRLF() bool {
+	if s.isCarryOver {
+		return false
+	}
+
+	return s.crlf
+}
+
+// Pos returns the position in the reader where the last scanned line starts.
+func (s *ForwardsLineScanner) Pos() int64 {
+	return s.pos
+}
+
+// NextPos returns the position in the reader where the next line to be scanned
+// starts, after the line ending of the last scanned line.
+func (s *ForwardsLineScanner) NextPos() int64 {
+	return s.nextPos
+}
+
+// Modified from bufio.ScanLines to make not drop carriage returns, split on
+// any delimiter and also return the delimiter itself. This lets us
+// differentiate between a line that is returned because it has a delimiter and
+// a line that is returned because it reached EOF.
+func scanLines(data []byte, atEOF bool, delim []byte) (advance int, token []byte, err error) {
 	if atEOF && len(data) == 0 {
 		return 0, nil, nil
 	}
-	if i := bytes.IndexByte(data, '\n'); i >= 0 {
-		// We have a full newline-terminated line.
-		return i + 1, data[0 : i+1], nil
+	if i := bytes.Index(data, delim); i >= 0 {
+		// We have a full delimiter-terminated line.
+		return i + len(delim), data[0 : i+len(delim)], nil
 	}
 	// If we're at EOF, we have a final, non-terminated line. Return it.
 	if atEOF {
@@ -111,3 +331,35 @@ func scanLines(data []byte, atEOF bool) (advance int, token []byte, err error) {
 	// Request more data.
 	return 0, nil, nil
 }
+
+// readerFrom reads an io.ReaderAt in order from a position, like an os.File
+// that was seeked to it would, but without a seek position shared with other
+// readers.
+type readerFrom struct {
+	r   io.ReaderAt
+	pos int64
+	// The position reading stops at as if the reader ended there, or -1 to
+	// read to the reader's end.
+	end int64
+}
+
+func (r *readerFrom) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	if r.end >= 0 {
+		if r.pos >= r.end {
+			return 0, io.EOF
+		}
+		p = p[:min(int64(len(p)), r.end-r.pos)]
+	}
+
+	n, err := r.r.ReadAt(p, r.pos)
+	r.pos += int64(n)
+	if n > 0 && err == io.EOF {
+		// Report EOF on the next read, like an os.File does. The input may
+		// have grown by then.
+		err = nil
+	}
+	return n, err
+}
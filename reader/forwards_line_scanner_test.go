@@ -1,7 +1,9 @@
 package reader
 
 import (
+	"context"
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -10,7 +12,7 @@ import (
 func TestForwardsLineScanner_ReadsLine(t *testing.T) {
 	f, _ := createTestFile(t, "hello\nyou\n")
 
-	scanner := NewForwardsLineScanner(f)
+	scanner := NewForwardsLineScanner(f, 0)
 	res := scanner.Scan()
 	assert.True(t, res)
 	assert.EqualValues(t, "hello", scanner.Text())
@@ -20,7 +22,7 @@ func TestForwardsLineScanner_ReadsLine(t *testing.T) {
 func TestForwardsLineScanner_ReadsTwoLines(t *testing.T) {
 	f, _ := createTestFile(t, "hello\nyou\n")
 
-	scanner := NewForwardsLineScanner(f)
+	scanner := NewForwardsLineScanner(f, 0)
 	res := scanner.Scan()
 	assert.True(t, res)
 	assert.EqualValues(t, "hello", scanner.Text())
@@ -35,7 +37,7 @@ func TestForwardsLineScanner_ReadsTwoLines(t *testing.T) {
 func TestForwardsLineScanner_FindsEOF(t *testing.T) {
 	f, _ := createTestFile(t, "hello")
 
-	scanner := NewForwardsLineScanner(f)
+	scanner := NewForwardsLineScanner(f, 0)
 	res := scanner.Scan()
 	assert.False(t, res)
 	assert.Nil(t, scanner.Bytes())
@@ -45,7 +47,7 @@ func TestForwardsLineScanner_FindsEOF(t *testing.T) {
 func TestForwardsLineScanner_FindsEOFAgain(t *testing.T) {
 	f, _ := createTestFile(t, "hello")
 
-	scanner := NewForwardsLineScanner(f)
+	scanner := NewForwardsLineScanner(f, 0)
 	res := scanner.Scan()
 	assert.False(t, res)
 	assert.Nil(t, scanner.Bytes())
@@ -60,7 +62,7 @@ func TestForwardsLineScanner_FindsEOFAgain(t *testing.T) {
 func TestForwardsLineScanner_ReadsLineEndingAtEOF(t *testing.T) {
 	f, _ := createTestFile(t, "hi\n")
 
-	scanner := NewForwardsLineScanner(f)
+	scanner := NewForwardsLineScanner(f, 0)
 	res := scanner.Scan()
 	assert.True(t, res)
 	assert.EqualValues(t, "hi", scanner.Text())
@@ -75,7 +77,7 @@ func TestForwardsLineScanner_ReadsLineEndingAtEOF(t *testing.T) {
 func TestForwardsLineScanner_ReadsPastEOF(t *testing.T) {
 	f, _ := createTestFile(t, "hi")
 
-	scanner := NewForwardsLineScanner(f)
+	scanner := NewForwardsLineScanner(f, 0)
 	res := scanner.Scan()
 	assert.False(t, res)
 	assert.Nil(t, scanner.Bytes())
@@ -92,7 +94,7 @@ func TestForwardsLineScanner_ReadsPastEOF(t *testing.T) {
 func TestForwardsLineScanner_ReadsWellPastEOF(t *testing.T) {
 	f, _ := createTestFile(t, "hi")
 
-	scanner := NewForwardsLineScanner(f)
+	scanner := NewForwardsLineScanner(f, 0)
 	res := scanner.Scan()
 	assert.False(t, res)
 	assert.Nil(t, scanner.Bytes())
@@ -114,7 +116,7 @@ func TestForwardsLineScanner_ReadsWellPastEOF(t *testing.T) {
 func TestForwardsLineScanner_ReadsPastStickyEOF(t *testing.T) {
 	f, _ := createTestFile(t, "hi")
 
-	scanner := NewForwardsLineScanner(f)
+	scanner := NewForwardsLineScanner(f, 0)
 	// Trying to scan again makes sure that the data from the first scanner carries over multiple empty scans.
 	for i := 0; i < 3; i++ {
 		res := scanner.Scan()
@@ -134,7 +136,7 @@ func TestForwardsLineScanner_ReadsPastStickyEOF(t *testing.T) {
 func TestForwardsLineScanner_ReadsPastMultipleEOFsDuringOneLine(t *testing.T) {
 	f, _ := createTestFile(t, "hi ")
 
-	scanner := NewForwardsLineScanner(f)
+	scanner := NewForwardsLineScanner(f, 0)
 	res := scanner.Scan()
 	assert.False(t, res)
 	assert.Nil(t, scanner.Bytes())
@@ -160,7 +162,7 @@ func TestForwardsLineScanner_ReadsPastMultipleEOFsDuringOneLine(t *testing.T) {
 func TestForwardsLineScanner_ReadsPastEOF_AtBoundary(t *testing.T) {
 	f, _ := createTestFile(t, "hi")
 
-	scanner := NewForwardsLineScanner(f)
+	scanner := NewForwardsLineScanner(f, 0)
 	res := scanner.Scan()
 	assert.False(t, res)
 	assert.Nil(t, scanner.Bytes())
@@ -177,7 +179,7 @@ func TestForwardsLineScanner_ReadsPastEOF_AtBoundary(t *testing.T) {
 func TestForwardsLineScanner_ReadsEmptyLines(t *testing.T) {
 	f, _ := createTestFile(t, "hi\n\n\nya\n")
 
-	scanner := NewForwardsLineScanner(f)
+	scanner := NewForwardsLineScanner(f, 0)
 	res := scanner.Scan()
 	assert.True(t, res)
 	assert.EqualValues(t, "hi", scanner.Text())
@@ -202,7 +204,7 @@ func TestForwardsLineScanner_ReadsEmptyLines(t *testing.T) {
 func TestForwardsLineScanner_ReadsEmptyLinesPastEOF(t *testing.T) {
 	f, _ := createTestFile(t, "hi")
 
-	scanner := NewForwardsLineScanner(f)
+	scanner := NewForwardsLineScanner(f, 0)
 	res := scanner.Scan()
 	assert.False(t, res)
 	assert.Nil(t, scanner.Bytes())
@@ -224,7 +226,7 @@ func TestForwardsLineScanner_ReadsEmptyLinesPastEOF(t *testing.T) {
 func TestForwardsLineScanner_ReadsEmptyLinesPastEOFAtEmptyLine(t *testing.T) {
 	f, _ := createTestFile(t, "hi\n")
 
-	scanner := NewForwardsLineScanner(f)
+	scanner := NewForwardsLineScanner(f, 0)
 	res := scanner.Scan()
 	assert.True(t, res)
 	assert.EqualValues(t, "hi", scanner.Text())
@@ -247,3 +249,187 @@ func TestForwardsLineScanner_ReadsEmptyLinesPastEOFAtEmptyLine(t *testing.T) {
 	assert.EqualValues(t, "ya", scanner.Text())
 	assert.NoError(t, scanner.Err())
 }
+
+func TestForwardsLineScanner_KeepsStartOfLongLines(t *testing.T) {
+	long := strings.Repeat("x", 3*forwardsPieceLen+5)
+	f, _ := createTestFile(t, "hello\n"+long+"\nyou\n")
+
+	scanner := NewForwardsLineScanner(f, 0, WithMaxLineLen(forwardsPieceLen+1))
+
+	assert.True(t, scanner.Scan())
+	assert.EqualValues(t, "hello", scanner.Text())
+	assert.EqualValues(t, 5, scanner.LineLen())
+	assert.False(t, scanner.Truncated())
+
+	assert.True(t, scanner.Scan())
+	assert.EqualValues(t, long[:forwardsPieceLen+1], scanner.Text())
+	assert.EqualValues(t, len(long), scanner.LineLen())
+	assert.True(t, scanner.Truncated())
+
+	assert.True(t, scanner.Scan())
+	assert.EqualValues(t, "you", scanner.Text())
+	assert.NoError(t, scanner.Err())
+}
+
+func TestForwardsLineScanner_ReadsLongLinesWhole(t *testing.T) {
+	long := strings.Repeat("x", 2*forwardsPieceLen+5)
+	f, _ := createTestFile(t, long+"\n")
+
+	scanner := NewForwardsLineScanner(f, 0)
+	assert.True(t, scanner.Scan())
+	assert.EqualValues(t, long, scanner.Text())
+	assert.EqualValues(t, len(long), scanner.LineLen())
+	assert.NoError(t, scanner.Err())
+}
+
+func TestForwardsLineScanner_StripsCarriageReturns(t *testing.T) {
+	// The carriage return of the long line ends its first piece, apart from
+	// its newline.
+	long := strings.Repeat("x", forwardsPieceLen-1)
+	f, _ := createTestFile(t, "hello\r\n\r\n"+long+"\r\nyou\n")
+
+	scanner := NewForwardsLineScanner(f, 0)
+	for _, expected := range []string{"hello", "", long, "you"} {
+		assert.True(t, scanner.Scan())
+		assert.EqualValues(t, expected, scanner.Text())
+		assert.EqualValues(t, len(expected), scanner.LineLen())
+		assert.EqualValues(t, expected != "you", scanner.CRLF())
+	}
+	assert.NoError(t, scanner.Err())
+}
+
+func TestForwardsLineScanner_ReportsPositions(t *testing.T) {
+	f, pos := createTestFile(t, "skip\nhello\r\n\nyo", 5)
+
+	// Positions count from the start of the reader.
+	scanner := NewForwardsLineScanner(f, pos)
+	assert.EqualValues(t, 5, scanner.NextPos())
+
+	assert.True(t, scanner.Scan())
+	assert.EqualValues(t, "hello", scanner.Text())
+	assert.EqualValues(t, 5, scanner.Pos())
+	assert.EqualValues(t, 12, scanner.NextPos())
+
+	assert.True(t, scanner.Scan())
+	assert.EqualValues(t, "", scanner.Text())
+	assert.EqualValues(t, 12, scanner.Pos())
+	assert.EqualValues(t, 13, scanner.NextPos())
+
+	// A partial line at EOF isn't scanned yet, so the positions stay.
+	assert.False(t, scanner.Scan())
+	assert.EqualValues(t, 13, scanner.NextPos())
+
+	appendToTestFile(t, f, "u\n")
+	assert.True(t, scanner.Scan())
+	assert.EqualValues(t, "you", scanner.Text())
+	assert.EqualValues(t, 13, scanner.Pos())
+	assert.EqualValues(t, 17, scanner.NextPos())
+	assert.NoError(t, scanner.Err())
+}
+
+func TestForwardsLineScanner_SplitsOnDelimiter(t *testing.T) {
+	f, _ := createTestFile(t, "a\nb\x00\r\n\x00c")
+
+	scanner := NewForwardsLineScanner(f, 0, WithDelimiter([]byte{0}))
+	assert.True(t, scanner.Scan())
+	assert.EqualValues(t, "a\nb", scanner.Text())
+	assert.True(t, scanner.Scan())
+	// Carriage returns are only dropped before newline delimiters.
+	assert.EqualValues(t, "\r\n", scanner.Text())
+	assert.False(t, scanner.CRLF())
+	assert.EqualValues(t, 7, scanner.NextPos())
+	assert.False(t, scanner.Scan())
+	assert.NoError(t, scanner.Err())
+}
+
+func TestForwardsLineScanner_StopsAtEnd(t *testing.T) {
+	f, _ := createTestFile(t, "a\nb\nc\n")
+
+	scanner := NewForwardsLineScanner(f, 0, WithEnd(4))
+	assert.True(t, scanner.Scan())
+	assert.EqualValues(t, "a", scanner.Text())
+	assert.True(t, scanner.Scan())
+	assert.EqualValues(t, "b", scanner.Text())
+	assert.False(t, scanner.Scan())
+	assert.NoError(t, scanner.Err())
+	assert.EqualValues(t, 4, scanner.NextPos())
+}
+
+func TestForwardsLineScanner_FindsDelimitersSplitAcrossWrites(t *testing.T) {
+	f, _ := createTestFile(t, "one--two-")
+
+	scanner := NewForwardsLineScanner(f, 0, WithDelimiter([]byte("--")))
+	assert.True(t, scanner.Scan())
+	assert.EqualValues(t, "one", scanner.Text())
+	assert.False(t, scanner.Scan())
+
+	// The delimiter's second half is written after the scanner hit EOF.
+	appendToTestFile(t, f, "-three--")
+	assert.True(t, scanner.Scan())
+	assert.EqualValues(t, "two", scanner.Text())
+	assert.EqualValues(t, 5, scanner.Pos())
+	assert.True(t, scanner.Scan())
+	assert.EqualValues(t, "three", scanner.Text())
+	assert.EqualValues(t, 17, scanner.NextPos())
+	assert.NoError(t, scanner.Err())
+}
+
+func TestForwardsLineScanner_FindsDelimitersSplitAcrossPieces(t *testing.T) {
+	long := strings.Repeat("x", forwardsPieceLen-1)
+	f, _ := createTestFile(t, long+"--after--")
+
+	scanner := NewForwardsLineScanner(f, 0, WithDelimiter([]byte("--")))
+	assert.True(t, scanner.Scan())
+	assert.EqualValues(t, long, scanner.Text())
+	assert.True(t, scanner.Scan())
+	assert.EqualValues(t, "after", scanner.Text())
+	assert.NoError(t, scanner.Err())
+}
+
+func TestForwardsLineScanner_CantBeUsedAfterClose(t *testing.T) {
+	f, _ := createTestFile(t, "hello\nyou\n")
+
+	scanner := NewForwardsLineScanner(f, 0)
+	assert.True(t, scanner.Scan())
+	assert.NoError(t, scanner.Close())
+
+	assert.False(t, scanner.Scan())
+	assert.ErrorIs(t, scanner.Err(), ErrUseAfterClose)
+}
+
+func TestForwardsLineScanner_WaitsForMoreWhenFollowing(t *testing.T) {
+	f, _ := createTestFile(t, "hello\nyo")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	changed := make(chan struct{})
+	scanner := NewForwardsLineScanner(f, 0, WithFollow(ctx, changed))
+
+	assert.True(t, scanner.Scan())
+	assert.EqualValues(t, "hello", scanner.Text())
+
+	// The partial line is read once the rest of it is written.
+	go func() {
+		appendToTestFile(t, f, "u\n")
+		changed <- struct{}{}
+	}()
+	assert.True(t, scanner.Scan())
+	assert.EqualValues(t, "you", scanner.Text())
+
+	// Canceling the context stops the wait.
+	go cancel()
+	assert.False(t, scanner.Scan())
+	assert.ErrorIs(t, scanner.Err(), context.Canceled)
+}
+
+func TestForwardsLineScanner_StopsFollowingWhenSignalsEnd(t *testing.T) {
+	f, _ := createTestFile(t, "hello\n")
+
+	changed := make(chan struct{})
+	close(changed)
+	scanner := NewForwardsLineScanner(f, 0, WithFollow(context.Background(), changed))
+
+	assert.True(t, scanner.Scan())
+	assert.False(t, scanner.Scan())
+	assert.NoError(t, scanner.Err())
+}
@@ -0,0 +1,329 @@
+package reader
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"sort"
+	"sync"
+)
+
+// ErrNotIndexed is returned when looking up a line or an offset past the part
+// of the file the index has covered so far.
+var ErrNotIndexed = errors.New("position is not indexed yet")
+
+// ErrIndexMismatch is returned when reading an index that was written for
+// other contents than the file's, or with another interval.
+var ErrIndexMismatch = errors.New("index doesn't match the file")
+
+// lineIndexMagic starts every index written by WriteTo, followed by the
+// version of its format.
+const lineIndexMagic = "gote-idx\x01"
+
+// lineIndexSumLen is how many bytes at the start of the indexed part of the
+// file, and at its end, a written index keeps checksums of. They tell the
+// same file apart from one that was rewritten since, while the file may have
+// grown.
+const lineIndexSumLen = 4096
+
+// LineIndex is a sparse index of the byte offsets lines start at. It keeps the
+// offset of every Nth line, so finding any line only takes reading up to N
+// lines from the nearest indexed one, instead of scanning the whole file.
+//
+// Lines are numbered from 1. The index is built incrementally by Build, and
+// lookups are safe to use concurrently with it.
+type LineIndex struct {
+	r     io.ReaderAt
+	every int64
+
+	// Serializes calls to Build.
+	buildMu sync.Mutex
+
+	mu sync.RWMutex
+	// offsets[i] is the byte offset line i*every+1 starts at.
+	offsets []int64
+	// The number of bytes from the start of the file that were indexed.
+	indexedTo int64
+	// The number of newlines found in the indexed bytes.
+	newlines int64
+}
+
+// NewLineIndex creates an empty index of the lines read from r that keeps the
+// offset of every given number of lines.
+func NewLineIndex(r io.ReaderAt, every int) *LineIndex {
+	if every <= 0 {
+		panic("every must be positive")
+	}
+
+	return &LineIndex{
+		r:       r,
+		every:   int64(every),
+		offsets: []int64{0},
+	}
+}
+
+// Build indexes the file from where the last build stopped up to its current
+// end. It may be called again after the file grew to index the rest of it.
+func (idx *LineIndex) Build(ctx context.Context) error {
+	idx.buildMu.Lock()
+	defer idx.buildMu.Unlock()
+
+	idx.mu.RLock()
+	pos, newlines := idx.indexedTo, idx.newlines
+	idx.mu.RUnlock()
+
+	buf := make([]byte, 64*1024)
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		start := pos
+		n, err := idx.r.ReadAt(buf, pos)
+
+		var found []int64
+		for i, b := range buf[:n] {
+			if b != '\n' {
+				continue
+			}
+			newlines++
+			if newlines%idx.every == 0 {
+				found = append(found, pos+int64(i)+1)
+			}
+		}
+		pos += int64(n)
+
+		idx.mu.Lock()
+		if idx.indexedTo != start {
+			// An index read with ReadFrom got further meanwhile, so the
+			// build goes on from where it ends.
+			pos, newlines = idx.indexedTo, idx.newlines
+			idx.mu.Unlock()
+			continue
+		}
+		idx.offsets = append(idx.offsets, found...)
+		idx.indexedTo = pos
+		idx.newlines = newlines
+		idx.mu.Unlock()
+
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to index lines: %w", err)
+		}
+	}
+}
+
+// Indexed returns the number of bytes from the start of the file that were
+// indexed, and the number of complete lines found in them.
+func (idx *LineIndex) Indexed() (size int64, lines int64) {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	return idx.indexedTo, idx.newlines
+}
+
+// OffsetOfLine returns the byte offset the given line starts at. It returns
+// ErrNotIndexed if the line starts past the indexed part of the file.
+func (idx *LineIndex) OffsetOfLine(line int64) (int64, error) {
+	if line < 1 {
+		return 0, fmt.Errorf("invalid line number %d", line)
+	}
+
+	idx.mu.RLock()
+	if line > idx.newlines+1 {
+		idx.mu.RUnlock()
+		return 0, ErrNotIndexed
+	}
+	i := (line - 1) / idx.every
+	offset := idx.offsets[i]
+	idx.mu.RUnlock()
+
+	// Skip the lines between the indexed one and the requested one.
+	toSkip := line - 1 - i*idx.every
+	if toSkip == 0 {
+		return offset, nil
+	}
+
+	var found int64
+	err := idx.scan(offset, func(chunk []byte, chunkPos int64) bool {
+		for i, b := range chunk {
+			if b != '\n' {
+				continue
+			}
+			toSkip--
+			if toSkip == 0 {
+				found = chunkPos + int64(i) + 1
+				return false
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return 0, err
+	}
+	if toSkip > 0 {
+		return 0, ErrNotIndexed
+	}
+	return found, nil
+}
+
+// LineAtOffset returns the number of the line containing the given byte
+// offset. It returns ErrNotIndexed if the offset is past the indexed part of
+// the file.
+func (idx *LineIndex) LineAtOffset(offset int64) (int64, error) {
+	if offset < 0 {
+		return 0, fmt.Errorf("invalid offset %d", offset)
+	}
+
+	idx.mu.RLock()
+	if offset > idx.indexedTo {
+		idx.mu.RUnlock()
+		return 0, ErrNotIndexed
+	}
+	// The last indexed line starting at or before the offset.
+	i := sort.Search(len(idx.offsets), func(i int) bool { return idx.offsets[i] > offset }) - 1
+	start := idx.offsets[i]
+	idx.mu.RUnlock()
+
+	line := int64(i)*idx.every + 1
+	err := idx.scan(start, func(chunk []byte, chunkPos int64) bool {
+		end := min(int64(len(chunk)), offset-chunkPos)
+		line += int64(bytes.Count(chunk[:end], []byte{'\n'}))
+		return chunkPos+int64(len(chunk)) < offset
+	})
+	if err != nil {
+		return 0, err
+	}
+	return line, nil
+}
+
+// scan reads the file from the given offset in chunks and calls fn with each
+// chunk and the offset it starts at, until fn returns false or the end of the
+// file is reached.
+func (idx *LineIndex) scan(offset int64, fn func(chunk []byte, chunkPos int64) bool) error {
+	buf := make([]byte, 4096)
+	for {
+		n, err := idx.r.ReadAt(buf, offset)
+		if n > 0 && !fn(buf[:n], offset) {
+			return nil
+		}
+		offset += int64(n)
+
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read lines: %w", err)
+		}
+	}
+}
+
+// WriteTo writes the index to w, to be read back with ReadFrom when the file is
+// opened again, instead of building it again.
+func (idx *LineIndex) WriteTo(w io.Writer) (int64, error) {
+	idx.mu.RLock()
+	indexedTo, newlines := idx.indexedTo, idx.newlines
+	offsets := idx.offsets[:len(idx.offsets):len(idx.offsets)]
+	idx.mu.RUnlock()
+
+	headSum, err := idx.checksum(0, min(indexedTo, lineIndexSumLen))
+	if err != nil {
+		return 0, err
+	}
+	tailSum, err := idx.checksum(max(indexedTo-lineIndexSumLen, 0), indexedTo)
+	if err != nil {
+		return 0, err
+	}
+
+	data := []byte(lineIndexMagic)
+	for _, v := range []uint64{uint64(idx.every), uint64(indexedTo), uint64(newlines), uint64(headSum), uint64(tailSum), uint64(len(offsets))} {
+		data = binary.AppendUvarint(data, v)
+	}
+	// The offsets only grow, so they are kept as the distances between them.
+	for i := 1; i < len(offsets); i++ {
+		data = binary.AppendUvarint(data, uint64(offsets[i]-offsets[i-1]))
+	}
+	n, err := w.Write(data)
+	return int64(n), err
+}
+
+// ReadFrom reads an index written by WriteTo for the same file, and replaces
+// this one with it unless this one got further. It returns ErrIndexMismatch if
+// the index is of other contents or another interval. Build goes on from where
+// the index read ends.
+func (idx *LineIndex) ReadFrom(r io.Reader) (int64, error) {
+	data, err := io.ReadAll(r)
+	n := int64(len(data))
+	if err != nil {
+		return n, err
+	}
+	rest, ok := bytes.CutPrefix(data, []byte(lineIndexMagic))
+	if !ok {
+		return n, fmt.Errorf("%w: not an index", ErrIndexMismatch)
+	}
+	next := func() uint64 {
+		v, size := binary.Uvarint(rest)
+		if size <= 0 {
+			ok = false
+			return 0
+		}
+		rest = rest[size:]
+		return v
+	}
+
+	every, indexedTo, newlines := int64(next()), int64(next()), int64(next())
+	headSum, tailSum, count := uint32(next()), uint32(next()), int64(next())
+	if !ok || every != idx.every || indexedTo < 0 || newlines < 0 || count != newlines/every+1 {
+		return n, ErrIndexMismatch
+	}
+	// The index is checked against the file before its offsets are read, and
+	// every offset after the first takes at least a byte, so a corrupt index
+	// isn't trusted with allocating them.
+	if sum, err := idx.checksum(0, min(indexedTo, lineIndexSumLen)); err != nil || sum != headSum {
+		return n, ErrIndexMismatch
+	}
+	if sum, err := idx.checksum(max(indexedTo-lineIndexSumLen, 0), indexedTo); err != nil || sum != tailSum {
+		return n, ErrIndexMismatch
+	}
+	if count-1 > int64(len(rest)) {
+		return n, fmt.Errorf("%w: the index is corrupt", ErrIndexMismatch)
+	}
+	offsets := make([]int64, 1, count)
+	for i := int64(1); i < count && ok; i++ {
+		offset := offsets[i-1] + int64(next())
+		if offset <= offsets[i-1] || offset > indexedTo {
+			ok = false
+		}
+		offsets = append(offsets, offset)
+	}
+	if !ok {
+		return n, fmt.Errorf("%w: the index is corrupt", ErrIndexMismatch)
+	}
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	if indexedTo > idx.indexedTo {
+		idx.offsets, idx.indexedTo, idx.newlines = offsets, indexedTo, newlines
+	}
+	return n, nil
+}
+
+// checksum returns the checksum of the bytes of the file between the given
+// offsets. It returns ErrIndexMismatch if the file ends before them.
+func (idx *LineIndex) checksum(from, to int64) (uint32, error) {
+	buf := make([]byte, to-from)
+	n, err := idx.r.ReadAt(buf, from)
+	if n < len(buf) {
+		if err == nil || errors.Is(err, io.EOF) {
+			err = ErrIndexMismatch
+		}
+		return 0, err
+	}
+	return crc32.ChecksumIEEE(buf), nil
+}
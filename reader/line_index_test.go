@@ -0,0 +1,139 @@
+package reader
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLineIndex_FindsOffsetsOfLines(t *testing.T) {
+	var sb strings.Builder
+	var offsets []int64
+	for i := 1; i <= 50; i++ {
+		offsets = append(offsets, int64(sb.Len()))
+		sb.WriteString(fmt.Sprintf("line %d %s\n", i, strings.Repeat("x", i%7)))
+	}
+	f, _ := createTestFile(t, sb.String())
+
+	idx := NewLineIndex(f, 4)
+	assert.NoError(t, idx.Build(context.Background()))
+
+	size, lines := idx.Indexed()
+	assert.EqualValues(t, sb.Len(), size)
+	assert.EqualValues(t, 50, lines)
+
+	for i, offset := range offsets {
+		got, err := idx.OffsetOfLine(int64(i + 1))
+		assert.NoError(t, err)
+		assert.Equal(t, offset, got, "line %d", i+1)
+
+		line, err := idx.LineAtOffset(offset)
+		assert.NoError(t, err)
+		assert.EqualValues(t, i+1, line, "offset %d", offset)
+
+		// The middle of a line belongs to it too.
+		line, err = idx.LineAtOffset(offset + 3)
+		assert.NoError(t, err)
+		assert.EqualValues(t, i+1, line, "offset %d", offset+3)
+	}
+}
+
+func TestLineIndex_ReportsUnindexedPositions(t *testing.T) {
+	f, _ := createTestFile(t, "one\ntwo\nthree\n")
+
+	idx := NewLineIndex(f, 2)
+
+	_, err := idx.OffsetOfLine(2)
+	assert.ErrorIs(t, err, ErrNotIndexed)
+	_, err = idx.LineAtOffset(5)
+	assert.ErrorIs(t, err, ErrNotIndexed)
+
+	assert.NoError(t, idx.Build(context.Background()))
+
+	// The empty line after the last newline.
+	offset, err := idx.OffsetOfLine(4)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 14, offset)
+
+	_, err = idx.OffsetOfLine(5)
+	assert.ErrorIs(t, err, ErrNotIndexed)
+}
+
+func TestLineIndex_ContinuesAfterFileGrows(t *testing.T) {
+	f, _ := createTestFile(t, "one\ntw")
+
+	idx := NewLineIndex(f, 1)
+	assert.NoError(t, idx.Build(context.Background()))
+
+	appendToTestFile(t, f, "o\nthree\n")
+	assert.NoError(t, idx.Build(context.Background()))
+
+	_, lines := idx.Indexed()
+	assert.EqualValues(t, 3, lines)
+
+	offset, err := idx.OffsetOfLine(3)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 8, offset)
+}
+
+func TestLineIndex_ReadsBackWhatItWrote(t *testing.T) {
+	var sb strings.Builder
+	for i := 1; i <= 50; i++ {
+		sb.WriteString(fmt.Sprintf("line %d %s\n", i, strings.Repeat("x", i%7)))
+	}
+	f, _ := createTestFile(t, sb.String())
+
+	built := NewLineIndex(f, 4)
+	assert.NoError(t, built.Build(context.Background()))
+	var buf bytes.Buffer
+	_, err := built.WriteTo(&buf)
+	assert.NoError(t, err)
+	written := buf.Bytes()
+
+	// Once the file grew, the build goes on from where the index read ends.
+	appendToTestFile(t, f, "line 51\nline 52\n")
+	idx := NewLineIndex(f, 4)
+	_, err = idx.ReadFrom(bytes.NewReader(written))
+	assert.NoError(t, err)
+	size, lines := idx.Indexed()
+	assert.EqualValues(t, sb.Len(), size)
+	assert.EqualValues(t, 50, lines)
+	assert.NoError(t, idx.Build(context.Background()))
+	_, lines = idx.Indexed()
+	assert.EqualValues(t, 52, lines)
+
+	for line := int64(1); line <= 51; line++ {
+		want, err := built.OffsetOfLine(line)
+		assert.NoError(t, err)
+		got, err := idx.OffsetOfLine(line)
+		assert.NoError(t, err)
+		assert.EqualValues(t, want, got, "line %d", line)
+	}
+	offset, err := idx.OffsetOfLine(53)
+	assert.NoError(t, err)
+	assert.EqualValues(t, sb.Len()+16, offset)
+
+	// Indexes of other intervals or contents are rejected.
+	_, err = NewLineIndex(f, 8).ReadFrom(bytes.NewReader(written))
+	assert.ErrorIs(t, err, ErrIndexMismatch)
+	other, _ := createTestFile(t, strings.Replace(sb.String(), "line 50", "line 5O", 1))
+	_, err = NewLineIndex(other, 4).ReadFrom(bytes.NewReader(written))
+	assert.ErrorIs(t, err, ErrIndexMismatch)
+	_, err = NewLineIndex(f, 4).ReadFrom(bytes.NewReader(written[:len(written)-1]))
+	assert.ErrorIs(t, err, ErrIndexMismatch)
+
+	// A corrupt index isn't trusted with how many offsets it has.
+	sum := uint64(crc32.ChecksumIEEE([]byte(sb.String())))
+	corrupt := []byte(lineIndexMagic)
+	for _, v := range []uint64{4, uint64(sb.Len()), 1 << 60, sum, sum, 1<<58 + 1} {
+		corrupt = binary.AppendUvarint(corrupt, v)
+	}
+	_, err = NewLineIndex(f, 4).ReadFrom(bytes.NewReader(corrupt))
+	assert.ErrorIs(t, err, ErrIndexMismatch)
+}
@@ -0,0 +1,33 @@
+package reader
+
+// LineScanner reads the lines of a reader one at a time, in the direction of
+// the scanner. Both ForwardsLineScanner and BackwardsLineScanner implement it,
+// so callers can handle both directions alike.
+type LineScanner interface {
+	// Scan advances to the next line, returning false at the end of the lines
+	// in the scanner's direction or on an error.
+	Scan() bool
+	// Bytes and Text return the last scanned line, without its line ending.
+	Bytes() []byte
+	Text() string
+	// Pos returns the position in the reader where the last scanned line
+	// starts.
+	Pos() int64
+	// LineLen returns the full length of the last scanned line, which is
+	// longer than what Bytes returns if only its start was kept.
+	LineLen() int
+	// Truncated returns true if only the start of the last scanned line was
+	// kept, because it is longer than the scanner's WithMaxLineLen.
+	Truncated() bool
+	// CRLF returns true if the last scanned line ended with \r\n.
+	CRLF() bool
+	// Err returns the error that stopped the scanner, if any.
+	Err() error
+	// Close releases the scanner.
+	Close() error
+}
+
+var (
+	_ LineScanner = (*ForwardsLineScanner)(nil)
+	_ LineScanner = (*BackwardsLineScanner)(nil)
+)
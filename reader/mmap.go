@@ -0,0 +1,180 @@
+package reader
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"runtime/debug"
+	"sync"
+)
+
+// errTruncated is returned by copyAt when the file was truncated below the
+// bytes it copies.
+var errTruncated = errors.New("file was truncated while it was read")
+
+// MappedFile reads a file through a memory mapping of it, so reads are plain
+// copies instead of a system call each. This matters most when scanning
+// backwards, which seeks before nearly every read.
+//
+// When a read goes past the end of the mapping the file is checked for growth
+// and mapped again, so a file being appended to can still be followed. When
+// the file is truncated, like when it is rotated by copying and truncating it,
+// accessing the pages past its new end faults. The fault is recovered from,
+// and the file is mapped again up to its new end.
+type MappedFile struct {
+	f *os.File
+
+	mu     sync.RWMutex
+	data   []byte
+	closed bool
+}
+
+// MapFile maps the given file into memory. The file must be a regular file,
+// and stays owned by the caller.
+func MapFile(f *os.File) (*MappedFile, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if !info.Mode().IsRegular() {
+		return nil, fmt.Errorf("can't map %s: not a regular file", f.Name())
+	}
+
+	m := &MappedFile{f: f}
+	if err := m.remap(info.Size()); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// remap replaces the mapping with one of the given size. The caller must hold
+// the write lock, or be the only one with access to m.
+func (m *MappedFile) remap(size int64) error {
+	if size == int64(len(m.data)) {
+		return nil
+	}
+
+	var data []byte
+	// Empty mappings are not allowed, an empty file just has no data.
+	if size > 0 {
+		var err error
+		if data, err = mmap(m.f, int(size)); err != nil {
+			return fmt.Errorf("failed to map %s: %w", m.f.Name(), err)
+		}
+	}
+
+	if m.data != nil {
+		if err := munmap(m.data); err != nil {
+			return errors.Join(fmt.Errorf("failed to unmap %s: %w", m.f.Name(), err), munmap(data))
+		}
+	}
+	m.data = data
+	return nil
+}
+
+// refresh maps the file again if its size is not the size of the current
+// mapping, like when it grew or was truncated.
+func (m *MappedFile) refresh() error {
+	info, err := m.f.Stat()
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.closed {
+		return os.ErrClosed
+	}
+	return m.remap(info.Size())
+}
+
+// ReadAt implements io.ReaderAt.
+func (m *MappedFile) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, fmt.Errorf("invalid offset %d", off)
+	}
+
+	m.mu.RLock()
+	fits := off+int64(len(p)) <= int64(len(m.data))
+	m.mu.RUnlock()
+
+	if !fits {
+		if err := m.refresh(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := m.copyAt(p, off)
+	if errors.Is(err, errTruncated) {
+		// Map only what is left of the file, and read that.
+		if err := m.refresh(); err != nil {
+			return 0, err
+		}
+		n, err = m.copyAt(p, off)
+	}
+	return n, err
+}
+
+// copyAt copies the mapped bytes at the given offset into p. If the file was
+// truncated below them, the fault of accessing them is returned as
+// errTruncated instead of crashing the process.
+func (m *MappedFile) copyAt(p []byte, off int64) (n int, err error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.closed {
+		return 0, os.ErrClosed
+	}
+	if off >= int64(len(m.data)) {
+		return 0, io.EOF
+	}
+
+	defer debug.SetPanicOnFault(debug.SetPanicOnFault(true))
+	defer func() {
+		if r := recover(); r != nil {
+			// Faults on memory access are the only panics expected.
+			if _, ok := r.(interface{ Addr() uintptr }); !ok {
+				panic(r)
+			}
+			n, err = 0, errTruncated
+		}
+	}()
+
+	n = copy(p, m.data[off:])
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// Size returns the size of the file, mapping it again if it changed.
+func (m *MappedFile) Size() (int64, error) {
+	if err := m.refresh(); err != nil {
+		return 0, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return int64(len(m.data)), nil
+}
+
+// Close unmaps the file. It does not close the file itself.
+func (m *MappedFile) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.closed {
+		return nil
+	}
+	m.closed = true
+
+	data := m.data
+	m.data = nil
+	if data == nil {
+		return nil
+	}
+	return munmap(data)
+}
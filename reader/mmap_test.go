@@ -0,0 +1,108 @@
+package reader
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMappedFile_ReadsAt(t *testing.T) {
+	f, _ := createTestFile(t, "hello\nyou\n")
+
+	m, err := MapFile(f)
+	assert.NoError(t, err)
+	defer m.Close()
+
+	buf := make([]byte, 3)
+	n, err := m.ReadAt(buf, 6)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "you", string(buf[:n]))
+
+	n, err = m.ReadAt(buf, 8)
+	assert.ErrorIs(t, err, io.EOF)
+	assert.EqualValues(t, "u\n", string(buf[:n]))
+}
+
+func TestMappedFile_FollowsGrowth(t *testing.T) {
+	f, _ := createTestFile(t, "")
+
+	m, err := MapFile(f)
+	assert.NoError(t, err)
+	defer m.Close()
+
+	buf := make([]byte, 6)
+	_, err = m.ReadAt(buf, 0)
+	assert.ErrorIs(t, err, io.EOF)
+
+	appendToTestFile(t, f, "hello\n")
+
+	n, err := m.ReadAt(buf, 0)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "hello\n", string(buf[:n]))
+
+	size, err := m.Size()
+	assert.NoError(t, err)
+	assert.EqualValues(t, 6, size)
+}
+
+func TestMappedFile_FollowsTruncation(t *testing.T) {
+	// Larger than a page, so the truncated pages fault when accessed.
+	f, _ := createTestFile(t, strings.Repeat("hello\n", 4096))
+
+	m, err := MapFile(f)
+	assert.NoError(t, err)
+	defer m.Close()
+
+	buf := make([]byte, 6)
+	n, err := m.ReadAt(buf, 12000)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "hello\n", string(buf[:n]))
+
+	// Rotated by copying and truncating it.
+	assert.NoError(t, os.Truncate(f.Name(), 0))
+	_, err = m.ReadAt(buf, 12000)
+	assert.ErrorIs(t, err, io.EOF)
+	_, err = m.ReadAt(buf, 0)
+	assert.ErrorIs(t, err, io.EOF)
+	size, err := m.Size()
+	assert.NoError(t, err)
+	assert.EqualValues(t, 0, size)
+
+	appendToTestFile(t, f, "you\n")
+	n, err = m.ReadAt(buf, 0)
+	assert.ErrorIs(t, err, io.EOF)
+	assert.EqualValues(t, "you\n", string(buf[:n]))
+}
+
+func TestMappedFile_ScansBothWays(t *testing.T) {
+	f, _ := createTestFile(t, "hello\nyou\nthere\n")
+
+	m, err := MapFile(f)
+	assert.NoError(t, err)
+	defer m.Close()
+
+	// The scanners share the mapping.
+	fwdScanner := NewForwardsLineScanner(m, 6)
+	assert.True(t, fwdScanner.Scan())
+	assert.EqualValues(t, "you", fwdScanner.Text())
+	assert.True(t, fwdScanner.Scan())
+	assert.EqualValues(t, "there", fwdScanner.Text())
+
+	bkdScanner, err := NewBackwardsLineScanner(m, 10, WithChunkSize(4))
+	assert.NoError(t, err)
+	line, pos, err := bkdScanner.ReadLine()
+	assert.NoError(t, err)
+	assert.EqualValues(t, "", string(line))
+	assert.EqualValues(t, 10, pos)
+	line, pos, err = bkdScanner.ReadLine()
+	assert.NoError(t, err)
+	assert.EqualValues(t, "you", string(line))
+	assert.EqualValues(t, 6, pos)
+	line, pos, err = bkdScanner.ReadLine()
+	assert.ErrorIs(t, err, io.EOF)
+	assert.EqualValues(t, "hello", string(line))
+	assert.EqualValues(t, 0, pos)
+}
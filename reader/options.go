@@ -0,0 +1,99 @@
+package reader
+
+import "context"
+
+// defaultChunkSize is how many bytes a backwards scanner reads at a time unless
+// another size is set.
+const defaultChunkSize = 1024
+
+// Option configures a line scanner when it is created.
+type Option func(*options)
+
+type options struct {
+	delim      []byte
+	maxLineLen int
+	chunkSize  int
+	start      func() int64
+	end        int64
+
+	followCtx     context.Context
+	followChanged <-chan struct{}
+}
+
+func newOptions(opts []Option) options {
+	o := options{delim: newline, chunkSize: defaultChunkSize, end: -1}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// WithDelimiter sets the delimiter lines end with, instead of a newline. Lines
+// that end with \r\n only have their carriage return dropped when the
+// delimiter is a newline.
+func WithDelimiter(delim []byte) Option {
+	if len(delim) == 0 {
+		panic("delimiter must not be empty")
+	}
+	return func(o *options) {
+		o.delim = delim
+	}
+}
+
+// WithMaxLineLen sets the most bytes of a line that are kept. Only the start of
+// longer lines is returned, and LineLen returns their full length. A length of
+// 0 keeps whole lines, which is the default.
+func WithMaxLineLen(n int) Option {
+	return func(o *options) {
+		o.maxLineLen = n
+	}
+}
+
+// WithChunkSize sets how many bytes a backwards scanner reads at a time. It
+// doesn't affect forwards scanners, which buffer as much as a line needs.
+func WithChunkSize(n int) Option {
+	if n <= 0 {
+		panic("chunk size must be positive")
+	}
+	return func(o *options) {
+		o.chunkSize = n
+	}
+}
+
+// WithFollow makes a forwards scanner wait at the end of the reader for more to
+// be written to it, instead of Scan returning false there for the caller to
+// poll it again. Scan reads again every time a value is received from changed,
+// which the caller sends on when the reader may have grown, like when a
+// file watcher reports a write.
+//
+// Scan returns false once changed is closed, or once the context is done, in
+// which case Err returns the context's error. It doesn't affect backwards
+// scanners, which never read past where they start.
+func WithFollow(ctx context.Context, changed <-chan struct{}) Option {
+	return func(o *options) {
+		o.followCtx = ctx
+		o.followChanged = changed
+	}
+}
+
+// WithStart makes a backwards scanner treat the position start returns as the
+// start of the reader, for readers whose start is dropped as they grow, like a
+// spill file that only keeps its newest bytes. start is called before every
+// read, so it may move while the scanner is used, and must be at the start of
+// a line. It doesn't affect forwards scanners, which are created after the
+// start.
+func WithStart(start func() int64) Option {
+	return func(o *options) {
+		o.start = start
+	}
+}
+
+// WithEnd makes a forwards scanner treat the given position as the end of the
+// reader, so it stops there even if the reader goes on, and a scanner that
+// follows the reader waits there for good. It must be at the start of a line.
+// It doesn't affect backwards scanners, which are created before the end.
+func WithEnd(end int64) Option {
+	return func(o *options) {
+		o.end = end
+	}
+}
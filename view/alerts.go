@@ -0,0 +1,157 @@
+package view
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/itchyny/gojq"
+)
+
+// flashDuration is how long the screen flashes when an alert rule matches.
+const flashDuration = 150 * time.Millisecond
+
+// alertRule matches the records to alert of when they are appended to the
+// input while following.
+type alertRule struct {
+	// The rule as it was given.
+	source string
+	// The regular expression the record's line is matched with, if the rule
+	// was given as /PATTERN/.
+	pattern *regexp.Regexp
+	// Otherwise, the compiled jq expression. A record matches if it yields a
+	// value other than false or null for it.
+	expr *gojq.Code
+}
+
+// parseAlertRules parses rules that are either /PATTERN/, a regular expression
+// matched with the records' lines, or a jq expression that selects records.
+func parseAlertRules(sources []string) ([]alertRule, error) {
+	rules := make([]alertRule, 0, len(sources))
+	for _, source := range sources {
+		rule, err := parseAlertRule(source)
+		if err != nil {
+			return nil, fmt.Errorf("invalid alert rule %q: %w", source, err)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+func parseAlertRule(source string) (alertRule, error) {
+	trimmed := strings.TrimSpace(source)
+	if len(trimmed) >= 2 && strings.HasPrefix(trimmed, "/") && strings.HasSuffix(trimmed, "/") {
+		pattern, err := regexp.Compile(trimmed[1 : len(trimmed)-1])
+		if err != nil {
+			return alertRule{}, err
+		}
+		return alertRule{source: source, pattern: pattern}, nil
+	}
+
+	query, err := gojq.Parse(trimmed)
+	if err != nil {
+		return alertRule{}, err
+	}
+	expr, err := gojq.Compile(query)
+	if err != nil {
+		return alertRule{}, err
+	}
+	return alertRule{source: source, expr: expr}, nil
+}
+
+// matches returns true if the rule matches the given record.
+func (r *alertRule) matches(rec *record) bool {
+	if r.pattern != nil {
+		return r.pattern.Match(rec.raw)
+	}
+	return rec.parsed != nil && selects(r.expr, rec.parsed)
+}
+
+// matchAlertRules returns true if any of the rules matches the given record.
+func matchAlertRules(rules []alertRule, rec *record) bool {
+	for i := range rules {
+		if rules[i].matches(rec) {
+			return true
+		}
+	}
+	return false
+}
+
+// SetAlertRules sets the rules of the records to alert of when they are
+// appended to the input while following. They take effect for records loaded
+// after the next call to SeekAndPopulate.
+func (b *Buffer) SetAlertRules(rules []alertRule) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.alertRules = rules
+}
+
+// alertRecord asks the application to alert of the given record, which was just
+// appended to the input, if the rules match it. Only one request is pending at
+// a time, so a burst of matches alerts once.
+func (b *Buffer) alertRecord(rules []alertRule, r *record) {
+	if r.excluded || !matchAlertRules(rules, r) {
+		return
+	}
+	b.alerts.Add(1)
+	b.lastAlert.Store(string(r.buf))
+	if !b.alertPending.CompareAndSwap(false, true) {
+		return
+	}
+	if err := b.postEvent(tcell.NewEventInterrupt(alertEvent{})); err != nil {
+		b.alertPending.Store(false)
+	}
+}
+
+// alertEvent is posted to the application when records that alert rules match
+// were appended to the input.
+type alertEvent struct{}
+
+// beeper is a screen that rings the terminal's bell, like a tcell.Screen.
+type beeper interface {
+	Beep() error
+}
+
+// alert rings the bell and flashes the screen for the records the buffer
+// alerted of, and sends a desktop notification of them if configured to.
+func (a *Application) alert() {
+	a.buffer.alertPending.Store(false)
+	if b, ok := a.screen.(beeper); ok {
+		b.Beep()
+	}
+	a.flashUntil = time.Now().Add(flashDuration)
+	screen := a.screen
+	time.AfterFunc(flashDuration, func() {
+		// Render again to end the flash.
+		screen.PostEvent(tcell.NewEventInterrupt(nil))
+	})
+	if a.config.Notify {
+		a.notifyDesktop()
+	}
+}
+
+// drawFlash reverses every cell of the screen while it flashes.
+func (a *Application) drawFlash() {
+	if !time.Now().Before(a.flashUntil) {
+		return
+	}
+	for y := 0; y < a.height; y++ {
+		for x := 0; x < a.width; x++ {
+			mainc, combc, style, _ := a.screen.GetContent(x, y)
+			_, _, attrs := style.Decompose()
+			a.screen.SetContent(x, y, mainc, combc, style.Reverse(attrs&tcell.AttrReverse == 0))
+		}
+	}
+}
+
+// formatAlerts describes how many records alert rules matched for the status
+// bar.
+func formatAlerts(n int64) string {
+	if n == 1 {
+		return "[1 alert]"
+	}
+	return fmt.Sprintf("[%s alerts]", formatCount(n))
+}
@@ -0,0 +1,65 @@
+package view
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseAlertRules(t *testing.T) {
+	rules, err := parseAlertRules([]string{"/time(d)? ?out/", `select(.level == "error")`})
+	assert.NoError(t, err)
+
+	timeout := &record{raw: []byte(`{"msg":"timed out"}`), parsed: map[string]any{"msg": "timed out"}}
+	failure := &record{raw: []byte(`{"level":"error"}`), parsed: map[string]any{"level": "error"}}
+	other := &record{raw: []byte(`{"level":"info"}`), parsed: map[string]any{"level": "info"}}
+	assert.True(t, rules[0].matches(timeout))
+	assert.False(t, rules[1].matches(timeout))
+	assert.True(t, rules[1].matches(failure))
+	assert.False(t, matchAlertRules(rules, other))
+
+	_, err = parseAlertRules([]string{"/(/"})
+	assert.ErrorContains(t, err, `invalid alert rule "/(/"`)
+	_, err = parseAlertRules([]string{"select("})
+	assert.Error(t, err)
+}
+
+func TestApplication_AlertsOfAppendedRecords(t *testing.T) {
+	line := `{"time":1700000000000,"name":"Pelecard","msg":"hi"}` + "\n"
+	alarming := `{"time":1700000000000,"name":"Pelecard","msg":"boom"}` + "\n"
+	// Records already in the input don't alert.
+	file, _ := createTestFile(t, line+alarming)
+
+	ctx, cancelCtx := context.WithCancel(context.Background())
+	defer cancelCtx()
+	screen := NewMemoryScreen(200, 10)
+	a := NewApplication(file, true, &Config{NoTutorial: true, Alerts: []string{"/boom/"}})
+	assert.NoError(t, a.setup(ctx, screen))
+	assert.Eventually(t, func() bool { return a.buffer.atEOF.Load() }, time.Second, 5*time.Millisecond)
+
+	w, err := os.OpenFile(file.Name(), os.O_APPEND|os.O_WRONLY, 0)
+	assert.NoError(t, err)
+	defer w.Close()
+	_, err = w.WriteString(line + alarming + alarming)
+	assert.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		for len(screen.Events()) > 0 {
+			a.handleEvent(<-screen.Events())
+		}
+		return a.buffer.Status().Alerts == 2 && screen.Beeps() > 0
+	}, 2*time.Second, 5*time.Millisecond)
+	assert.LessOrEqual(t, screen.Beeps(), 2)
+
+	a.flashUntil = time.Now().Add(time.Minute)
+	a.render()
+	assert.True(t, strings.Contains(screen.Line(9), "[2 alerts]"), screen.Line(9))
+	_, _, style, _ := screen.GetContent(0, 0)
+	_, _, attrs := style.Decompose()
+	assert.NotZero(t, attrs&tcell.AttrReverse)
+}
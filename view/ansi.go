@@ -0,0 +1,236 @@
+package view
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// ansiMode selects what is done with ANSI escape sequences in records.
+type ansiMode int
+
+const (
+	// Escape sequences are shown as they appear in the record.
+	ansiOff ansiMode = iota
+	// Escape sequences are removed.
+	ansiStrip
+	// Escape sequences are removed, and the colors and attributes they set
+	// are applied to the text that follows them.
+	ansiColor
+)
+
+// parseANSIMode parses an ANSI mode as given on the command line.
+func parseANSIMode(value string) (ansiMode, error) {
+	switch value {
+	case "off":
+		return ansiOff, nil
+	case "strip":
+		return ansiStrip, nil
+	case "color":
+		return ansiColor, nil
+	}
+	return ansiOff, fmt.Errorf("unknown ANSI mode %q, expected off, strip or color", value)
+}
+
+// styleSpan is a span of bytes of a record's text drawn with a style.
+type styleSpan struct {
+	start, end int
+	style      tcell.Style
+}
+
+// The escape character, as written raw and as escaped in JSON strings.
+const (
+	rawEscape  = "\x1b"
+	jsonEscape = `\u001b`
+)
+
+// processANSI removes the escape sequences from a record's JSON text. In color
+// mode it also returns the spans of the remaining text that the SGR sequences
+// styled, sorted by offset.
+func processANSI(text []byte, mode ansiMode) ([]byte, []styleSpan) {
+	if mode == ansiOff || (!bytes.Contains(text, []byte(rawEscape)) && !bytes.Contains(text, []byte(jsonEscape[:5]))) {
+		return text, nil
+	}
+
+	var out []byte
+	var spans []styleSpan
+	style := tcell.StyleDefault
+	styleStart := 0
+	setStyle := func(newStyle tcell.Style) {
+		if newStyle == style {
+			return
+		}
+		if style != tcell.StyleDefault && len(out) > styleStart {
+			spans = append(spans, styleSpan{start: styleStart, end: len(out), style: style})
+		}
+		style, styleStart = newStyle, len(out)
+	}
+
+	for i := 0; i < len(text); {
+		escLen := 0
+		switch {
+		case text[i] == rawEscape[0]:
+			escLen = 1
+		case text[i] == '\\' && i+1 < len(text):
+			if i+len(jsonEscape) <= len(text) && strings.EqualFold(string(text[i:i+len(jsonEscape)]), jsonEscape) {
+				escLen = len(jsonEscape)
+			} else {
+				// Copy other escapes whole, so an escaped backslash isn't
+				// mistaken for the start of the next one.
+				out = append(out, text[i:i+2]...)
+				i += 2
+				continue
+			}
+		}
+		if escLen == 0 {
+			out = append(out, text[i])
+			i++
+			continue
+		}
+
+		i += escLen
+		if i >= len(text) {
+			break
+		}
+		if text[i] != '[' {
+			// A two character escape sequence.
+			i++
+			continue
+		}
+
+		// A control sequence: parameter and intermediate bytes up to a final
+		// byte.
+		paramsStart := i + 1
+		end := paramsStart
+		for end < len(text) && text[end] >= 0x20 && text[end] <= 0x3f {
+			end++
+		}
+		if end >= len(text) {
+			i = end
+			break
+		}
+		if mode == ansiColor && text[end] == 'm' {
+			setStyle(applySGR(style, string(text[paramsStart:end])))
+		}
+		i = end + 1
+	}
+	setStyle(tcell.StyleDefault)
+
+	return out, spans
+}
+
+// applySGR applies the parameters of a Select Graphic Rendition sequence, such
+// as "1;31", to the given style.
+func applySGR(style tcell.Style, params string) tcell.Style {
+	codes := strings.Split(params, ";")
+	for i := 0; i < len(codes); i++ {
+		code, err := strconv.Atoi(codes[i])
+		if err != nil {
+			// An empty parameter means 0.
+			code = 0
+		}
+
+		switch {
+		case code == 0:
+			style = tcell.StyleDefault
+		case code == 1:
+			style = style.Bold(true)
+		case code == 2:
+			style = style.Dim(true)
+		case code == 3:
+			style = style.Italic(true)
+		case code == 4:
+			style = style.Underline(true)
+		case code == 5:
+			style = style.Blink(true)
+		case code == 7:
+			style = style.Reverse(true)
+		case code == 22:
+			style = style.Bold(false).Dim(false)
+		case code == 23:
+			style = style.Italic(false)
+		case code == 24:
+			style = style.Underline(false)
+		case code == 25:
+			style = style.Blink(false)
+		case code == 27:
+			style = style.Reverse(false)
+		case code >= 30 && code <= 37:
+			style = style.Foreground(tcell.PaletteColor(code - 30))
+		case code == 39:
+			style = style.Foreground(tcell.ColorDefault)
+		case code >= 40 && code <= 47:
+			style = style.Background(tcell.PaletteColor(code - 40))
+		case code == 49:
+			style = style.Background(tcell.ColorDefault)
+		case code >= 90 && code <= 97:
+			style = style.Foreground(tcell.PaletteColor(code - 90 + 8))
+		case code >= 100 && code <= 107:
+			style = style.Background(tcell.PaletteColor(code - 100 + 8))
+		case code == 38 || code == 48:
+			var color tcell.Color
+			color, i = parseExtendedColor(codes, i+1)
+			if color == tcell.ColorDefault {
+				continue
+			}
+			if code == 38 {
+				style = style.Foreground(color)
+			} else {
+				style = style.Background(color)
+			}
+		}
+	}
+	return style
+}
+
+// parseExtendedColor parses the 256 color ("5;N") or true color ("2;R;G;B")
+// arguments of an SGR 38 or 48 code, starting at codes[i]. It returns the color,
+// or tcell.ColorDefault if it is invalid, and the index of the last code used.
+func parseExtendedColor(codes []string, i int) (tcell.Color, int) {
+	arg := func(j int) int {
+		if j >= len(codes) {
+			return -1
+		}
+		n, err := strconv.Atoi(codes[j])
+		if err != nil || n < 0 || n > 255 {
+			return -1
+		}
+		return n
+	}
+
+	switch arg(i) {
+	case 5:
+		if n := arg(i + 1); n >= 0 {
+			return tcell.PaletteColor(n), i + 1
+		}
+		return tcell.ColorDefault, i + 1
+	case 2:
+		r, g, b := arg(i+1), arg(i+2), arg(i+3)
+		if r < 0 || g < 0 || b < 0 {
+			return tcell.ColorDefault, min(i+3, len(codes)-1)
+		}
+		return tcell.NewRGBColor(int32(r), int32(g), int32(b)), i + 3
+	}
+	return tcell.ColorDefault, i
+}
+
+// styleAt returns the style of the span at the given byte offset. spans must be
+// sorted, as returned by processANSI.
+func styleAt(spans []styleSpan, offset int) (tcell.Style, bool) {
+	lo, hi := 0, len(spans)
+	for lo < hi {
+		mid := (lo + hi) / 2
+		switch {
+		case offset < spans[mid].start:
+			hi = mid
+		case offset >= spans[mid].end:
+			lo = mid + 1
+		default:
+			return spans[mid].style, true
+		}
+	}
+	return tcell.StyleDefault, false
+}
@@ -0,0 +1,45 @@
+package view
+
+import (
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProcessANSI_StripsEscapedSequences(t *testing.T) {
+	text, spans := processANSI([]byte(`{"msg":"\u001b[31mred\u001b[0m ok"}`), ansiStrip)
+	assert.EqualValues(t, `{"msg":"red ok"}`, text)
+	assert.Empty(t, spans)
+}
+
+func TestProcessANSI_KeepsEscapedBackslashes(t *testing.T) {
+	text, _ := processANSI([]byte(`{"msg":"\\u001b[31m"}`), ansiStrip)
+	assert.EqualValues(t, `{"msg":"\\u001b[31m"}`, text)
+}
+
+func TestProcessANSI_StylesColoredText(t *testing.T) {
+	text, spans := processANSI([]byte("a\x1b[1;31mbc\x1b[22md\x1b[mef"), ansiColor)
+	assert.EqualValues(t, "abcdef", text)
+	assert.EqualValues(t, []styleSpan{
+		{start: 1, end: 3, style: tcell.StyleDefault.Bold(true).Foreground(tcell.ColorMaroon)},
+		{start: 3, end: 4, style: tcell.StyleDefault.Foreground(tcell.ColorMaroon)},
+	}, spans)
+
+	style, ok := styleAt(spans, 2)
+	assert.True(t, ok)
+	assert.EqualValues(t, spans[0].style, style)
+	_, ok = styleAt(spans, 4)
+	assert.False(t, ok)
+}
+
+func TestProcessANSI_LeavesTextAloneWhenOff(t *testing.T) {
+	text, spans := processANSI([]byte(`"\u001b[31mred"`), ansiOff)
+	assert.EqualValues(t, `"\u001b[31mred"`, text)
+	assert.Nil(t, spans)
+}
+
+func TestApplySGR_ExtendedColors(t *testing.T) {
+	assert.EqualValues(t, tcell.StyleDefault.Foreground(tcell.PaletteColor(208)), applySGR(tcell.StyleDefault, "38;5;208"))
+	assert.EqualValues(t, tcell.StyleDefault.Background(tcell.NewRGBColor(1, 2, 3)).Bold(true), applySGR(tcell.StyleDefault, "48;2;1;2;3;1"))
+}
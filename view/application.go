@@ -0,0 +1,828 @@
+package view
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/YLivay/gote/input"
+	"github.com/YLivay/gote/logging"
+	"github.com/gdamore/tcell/v2"
+)
+
+type Application struct {
+	// The input file handle
+	inputReader *os.File
+
+	// The settings the application was launched with.
+	config *Config
+
+	// If true, continue reading from reader forwards
+	followMode bool
+	// Following that was paused by scrolling up, or nil if it wasn't.
+	pause *followPause
+
+	// The width of the terminal
+	width int
+	// The height of the terminal
+	height int
+
+	screen Screen
+	buffer *Buffer
+
+	// The key bindings the application responds to.
+	keymap keymap
+	// The first run tutorial. It is nil when no tutorial is shown.
+	tutorial *tutorial
+	// The remembered position reading the input file is offered to resume
+	// at, or nil if it isn't offered.
+	resume *filePosition
+	// If true, the help screen is shown on top of the log lines.
+	showHelp bool
+	// The popup showing a single record in detail, or nil if it is closed.
+	detail *detailView
+	// If true, records are highlighted as JSON.
+	highlight bool
+	// The palette everything is drawn with, degraded to the colors the
+	// screen supports.
+	theme *theme
+
+	// What the gutter on the left of the log lines shows.
+	gutter gutterMode
+	// The width of the gutter, including the source column and the space
+	// separating it from the log lines.
+	gutterWidth int
+	// The names of the files the input is merged from, as the source column
+	// was laid out for.
+	sourceNames []string
+
+	// The text selected with the mouse or keyboard, or nil if there is none.
+	selection *selection
+	// If true, the primary mouse button is held down and moving the mouse
+	// extends the selection.
+	dragging bool
+	// If true, whole lines are being selected with the keyboard, and movement
+	// keys extend the selection instead of scrolling.
+	visual bool
+
+	// A short message shown in the status bar until the next key press.
+	message string
+	// A transient notice shown in the status bar until it expires, or nil.
+	notice *notice
+	// The screen is flashed until then when an alert rule matches.
+	flashUntil time.Time
+	// If true, the terminal reported it lost focus, so alerts are sent as
+	// desktop notifications when configured to.
+	unfocused bool
+	// When the last desktop notification was sent.
+	lastDesktopNotify time.Time
+	// The alerts the buffer raised when they were last counted towards a
+	// desktop notification, and the ones raised while the terminal wasn't
+	// focused that no notification was sent of yet.
+	desktopSeen    int64
+	desktopPending int64
+	// If true, a desktopFlush is posted once desktopNotifyInterval passed.
+	desktopFlushPending bool
+	// The text being typed into the status bar, or nil if nothing is asked
+	// for.
+	prompt *prompt
+	// The long scan of the input running in the background, or nil.
+	task *task
+
+	// Copies the input into the file the buffer reads if it can't be seeked,
+	// or nil if the input is read directly.
+	spool *input.Spool
+	// The notices of the inputs, shown in the status bar, or nil if the
+	// inputs don't have any.
+	inputNotices *input.Notices
+	// The absolute path of the input file its reading position is
+	// remembered by, or empty if it isn't remembered.
+	inputPath string
+
+	// Where the buffer writes its diagnostics, besides the ring of the most
+	// recent ones the debug panel shows.
+	debugLog io.Writer
+	logRing  *logging.Ring
+	// Logs the application's own diagnostics along with the buffer's.
+	logger *logging.Logger
+	// If true, the debug panel is shown.
+	showDebug bool
+	// The stats overlay, if it is shown.
+	stats *statsView
+	// The sidebar of a field's values, if it is shown.
+	facets *facetView
+	// The pattern last searched for, or nil if none was.
+	search *search
+	// The keys typed so far of a sequence, like the count before an action.
+	pending pendingKeys
+	// Set by the commands that quit, which can't return from handleEvent
+	// themselves.
+	quitRequested bool
+
+	// The Lua scripts that were loaded, whose key actions are added to the
+	// keymap, if any.
+	scripts *scripts
+
+	// The original lines of the marked records, by their offsets, which are
+	// printed when quitting. Guarded by muMarks, since they are printed
+	// after the screen is closed.
+	muMarks sync.Mutex
+	marks   map[int64][]byte
+}
+
+func NewApplication(inputReader *os.File, followMode bool, config *Config) *Application {
+	km, err := lookupKeymap(config.Keymap)
+	if err != nil {
+		// The flags were checked already, so only a config made in code
+		// can get here.
+		km = defaultKeymap
+	}
+	application := &Application{
+		inputReader: inputReader,
+		config:      config,
+		followMode:  followMode,
+		keymap:      km,
+		highlight:   !config.NoHighlight,
+		gutter:      config.Gutter,
+		debugLog:    io.Discard,
+		logRing:     logging.NewRing(LogRingSize),
+		logger:      logging.New(io.Discard, logging.Text, logging.Levels{}).Named("application"),
+	}
+
+	if !config.NoTutorial && isFirstRun() {
+		application.tutorial = newTutorial(application.keymap)
+	}
+
+	return application
+}
+
+// SetSpool tells the application of the spool that copies the input into the
+// file it reads, and of the notices of the inputs, which it shows in the status
+// bar. It must be called before Run.
+func (a *Application) SetSpool(spool *input.Spool, notices *input.Notices) {
+	a.spool = spool
+	a.inputNotices = notices
+}
+
+// SetLog makes the buffer write its diagnostics to debugLog, and keep the most
+// recent ones for the debug panel in the given ring. It must be called before
+// Run.
+func (a *Application) SetLog(debugLog io.Writer, ring *logging.Ring) {
+	a.debugLog = debugLog
+	a.logRing = ring
+}
+
+// SetInputPath makes the application remember the reading position of the
+// input by its absolute path, and return to it the next time it is read. It
+// must be called before Run.
+func (a *Application) SetInputPath(path string) {
+	a.inputPath = path
+}
+
+func (a *Application) Run(ctx context.Context, cancelCtx context.CancelFunc) error {
+	screen, err := tcell.NewScreen()
+	if err != nil {
+		return fmt.Errorf("failed to create terminal screen: %w", err)
+	}
+	if err := screen.Init(); err != nil {
+		return fmt.Errorf("failed to initialize terminal screen: %w", err)
+	}
+
+	quit := func() {
+		// You have to catch panics in a defer, clean up, and
+		// re-raise them - otherwise your application can
+		// die without leaving any diagnostic trace.
+		maybePanic := recover()
+		screen.Fini()
+		if maybePanic != nil {
+			panic(maybePanic)
+		}
+	}
+	defer quit()
+
+	if err := a.setup(ctx, screen); err != nil {
+		return err
+	}
+
+	go func() {
+		defer cancelCtx()
+		defer a.saveInputPosition()
+
+		eventsCh := make(chan tcell.Event)
+		quitCh := make(chan struct{})
+		defer close(quitCh)
+
+		go screen.ChannelEvents(eventsCh, quitCh)
+
+		for {
+			// Update screen
+			screen.Show()
+
+			// Get next event.
+			ev := <-eventsCh
+			if ev == nil {
+				return
+			}
+
+			if !a.handleEvent(ev) {
+				return
+			}
+		}
+	}()
+
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+// setup attaches the application to an initialized screen, creates its buffer
+// and starts populating it. It does not start processing screen events, this
+// is done by feeding them to handleEvent.
+func (a *Application) setup(ctx context.Context, screen Screen) error {
+	a.width, a.height = screen.Size()
+	a.screen = screen
+	a.inputNotices.Attach(func(text string, err bool) {
+		screen.PostEvent(tcell.NewEventInterrupt(&notice{text: text, err: err}))
+	})
+
+	theme, err := lookupTheme(a.config.Theme)
+	if err != nil {
+		return err
+	}
+	a.theme = theme.degrade(screen.Colors())
+
+	a.sourceNames = a.spool.Sources()
+	if info, err := a.inputReader.Stat(); err == nil {
+		a.gutterWidth = gutterWidthFor(a.gutter, info.Size()) + a.sourceWidth()
+	} else {
+		a.gutterWidth = gutterWidthFor(a.gutter, 0) + a.sourceWidth()
+	}
+
+	logger := logging.New(io.MultiWriter(a.debugLog, a.logRing), a.config.LogFormat, a.config.LogLevels)
+	a.logger = logger.Named("application")
+
+	buffer, err := NewBuffer(a.textWidth(), a.viewHeight(), a.followMode, a.inputReader, ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create buffer: %w", err)
+	}
+	a.buffer = buffer
+
+	if err := configureBuffer(buffer, a.config, a.spool, logger); err != nil {
+		return err
+	}
+	if a.scripts = buffer.scripts; a.scripts != nil {
+		a.keymap = append(slices.Clone(a.keymap), a.scripts.bindings()...)
+	}
+	if a.config.Mmap {
+		if err := buffer.UseMmap(); err != nil {
+			// Reading the file normally still works, just slower.
+			a.logger.Warn("failed to map input file, reading it normally:", err.Error())
+		}
+	}
+
+	whence := io.SeekStart
+	if a.followMode {
+		whence = io.SeekEnd
+	}
+
+	if a.config.Tail > 0 {
+		err = a.buffer.SeekToTail(a.config.Tail)
+	} else if a.config.Start != nil {
+		err = a.seekToStart()
+	} else {
+		err = a.buffer.SeekAndPopulate(0, whence)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to populate the application buffer: %w", err)
+	}
+
+	if a.config.DebugListen != "" {
+		addr, err := startDebugServer(ctx, a.config.DebugListen, buffer, logger.Named("debug"))
+		if err != nil {
+			return fmt.Errorf("failed to start debug server: %w", err)
+		}
+		a.logger.Info("serving debug endpoints on", addr.String())
+	}
+
+	buffer.SetPostEventFunc(func(ev tcell.Event) error {
+		return screen.PostEvent(ev)
+	})
+	go updateRate(ctx, buffer, screen)
+
+	screen.EnableMouse()
+	if f, ok := screen.(focusReporter); ok && a.config.Notify {
+		f.EnableFocus()
+	}
+
+	a.offerResume()
+	a.render()
+
+	return nil
+}
+
+// configureBuffer applies the configured way of reading and parsing records to
+// the buffer of the given input spool, if there is one, and makes it log its
+// diagnostics as the buffer component of the given logger.
+func configureBuffer(buffer *Buffer, config *Config, spool *input.Spool, logger *logging.Logger) error {
+	buffer.SetLogger(logger.Named("buffer"))
+	if config.Filter != "" {
+		if err := buffer.SetFilter(config.Filter); err != nil {
+			return fmt.Errorf("invalid filter %q: %w", config.Filter, err)
+		}
+	}
+	highlightRules, err := parseHighlightRules(config.HighlightRules)
+	if err != nil {
+		return err
+	}
+	buffer.SetHighlightRules(highlightRules)
+	alertRules, err := parseAlertRules(config.Alerts)
+	if err != nil {
+		return err
+	}
+	if len(alertRules) > 0 {
+		buffer.SetAlertRules(alertRules)
+	}
+	scripts, err := scriptsFromConfig(config, logger)
+	if err != nil {
+		return err
+	}
+	buffer.SetScripts(scripts)
+	buffer.SetDimExcluded(config.DimExcluded)
+	buffer.SetSampling(config.Sample)
+	if config.TabWidth > 0 {
+		buffer.SetTabWidth(config.TabWidth)
+	}
+	buffer.SetANSIMode(config.ANSIMode)
+	buffer.SetEagerness(config.EagerForward, config.EagerBack)
+	buffer.SetFollowName(config.FollowName)
+	buffer.SetBudget(config.MaxLines, config.MaxMemoryMB<<20)
+	buffer.SetMaxRecordSize(config.MaxRecordKB << 10)
+	if len(config.Delimiter) > 0 {
+		buffer.SetDelimiter(config.Delimiter)
+	}
+	if spool != nil {
+		buffer.SetInputStart(spool.Start)
+	}
+	if !config.NoIndexCache {
+		if dir, err := indexCacheDir(); err == nil {
+			buffer.SetIndexCache(dir)
+		}
+	}
+	if config.Transform != "" {
+		if err := buffer.SetTransform(config.Transform); err != nil {
+			return err
+		}
+	}
+	if err := buffer.SetRecordStart(config.RecordStart); err != nil {
+		return err
+	}
+	if !config.Since.IsZero() || !config.Until.IsZero() {
+		return buffer.SetTimeRange(config.Since, config.Until)
+	}
+	return nil
+}
+
+// setFollowMode starts or stops following new records at the end of the input.
+func (a *Application) setFollowMode(followMode bool) {
+	if a.followMode == followMode {
+		return
+	}
+	a.followMode = followMode
+	a.stopPause()
+	if a.config.SparklineUnit > 0 {
+		// The sparkline takes a line of the screen while following.
+		a.buffer.ResizeScreen(a.textWidth(), a.viewHeight())
+	}
+	a.buffer.SetFollowMode(a.followMode)
+	a.clearSelection()
+}
+
+// handleEvent processes a single screen event. It returns false if the
+// application should stop processing events and quit.
+func (a *Application) handleEvent(ev tcell.Event) bool {
+	switch ev := ev.(type) {
+	case *tcell.EventResize:
+		a.resize(ev.Size())
+		a.screen.Sync()
+	case *tcell.EventKey:
+		a.message = ""
+		act := a.keymap.lookup(ev)
+		if act == actionQuit && ev.Key() == tcell.KeyCtrlC {
+			// Ctrl+C always quits, even when an overlay is shown.
+			return false
+		}
+
+		if a.task != nil {
+			// Keys wait for the task, which would move the screen away
+			// from where they took it.
+			if ev.Key() == tcell.KeyEscape {
+				a.cancelTask()
+			}
+			a.render()
+			return true
+		}
+
+		if a.prompt != nil {
+			a.handlePromptKey(ev)
+			if a.quitRequested {
+				return false
+			}
+			a.render()
+			return true
+		}
+
+		if a.tutorial != nil {
+			a.handleTutorialKey(ev)
+			a.render()
+			return true
+		}
+
+		if a.resume != nil {
+			a.handleResumeKey(ev)
+			a.render()
+			return true
+		}
+
+		if a.detail != nil {
+			a.handleDetailKey(ev, act)
+			a.render()
+			return true
+		}
+
+		if a.showHelp {
+			if act == actionToggleHelp || act == actionQuit || ev.Key() == tcell.KeyEscape {
+				a.showHelp = false
+				a.render()
+			}
+			return true
+		}
+
+		if a.showDebug {
+			if act == actionToggleDebug || act == actionQuit || ev.Key() == tcell.KeyEscape {
+				a.showDebug = false
+				a.render()
+			}
+			return true
+		}
+
+		if a.stats != nil {
+			a.handleStatsKey(ev, act)
+			a.render()
+			return true
+		}
+
+		if a.facets != nil && a.facets.focused && a.handleFacetKey(ev, act) {
+			a.render()
+			return true
+		}
+
+		if a.visual && a.handleVisualKey(ev, act) {
+			a.render()
+			return true
+		}
+
+		act, count, ok := a.sequenceKey(ev, act)
+		if !ok {
+			a.render()
+			return true
+		}
+		if act == actionScript {
+			a.runScriptKey(ev.Rune())
+			a.render()
+			return true
+		}
+		if count > 0 {
+			return a.performCounted(act, count)
+		}
+		if !a.performAction(act) {
+			return false
+		}
+	case *tcell.EventFocus:
+		a.setFocused(ev)
+	case *tcell.EventMouse:
+		if a.prompt == nil && a.task == nil && a.tutorial == nil && a.resume == nil && !a.showHelp && !a.showDebug && a.stats == nil && a.detail == nil {
+			a.handleMouse(ev)
+		}
+	case *tcell.EventInterrupt:
+		if result, ok := ev.Data().(*saveResult); ok {
+			a.message = result.message()
+		} else if n, ok := ev.Data().(*notice); ok {
+			a.showNotice(n)
+		} else if _, ok := ev.Data().(noticeExpired); ok {
+			// The status bar is redrawn without the expired notice.
+		} else if _, ok := ev.Data().(pauseUpdate); ok {
+			// The status bar is redrawn with the records appended so far.
+		} else if _, ok := ev.Data().(alertEvent); ok {
+			a.alert()
+		} else if _, ok := ev.Data().(desktopFlush); ok {
+			a.flushDesktop()
+		} else if _, ok := ev.Data().(rateUpdate); ok {
+			// The status bar is redrawn with the current ingest rate.
+		} else if _, ok := ev.Data().(statsUpdate); ok {
+			// The overlay is redrawn with the stats counted so far.
+		} else if done, ok := ev.Data().(*taskDone); ok {
+			a.finishTask(done)
+		} else if _, ok := ev.Data().(taskUpdate); ok {
+			// The status bar is redrawn with the task's progress.
+		} else {
+			a.buffer.clearRenderRequest()
+		}
+		a.render()
+	}
+
+	return true
+}
+
+// performAction performs the given action and rerenders the screen if needed.
+// It returns false if the application should quit.
+func (a *Application) performAction(act action) bool {
+	switch act {
+	case actionScrollUp:
+		a.scroll(-1)
+	case actionScrollDown:
+		a.scroll(1)
+	case actionPageUp:
+		a.scroll(-a.viewHeight())
+	case actionPageDown:
+		a.scroll(a.viewHeight())
+	case actionToggleWrap:
+		a.buffer.SetWrap(!a.buffer.Wrap())
+	case actionToggleHighlight:
+		a.highlight = !a.highlight
+	case actionTogglePause:
+		a.togglePause()
+	case actionToggleFollow:
+		a.setFollowMode(!a.followMode)
+	case actionTop:
+		a.seekToEdge(false)
+	case actionBottom:
+		a.seekToEdge(true)
+	case actionSearch:
+		a.startSearch(false)
+	case actionSearchBackwards:
+		a.startSearch(true)
+	case actionNextMatch:
+		a.findNext(false, 1)
+	case actionPrevMatch:
+		a.findNext(true, 1)
+	case actionCycleGutter:
+		a.setGutter(a.gutter.next())
+	case actionShowDetail:
+		if r := a.recordUnderCursor(); r != nil {
+			raw := r.raw
+			if r.fullLen > 0 {
+				// Show the whole line, not just the start the record holds.
+				if line, err := a.buffer.ReadFullLine(r); err != nil {
+					a.notifyError("reading the whole record failed: " + err.Error())
+				} else {
+					raw = line
+				}
+			}
+			a.detail = newDetailView(r, raw)
+		}
+	case actionToggleCollapse:
+		if r := a.recordUnderCursor(); r != nil {
+			a.buffer.ToggleCollapsed(r)
+			// The content under the selection moved.
+			a.clearSelection()
+		}
+	case actionToggleDimExcluded:
+		if err := a.setDimExcluded(!a.buffer.DimExcluded()); err != nil {
+			a.notifyError("reloading the records failed: " + err.Error())
+		}
+	case actionToggleSampling:
+		if err := a.toggleSampling(); err != nil {
+			a.notifyError("reloading the records failed: " + err.Error())
+		}
+	case actionToggleRepeats:
+		if err := a.buffer.SetCollapseRepeats(!a.buffer.CollapseRepeats()); err != nil {
+			a.notifyError("collapsing repeats failed: " + err.Error())
+		}
+		a.clearSelection()
+	case actionVisualSelect:
+		a.startVisual()
+	case actionYank:
+		a.yank()
+	case actionYankRecord:
+		a.yankRecord()
+	case actionSave:
+		a.startSave()
+	case actionPipe:
+		a.startPipe()
+	case actionExportCSV:
+		a.startExportCSV()
+	case actionShell:
+		a.startShell()
+	case actionCommand:
+		a.startCommand()
+	case actionToggleMark:
+		a.toggleMark()
+	case actionPrintMarks:
+		a.showMarks()
+	case actionToggleHelp:
+		a.showHelp = !a.showHelp
+	case actionToggleDebug:
+		a.showDebug = !a.showDebug
+	case actionToggleStats:
+		a.showStats()
+	case actionFacets:
+		if a.facets != nil {
+			a.closeFacets()
+		} else {
+			a.startFacets()
+		}
+	case actionFocusFacets:
+		if a.facets != nil {
+			a.facets.focused = true
+		}
+	case actionQuit:
+		return false
+	default:
+		return true
+	}
+
+	a.render()
+	return true
+}
+
+// recordUnderCursor returns the record under the mouse cursor, or the one at
+// the top of the screen if there is no cursor.
+func (a *Application) recordUnderCursor() *record {
+	line := 0
+	if a.selection != nil {
+		line = a.selection.endY
+	}
+	return a.buffer.records.RecordAtScreenLine(line)
+}
+
+// resize lays out the screen again for its new size. The selection is bound to
+// screen cells, so it is cleared.
+func (a *Application) resize(width, height int) {
+	a.width, a.height = width, height
+	a.buffer.ResizeScreen(a.textWidth(), a.viewHeight())
+	a.clearSelection()
+	if a.detail != nil {
+		a.detail.scroll(0, a.detailPageHeight())
+	}
+	a.render()
+}
+
+// handleTutorialKey moves between the tutorial pages, or dismisses it.
+func (a *Application) handleTutorialKey(ev *tcell.EventKey) {
+	switch ev.Key() {
+	case tcell.KeyEnter, tcell.KeyRight:
+		if a.tutorial.next() {
+			return
+		}
+	case tcell.KeyLeft, tcell.KeyBackspace, tcell.KeyBackspace2:
+		a.tutorial.prev()
+		return
+	case tcell.KeyRune:
+		if ev.Rune() != ' ' {
+			return
+		}
+		if a.tutorial.next() {
+			return
+		}
+	case tcell.KeyEscape:
+	default:
+		return
+	}
+
+	// Reaching here means the tutorial was finished or skipped.
+	a.tutorial = nil
+	if err := markTutorialSeen(); err != nil {
+		a.logger.Error("failed to mark tutorial as seen:", err.Error())
+	}
+}
+
+// viewWidth returns the number of columns available for log lines. The right
+// edge is reserved for the scrollbar, and the sidebar if it is shown.
+func (a *Application) viewWidth() int {
+	return max(a.width-scrollbarWidth-a.facetWidth(), 0)
+}
+
+// textWidth returns the number of columns available for the text of log
+// lines, after the gutter.
+func (a *Application) textWidth() int {
+	return max(a.viewWidth()-a.gutterWidth, 0)
+}
+
+// viewHeight returns the number of rows available for log lines. The bottom
+// row is reserved for the status bar.
+func (a *Application) viewHeight() int {
+	if a.showsSparkline() {
+		return max(a.height-2, 0)
+	}
+	return max(a.height-1, 0)
+}
+
+// render redraws the log lines, the status bar and whichever overlay is
+// active.
+func (a *Application) render() {
+	a.layoutSources()
+	a.screen.Clear()
+	a.RenderLogLines(a.buffer.records.GetRenderLines(a.viewHeight()))
+	a.drawSelection()
+	a.drawScrollbar()
+	a.drawFacets()
+	a.drawSparkline()
+	a.drawStatusBar()
+	a.drawTask()
+	if a.prompt != nil {
+		a.drawPrompt(a.prompt)
+	} else {
+		a.screen.HideCursor()
+	}
+
+	if a.detail != nil {
+		a.drawDetailView(a.detail)
+	}
+
+	if a.tutorial != nil {
+		a.drawOverlay(a.tutorial.overlay())
+	} else if a.resume != nil {
+		a.drawOverlay(resumeOverlay(a.resume))
+	} else if a.showHelp {
+		a.drawOverlay(helpOverlay(a.keymap))
+	} else if a.showDebug {
+		a.drawOverlay(debugOverlay(a.logRing, a.height))
+	} else if a.stats != nil {
+		a.drawOverlay(a.stats.overlay())
+	}
+	a.drawFlash()
+}
+
+func (a *Application) RenderLogLines(lines []renderLine) {
+	var x, y int
+	y = 0
+	var state *stepState
+	for _, line := range lines {
+		a.drawGutter(y, line)
+		a.drawSource(y, line)
+		x = a.gutterWidth
+		state = nil
+
+		excluded := line.record.excluded
+		var spans []jsonSpan
+		if a.highlight && !excluded {
+			spans = line.record.Spans()
+		}
+		levelStyle, hasLevelStyle := a.theme.levels[line.record.level]
+
+		text := line.text
+		for len(text) > 0 {
+			// The offset of the current grapheme within the record's buffer.
+			bufOffset := line.offset + len(line.text) - len(text)
+
+			var ch string
+			ch, text, state = step(text, state)
+			w := state.Width()
+
+			style := a.theme.text
+			if spans != nil {
+				style = a.theme.json[classAt(spans, bufOffset)]
+			}
+			if hasLevelStyle {
+				style = mergeStyle(style, levelStyle)
+			}
+			if ansiStyle, ok := styleAt(line.record.ansiSpans, bufOffset); ok {
+				style = mergeStyle(style, a.theme.degradeStyle(ansiStyle))
+			}
+			if line.record.hasRuleStyle {
+				style = mergeStyle(style, a.theme.degradeStyle(line.record.ruleStyle))
+			}
+			if excluded {
+				style = a.theme.dim
+			}
+
+			for offset := w - 1; offset >= 0; offset-- {
+				runes := []rune(ch)
+				if offset == 0 {
+					a.screen.SetContent(x+offset, y, runes[0], runes[1:], style)
+				} else {
+					a.screen.SetContent(x+offset, y, ' ', nil, style)
+				}
+			}
+
+			x += w
+		}
+
+		if line.marker != "" {
+			x = a.drawText(x, y, a.viewWidth()-x, line.marker, a.theme.dim)
+		}
+
+		// Extend a matching rule's style to the end of the row so background
+		// colors mark the whole record.
+		if line.record.hasRuleStyle {
+			for ; x < a.viewWidth(); x++ {
+				a.screen.SetContent(x, y, ' ', nil, a.theme.degradeStyle(line.record.ruleStyle))
+			}
+		}
+		a.drawMark(y, line)
+		y++
+	}
+}
@@ -0,0 +1,159 @@
+package view
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+// generateTestRecords generates a log file's contents made of count JSON
+// records that pass the buffer's filter, interleaved with lines it should
+// skip. The length of each message varies so records wrap to different heights.
+func generateTestRecords(count int, seed byte) string {
+	var sb strings.Builder
+	for i := 0; i < count; i++ {
+		msgLen := (int(seed)*(i+1)*31)%200 + 1
+		fmt.Fprintf(&sb, `{"time":%d,"name":"Pelecard","msg":"%s"}`+"\n", 1700000000000+i*1000, strings.Repeat("m ", msgLen/2+1))
+		if (i+int(seed))%5 == 0 {
+			sb.WriteString("not a json line\n")
+		}
+	}
+	return sb.String()
+}
+
+// FuzzApplication_KeyEvents feeds random key and resize events into a headless
+// application, interleaved with seeks and prunes of its buffer, and asserts
+// that it does not panic and that its buffer is left in a consistent state.
+func FuzzApplication_KeyEvents(f *testing.F) {
+	f.Add(byte(0), false, []byte{})
+	f.Add(byte(10), false, []byte{0, 1, 2, 3, 0, 0, 1, 1, 2, 2, 3, 3})
+	f.Add(byte(40), true, []byte{1, 1, 1, 3, 3, 3, 0, 2, 4, 17, 4, 200, 0, 0})
+	f.Add(byte(3), false, []byte{2, 2, 2, 2, 4, 1, 3, 3, 3, 0, 0, 0, 5, 6})
+	f.Add(byte(100), true, []byte{4, 255, 255, 2, 2, 1, 1, 4, 9, 9, 3, 3, 3, 3})
+
+	f.Fuzz(func(t *testing.T, recordCount byte, followMode bool, events []byte) {
+		file, _ := createTestFile(t, generateTestRecords(int(recordCount), recordCount))
+
+		ctx, cancelCtx := context.WithCancel(context.Background())
+		defer cancelCtx()
+
+		screen := tcell.NewSimulationScreen("UTF-8")
+		if err := screen.Init(); err != nil {
+			t.Fatalf("Failed to initialize simulation screen: %v", err)
+		}
+		defer screen.Fini()
+		screen.SetSize(80, 25)
+
+		application := NewApplication(file, followMode, &Config{NoTutorial: true, MaxLines: 200})
+		if err := application.setup(ctx, screen); err != nil {
+			t.Fatalf("Failed to set up application: %v", err)
+		}
+
+		for i := 0; i < len(events); i++ {
+			var ev tcell.Event
+			switch events[i] % 16 {
+			case 0:
+				ev = tcell.NewEventKey(tcell.KeyUp, 0, tcell.ModNone)
+			case 1:
+				ev = tcell.NewEventKey(tcell.KeyDown, 0, tcell.ModNone)
+			case 2:
+				ev = tcell.NewEventKey(tcell.KeyPgUp, 0, tcell.ModNone)
+			case 3:
+				ev = tcell.NewEventKey(tcell.KeyPgDn, 0, tcell.ModNone)
+			case 4:
+				// A resize consumes the next two bytes as its dimensions.
+				w, h := 0, 0
+				if i+2 < len(events) {
+					w, h = int(events[i+1]), int(events[i+2])
+					i += 2
+				}
+				screen.SetSize(w, h)
+				ev = tcell.NewEventResize(w, h)
+			case 5:
+				ev = tcell.NewEventInterrupt(nil)
+			case 6:
+				// A seek consumes the next byte as a position relative to the
+				// end of the file.
+				var pos int64
+				if i+1 < len(events) {
+					pos = -int64(events[i+1]) * 37
+					i++
+				}
+				if err := application.buffer.SeekAndPopulate(pos, io.SeekEnd); err != nil {
+					// Seeking before the start of the file is expected to fail.
+					t.Logf("Seek to %d failed: %v", pos, err)
+				}
+				continue
+			case 7:
+				application.buffer.prune()
+				assertRecordListInvariants(t, application.buffer.records)
+				continue
+			case 8:
+				ev = tcell.NewEventKey(tcell.KeyRune, 'w', tcell.ModNone)
+			case 9:
+				// A mouse event consumes the next two bytes as its position.
+				// The event's own byte picks the buttons.
+				buttons := []tcell.ButtonMask{tcell.ButtonNone, tcell.Button1, tcell.WheelUp, tcell.WheelDown}[events[i]/10%4]
+				x, y := 0, 0
+				if i+2 < len(events) {
+					x, y = int(events[i+1]), int(events[i+2])
+					i += 2
+				}
+				ev = tcell.NewEventMouse(x, y, buttons, tcell.ModNone)
+			case 10:
+				ev = tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModNone)
+			case 11:
+				ev = tcell.NewEventKey(tcell.KeyEscape, 0, tcell.ModNone)
+			case 12:
+				ev = tcell.NewEventKey(tcell.KeyRune, 'c', tcell.ModNone)
+			case 13:
+				ev = tcell.NewEventKey(tcell.KeyRune, 'g', tcell.ModNone)
+			case 14:
+				ev = tcell.NewEventKey(tcell.KeyRune, 'F', tcell.ModNone)
+			case 15:
+				ev = tcell.NewEventKey(tcell.KeyRune, 'v', tcell.ModNone)
+			}
+
+			if !application.handleEvent(ev) {
+				break
+			}
+			assertRecordListInvariants(t, application.buffer.records)
+		}
+
+		// Stop the readers and wait for them to finish before inspecting the
+		// buffer.
+		<-application.buffer.cancelPopulate(errors.New("test done"))
+		assertRecordListInvariants(t, application.buffer.records)
+	})
+}
+
+func TestApplication_DimsExcludedRecords(t *testing.T) {
+	file, _ := createTestFile(t, `{"time":1700000000000,"name":"Pelecard","msg":"hi"}`+"\n"+`{"time":1700000000000,"name":"Other","msg":"ho"}`+"\n")
+
+	ctx, cancelCtx := context.WithCancel(context.Background())
+	defer cancelCtx()
+	screen := NewMemoryScreen(80, 5)
+	a := NewApplication(file, false, &Config{NoTutorial: true, Filter: defaultFilter})
+	assert.NoError(t, a.setup(ctx, screen))
+	assert.Eventually(t, func() bool { return a.buffer.Status().Records == 1 }, time.Second, 5*time.Millisecond)
+
+	a.handleEvent(tcell.NewEventKey(tcell.KeyRune, 'X', tcell.ModNone))
+	assert.True(t, a.buffer.DimExcluded())
+	assert.Eventually(t, func() bool { return a.buffer.Status().Records == 2 }, time.Second, 5*time.Millisecond)
+	a.render()
+	assert.Contains(t, screen.Line(1), `"name":"Other"`)
+	_, _, style, _ := screen.GetContent(0, 1)
+	assert.Equal(t, a.theme.dim, style)
+	_, _, style, _ = screen.GetContent(0, 0)
+	assert.NotEqual(t, a.theme.dim, style)
+
+	a.handleEvent(tcell.NewEventKey(tcell.KeyRune, 'X', tcell.ModNone))
+	assert.Eventually(t, func() bool { return a.buffer.Status().Records == 1 }, time.Second, 5*time.Millisecond)
+}
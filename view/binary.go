@@ -0,0 +1,67 @@
+package view
+
+import (
+	"bytes"
+	"fmt"
+	"unicode/utf8"
+)
+
+// looksBinary returns true if the given line has bytes that text doesn't, like
+// NUL bytes or bytes that aren't valid UTF-8, so the input it was read from may
+// be a binary file. Long lines are cut when they are read, so a character cut
+// off at the end of the line doesn't count.
+func looksBinary(line []byte) bool {
+	line = trimPartialRune(line)
+	return bytes.IndexByte(line, 0) >= 0 || !utf8.Valid(line)
+}
+
+// trimPartialRune removes the start of a multi byte character that was cut off
+// at the end of the given text.
+func trimPartialRune(text []byte) []byte {
+	for i := len(text) - 1; i >= 0 && i >= len(text)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(text[i]) {
+			if !utf8.FullRune(text[i:]) {
+				return text[:i]
+			}
+			break
+		}
+	}
+	return text
+}
+
+// isControlByte returns true if the given byte is a control character that
+// would move the terminal's cursor or otherwise garble the screen if it was
+// drawn as it is. Tabs are expanded and escape characters are handled with the
+// sequences they start, so they are left alone.
+func isControlByte(c byte) bool {
+	return (c < 0x20 && c != '\t' && c != rawEscape[0]) || c == 0x7f
+}
+
+// escapeBinary replaces control characters and bytes that aren't valid UTF-8
+// in a record's text with \xNN escapes, so they are shown instead of garbling
+// the screen. The text is returned as it is if there is nothing to escape.
+func escapeBinary(text []byte) []byte {
+	var out []byte
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRune(text[i:])
+		if (r != utf8.RuneError || size != 1) && !isControlByte(text[i]) {
+			if out != nil {
+				out = append(out, text[i:i+size]...)
+			}
+			i += size
+			continue
+		}
+
+		if out == nil {
+			out = make([]byte, i, len(text)+8)
+			copy(out, text[:i])
+		}
+		out = fmt.Appendf(out, `\x%02x`, text[i])
+		i++
+	}
+
+	if out == nil {
+		return text
+	}
+	return out
+}
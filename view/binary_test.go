@@ -0,0 +1,25 @@
+package view
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLooksBinary_SpotsNulAndInvalidUTF8(t *testing.T) {
+	assert.False(t, looksBinary([]byte(`{"msg":"héllo\tthere"}`)))
+	assert.True(t, looksBinary([]byte("{\"msg\":\"a\x00b\"}")))
+	assert.True(t, looksBinary([]byte("{\"msg\":\"a\xffb\"}")))
+	// A character cut off at the end of a long line isn't a sign of a binary
+	// file.
+	assert.False(t, looksBinary([]byte("abc\xe6\x97")))
+	assert.True(t, looksBinary([]byte("abc\x97")))
+}
+
+func TestEscapeBinary_EscapesBytesThatGarbleTheScreen(t *testing.T) {
+	text := []byte("plain 日本\ttext")
+	assert.Same(t, &text[0], &escapeBinary(text)[0])
+
+	assert.EqualValues(t, `a\x00b\x07\x7f日\xff`+"\t\x1b[1m", string(escapeBinary([]byte("a\x00b\x07\x7f日\xff\t\x1b[1m"))))
+	assert.EqualValues(t, `\x0d\x0a`, string(escapeBinary([]byte("\r\n"))))
+}
@@ -0,0 +1,2268 @@
+package view
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"regexp"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/YLivay/gote/logging"
+	"github.com/YLivay/gote/reader"
+	"github.com/gdamore/tcell/v2"
+	"github.com/itchyny/gojq"
+)
+
+// defaultMaxRecordSize is the most bytes of a line that are read into a record
+// unless configured otherwise.
+const defaultMaxRecordSize = 1 << 20
+
+// readBatchSize is the most records the readers read before inserting them
+// into the buffer.
+const readBatchSize = 32
+
+// lineIndexInterval is how many lines apart the offsets kept by the buffer's
+// line index are.
+const lineIndexInterval = 1024
+
+type Buffer struct {
+	// The terminal width. Records will be wrapped or truncated to lines of
+	// this length.
+	width int
+	// If true, records are wrapped to as many lines as needed to fit the
+	// terminal's width. Otherwise they are truncated to a single line.
+	wrap bool
+	// The terminal height. This is used to calculate how many lines are
+	// actually visible on screen.
+	height int
+	// If true, will continue reading from the input file forwards and scroll to
+	// keep the last line of the last record on the screen.
+	followMode bool
+	// If true, following continues with whatever file is at the input file's
+	// path when it is replaced, like when logs are rotated. Otherwise the
+	// originally opened file is followed.
+	followName bool
+
+	// Mutex to serialize operations.
+	mu *sync.Mutex
+	// The context for this buffer. when it finishes (or canceled) a best effort
+	// is done to close and free resources.
+	ctx context.Context
+
+	// The input file the readers read, and the path it was opened from. The
+	// file is replaced if it is rotated while being followed.
+	inputFile *os.File
+	inputName string
+	// Closes what was opened for reading the current input file.
+	closeInput func()
+	// Starts building the line index of the current input file, the first
+	// time it is called. It is called when the buffer is populated, so the
+	// buffer is configured by then.
+	startIndexing func()
+	// The directory the line indexes of large input files are kept in, or
+	// empty if they aren't.
+	indexCache string
+	// If true, the input file is read through a memory mapping.
+	useMmap bool
+	// What the scanners read the input file with, which is either the file
+	// itself or its memory mapping. The scanners read at positions rather than
+	// seeking, so they share it.
+	input io.ReaderAt
+	// A scanner that reads forwards from input line by line.
+	fwdScanner *reader.ForwardsLineScanner
+	// The line numbers of the next lines fwdScanner and bkdScanner will return,
+	// counting from 1. They are 0 if unknown, which is the case unless the
+	// buffer was oriented at the start of the file or at a position the line
+	// index already covers.
+	fwdLineNumber int64
+	bkdLineNumber int64
+	// The continuation lines bkdScanner read since the last line that started
+	// a record, last one first. They are joined to the record of the next line
+	// that starts one.
+	bkdContinuations []continuation
+	// Waits for the input file to be written to when following it.
+	watcher *fileWatcher
+	// A sparse index of where lines start in the input file. It is built in
+	// the background and used to jump to line numbers and to number lines
+	// when seeking.
+	index *reader.LineIndex
+	// A scanner that reads backwards from input line by line.
+	bkdScanner *reader.BackwardsLineScanner
+
+	// How many lines to eagerly preload ahead of the bottom of the screen. If
+	// 0, twice the screen height is preloaded.
+	fwdEager int
+	// How many lines to eagerly preload ahead of the top of the screen. If 0,
+	// twice the screen height is preloaded.
+	bkdEager int
+
+	// Guards continueReads, which setupAsyncReads replaces while the readers
+	// and the application may be calling it through continueAsyncReads.
+	muContinueReads *sync.Mutex
+	// A function that triggers the current async readers to reevaluate how
+	// many lines they need to read in each direction and continue reading if
+	// necessary.
+	continueReads func()
+
+	// The managed list of records loaded by this buffer's scanners.
+	records *bufferRecordList
+
+	// The jq query that is applied to the lines read from the input file, as
+	// it was given.
+	jqQuery string
+	// A compiled jq expression that will be applied to the lines read from the input file.
+	jqExpr *gojq.Code
+	// If true, the records the jq expression excludes are shown dimmed
+	// instead of hidden.
+	dimExcluded bool
+	// Which records are loaded, for an overview of a huge input.
+	sample sampling
+
+	// Rules that style records based on their original contents. They are
+	// evaluated once for every record as it is loaded.
+	highlightRules []highlightRule
+	// Lua scripts that filter and style records, if any were loaded.
+	scripts *scripts
+	// The number of columns between tab stops that tabs in records are
+	// expanded to.
+	tabWidth int
+	// What is done with ANSI escape sequences in records.
+	ansiMode ansiMode
+
+	// How much the loaded records may hold before the ones the readers don't
+	// need are pruned.
+	budget recordBudget
+
+	// The most bytes of a line that are read into a record, or 0 for no
+	// limit. Only the start of longer lines is shown.
+	maxRecordSize int
+	// The delimiter records end with in the input file.
+	delimiter []byte
+	// Returns where the input file starts, if its oldest bytes may be
+	// dropped as it grows, or nil if it starts at 0.
+	inputStart func() int64
+	// The time range the records are restricted to, where a zero time
+	// leaves an end of it open, and the offsets in the input file it starts
+	// and ends at. rangeEnd is -1 while no record after the range was found.
+	since, until         time.Time
+	rangeStart, rangeEnd int64
+	// The pattern of the lines that start a record. Other lines continue the
+	// record before them and are joined to it. If nil, every line is read on
+	// its own.
+	recordStart *regexp.Regexp
+	// If true, a line read from the input file had NUL bytes or bytes that
+	// aren't valid UTF-8, so it may be a binary file.
+	binary atomic.Bool
+
+	// A callback to invoke when an event is received. It will be posted to the
+	// application screen.
+	postEvent func(tcell.Event) error
+	// If true, an event asking the application to render the buffer was posted
+	// and not handled yet.
+	renderPending atomic.Bool
+	// When the application was last notified of the filter failing on a
+	// record, in nanoseconds since the epoch, or 0 if it wasn't.
+	lastFilterError atomic.Int64
+	// If true, the forwards reader reached the end of the input and nothing
+	// was read since.
+	atEOF atomic.Bool
+
+	// A mutex to serialize canceling the current populate process.
+	muCancelPopulate *sync.Mutex
+
+	// A cancel function to stop the current record population process. This
+	// will be called whenever the current async readers should be disposed. For
+	// example, this will be called before seeking and reorienting the buffer,
+	// or on reader errors.
+	cancelPopulate func(err error) <-chan any
+
+	// Counts what the readers did, for the debug server.
+	stats *bufferStats
+	// Counts the records read since the last seek and how many of them the
+	// filter matched, for the status bar.
+	counts *recordCounts
+	// Measures how fast lines are appended to the input while following.
+	ingest ingestRate
+
+	// The rules of the records to alert of when they are appended while
+	// following, how many records they matched, and whether the application
+	// was asked to alert of them and didn't yet.
+	alertRules   []alertRule
+	alerts       atomic.Int64
+	alertPending atomic.Bool
+	// The last record alert rules matched, as it is displayed.
+	lastAlert atomic.Value
+
+	// A logger to use. It discards what is logged unless another is set.
+	logger *logging.Logger
+
+	// An external command lines are piped through before they are parsed, if
+	// one was set.
+	transform atomic.Pointer[lineTransformer]
+
+	// The functions library users registered to be called when things happen
+	// in the buffer.
+	recordHooks hooks[Record]
+	filterHooks hooks[string]
+	followHooks hooks[bool]
+	eofHooks    hooks[int64]
+}
+
+// NewBuffer returns a buffer of the given input file, of a screen of the given
+// size, which follows the end of the file if followMode is true. The records are
+// shown as they are until SetFilter sets a jq expression to filter them with.
+func NewBuffer(width, height int, followMode bool, inputReader *os.File, ctx context.Context) (*Buffer, error) {
+	jqQueryStr := "."
+	jqQuery, err := gojq.Parse(jqQueryStr)
+	if err != nil {
+		return nil, err
+	}
+	jqExpr, err := gojq.Compile(jqQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	buffer := &Buffer{
+		mu:              &sync.Mutex{},
+		ctx:             ctx,
+		width:           width,
+		height:          height,
+		wrap:            true,
+		tabWidth:        defaultTabWidth,
+		maxRecordSize:   defaultMaxRecordSize,
+		delimiter:       []byte{'\n'},
+		rangeEnd:        -1,
+		followMode:      followMode,
+		inputName:       inputReader.Name(),
+		closeInput:      func() {},
+		muContinueReads: &sync.Mutex{},
+		continueReads:   func() {},
+		records:         NewBufferRecordList(lineLayout{width: width, wrap: true}),
+		jqQuery:         jqQueryStr,
+		jqExpr:          jqExpr,
+		postEvent: func(e tcell.Event) error {
+			return nil
+		},
+		muCancelPopulate: &sync.Mutex{},
+		cancelPopulate: func(err error) <-chan any {
+			ch := make(chan any)
+			close(ch)
+			return ch
+		},
+		stats:  &bufferStats{},
+		counts: newRecordCounts(),
+		logger: logging.New(io.Discard, logging.Text, logging.Levels{}).Named("buffer"),
+	}
+
+	// buffer.setupAsyncReads(nil)
+
+	if err := buffer.attachInput(inputReader, false); err != nil {
+		return nil, err
+	}
+
+	// Close the input once the readers are done with it.
+	context.AfterFunc(ctx, func() {
+		buffer.muCancelPopulate.Lock()
+		defer buffer.muCancelPopulate.Unlock()
+		<-buffer.cancelPopulate(ctx.Err())
+
+		buffer.mu.Lock()
+		defer buffer.mu.Unlock()
+		buffer.closeInput()
+		buffer.closeInput = func() {}
+	})
+
+	return buffer, nil
+}
+
+// attachInput points the readers, the line index and the watcher at the given
+// input file, and closes the ones of the previous input. If owned is true, the
+// file itself is closed along with them.
+//
+// This function is not concurrency safe, and the readers must be stopped.
+func (b *Buffer) attachInput(file *os.File, owned bool) error {
+	var closers []io.Closer
+	if owned {
+		closers = append(closers, file)
+	}
+
+	var input io.ReaderAt = file
+	if b.useMmap {
+		mapped, err := reader.MapFile(file)
+		if err != nil {
+			for _, closer := range closers {
+				closer.Close()
+			}
+			return err
+		}
+		closers = append(closers, mapped)
+		input = mapped
+	}
+
+	inputCtx, cancelInput := context.WithCancel(b.ctx)
+	watcher, err := newFileWatcher(inputCtx, file.Name())
+	if err != nil {
+		b.logger.Named("attachInput").Warn("failed to watch input file, polling it instead:", err.Error())
+	}
+	index := reader.NewLineIndex(file, lineIndexInterval)
+	startIndexing := sync.OnceFunc(func() {
+		indexCache := b.indexCache
+		if b.inputStart != nil {
+			// A spooled input is a new file every time.
+			indexCache = ""
+		}
+		go func() {
+			buildLineIndex(inputCtx, index, file, indexCache, b.logger.Named("buildLineIndex"))
+
+			// The records read before the index covered them, like the
+			// last ones of a file followed from its end, are numbered
+			// now, unless the input was replaced meanwhile.
+			b.mu.Lock()
+			numbered := b.index == index && bytes.Equal(b.delimiter, []byte{'\n'}) && b.numberRecords(index)
+			b.mu.Unlock()
+			if numbered {
+				b.requestRender()
+			}
+		}()
+	})
+
+	b.closeInput()
+	b.closeInput = func() {
+		cancelInput()
+		// Mappings are closed before the files they map.
+		for i := len(closers) - 1; i >= 0; i-- {
+			if err := closers[i].Close(); err != nil {
+				b.logger.Named("closeInput").Error("failed to close input:", err.Error())
+			}
+		}
+	}
+
+	b.inputFile = file
+	b.binary.Store(false)
+	// The time range's offsets are of the previous input, so only its times
+	// restrict the records of this one.
+	b.rangeStart, b.rangeEnd = 0, -1
+	b.input = input
+	b.watcher = watcher
+	b.index = index
+	b.startIndexing = startIndexing
+	return nil
+}
+
+// ResizeScreen sets the size of the screen records are shown on. Records that
+// are already loaded are laid out again to the new width, keeping the record at
+// the top of the screen in place, and the readers are restarted to load enough
+// lines for the new height.
+func (b *Buffer) ResizeScreen(width, height int) {
+	b.mu.Lock()
+	b.width = width
+	b.height = height
+	b.mu.Unlock()
+
+	b.layoutRecords()
+
+	// The readers capture the height when they start, so restart them.
+	b.setupAsyncReads(errors.New("screen size changed"))
+}
+
+// SetFollowMode starts or stops following the end of the input file. When
+// following, the screen jumps to the last loaded record and the forwards reader
+// keeps polling the file for new records.
+func (b *Buffer) SetFollowMode(followMode bool) {
+	b.mu.Lock()
+	b.followMode = followMode
+	height := b.height
+	b.mu.Unlock()
+
+	if followMode {
+		b.ingest.reset()
+		b.records.WithLock(func(records *bufferRecordList) any {
+			records.ScrollToBottom(height)
+			return true
+		})
+	}
+
+	// The readers capture the follow mode when they start, so restart them.
+	b.setupAsyncReads(errors.New("follow mode changed"))
+	b.followHooks.call(followMode)
+}
+
+// FollowMode returns true if the buffer is following the end of the input
+// file.
+func (b *Buffer) FollowMode() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.followMode
+}
+
+// SetFollowName sets whether following continues with a new file that
+// replaces the input file at its path, instead of the originally opened file.
+func (b *Buffer) SetFollowName(followName bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.followName = followName
+}
+
+// SetEagerness sets how many lines are preloaded ahead of the bottom and top of
+// the screen. Preloading more makes scrolling smoother on slow inputs at the
+// cost of memory. A value of 0 preloads twice the screen height.
+func (b *Buffer) SetEagerness(fwdEager, bkdEager int) {
+	b.mu.Lock()
+	b.fwdEager = fwdEager
+	b.bkdEager = bkdEager
+	b.mu.Unlock()
+
+	b.setupAsyncReads(errors.New("eagerness settings changed"))
+}
+
+// eagerness returns how many lines to preload ahead of the top and bottom of
+// the screen.
+//
+// This function is not concurrency safe.
+func (b *Buffer) eagerness() (bkdEager, fwdEager int) {
+	bkdEager, fwdEager = b.bkdEager, b.fwdEager
+	if bkdEager == 0 {
+		bkdEager = b.height * 2
+	}
+	if fwdEager == 0 {
+		fwdEager = b.height * 2
+	}
+	return bkdEager, fwdEager
+}
+
+// SetWrap switches between wrapping records to as many lines as needed and
+// truncating them to a single line. Records that are already loaded are laid
+// out again, keeping the record at the top of the screen in place.
+func (b *Buffer) SetWrap(wrap bool) {
+	b.mu.Lock()
+	b.wrap = wrap
+	b.mu.Unlock()
+
+	b.relayout()
+}
+
+// SetWidth sets the width records are wrapped or truncated to. Records that
+// are already loaded are laid out again, keeping the record at the top of the
+// screen in place.
+func (b *Buffer) SetWidth(width int) {
+	b.mu.Lock()
+	b.width = width
+	b.mu.Unlock()
+
+	b.relayout()
+}
+
+// relayout lays out the loaded records again after the buffer's width or wrap
+// mode changed.
+func (b *Buffer) relayout() {
+	b.layoutRecords()
+
+	// The amount of lines loaded around the screen changed, so the readers may
+	// need to load more.
+	b.continueAsyncReads()
+}
+
+// layoutRecords lays out the loaded records with the buffer's current width
+// and wrap mode. In follow mode the screen is kept at the bottom.
+func (b *Buffer) layoutRecords() {
+	b.mu.Lock()
+	layout := lineLayout{width: b.width, wrap: b.wrap}
+	followMode, height := b.followMode, b.height
+	b.mu.Unlock()
+
+	b.records.WithLock(func(records *bufferRecordList) any {
+		records.SetLayout(layout)
+		if followMode {
+			records.ScrollToBottom(height)
+		}
+		return true
+	})
+}
+
+// Wrap returns true if records are wrapped, and false if they are truncated.
+func (b *Buffer) Wrap() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.wrap
+}
+
+// ToggleCollapsed collapses the given record to a single summary line, or
+// expands it back if it is already collapsed.
+func (b *Buffer) ToggleCollapsed(r *record) {
+	b.mu.Lock()
+	followMode, height := b.followMode, b.height
+	b.mu.Unlock()
+
+	b.records.WithLock(func(records *bufferRecordList) any {
+		records.SetCollapsed(r, !r.collapsed)
+		if followMode {
+			records.ScrollToBottom(height)
+		}
+		return true
+	})
+
+	// The amount of lines loaded around the screen changed, so the readers may
+	// need to load more.
+	b.continueAsyncReads()
+}
+
+// SetCollapseRepeats sets whether runs of consecutive records that display the
+// same are collapsed into a single record. The buffer is populated again from
+// the record at the top of the screen, so records that were already collapsed
+// are expanded back.
+func (b *Buffer) SetCollapseRepeats(collapse bool) error {
+	top, _ := b.records.WithLock(func(records *bufferRecordList) any {
+		records.SetCollapseRepeats(collapse)
+		return records.ScreenTopRecord()
+	}).(*record)
+	if top == nil || top.byteOffset < 0 {
+		return nil
+	}
+
+	return b.SeekAndPopulate(top.byteOffset, io.SeekStart)
+}
+
+// CollapseRepeats returns true if runs of consecutive records that display the
+// same are collapsed into a single record.
+func (b *Buffer) CollapseRepeats() bool {
+	return b.records.WithLock(func(records *bufferRecordList) any {
+		return records.collapseRepeats
+	}).(bool)
+}
+
+// SetFilter sets the jq expression that selects records and transforms them to
+// what is displayed. It takes effect for records loaded after the next call to
+// SeekAndPopulate.
+func (b *Buffer) SetFilter(query string) error {
+	parsed, err := gojq.Parse(query)
+	if err != nil {
+		return err
+	}
+	code, err := gojq.Compile(parsed)
+	if err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	b.jqQuery, b.jqExpr = query, code
+	b.mu.Unlock()
+
+	b.filterHooks.call(query)
+	return nil
+}
+
+// SetDimExcluded sets whether the records the filter excludes are shown dimmed
+// instead of hidden. It takes effect for records loaded after the next call to
+// SeekAndPopulate. Records that are shown dimmed aren't saved, searched or
+// counted.
+func (b *Buffer) SetDimExcluded(dim bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.dimExcluded = dim
+}
+
+// DimExcluded returns true if the records the filter excludes are shown dimmed
+// instead of hidden.
+func (b *Buffer) DimExcluded() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.dimExcluded
+}
+
+// SetHighlightRules sets the rules records are styled with. Rules take effect
+// for records loaded after the next call to SeekAndPopulate.
+func (b *Buffer) SetHighlightRules(rules []highlightRule) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.highlightRules = rules
+}
+
+// SetScripts sets the Lua scripts records are filtered and styled with. They
+// take effect for records loaded after the next call to SeekAndPopulate.
+func (b *Buffer) SetScripts(scripts *scripts) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.scripts = scripts
+}
+
+// SetTabWidth sets the number of columns between tab stops that tabs in records
+// are expanded to. It takes effect for records loaded after the next call to
+// SeekAndPopulate.
+func (b *Buffer) SetTabWidth(width int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.tabWidth = width
+}
+
+// SetLogger sets the logger the buffer writes its diagnostics to. The readers
+// use the logger without locking, so it must be set before the buffer is first
+// populated.
+func (b *Buffer) SetLogger(logger *logging.Logger) {
+	b.logger = logger
+}
+
+// SetTransform starts the given shell command to pipe lines through before they
+// are parsed. It answers every line with the line transformed, or with an empty
+// line to drop it, and keeps running until the buffer's context is done. Like
+// the logger, it must be set before the buffer is first populated. If the
+// command fails or stops answering, lines are parsed untransformed instead.
+func (b *Buffer) SetTransform(command string) error {
+	transform, err := newLineTransformer(b.ctx, command, b.logger.Named("transform"))
+	if err != nil {
+		return err
+	}
+	b.transform.Store(transform)
+	return nil
+}
+
+// SetANSIMode sets what is done with ANSI escape sequences in records. It takes
+// effect for records loaded after the next call to SeekAndPopulate.
+func (b *Buffer) SetANSIMode(mode ansiMode) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.ansiMode = mode
+}
+
+// SetMaxRecordSize sets the most bytes of a line that are read into a record,
+// or 0 for no limit. Longer lines are truncated. It takes effect for records
+// loaded after the next call to SeekAndPopulate.
+func (b *Buffer) SetMaxRecordSize(size int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.maxRecordSize = size
+}
+
+// SetDelimiter sets the delimiter records end with in the input file, instead
+// of a newline. It takes effect for records loaded after the next call to
+// SeekAndPopulate.
+func (b *Buffer) SetDelimiter(delim []byte) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.delimiter = delim
+}
+
+// SetInputStart sets the function that returns where the input file starts, for
+// input files whose oldest bytes are dropped as they grow, like a spill file
+// that only keeps a window of its input. Records before the start aren't read.
+func (b *Buffer) SetInputStart(start func() int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.inputStart = start
+}
+
+// SetRecordStart sets the pattern of the lines that start a record, so the
+// lines that don't match it are joined to the record before them, like the
+// lines of a stack trace. An empty pattern reads every line on its own. It takes
+// effect for records loaded after the next call to SeekAndPopulate.
+func (b *Buffer) SetRecordStart(pattern string) error {
+	var recordStart *regexp.Regexp
+	if pattern != "" {
+		var err error
+		if recordStart, err = regexp.Compile(pattern); err != nil {
+			return err
+		}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.recordStart = recordStart
+	return nil
+}
+
+// ReadFullLine reads the whole line a truncated record was read from, as it
+// is in the input file.
+func (b *Buffer) ReadFullLine(r *record) ([]byte, error) {
+	b.mu.Lock()
+	file := b.inputFile
+	b.mu.Unlock()
+
+	line := make([]byte, r.fullLen)
+	if _, err := file.ReadAt(line, r.byteOffset); err != nil {
+		return nil, fmt.Errorf("failed to read line at byte %d: %w", r.byteOffset, err)
+	}
+	return trimBOM(r.byteOffset, line), nil
+}
+
+// SetBudget sets how many lines and bytes the loaded records may hold before
+// the ones far from the screen are pruned. A limit of 0 is unlimited.
+func (b *Buffer) SetBudget(lines, bytes int) {
+	b.mu.Lock()
+	b.budget = recordBudget{lines: lines, bytes: bytes}
+	b.mu.Unlock()
+
+	b.enforceBudget()
+}
+
+// UseMmap makes the buffer read the input file through a memory mapping of it
+// instead of a system call per read, which makes scanning large files
+// backwards much faster. It must be called before the buffer is populated.
+func (b *Buffer) UseMmap() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	mapped, err := reader.MapFile(b.inputFile)
+	if err != nil {
+		return err
+	}
+
+	closeInput := b.closeInput
+	b.closeInput = func() {
+		mapped.Close()
+		closeInput()
+	}
+
+	b.useMmap = true
+	b.input = mapped
+	return nil
+}
+
+func (b *Buffer) SetPostEventFunc(postEvent func(tcell.Event) error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.postEvent = postEvent
+}
+
+// requestRender posts an event asking the application to render the buffer
+// again. Only one request is pending at a time, so inputs that are read fast
+// don't flood the event loop with renders of states that are already stale.
+func (b *Buffer) requestRender() {
+	if !b.renderPending.CompareAndSwap(false, true) {
+		return
+	}
+	if err := b.postEvent(tcell.NewEventInterrupt(nil)); err != nil {
+		b.renderPending.Store(false)
+	}
+}
+
+// clearRenderRequest marks the pending render request as handled. It must be
+// called before rendering, so changes made while rendering request another.
+func (b *Buffer) clearRenderRequest() {
+	b.renderPending.Store(false)
+}
+
+// SeekAndPopulate seeks to the given position and populates the buffer with
+// records. It also starts asynchronous reads to keep the buffer populated as
+// you move around.
+func (b *Buffer) SeekAndPopulate(pos int64, whence int) error {
+	// Hold off other restarts of the readers until the scanners are replaced,
+	// so the populate process canceled here is the one using them.
+	b.muCancelPopulate.Lock()
+	b.mu.Lock()
+
+	<-b.cancelPopulate(errors.New("changing seek position"))
+
+	if err := b.seekAndOrient(pos, whence); err != nil {
+		b.mu.Unlock()
+		b.muCancelPopulate.Unlock()
+		return fmt.Errorf("failed to orient buffer: %w", err)
+	}
+
+	b.records.Clear()
+	b.counts.reset()
+
+	b.mu.Unlock()
+	b.muCancelPopulate.Unlock()
+
+	b.setupAsyncReads(errors.New("changing seek position"))
+
+	return nil
+}
+
+// SeekToLine seeks to the start of the given line, counting from 1, and
+// populates the buffer with records from there. Lines past the part of the
+// file the line index covers are indexed first.
+func (b *Buffer) SeekToLine(line int64) error {
+	offset, err := b.lineOffset(nil, line)
+	if err != nil {
+		return err
+	}
+	return b.SeekAndPopulate(offset, io.SeekStart)
+}
+
+// lineOffset returns the offset of the start of the given line, counting from
+// 1. Lines past the part of the file the line index covers are indexed first,
+// until the given progress's context is done, and ErrNotIndexed is returned
+// for lines past its end.
+func (b *Buffer) lineOffset(p *scanProgress, line int64) (int64, error) {
+	b.mu.Lock()
+	index := b.index
+	b.mu.Unlock()
+
+	offset, err := index.OffsetOfLine(line)
+	if errors.Is(err, reader.ErrNotIndexed) {
+		ctx := b.ctx
+		if p != nil {
+			ctx = p.ctx
+		}
+		if err := index.Build(ctx); err != nil {
+			return 0, fmt.Errorf("failed to index lines: %w", err)
+		}
+		offset, err = index.OffsetOfLine(line)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to find line %d: %w", line, err)
+	}
+	return offset, nil
+}
+
+// indexedSize returns how much of the input the line index covers.
+func (b *Buffer) indexedSize() int64 {
+	b.mu.Lock()
+	index := b.index
+	b.mu.Unlock()
+
+	size, _ := index.Indexed()
+	return size
+}
+
+// SeekToTail populates the buffer with records from the given number of
+// records before the end of the input, like tail -n, or from its start if it
+// has fewer. The input is read backwards for them first.
+func (b *Buffer) SeekToTail(n int) error {
+	offset, err := b.tailOffset(n)
+	if err != nil {
+		return fmt.Errorf("failed to find the last %d records: %w", n, err)
+	}
+	return b.SeekAndPopulate(offset, io.SeekStart)
+}
+
+// tailOffset returns the offset of the record the given number of records
+// before the end of the input, or of its start if it has fewer.
+func (b *Buffer) tailOffset(n int) (int64, error) {
+	b.mu.Lock()
+	input, inputFile := b.input, b.inputFile
+	opts := b.scannerOptions()
+	parseOpts := b.parseOptions()
+	parseOpts.backwards = true
+	recordStart := b.recordStart
+	rangeEnd := b.rangeEnd
+	b.mu.Unlock()
+
+	info, err := inputFile.Stat()
+	if err != nil {
+		return 0, err
+	}
+	end := info.Size()
+	if rangeEnd >= 0 {
+		end = min(end, rangeEnd)
+	}
+	scanner, err := reader.NewBackwardsLineScanner(input, end, opts...)
+	if err != nil {
+		return 0, err
+	}
+	defer scanner.Close()
+
+	found := 0
+	offset := int64(0)
+	for found < n && scanner.Scan() {
+		line := scanner.Bytes()
+		// Only the lines that start records are counted, so the others
+		// are joined to them once they are read forwards.
+		if recordStart != nil && !recordStart.Match(line) {
+			continue
+		}
+		if b.readRecord(scanner.Pos(), line, scanner.LineLen(), scanner.CRLF(), parseOpts) == nil {
+			continue
+		}
+		found++
+		offset = scanner.Pos()
+	}
+	if err := scanner.Err(); err != nil && !errors.Is(err, io.EOF) {
+		return 0, err
+	}
+	if found < n {
+		return 0, nil
+	}
+	return offset, nil
+}
+
+// Scroll scrolls the buffer by the given number of lines. A positive number
+// scrolls down, a negative number scrolls up.
+//
+// Returns the number of lines actually moved. If scrolling down the value will
+// be positive or zero, if scrolling up the value will be negative or zero.
+func (b *Buffer) Scroll(lines int) int {
+	logger := b.logger.Named("Scroll")
+	logger.Debug("scrolling buffer by", lines, "lines")
+
+	if lines == 0 {
+		return 0
+	}
+
+	var linesMoved int
+	b.records.WithLock(func(records *bufferRecordList) any {
+		logger.Debug("current record status: linesAboveScreenTop =", records.linesAboveScreenTop, ", linesBelowScreenTop =", records.linesBelowScreenTop, ", screenTopOffset =", records.screenTopOffset)
+		if lines > 0 {
+			linesMoved = records.ScrollDown(lines)
+		} else {
+			linesMoved = -records.ScrollUp(-lines)
+		}
+		logger.Debug("scrolled buffer by", linesMoved, "lines")
+		logger.Debug("after scrolling record status: linesAboveScreenTop =", records.linesAboveScreenTop, ", linesBelowScreenTop =", records.linesBelowScreenTop, ", screenTopOffset =", records.screenTopOffset)
+		return true
+	})
+
+	b.continueAsyncReads()
+
+	return linesMoved
+}
+
+// BufferStatus is a snapshot of the buffer's state for display purposes.
+type BufferStatus struct {
+	// The byte offset of the record at the top of the screen, or -1 if no
+	// record is loaded.
+	ByteOffset int64
+	// The byte offset of the end of the last record on the screen, or -1 if no
+	// record is loaded.
+	EndByteOffset int64
+	// The size of the input file, or -1 if it could not be determined.
+	FileSize int64
+	// Whether the buffer is following the end of the input file.
+	FollowMode bool
+	// The jq query records are filtered and transformed with.
+	Filter string
+	// The number of records currently loaded.
+	Records int
+	// The number of records read since the last seek, and how many of them
+	// the filter matched.
+	Read, Matched int64
+	// The lines appended to the input in the last second, and the most in
+	// any second, while following.
+	IngestRate, IngestPeak int64
+	// The number of appended records alert rules matched.
+	Alerts int64
+	// Which records are loaded, for an overview of a huge input.
+	Sample sampling
+	// The number of lines of the input, estimated by the part of it the line
+	// index covered so far unless LinesExact is true, or 0 if it isn't known.
+	Lines      int64
+	LinesExact bool
+	// Whether the input file may be a binary file.
+	Binary bool
+}
+
+// Status returns a snapshot of the buffer's state.
+func (b *Buffer) Status() BufferStatus {
+	b.mu.Lock()
+	status := BufferStatus{
+		ByteOffset:    -1,
+		EndByteOffset: -1,
+		FileSize:      -1,
+		FollowMode:    b.followMode,
+		Filter:        b.jqQuery,
+		Sample:        b.sample,
+		Binary:        b.binary.Load(),
+	}
+	status.Read, status.Matched = b.counts.get()
+	status.Alerts = b.alerts.Load()
+	if status.FollowMode {
+		status.IngestRate, status.IngestPeak = b.ingest.get(time.Now())
+	}
+	height := b.height
+	inputFile := b.inputFile
+	index := b.index
+	// The index counts newlines, which only end records by default.
+	countsLines := index != nil && bytes.Equal(b.delimiter, []byte{'\n'})
+	b.mu.Unlock()
+
+	if info, err := inputFile.Stat(); err == nil {
+		status.FileSize = info.Size()
+	}
+	if countsLines {
+		indexedSize, indexedLines := index.Indexed()
+		status.Lines, status.LinesExact = estimateLines(indexedSize, indexedLines, status.FileSize)
+	}
+
+	b.records.WithLock(func(records *bufferRecordList) any {
+		status.Records = records.Len()
+		if r := records.ScreenTopRecord(); r != nil {
+			status.ByteOffset = r.byteOffset
+		}
+		if r := records.ScreenBottomRecord(height); r != nil {
+			status.EndByteOffset = r.byteOffset + int64(r.byteLen)
+		}
+		return true
+	})
+
+	return status
+}
+
+// continueAsyncReads triggers the current async readers to reevaluate how many
+// lines they need to read in each direction and continue reading if necessary.
+func (b *Buffer) continueAsyncReads() {
+	b.muContinueReads.Lock()
+	continueReads := b.continueReads
+	b.muContinueReads.Unlock()
+
+	continueReads()
+}
+
+// setupAsyncReads sets up two separate goroutines to read from our backwards
+// and forwards readers to populate the buffer with records.
+//
+// calls to setupAsyncReads run serially because concurrent execution can lead
+// to concurrent reads on the same readers which can mangle the order of
+// appending/prepending into the records buffer, but the read loop itself is
+// lockless.
+//
+// Calling this function will cancel the current populate process before
+// starting the new one.
+func (b *Buffer) setupAsyncReads(restartReason error) {
+	b.muCancelPopulate.Lock()
+	defer b.muCancelPopulate.Unlock()
+
+	b.stats.readerRestarts.Add(1)
+
+	// When both readers are done and the continue channel has been disposed we
+	// can consider this operation as done.
+	bkdReaderDone := make(chan any)
+	fwdReaderDone := make(chan any)
+	continueMu := &sync.RWMutex{}
+	continueCh := make(chan any)
+	continueDone := false
+	// Closed once continueDone is set. continueCh itself is replaced on every
+	// continue, so it cannot be waited on from here.
+	continueDisposed := make(chan any)
+	doneCh := make(chan any)
+	go func() {
+		<-bkdReaderDone
+		<-fwdReaderDone
+		<-continueDisposed
+		close(doneCh)
+	}()
+
+	// Used to signal the current populate process to abort.
+	innerCtx, innerCancel := context.WithCancelCause(b.ctx)
+
+	// Wrap innerCancel with a function that allows the caller to await the
+	// populate process finishing.
+	cancelPopulate := func(err error) <-chan any {
+		// Generate a short 8 character hex string
+		var buf [4]byte
+		if _, err := rand.Read(buf[:]); err != nil {
+			panic(err)
+		}
+		logger, id := b.logger.Named("cancelPopulate"), fmt.Sprintf("%x", buf[:])
+
+		// log which function called cancelPopulate
+		pc, _, lineNo, ok := runtime.Caller(1)
+		if ok {
+			funcName := runtime.FuncForPC(pc).Name()
+			logger.Debug(id, "called by", fmt.Sprintf("%s:%d", funcName, lineNo))
+		} else {
+			logger.Debug(id, "called by unknown")
+		}
+
+		innerCancel(err)
+		go func() {
+			logger.Debug(id, "acquiring continueMu")
+			continueMu.Lock()
+			logger.Debug(id, "acquired continueMu")
+			if !continueDone {
+				logger.Debug(id, "closing continueCh")
+				close(continueCh)
+				continueDone = true
+				close(continueDisposed)
+			} else {
+				logger.Debug(id, "continueCh already closed")
+			}
+			logger.Debug(id, "releasing continueMu")
+			continueMu.Unlock()
+			logger.Debug(id, "released continueMu")
+		}()
+		return doneCh
+	}
+
+	oldCancelPopulate := b.cancelPopulate
+	b.cancelPopulate = cancelPopulate
+	b.logger.Named("setupAsyncReads").Debug("waiting for old populate process to finish")
+	<-oldCancelPopulate(restartReason)
+	b.logger.Named("setupAsyncReads").Debug("old populate process finished")
+
+	var bkdToRead, fwdToRead int
+	var followMode bool
+
+	continueReads := func() {
+		// Generate a short 8 character hex string
+		var buf [4]byte
+		if _, err := rand.Read(buf[:]); err != nil {
+			panic(err)
+		}
+		logger, id := b.logger.Named("continueAsyncReads"), fmt.Sprintf("%x", buf[:])
+
+		// log which function called cancelPopulate
+		pc, _, lineNo, ok := runtime.Caller(1)
+		if ok {
+			funcName := runtime.FuncForPC(pc).Name()
+			logger.Debug(id, "called by", fmt.Sprintf("%s:%d", funcName, lineNo))
+		} else {
+			logger.Debug(id, "called by unknown")
+		}
+
+		go func() {
+			if innerCtx.Err() != nil {
+				logger.Debug(id, "skipping because innerCtx is canceled")
+				return
+			}
+
+			logger.Debug(id, "acquiring buffer lock")
+			b.mu.Lock()
+			logger.Debug(id, "acquired buffer lock.")
+			logger.Debug(id, "calculating lines to read.")
+			newBkdToRead, newFwdToRead := b.calcLinesToReadUsingRecords(b.records)
+			newFollowMode := b.followMode
+			logger.Debug(id, "calculated lines to read (bkdToRead =", newBkdToRead, ", fwdToRead =", newFwdToRead, ").")
+			logger.Debug(id, "releasing buffer lock.")
+			b.mu.Unlock()
+			logger.Debug(id, "released buffer lock.")
+
+			logger.Debug(id, "acquiring continueMu")
+			continueMu.Lock()
+			logger.Debug(id, "acquired continueMu.")
+			// The readers read these under continueMu too.
+			bkdToRead, fwdToRead, followMode = newBkdToRead, newFwdToRead, newFollowMode
+			if !continueDone {
+				logger.Debug(id, "closing continueCh and opening a new one.")
+				close(continueCh)
+				continueCh = make(chan any)
+			} else {
+				logger.Debug(id, "not closing continueCh because continueDone = true.")
+			}
+			logger.Debug(id, "releasing continueMu.")
+			continueMu.Unlock()
+			logger.Debug(id, "released continueMu.")
+		}()
+	}
+	b.muContinueReads.Lock()
+	b.continueReads = continueReads
+	b.muContinueReads.Unlock()
+
+	// By this point we are guaranteed reader exclusivity, now we need to lock
+	// the buffer itself to get a consistent view of the buffer state.
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// At this point its possible that this operation has already been canceled,
+	// so check for it.
+	if innerCtx.Err() != nil {
+		close(bkdReaderDone)
+		close(fwdReaderDone)
+		return
+	}
+
+	// By this point the operation is not canceled and we have exclusive access
+	// to the buffer. Set up the new readers loop.
+
+	bkdScanner, fwdScanner := b.bkdScanner, b.fwdScanner
+	if bkdScanner == nil || fwdScanner == nil {
+		// The last seek failed, so there is no position to read from until
+		// the next one succeeds.
+		close(bkdReaderDone)
+		close(fwdReaderDone)
+		return
+	}
+	height := b.height
+	budget := b.budget
+	parseOpts := b.parseOptions()
+	parseOpts.dimExcluded = b.dimExcluded
+	parseOpts.sample = b.sample
+	parseOpts.ctx = innerCtx
+	alertRules := b.alertRules
+	recordStart := b.recordStart
+	bkdToRead, fwdToRead = b.calcLinesToReadUsingRecords(b.records)
+	followMode = b.followMode
+	followName := b.followName
+	// The input may be replaced while the readers run, so they check the
+	// one they read for rotation.
+	inputFile, inputName := b.inputFile, b.inputName
+	// Line numbers the readers started without, because the line index
+	// didn't cover their position yet, are looked up in it as they read.
+	index := b.index
+	countLines := bytes.Equal(b.delimiter, []byte{'\n'})
+
+	firstBkdRead := true
+	firstFwdRead := true
+	// The backwards reader starts once the forwards reader first loaded the
+	// records from the position, so they are the ones at the top of the
+	// screen whichever reader would have gotten to its records first.
+	fwdFirstRead := make(chan struct{})
+	fwdFirstReadDone := sync.OnceFunc(func() { close(fwdFirstRead) })
+
+	// The readers wait on the continue channel as it is now. A continue that
+	// happens before they get to waiting then wakes them up instead of being
+	// missed.
+	continueMu.RLock()
+	initialContinueCh := continueCh
+	continueMu.RUnlock()
+
+	b.logger.Named("setupAsyncReads").Info("starting readers loop (bkdToRead =", bkdToRead, ", fwdToRead =", fwdToRead, ")")
+
+	go func() {
+		defer close(bkdReaderDone)
+		logger := b.logger.Named("bkdReadLoop")
+		parseOpts := parseOpts
+		parseOpts.backwards = true
+
+		// Records are inserted in batches to take the records lock and
+		// request renders less often.
+		var batch []*record
+		// If true, records were read before their line numbers were known,
+		// which are filled in once they are.
+		var unnumbered bool
+		flush := func() {
+			if len(batch) == 0 {
+				return
+			}
+
+			overBudget := false
+			b.records.WithLock(func(records *bufferRecordList) any {
+				logger.Debug("prepending", len(batch), "records")
+				records.PrependAll(batch)
+				b.stats.recordsLoaded.Add(int64(len(batch)))
+				logger.Debug("after prepending records status: linesAboveScreenTop =", records.linesAboveScreenTop, ", linesBelowScreenTop =", records.linesBelowScreenTop, ", screenTopOffset =", records.screenTopOffset)
+
+				// If prepending but we don't have a full screen of lines yet,
+				// we should scroll up to try and fit more lines on screen.
+				batchLines := 0
+				for _, r := range batch {
+					batchLines += len(records.LinesOf(r))
+				}
+				_, onScreen, _ := records.CalcScreenLines(height)
+				canScroll := min(height-onScreen, batchLines)
+				if canScroll > 0 {
+					logger.Debug("scrolling up", canScroll, "lines")
+					records.ScrollUp(canScroll)
+					logger.Debug("after scrolling up. linesAboveScreenTop =", records.linesAboveScreenTop, ", linesBelowScreenTop =", records.linesBelowScreenTop, ", screenTopOffset =", records.screenTopOffset)
+					b.continueAsyncReads()
+				}
+
+				overBudget = budget.exceededBy(records) && b.prunable(records)
+				return true
+			})
+			b.exportRecords(batch)
+			batch = nil
+
+			b.requestRender()
+			if overBudget {
+				go b.enforceBudget()
+			}
+		}
+		// The scanner is past the lines in the batch, so it must not be lost
+		// when stopping.
+		defer flush()
+
+		select {
+		case <-fwdFirstRead:
+		case <-innerCtx.Done():
+		}
+
+		myContinueCh := initialContinueCh
+		var myBkdToRead int
+		for {
+			if firstBkdRead {
+				firstBkdRead = false
+			} else {
+				logger.Debug("waiting for continueCh")
+				<-myContinueCh
+				logger.Debug("got continueCh")
+			}
+
+			if innerCtx.Err() != nil {
+				logger.Debug("innerCtx is canceled, stopping")
+				return
+			}
+
+			logger.Debug("acquiring continueMu for reading")
+			continueMu.RLock()
+			logger.Debug("acquired continueMu for reading")
+			myContinueCh = continueCh
+			myBkdToRead = bkdToRead
+			logger.Debug("will try reading", myBkdToRead, "lines")
+			logger.Debug("releasing continueMu for reading")
+			continueMu.RUnlock()
+			logger.Debug("released continueMu for reading")
+
+			for i := 0; i < myBkdToRead; i++ {
+				logger.Debug("loop", i+1, "of", myBkdToRead)
+				if innerCtx.Err() != nil {
+					logger.Debug("innerCtx is canceled, stopping")
+					return
+				}
+
+				logger.Debug("reading line")
+				line, pos, err := bkdScanner.ReadLine()
+				if errors.Is(err, os.ErrClosed) {
+					logger.Info("input file was closed, stopping")
+					return
+				}
+				if err != nil && !errors.Is(err, io.EOF) {
+					logger.Error("failed to read line:", err.Error())
+					b.notify("failed to read the input: "+err.Error(), true)
+					return
+				}
+				logger.Debug("read line:", string(line))
+
+				// When EOF is returned with an empty line it doesnt necessarily
+				// mean that an empty line exists at the start of the file. More
+				// likely it means we didn't read anything, so avoid adding this
+				// line to the buffer.
+				if len(line) == 0 && errors.Is(err, io.EOF) {
+					logger.Info("EOF with empty line, stopping.")
+					return
+				}
+				b.stats.linesRead.Add(1)
+
+				lineNumber := b.bkdLineNumber
+				if lineNumber == 0 && countLines {
+					if lineNumber = lineNumberAt(index, pos); lineNumber > 0 && unnumbered {
+						flush()
+						if b.numberRecords(index) {
+							b.requestRender()
+						}
+						unnumbered = false
+					}
+				}
+				if lineNumber > 0 {
+					b.bkdLineNumber = lineNumber - 1
+				}
+
+				if recordStart != nil && !recordStart.Match(line) && !errors.Is(err, io.EOF) {
+					// The record the line continues is read next.
+					b.bkdContinuations = append(b.bkdContinuations, continuation{line, bkdScanner.LineLen(), bkdScanner.CRLF()})
+					myBkdToRead++
+					continue
+				}
+				continuations := b.bkdContinuations
+				b.bkdContinuations = nil
+				if !parseOpts.sample.keeps(pos, lineNumber) {
+					// The lines the record continues on are dropped
+					// along with it.
+					myBkdToRead++
+					if errors.Is(err, io.EOF) {
+						return
+					}
+					continue
+				}
+
+				r := b.readRecord(pos, line, bkdScanner.LineLen(), bkdScanner.CRLF(), parseOpts)
+				b.counts.count(pos, r != nil && !r.excluded)
+				if r != nil {
+					for j := len(continuations) - 1; j >= 0; j-- {
+						c := continuations[j]
+						r = joinContinuation(r, c.line, c.lineLen, c.crlf, parseOpts)
+					}
+					r.lineNumber = lineNumber
+					unnumbered = unnumbered || (lineNumber == 0 && countLines)
+					batch = append(batch, r)
+					if len(batch) >= readBatchSize {
+						flush()
+					}
+				} else {
+					myBkdToRead++
+				}
+
+				if errors.Is(err, io.EOF) {
+					logger.Info("EOF, stopping")
+					return
+				}
+			}
+			flush()
+		}
+	}()
+
+	go func() {
+		defer close(fwdReaderDone)
+		defer fwdFirstReadDone()
+		logger := b.logger.Named("fwdReadLoop")
+
+		// Records are inserted in batches to take the records lock and
+		// request renders less often.
+		var batch []*record
+		// If true, records were read before their line numbers were known,
+		// which are filled in once they are.
+		var unnumbered bool
+		var batchFollowMode bool
+		flush := func() {
+			if len(batch) == 0 {
+				return
+			}
+
+			overBudget := false
+			b.records.WithLock(func(records *bufferRecordList) any {
+				logger.Debug("appending", len(batch), "records")
+				records.AppendAll(batch)
+				b.stats.recordsLoaded.Add(int64(len(batch)))
+				logger.Debug("after appending records status: linesAboveScreenTop =", records.linesAboveScreenTop, ", linesBelowScreenTop =", records.linesBelowScreenTop, ", screenTopOffset =", records.screenTopOffset)
+
+				if batchFollowMode {
+					logger.Debug("scrolling to bottom")
+					records.ScrollToBottom(height)
+					logger.Debug("after scrolling to bottom. linesAboveScreenTop =", records.linesAboveScreenTop, ", linesBelowScreenTop =", records.linesBelowScreenTop, ", screenTopOffset =", records.screenTopOffset)
+					b.continueAsyncReads()
+				}
+
+				overBudget = budget.exceededBy(records) && b.prunable(records)
+				return true
+			})
+			b.exportRecords(batch)
+			batch = nil
+
+			b.requestRender()
+			if overBudget {
+				go b.enforceBudget()
+			}
+		}
+		// The scanner is past the lines in the batch, so it must not be lost
+		// when stopping.
+		defer flush()
+
+		myContinueCh := initialContinueCh
+		var myFwdToRead int
+		// Where the reader last reached the end of the input, so the EOF
+		// hooks aren't called again when the watcher wakes it up without
+		// anything appended.
+		lastEOF := int64(-1)
+		b.atEOF.Store(false)
+		for {
+			if firstFwdRead {
+				firstFwdRead = false
+			} else {
+				logger.Debug("waiting for continueCh")
+				<-myContinueCh
+				logger.Debug("got continueCh")
+			}
+
+			if innerCtx.Err() != nil {
+				logger.Debug("innerCtx is canceled, stopping")
+				return
+			}
+
+			logger.Debug("acquiring continueMu for reading")
+			continueMu.RLock()
+			logger.Debug("acquired continueMu for reading")
+			myContinueCh = continueCh
+			myFwdToRead = fwdToRead
+			myFollowMode := followMode
+			batchFollowMode = myFollowMode
+			logger.Debug("will try reading", myFwdToRead, "lines")
+			logger.Debug("releasing continueMu for reading")
+			continueMu.RUnlock()
+			logger.Debug("released continueMu for reading")
+
+			for i := 0; i < myFwdToRead || myFollowMode; i++ {
+				logger.Debug("loop", i+1, "of", myFwdToRead)
+				if innerCtx.Err() != nil {
+					logger.Debug("innerCtx is canceled, stopping")
+					return
+				}
+
+				logger.Debug("reading line")
+				if !fwdScanner.Scan() {
+					if err := fwdScanner.Err(); errors.Is(err, os.ErrClosed) {
+						logger.Info("input file was closed, stopping")
+						return
+					} else if err != nil {
+						logger.Error("failed to read line:", err.Error())
+						b.notify("failed to read the input: "+err.Error(), true)
+						return
+					}
+					b.atEOF.Store(true)
+
+					if end := fwdScanner.NextPos(); end != lastEOF {
+						flush()
+						fwdFirstReadDone()
+						lastEOF = end
+						b.eofHooks.call(end)
+					}
+
+					if myFollowMode {
+						// A rotated file won't be written to anymore, so start
+						// over with whatever is at its path now.
+						if reason := inputRotation(inputFile, inputName, fwdScanner.NextPos(), followName); reason != "" {
+							logger.Info("EOF in follow mode and", reason+", reopening it")
+							go b.reopenInput(inputName, reason)
+							return
+						}
+
+						// If EOF, but we're in follow mode, wait for the file to
+						// be written to and try reading it again.
+						flush()
+						logger.Debug("EOF in follow mode, waiting for the file to change")
+						b.watcher.wait(innerCtx)
+						continue
+					} else {
+						// If EOF and we're not in follow mode, stop. we have
+						// all the data we wanted.
+						logger.Info("EOF and not in follow mode, stopping")
+						return
+					}
+				}
+
+				line := fwdScanner.Bytes()
+				logger.Debug("read line:", string(line))
+				b.stats.linesRead.Add(1)
+				if myFollowMode && lastEOF >= 0 {
+					b.ingest.add(time.Now())
+				}
+
+				pos := fwdScanner.Pos()
+				lineLen, crlf := fwdScanner.LineLen(), fwdScanner.CRLF()
+
+				lineNumber := b.fwdLineNumber
+				if lineNumber == 0 && countLines {
+					if lineNumber = lineNumberAt(index, pos); lineNumber > 0 && unnumbered {
+						flush()
+						if b.numberRecords(index) {
+							b.requestRender()
+						}
+						unnumbered = false
+					}
+				}
+				if lineNumber > 0 {
+					b.fwdLineNumber = lineNumber + 1
+				}
+
+				if recordStart != nil && !recordStart.Match(line) {
+					// Join the line to the record before it, wherever it
+					// is. Lines of records that weren't loaded are dropped
+					// along with them.
+					if last := len(batch) - 1; last >= 0 && recordEnd(batch[last]) == pos {
+						batch[last] = joinContinuation(batch[last], line, lineLen, crlf, parseOpts)
+						continue
+					}
+					flush()
+					joined := b.records.WithLock(func(records *bufferRecordList) any {
+						if last := records.Last(); last != nil && recordEnd(last) == pos {
+							records.ReplaceLast(joinContinuation(last, line, lineLen, crlf, parseOpts))
+							return true
+						}
+						return false
+					})
+					if joined.(bool) {
+						b.requestRender()
+					}
+					continue
+				}
+
+				if !parseOpts.sample.keeps(pos, lineNumber) {
+					myFwdToRead++
+					continue
+				}
+
+				r := b.readRecord(pos, line, lineLen, crlf, parseOpts)
+				b.counts.count(pos, r != nil && !r.excluded)
+				if r != nil && myFollowMode && lastEOF >= 0 && alertRules != nil {
+					b.alertRecord(alertRules, r)
+				}
+				if r != nil {
+					r.lineNumber = lineNumber
+					unnumbered = unnumbered || (lineNumber == 0 && countLines)
+					batch = append(batch, r)
+					if len(batch) >= readBatchSize {
+						flush()
+					}
+				} else {
+					myFwdToRead++
+				}
+			}
+			flush()
+			fwdFirstReadDone()
+		}
+	}()
+}
+
+// parseOptions are the settings records are parsed with. They are captured
+// when the readers start, so they stay the same for all records they load.
+type parseOptions struct {
+	// The compiled jq expression that selects and transforms records.
+	filter         *gojq.Code
+	highlightRules []highlightRule
+	scripts        *scripts
+	tabWidth       int
+	ansiMode       ansiMode
+	// The delimiter records end with, which isn't part of their lines.
+	delimiter []byte
+	// The time range records are restricted to. A zero time leaves an end
+	// of it open.
+	since, until time.Time
+	// If true, records the filter excludes are kept and shown dimmed. Only
+	// the readers that load the records shown keep them.
+	dimExcluded bool
+	// Which records the readers that load the records shown load.
+	sample sampling
+
+	// The context lines are transformed in, and the input and options the
+	// lines after them are read from to be transformed in the same batch, in
+	// the direction of the reader.
+	ctx            context.Context
+	input          io.ReaderAt
+	scannerOptions []reader.Option
+	backwards      bool
+}
+
+// parseOptions returns the settings records are currently parsed with.
+//
+// This function is not concurrency safe.
+func (b *Buffer) parseOptions() parseOptions {
+	return parseOptions{
+		filter:         b.jqExpr,
+		highlightRules: b.highlightRules,
+		scripts:        b.scripts,
+		tabWidth:       b.tabWidth,
+		ansiMode:       b.ansiMode,
+		delimiter:      b.delimiter,
+		since:          b.since,
+		until:          b.until,
+		ctx:            b.ctx,
+		input:          b.input,
+		scannerOptions: b.scannerOptions(),
+	}
+}
+
+// readRecord creates the record for a line read from the input file, whose
+// full length is lineLen. If crlf is true, the line ended with \r\n rather than
+// just the delimiter. It returns nil if the line is not a record.
+//
+// Lines that are longer than what was read of them can't be parsed, so their
+// start is shown as it is, regardless of the jq expression.
+func (b *Buffer) readRecord(pos int64, line []byte, lineLen int, crlf bool, opts parseOptions) *record {
+	if !b.binary.Load() && looksBinary(line) {
+		b.logger.Named("readRecord").Info("line at", pos, "looks binary")
+		b.binary.Store(true)
+	}
+
+	truncated := lineLen > len(line)
+	byteLen := lineLen + lineEndLen(opts.delimiter, crlf)
+	line = trimBOM(pos, line)
+
+	if !truncated {
+		r := b.parseLine(pos, line, opts)
+		if r != nil {
+			r.byteLen = byteLen
+		}
+		return r
+	}
+
+	// The line's buffer may be reused by the scanner it was read with.
+	raw := bytes.Clone(trimPartialRune(line))
+	text, ansiSpans := displayText(raw, opts)
+	r := newRecord(pos, text)
+	r.raw = raw
+	r.byteLen = byteLen
+	r.fullLen = lineLen
+	r.ansiSpans = ansiSpans
+	return r
+}
+
+// lineEndLen returns the length of a line's ending, which is the given
+// delimiter, preceded by a carriage return if crlf is true.
+func lineEndLen(delim []byte, crlf bool) int {
+	if crlf {
+		return len(delim) + 1
+	}
+	return len(delim)
+}
+
+// displayText processes a record's text into what is displayed, with the
+// escape sequences and tabs in it handled as the options say. Bytes that would
+// garble the screen are escaped first.
+func displayText(text []byte, opts parseOptions) ([]byte, []styleSpan) {
+	text = escapeBinary(text)
+	text, ansiSpans := processANSI(text, opts.ansiMode)
+	if bytes.IndexByte(text, '\t') >= 0 {
+		expandTabsInSpans(ansiSpans, string(text), opts.tabWidth)
+		text = []byte(ExpandTabs(string(text), opts.tabWidth))
+	}
+	return text, ansiSpans
+}
+
+func (b *Buffer) parseLine(pos int64, line []byte, opts parseOptions) *record {
+	original := line
+	transform := b.transform.Load()
+	if transform != nil {
+		transformed, ok, err := b.transformLine(transform, pos, line, opts)
+		switch {
+		case err != nil && opts.ctx.Err() != nil:
+			// The reader stopped while waiting for the answer.
+			return nil
+		case err != nil:
+			b.disableTransform(transform, err)
+			transform = nil
+		case !ok:
+			return nil
+		default:
+			line = transformed
+		}
+	}
+
+	// Only objects are records. Decoding straight into a map rejects anything
+	// else without boxing it first, except null which leaves the map nil.
+	var parsed map[string]any
+	if err := json.Unmarshal(line, &parsed); err != nil || parsed == nil {
+		return nil
+	}
+	if !opts.inTimeRange(parsed) {
+		return nil
+	}
+	if opts.scripts != nil && !opts.scripts.keep(parsed) {
+		return nil
+	}
+
+	jqIter := opts.filter.Run(parsed)
+	result, ok := jqIter.Next()
+	if !ok {
+		return excludedRecord(pos, original, opts)
+	}
+	if _err, ok := result.(error); ok {
+		b.logger.Named("parseLine").Warn("jq error:", _err.Error())
+		b.notifyFilterError(_err)
+		return excludedRecord(pos, original, opts)
+	}
+
+	// The line's buffer may be reused by the scanner it was read with.
+	raw := bytes.Clone(original)
+
+	// jq passes its input through untouched when it only selects records, so
+	// the line can be displayed as it is instead of marshaled again. The raw
+	// line is kept as it was read, so a transformed one is displayed apart.
+	var newLine, inRaw []byte
+	if resultMap, ok := result.(map[string]any); ok && sameMap(resultMap, parsed) {
+		if transform != nil {
+			newLine = bytes.TrimSpace(line)
+		} else {
+			newLine = bytes.TrimSpace(raw)
+			inRaw = newLine
+		}
+	} else {
+		var err error
+		if newLine, err = json.Marshal(result); err != nil {
+			return nil
+		}
+	}
+	newLine, ansiSpans := displayText(newLine, opts)
+
+	r := newRecord(pos, newLine)
+	r.raw = raw
+	// Escape sequences and tabs are processed into a copy of the line.
+	r.bufInRaw = len(inRaw) > 0 && len(newLine) > 0 && &newLine[0] == &inRaw[0]
+	r.byteLen = len(original) + 1
+	r.parsed = parsed
+	r.level = recordLevel(parsed)
+	r.ansiSpans = ansiSpans
+	r.ruleStyle, r.hasRuleStyle = matchHighlightRules(opts.highlightRules, parsed)
+	if !r.hasRuleStyle && opts.scripts != nil {
+		r.ruleStyle, r.hasRuleStyle = opts.scripts.style(parsed)
+	}
+	return r
+}
+
+// excludedRecord creates the record for a line the filter excluded, which is
+// displayed as it was read. It returns nil unless the options keep such
+// records.
+func excludedRecord(pos int64, line []byte, opts parseOptions) *record {
+	if !opts.dimExcluded {
+		return nil
+	}
+
+	// The line's buffer may be reused by the scanner it was read with.
+	raw := bytes.Clone(line)
+	inRaw := bytes.TrimSpace(raw)
+	text, ansiSpans := displayText(inRaw, opts)
+
+	r := newRecord(pos, text)
+	r.raw = raw
+	r.bufInRaw = len(inRaw) > 0 && len(text) > 0 && &text[0] == &inRaw[0]
+	r.byteLen = len(line) + 1
+	r.ansiSpans = ansiSpans
+	r.excluded = true
+	return r
+}
+
+// sameMap returns true if the given maps are the same map, not just equal ones.
+func sameMap(a, b map[string]any) bool {
+	return reflect.ValueOf(a).UnsafePointer() == reflect.ValueOf(b).UnsafePointer()
+}
+
+// seekAndOrient seeks to a given position and "orients" the buffer. The
+// forwards and backwards scanners are reinstantiated.
+//
+// orientation is done by scanning backwards until an end of line is found or
+// the start of the file is reached. That new position is where the forwards and
+// backwards readers will start reading from.
+//
+// This function is not concurrency safe.
+func (b *Buffer) seekAndOrient(pos int64, whence int) error {
+	if err := b.closeScanners(); err != nil {
+		return err
+	}
+	b.startIndexing()
+
+	switch whence {
+	case io.SeekStart:
+	case io.SeekEnd:
+		info, err := b.inputFile.Stat()
+		if err != nil {
+			return err
+		}
+		pos += info.Size()
+	default:
+		return fmt.Errorf("unsupported whence %d", whence)
+	}
+	if start := b.start(); start != nil {
+		pos = max(pos, start())
+	}
+	if b.rangeEnd >= 0 {
+		pos = min(pos, b.rangeEnd)
+	}
+
+	bkdScanner, err := reader.NewBackwardsLineScanner(b.input, pos, b.scannerOptions()...)
+	if err != nil {
+		return err
+	}
+
+	_, pos, err = bkdScanner.ReadLine()
+	if err == nil && b.recordStart != nil {
+		pos, err = b.orientToRecordStart(bkdScanner, pos)
+	}
+	if err != nil && !errors.Is(err, io.EOF) {
+		if err2 := bkdScanner.Close(); err2 != nil {
+			return errors.Join(err, err2)
+		}
+		return err
+	}
+	b.bkdContinuations = nil
+
+	// Start reading forwards from the position of the record.
+	fwdScanner := reader.NewForwardsLineScanner(b.input, pos, b.scannerOptions()...)
+
+	b.bkdScanner = bkdScanner
+	b.fwdScanner = fwdScanner
+
+	// Line numbers are counted from the start of the file, so they are only
+	// known if the index covers the position. The index counts newlines, so
+	// they aren't known for records with another delimiter either.
+	if lineNumber, err := b.index.LineAtOffset(pos); err == nil && bytes.Equal(b.delimiter, []byte{'\n'}) {
+		b.fwdLineNumber = lineNumber
+	} else {
+		b.fwdLineNumber = 0
+	}
+	b.bkdLineNumber = max(b.fwdLineNumber-1, 0)
+
+	return nil
+}
+
+// orientToRecordStart reads back from the line at pos, which the backwards
+// scanner was just oriented at, to the line that starts the record it is a part
+// of, and returns the position of that line.
+func (b *Buffer) orientToRecordStart(bkdScanner reader.LineScanner, pos int64) (int64, error) {
+	line, err := b.lineAt(pos)
+	if err != nil || line == nil || b.recordStart.Match(line) {
+		return pos, err
+	}
+
+	for i := 0; i < maxOrientLines && bkdScanner.Scan(); i++ {
+		pos = bkdScanner.Pos()
+		if b.recordStart.Match(bkdScanner.Bytes()) {
+			break
+		}
+	}
+	return pos, bkdScanner.Err()
+}
+
+// scannerOptions returns the options the scanners are created with.
+//
+// This function is not concurrency safe.
+func (b *Buffer) scannerOptions() []reader.Option {
+	opts := []reader.Option{
+		reader.WithMaxLineLen(b.maxRecordSize),
+		reader.WithDelimiter(b.delimiter),
+	}
+	if start := b.start(); start != nil {
+		opts = append(opts, reader.WithStart(start))
+	}
+	if b.rangeEnd >= 0 {
+		opts = append(opts, reader.WithEnd(b.rangeEnd))
+	}
+	return opts
+}
+
+// start returns the function that returns where the readers start in the input
+// file, which is where the time range starts unless the input's start was
+// dropped past it, or nil if they start at 0.
+//
+// This function is not concurrency safe.
+func (b *Buffer) start() func() int64 {
+	inputStart, rangeStart := b.inputStart, b.rangeStart
+	switch {
+	case rangeStart == 0:
+		return inputStart
+	case inputStart == nil:
+		return func() int64 { return rangeStart }
+	}
+	return func() int64 { return max(inputStart(), rangeStart) }
+}
+
+// closeScanners closes the scanners. They are cleared first so a failed seek
+// doesn't leave closed ones behind for the next readers to use.
+//
+// This function is not concurrency safe.
+func (b *Buffer) closeScanners() error {
+	bkdScanner, fwdScanner := b.bkdScanner, b.fwdScanner
+	b.bkdScanner, b.fwdScanner = nil, nil
+
+	var errs []error
+	if bkdScanner != nil {
+		errs = append(errs, bkdScanner.Close())
+	}
+	if fwdScanner != nil {
+		errs = append(errs, fwdScanner.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// lineAt reads the line that starts at the given position in the input file.
+// It returns nil if the line isn't complete yet.
+func (b *Buffer) lineAt(pos int64) ([]byte, error) {
+	scanner := reader.NewForwardsLineScanner(b.input, pos, b.scannerOptions()...)
+	defer scanner.Close()
+	if !scanner.Scan() {
+		return nil, scanner.Err()
+	}
+	return scanner.Bytes(), nil
+}
+
+// recordEnd returns the position in the input file right after the given
+// record.
+func recordEnd(r *record) int64 {
+	return r.byteOffset + int64(r.byteLen)
+}
+
+// orientAround points the backwards scanner at the start of the given head
+// record and the forwards scanner at the end of the given tail record, so the
+// readers continue from the ends of the loaded records.
+//
+// This function is not concurrency safe.
+func (b *Buffer) orientAround(head, tail *record) error {
+	if head.byteOffset < 0 || tail.byteOffset < 0 {
+		return errors.New("records have unknown positions")
+	}
+
+	if err := b.closeScanners(); err != nil {
+		return err
+	}
+
+	bkdScanner, err := reader.NewBackwardsLineScanner(b.input, head.byteOffset, b.scannerOptions()...)
+	if err != nil {
+		return err
+	}
+	// The head starts right after a newline, so the first read returns the
+	// empty remainder of the line before it.
+	if _, _, err := bkdScanner.ReadLine(); err != nil && !errors.Is(err, io.EOF) {
+		return errors.Join(err, bkdScanner.Close())
+	}
+
+	fwdScanner := reader.NewForwardsLineScanner(b.input, recordEnd(tail), b.scannerOptions()...)
+
+	b.bkdScanner = bkdScanner
+	b.fwdScanner = fwdScanner
+
+	b.bkdLineNumber = max(head.lineNumber-1, 0)
+	b.bkdContinuations = nil
+	b.fwdLineNumber = 0
+	if tail.lineNumber > 0 {
+		b.fwdLineNumber = tail.lineNumber + 1
+	}
+
+	return nil
+}
+
+// calcLinesToReadUsingRecords calculates how many lines the buffer should read
+// above or below its current positions. This considers the already loaded lines
+// and the buffer's eagerness. Note: this returns number of lines, not records.
+func (b *Buffer) calcLinesToReadUsingRecords(records *bufferRecordList) (bkdLines, fwdLines int) {
+	// Figure out how many lines we have above, below and on the screen.
+	aboveScreen, onScreen, belowScreen := records.CalcScreenLines(b.height)
+
+	return b.calcLinesToReadUsingAvailableLines(aboveScreen, onScreen, belowScreen)
+}
+
+// calcLinesToReadUsingAvailableLines calculates how many lines the buffer
+// should read above or below its current positions. This considers the buffer's
+// eagerness. Note: this returns number of lines, not records.
+func (b *Buffer) calcLinesToReadUsingAvailableLines(aboveScreen, onScreen, belowScreen int) (bkdLines, fwdLines int) {
+	bkdEager, fwdEager := b.eagerness()
+	bkdLines = max(bkdEager-aboveScreen, b.height-onScreen)
+	if b.followMode {
+		// In follow mode it doesnt matter how many lines we return in fwdLines. We will always try reading more.
+		fwdLines = 0
+	} else {
+		// In non-follow mode we are interested in reading ahead of both the top and
+		// bottom of the screen.
+		fwdLines = b.height - onScreen + max(fwdEager-belowScreen, 0)
+	}
+	return
+}
+
+// recordBudget limits how much the loaded records may hold. A limit of 0 is
+// unlimited.
+type recordBudget struct {
+	lines int
+	bytes int
+}
+
+// exceededBy returns true if the given records hold more than the budget
+// allows.
+func (bu recordBudget) exceededBy(records *bufferRecordList) bool {
+	return (bu.lines > 0 && records.linesTotal > bu.lines) || (bu.bytes > 0 && records.size > bu.bytes)
+}
+
+// prunable returns true if prune would remove any records. Only records beyond
+// what the readers want loaded around the screen are pruned, otherwise they
+// would be read again right away.
+func (b *Buffer) prunable(records *bufferRecordList) bool {
+	if records.Len() == 0 {
+		return false
+	}
+
+	hasAbove, hasOnScreen, hasBelow := records.CalcScreenLines(b.height)
+	wantsAbove, wantsBelow := b.calcLinesToReadUsingAvailableLines(hasAbove, hasOnScreen, hasBelow)
+	if records.screenTop != 0 && hasAbove-len(records.LinesOf(records.First())) > wantsAbove {
+		return true
+	}
+	return !b.followMode && records.screenTop != records.Len()-1 && hasBelow-len(records.LinesOf(records.Last())) > wantsBelow
+}
+
+// enforceBudget prunes the records the readers don't need if the loaded records
+// exceed the budget. The readers are restarted from the new ends of the records
+// so they don't leave gaps where records were pruned.
+func (b *Buffer) enforceBudget() {
+	b.muCancelPopulate.Lock()
+	b.mu.Lock()
+
+	over, _ := b.records.WithLock(func(records *bufferRecordList) any {
+		return b.budget.exceededBy(records) && b.prunable(records)
+	}).(bool)
+	if !over {
+		b.mu.Unlock()
+		b.muCancelPopulate.Unlock()
+		return
+	}
+
+	<-b.cancelPopulate(errors.New("pruning records over budget"))
+
+	prunedBack, prunedFwd := b.prune()
+	b.logger.Named("enforceBudget").Info("pruned", prunedBack, "records above and", prunedFwd, "records below the screen")
+
+	var head, tail *record
+	b.records.WithLock(func(records *bufferRecordList) any {
+		head, tail = records.First(), records.Last()
+		return true
+	})
+	err := b.orientAround(head, tail)
+	b.mu.Unlock()
+	b.muCancelPopulate.Unlock()
+
+	if err != nil {
+		b.logger.Named("enforceBudget").Warn("failed to reorient after pruning:", err.Error())
+		return
+	}
+
+	b.setupAsyncReads(errors.New("pruned records over budget"))
+}
+
+// lineNumberAt returns the number of the line at the given offset, or 0 if the
+// line index doesn't cover it yet.
+func lineNumberAt(index *reader.LineIndex, pos int64) int64 {
+	lineNumber, err := index.LineAtOffset(pos)
+	if err != nil {
+		return 0
+	}
+	return lineNumber
+}
+
+// numberRecords fills in the line numbers of the loaded records that were read
+// before the given line index covered them, and returns whether it did.
+func (b *Buffer) numberRecords(index *reader.LineIndex) bool {
+	return b.records.WithLock(func(records *bufferRecordList) any {
+		numbered := false
+		for i := 0; i < records.records.count; i++ {
+			r := records.records.at(i)
+			if r.lineNumber > 0 {
+				continue
+			}
+			lineNumber := lineNumberAt(index, r.byteOffset)
+			if lineNumber == 0 {
+				// The index doesn't cover the rest of the records either.
+				break
+			}
+			numberedRecord := *r
+			numberedRecord.lineNumber = lineNumber
+			records.replace(i, &numberedRecord)
+			numbered = true
+		}
+		return numbered
+	}).(bool)
+}
+
+// inputRotation returns why the given input file can't be followed past the
+// given position anymore, or an empty string if it can. This is the case when
+// it was truncated, or if following by name, replaced by another file at its
+// path.
+func inputRotation(file *os.File, path string, pos int64, followName bool) string {
+	info, err := file.Stat()
+	if err != nil {
+		return ""
+	}
+	if info.Size() < pos {
+		return "input file was truncated"
+	}
+	if !followName {
+		return ""
+	}
+	// While the path is missing, the old file is still followed, as it may
+	// still be written to until a new one takes its place.
+	if pathInfo, err := os.Stat(path); err == nil && !os.SameFile(info, pathInfo) {
+		return "input file was replaced"
+	}
+	return ""
+}
+
+// reopenInput opens the input file's path again and populates the buffer from
+// its start. It is used when the input file was rotated.
+func (b *Buffer) reopenInput(path string, reason string) {
+	file, err := os.Open(path)
+	if err != nil {
+		b.logger.Named("reopenInput").Error("failed to reopen input file:", err.Error())
+		return
+	}
+	if err := b.replaceInput(file, errors.New(reason)); err != nil && b.ctx.Err() == nil {
+		b.logger.Named("reopenInput").Error("failed to reopen input file:", err.Error())
+	}
+}
+
+// Open makes the buffer read the file at the given path instead of its input,
+// and populates it from the file's start. The records are parsed the same way.
+func (b *Buffer) Open(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	if info, err := file.Stat(); err != nil || !info.Mode().IsRegular() {
+		file.Close()
+		return fmt.Errorf("%s is not a regular file", path)
+	}
+
+	b.mu.Lock()
+	b.inputName = path
+	// The start of the previous input, if it was spooled, isn't this
+	// file's.
+	b.inputStart = nil
+	b.mu.Unlock()
+
+	return b.replaceInput(file, errors.New("opening "+path))
+}
+
+// replaceInput makes the buffer read the given file, which it then owns, and
+// populates it from the file's start.
+func (b *Buffer) replaceInput(file *os.File, reason error) error {
+	b.muCancelPopulate.Lock()
+	b.mu.Lock()
+
+	<-b.cancelPopulate(reason)
+
+	if b.ctx.Err() != nil {
+		// The input was already closed for good.
+		b.mu.Unlock()
+		b.muCancelPopulate.Unlock()
+		file.Close()
+		return b.ctx.Err()
+	}
+
+	err := b.attachInput(file, true)
+	if err != nil {
+		file.Close()
+	} else {
+		err = b.seekAndOrient(0, io.SeekStart)
+		b.records.Clear()
+	}
+	b.mu.Unlock()
+	b.muCancelPopulate.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	b.setupAsyncReads(reason)
+	return nil
+}
+
+// prune prunes the buffer to the desired size.
+func (b *Buffer) prune() (int, int) {
+	result := b.records.WithLock(func(records *bufferRecordList) any {
+		prunedBack, prunedFwd := 0, 0
+		hasAbove, hasOnScreen, hasBelow := records.CalcScreenLines(b.height)
+		wantsAbove, wantsBelow := b.calcLinesToReadUsingAvailableLines(hasAbove, hasOnScreen, hasBelow)
+
+		// Nothing to prune in an empty buffer.
+		if records.Len() == 0 {
+			return []int{prunedBack, prunedFwd}
+		}
+
+		// Prune the buffer to the desired size. The screen top record is never
+		// pruned so the first and last records always exist within these loops.
+		recordLines := len(records.LinesOf(records.First()))
+		for records.screenTop != 0 && hasAbove-recordLines > wantsAbove {
+			records.PopFirst()
+			hasAbove -= recordLines
+			recordLines = len(records.LinesOf(records.First()))
+			prunedBack++
+		}
+
+		// Only prune forward buffer if we are not in follow mode.
+		if !b.followMode {
+			recordLines = len(records.LinesOf(records.Last()))
+			for records.screenTop != records.Len()-1 && hasBelow-recordLines > wantsBelow {
+				records.PopLast()
+				hasBelow -= recordLines
+				recordLines = len(records.LinesOf(records.Last()))
+				prunedFwd++
+			}
+		}
+
+		return []int{prunedBack, prunedFwd}
+	})
+
+	// Sanity check.
+	cast, ok := result.([]int)
+	if !ok || len(cast) != 2 {
+		panic("unexpected type")
+	}
+
+	b.stats.prunes.Add(1)
+	b.stats.recordsPruned.Add(int64(cast[0] + cast[1]))
+
+	return cast[0], cast[1]
+}
@@ -0,0 +1,620 @@
+package view
+
+import (
+	"sync"
+)
+
+type bufferRecordList struct {
+	mu *sync.Mutex
+
+	// The records in the list, in the order they appear in the input file.
+	records recordDeque
+
+	// Index of the record that is currently at the top of the screen, or 0 if
+	// the list is empty.
+	screenTop int
+	// A record may span multiple screen lines. This is the offset of the first
+	// line within the record to render at the top of the screen.
+	screenTopOffset int
+
+	// Number of lines above the screen top, not including the screen top itself.
+	linesAboveScreenTop int
+	// Number of lines below the screen top, including the screen top itself.
+	linesBelowScreenTop int
+	// Total number of lines the records in the list span.
+	linesTotal int
+	// Number of bytes the records in the list hold, see record.size.
+	size int
+
+	// The layout records are split into lines with. All the line counters
+	// above are in terms of this layout.
+	layout lineLayout
+
+	// If true, a record added next to one that displays the same is merged
+	// into it instead, see record.repeats.
+	collapseRepeats bool
+
+	// If true, we're within a WithLock call. This will prevent the other
+	// functions from attempting to lock the mutex.
+	withinLock bool
+}
+
+func NewBufferRecordList(layout lineLayout) *bufferRecordList {
+	return &bufferRecordList{
+		mu:     &sync.Mutex{},
+		layout: layout,
+	}
+}
+
+func (l *bufferRecordList) WithLock(f func(*bufferRecordList) any) any {
+	if l.withinLock {
+		return f(l)
+	}
+
+	l.mu.Lock()
+	defer func() {
+		l.mu.Unlock()
+	}()
+
+	// Construct a new instance that will not perform locks.
+	unlockedInst := &bufferRecordList{
+		records:             l.records,
+		screenTop:           l.screenTop,
+		screenTopOffset:     l.screenTopOffset,
+		linesAboveScreenTop: l.linesAboveScreenTop,
+		linesBelowScreenTop: l.linesBelowScreenTop,
+		linesTotal:          l.linesTotal,
+		size:                l.size,
+		layout:              l.layout,
+		collapseRepeats:     l.collapseRepeats,
+		withinLock:          true,
+	}
+
+	result := f(unlockedInst)
+
+	// Assign back to the original instance.
+	l.records = unlockedInst.records
+	l.screenTop = unlockedInst.screenTop
+	l.screenTopOffset = unlockedInst.screenTopOffset
+	l.linesAboveScreenTop = unlockedInst.linesAboveScreenTop
+	l.linesBelowScreenTop = unlockedInst.linesBelowScreenTop
+	l.linesTotal = unlockedInst.linesTotal
+	l.size = unlockedInst.size
+	l.layout = unlockedInst.layout
+	l.collapseRepeats = unlockedInst.collapseRepeats
+
+	return result
+}
+
+// Append adds a record to the end of the list.
+func (l *bufferRecordList) Append(r *record) {
+	if !l.withinLock {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+	}
+
+	if last := l.records.count - 1; l.collapseRepeats && last >= 0 && displaysSame(l.records.at(last), r) {
+		l.replace(last, mergeRepeat(l.records.at(last), r))
+		return
+	}
+
+	numLines := len(r.Lines(l.layout))
+	l.records.pushBack(r, numLines)
+	if l.records.count == 1 {
+		l.screenTop = 0
+		l.screenTopOffset = 0
+		l.linesAboveScreenTop = 0
+		l.linesBelowScreenTop = numLines
+	} else {
+		l.linesBelowScreenTop += numLines
+	}
+	l.linesTotal += numLines
+	l.size += r.size()
+}
+
+// Prepend adds a record to the start of the list.
+func (l *bufferRecordList) Prepend(r *record) {
+	if !l.withinLock {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+	}
+
+	if l.collapseRepeats && l.records.count > 0 && displaysSame(l.records.at(0), r) {
+		l.replace(0, mergeRepeat(r, l.records.at(0)))
+		return
+	}
+
+	numLines := len(r.Lines(l.layout))
+	l.records.pushFront(r, numLines)
+	if l.records.count == 1 {
+		l.screenTop = 0
+		l.screenTopOffset = 0
+		l.linesAboveScreenTop = 0
+		l.linesBelowScreenTop = numLines
+	} else {
+		// The screen top record moved one index further from the start.
+		l.screenTop++
+		l.linesAboveScreenTop += numLines
+	}
+	l.linesTotal += numLines
+	l.size += r.size()
+}
+
+// AppendAll adds records to the end of the list, in order, under a single
+// lock.
+func (l *bufferRecordList) AppendAll(rs []*record) {
+	l.WithLock(func(records *bufferRecordList) any {
+		for _, r := range rs {
+			records.Append(r)
+		}
+		return true
+	})
+}
+
+// PrependAll adds records to the start of the list under a single lock. They
+// are prepended in order, so the last one ends up first, which is the order
+// they are read in when reading backwards.
+func (l *bufferRecordList) PrependAll(rs []*record) {
+	l.WithLock(func(records *bufferRecordList) any {
+		for _, r := range rs {
+			records.Prepend(r)
+		}
+		return true
+	})
+}
+
+// PopFirst removes the first record from the list and returns it.
+//
+// If the screen top is the same as the record being removed, the screen top is
+// moved to the next record and the screen top offset is reset to 0.
+func (l *bufferRecordList) PopFirst() *record {
+	if !l.withinLock {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+	}
+
+	if l.records.count == 0 {
+		return nil
+	}
+
+	r, numLines := l.records.popFront()
+	if l.records.count == 0 {
+		l.screenTop = 0
+		l.screenTopOffset = 0
+		l.linesAboveScreenTop = 0
+		l.linesBelowScreenTop = 0
+	} else if l.screenTop == 0 {
+		// The lines of the first record from the screen top offset onwards
+		// were counted as below the screen top, and now nothing is above it.
+		// The next record is now the first one, so the index stays 0.
+		l.linesBelowScreenTop -= numLines - l.screenTopOffset
+		l.linesAboveScreenTop = 0
+		l.screenTopOffset = 0
+	} else {
+		l.screenTop--
+		l.linesAboveScreenTop -= numLines
+	}
+
+	l.linesTotal -= numLines
+	l.size -= r.size()
+
+	return r
+}
+
+// PopLast removes the last record from the list and returns it.
+//
+// If the screen top is the same as the record being removed, the screen top is
+// moved to the previous record and the screen top offset is reset to 0.
+func (l *bufferRecordList) PopLast() *record {
+	if !l.withinLock {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+	}
+
+	if l.records.count == 0 {
+		return nil
+	}
+
+	r, numLines := l.records.popBack()
+	if l.records.count == 0 {
+		l.screenTop = 0
+		l.screenTopOffset = 0
+		l.linesAboveScreenTop = 0
+		l.linesBelowScreenTop = 0
+	} else if l.screenTop == l.records.count {
+		// The screen top moves to the first line of the previous record, so
+		// all of its lines are now below the screen top.
+		prevLines := l.records.linesAt(l.records.count - 1)
+		l.linesAboveScreenTop -= l.screenTopOffset + prevLines
+		l.linesBelowScreenTop = prevLines
+		l.screenTop = l.records.count - 1
+		l.screenTopOffset = 0
+	} else {
+		l.linesBelowScreenTop -= numLines
+	}
+
+	l.linesTotal -= numLines
+	l.size -= r.size()
+
+	return r
+}
+
+// Clear clears all the records from this list and resets the screen top and
+// screen top offset.
+func (l *bufferRecordList) Clear() {
+	if !l.withinLock {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+	}
+
+	l.records.clear()
+	l.screenTop = 0
+	l.screenTopOffset = 0
+	l.linesAboveScreenTop = 0
+	l.linesBelowScreenTop = 0
+	l.linesTotal = 0
+	l.size = 0
+}
+
+// Len returns the number of records in the list.
+func (l *bufferRecordList) Len() int {
+	if !l.withinLock {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+	}
+
+	return l.records.count
+}
+
+// Size returns the number of bytes the records in the list hold.
+func (l *bufferRecordList) Size() int {
+	if !l.withinLock {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+	}
+
+	return l.size
+}
+
+// RecordAt returns the record at the given index, counting from the first
+// record in the list, or nil if there is no such record.
+func (l *bufferRecordList) RecordAt(i int) *record {
+	if !l.withinLock {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+	}
+
+	if i < 0 || i >= l.records.count {
+		return nil
+	}
+	return l.records.at(i)
+}
+
+// First returns the first record in the list, or nil if the list is empty.
+func (l *bufferRecordList) First() *record {
+	return l.RecordAt(0)
+}
+
+// Last returns the last record in the list, or nil if the list is empty.
+func (l *bufferRecordList) Last() *record {
+	if !l.withinLock {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+	}
+
+	if l.records.count == 0 {
+		return nil
+	}
+	return l.records.at(l.records.count - 1)
+}
+
+// ScreenTopRecord returns the record at the top of the screen, or nil if the
+// list is empty.
+func (l *bufferRecordList) ScreenTopRecord() *record {
+	if !l.withinLock {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+	}
+
+	if l.records.count == 0 {
+		return nil
+	}
+	return l.records.at(l.screenTop)
+}
+
+// ScreenBottomRecord returns the record the last line on the screen belongs to,
+// given the screen's height, or nil if the list is empty.
+func (l *bufferRecordList) ScreenBottomRecord(screenHeight int) *record {
+	if !l.withinLock {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+	}
+
+	if l.records.count == 0 {
+		return nil
+	}
+
+	// The screen always shows at least the screen top line, and ends early if
+	// the records run out.
+	line := min(l.linesAboveScreenTop+max(screenHeight, 1)-1, l.linesTotal-1)
+	i, _ := l.records.find(line)
+	return l.records.at(i)
+}
+
+// RecordAtScreenLine returns the record the given line of the screen belongs
+// to, counting from the top of the screen, or nil if there is no such line.
+func (l *bufferRecordList) RecordAtScreenLine(line int) *record {
+	if !l.withinLock {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+	}
+
+	if line < 0 || l.linesAboveScreenTop+line >= l.linesTotal {
+		return nil
+	}
+
+	i, _ := l.records.find(l.linesAboveScreenTop + line)
+	return l.records.at(i)
+}
+
+// LinesOf returns the lines the given record spans in the list's layout.
+func (l *bufferRecordList) LinesOf(r *record) []string {
+	if !l.withinLock {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+	}
+
+	return r.Lines(l.layout)
+}
+
+// SetLayout changes the layout records are split into lines with, and
+// recalculates the line counters accordingly.
+//
+// The screen top stays on the same record. If the record now spans fewer lines
+// than the screen top offset, the offset moves to its last line.
+func (l *bufferRecordList) SetLayout(layout lineLayout) {
+	if !l.withinLock {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+	}
+
+	l.layout = layout
+	l.records.setAllLines(func(r *record) int {
+		return len(r.Lines(l.layout))
+	})
+	l.linesTotal = l.records.linesBefore(l.records.count)
+	l.linesAboveScreenTop = 0
+	if l.records.count > 0 {
+		l.screenTopOffset = min(l.screenTopOffset, l.records.linesAt(l.screenTop)-1)
+		l.linesAboveScreenTop = l.records.linesBefore(l.screenTop) + l.screenTopOffset
+	}
+	l.linesBelowScreenTop = l.linesTotal - l.linesAboveScreenTop
+}
+
+// SetCollapsed collapses or expands the given record, and adjusts the line
+// counters to its new number of lines.
+//
+// If the record is the screen top and now spans fewer lines than the screen top
+// offset, the offset moves to its last line.
+func (l *bufferRecordList) SetCollapsed(r *record, collapsed bool) {
+	if !l.withinLock {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+	}
+
+	// Find the record. Records are collapsed one at a time by the user, so a
+	// linear search is fine.
+	index := -1
+	for i := 0; i < l.records.count; i++ {
+		if l.records.at(i) == r {
+			index = i
+			break
+		}
+	}
+
+	before := len(r.Lines(l.layout))
+	r.collapsed = collapsed
+	if index == -1 {
+		return
+	}
+	l.resized(index, before, len(r.Lines(l.layout)))
+}
+
+// ReplaceLast replaces the last record with another one, such as a copy of it
+// with more lines joined to it. The list must not be empty.
+func (l *bufferRecordList) ReplaceLast(r *record) {
+	if !l.withinLock {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+	}
+
+	l.replace(l.records.count-1, r)
+}
+
+// SetCollapseRepeats sets whether records added next to one that displays the
+// same are merged into it. Records that are already in the list are left as
+// they are.
+func (l *bufferRecordList) SetCollapseRepeats(collapse bool) {
+	if !l.withinLock {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+	}
+
+	l.collapseRepeats = collapse
+}
+
+// replace replaces the record at the given index with another one, and
+// adjusts the counters to its size.
+func (l *bufferRecordList) replace(index int, r *record) {
+	old := l.records.at(index)
+	l.records.set(index, r)
+	l.size += r.size() - old.size()
+	l.resized(index, l.records.linesAt(index), len(r.Lines(l.layout)))
+}
+
+// resized adjusts the line counters after the record at the given index went
+// from spanning before lines to after lines.
+//
+// If the record is the screen top and now spans fewer lines than the screen top
+// offset, the offset moves to its last line.
+func (l *bufferRecordList) resized(index int, before, after int) {
+	l.records.setLines(index, after)
+
+	l.linesTotal += after - before
+	switch {
+	case index == l.screenTop:
+		newOffset := min(l.screenTopOffset, after-1)
+		l.linesAboveScreenTop -= l.screenTopOffset - newOffset
+		l.screenTopOffset = newOffset
+		l.linesBelowScreenTop = l.linesTotal - l.linesAboveScreenTop
+	case index < l.screenTop:
+		l.linesAboveScreenTop += after - before
+	default:
+		l.linesBelowScreenTop += after - before
+	}
+}
+
+// moveScreenTop moves the screen top to the given line, counting from the
+// first line of the list. The line must be one of the lines the records span.
+func (l *bufferRecordList) moveScreenTop(line int) {
+	l.screenTop, l.screenTopOffset = l.records.find(line)
+	l.linesAboveScreenTop = line
+	l.linesBelowScreenTop = l.linesTotal - line
+}
+
+// ScrollUp attempts to move the screen top up by the given number of lines.
+//
+// Returns the number of lines actually moved.
+func (l *bufferRecordList) ScrollUp(lines int) int {
+	if !l.withinLock {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+	}
+
+	if l.records.count == 0 {
+		return 0
+	}
+
+	line := max(l.linesAboveScreenTop-lines, 0)
+	linesMoved := l.linesAboveScreenTop - line
+	l.moveScreenTop(line)
+	return linesMoved
+}
+
+// ScrollDown attempts to move the screen top down by the given number of lines.
+//
+// Returns the number of lines actually moved.
+func (l *bufferRecordList) ScrollDown(lines int) int {
+	if !l.withinLock {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+	}
+
+	if l.records.count == 0 {
+		return 0
+	}
+
+	line := min(l.linesAboveScreenTop+lines, l.linesTotal-1)
+	linesMoved := line - l.linesAboveScreenTop
+	l.moveScreenTop(line)
+	return linesMoved
+}
+
+// ScrollToBottom attempts to move the screen top to the bottom of the list
+// leaving the given height of lines on the screen.
+func (l *bufferRecordList) ScrollToBottom(height int) {
+	if !l.withinLock {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+	}
+
+	if l.records.count == 0 {
+		return
+	}
+
+	// The last line always stays on the screen.
+	l.moveScreenTop(max(l.linesTotal-max(height, 1), 0))
+}
+
+// CalcScreenLines calculates how many of the record's lines are above, on, and
+// below the screen, given the screen's height.
+//
+// If the records list is empty, this function returns 0 for all three values.
+func (l *bufferRecordList) CalcScreenLines(screenHeight int) (aboveScreen, onScreen, belowScreen int) {
+	if !l.withinLock {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+	}
+
+	aboveScreen = l.linesAboveScreenTop
+	if l.linesBelowScreenTop <= screenHeight {
+		onScreen = l.linesBelowScreenTop
+		belowScreen = 0
+	} else {
+		onScreen = screenHeight
+		belowScreen = l.linesBelowScreenTop - screenHeight
+	}
+	return
+}
+
+// renderLine is a single screen line of a record.
+type renderLine struct {
+	// The text of the line.
+	text string
+	// The record the line belongs to.
+	record *record
+	// The byte offset of the line within the record's buffer.
+	offset int
+	// A marker displayed after the text, such as the number of lines hidden
+	// in a collapsed record.
+	marker string
+	// If true, this is the first line of the record.
+	first bool
+}
+
+// GetRenderLines returns the lines to render on the screen starting from screen
+// top and screen top offset, along with the records they belong to.
+func (l *bufferRecordList) GetRenderLines(lineCount int) []renderLine {
+	if !l.withinLock {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+	}
+
+	result := make([]renderLine, 0)
+
+	offset := l.screenTopOffset
+	for index := l.screenTop; index < l.records.count && lineCount > 0; index++ {
+		r := l.records.at(index)
+		laidOut := r.layOut(l.layout)
+		for i := offset; i < len(laidOut.lines) && lineCount > 0; i++ {
+			text := laidOut.lines[i]
+			markerStart := len(text)
+			if i == len(laidOut.lines)-1 {
+				markerStart -= laidOut.markerLen
+			}
+			result = append(result, renderLine{
+				text:   text[:markerStart],
+				record: r,
+				offset: laidOut.offsets[i],
+				marker: text[markerStart:],
+				first:  i == 0,
+			})
+			lineCount--
+		}
+		offset = 0
+	}
+
+	return result
+}
+
+// GetLinesToRender returns the lines to render on the screen starting from screen top and screen top offset.
+func (l *bufferRecordList) GetLinesToRender(lineCount int) []string {
+	renderLines := l.GetRenderLines(lineCount)
+
+	result := make([]string, len(renderLines))
+	for i, line := range renderLines {
+		result[i] = line.text + line.marker
+	}
+
+	return result
+}
@@ -0,0 +1,131 @@
+package view
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// FuzzBufferRecordList_Operations applies a random sequence of operations to a
+// record list and checks its invariants after each one.
+func FuzzBufferRecordList_Operations(f *testing.F) {
+	f.Add([]byte{0, 1, 2, 3, 4, 5, 6, 7})
+	f.Add([]byte{1, 1, 1, 2, 2, 2, 2, 2, 5, 3, 3, 4})
+	f.Add([]byte{0, 0, 6, 6, 6, 2, 2, 3, 3, 3, 3})
+	f.Add([]byte{7, 5, 5, 5, 4, 4, 4, 4, 0, 6})
+	f.Add([]byte{0, 0, 1, 1, 5, 5, 8, 4, 8, 17, 3, 2})
+	f.Add([]byte{0, 0, 0, 1, 5, 5, 9, 19, 29, 4, 9, 39, 3, 2})
+
+	f.Fuzz(func(t *testing.T, ops []byte) {
+		l := NewBufferRecordList(lineLayout{width: 2, wrap: true})
+		for i, op := range ops {
+			// The upper bits of each op are used as its argument.
+			arg := int(op>>3) + 1
+			lines := strings.Repeat("x ", arg)
+
+			switch op % 11 {
+			case 0:
+				l.Append(newRecord(-1, []byte(lines)))
+			case 1:
+				l.Prepend(newRecord(-1, []byte(lines)))
+			case 2:
+				l.PopFirst()
+			case 3:
+				l.PopLast()
+			case 4:
+				l.ScrollUp(arg)
+			case 5:
+				l.ScrollDown(arg)
+			case 6:
+				l.ScrollToBottom(arg)
+			case 7:
+				l.Clear()
+			case 8:
+				l.SetLayout(lineLayout{width: arg, wrap: !l.layout.wrap})
+			case 9:
+				// Toggle the head, screen top or tail record.
+				if r := []*record{l.First(), l.ScreenTopRecord(), l.Last()}[arg%3]; r != nil {
+					l.SetCollapsed(r, !r.collapsed)
+				}
+			case 10:
+				l.SetCollapseRepeats(!l.collapseRepeats)
+			}
+
+			t.Logf("op %d: %d(%d)", i, op%11, arg)
+			assertRecordListInvariants(t, l)
+		}
+	})
+}
+
+func TestBufferRecordList_SetLayoutKeepsScreenTopRecord(t *testing.T) {
+	l := NewBufferRecordList(lineLayout{width: 2, wrap: true})
+	l.Append(newRecord(-1, []byte("a a a")))
+	l.Append(newRecord(-1, []byte("b b b")))
+	l.Append(newRecord(-1, []byte("c c c")))
+
+	l.ScrollDown(4)
+	assert.EqualValues(t, []string{"b ", "b"}, l.GetLinesToRender(2))
+
+	l.SetLayout(lineLayout{width: 2, wrap: false})
+	assertRecordListInvariants(t, l)
+	assert.EqualValues(t, []string{"b ", "c "}, l.GetLinesToRender(2))
+	assert.EqualValues(t, 1, l.linesAboveScreenTop)
+	assert.EqualValues(t, 3, l.linesTotal)
+
+	l.SetLayout(lineLayout{width: 2, wrap: true})
+	assertRecordListInvariants(t, l)
+	assert.EqualValues(t, []string{"b ", "b "}, l.GetLinesToRender(2))
+}
+
+func TestBufferRecordList_SetCollapsedAdjustsLines(t *testing.T) {
+	l := NewBufferRecordList(lineLayout{width: 20, wrap: true})
+	l.Append(newRecord(-1, []byte("a a a a a a a a a a a a a a a a")))
+	l.Append(newRecord(-1, []byte("b")))
+
+	assert.EqualValues(t, 3, l.linesTotal)
+
+	r := l.First()
+	l.SetCollapsed(r, true)
+	assertRecordListInvariants(t, l)
+	assert.EqualValues(t, 2, l.linesTotal)
+	assert.EqualValues(t, []string{"a a a a a [+1 lines]", "b"}, l.GetLinesToRender(3))
+
+	l.SetCollapsed(r, false)
+	assertRecordListInvariants(t, l)
+	assert.EqualValues(t, 3, l.linesTotal)
+}
+
+func TestBufferRecordList_BatchInsertionKeepsReadOrder(t *testing.T) {
+	l := NewBufferRecordList(lineLayout{width: 10, wrap: true})
+	l.AppendAll([]*record{newRecord(-1, []byte("c")), newRecord(-1, []byte("d"))})
+	assertRecordListInvariants(t, l)
+
+	// Reading backwards finds the closest record first.
+	l.PrependAll([]*record{newRecord(-1, []byte("b")), newRecord(-1, []byte("a"))})
+	assertRecordListInvariants(t, l)
+
+	l.ScrollToBottom(4)
+	assert.EqualValues(t, []string{"a", "b", "c", "d"}, l.GetLinesToRender(4))
+	assert.EqualValues(t, 4, l.Len())
+}
+
+func TestBufferRecordList_CollapsesRepeats(t *testing.T) {
+	l := NewBufferRecordList(lineLayout{width: 20, wrap: true})
+	l.SetCollapseRepeats(true)
+	l.Append(&record{byteOffset: 10, byteLen: 5, buf: []byte("b")})
+	l.Append(&record{byteOffset: 15, byteLen: 5, buf: []byte("b")})
+	l.Prepend(&record{byteOffset: 5, byteLen: 5, buf: []byte("b")})
+	l.Prepend(&record{byteOffset: 0, byteLen: 5, buf: []byte("a")})
+	l.Append(&record{byteOffset: 20, byteLen: 5, buf: []byte("c")})
+	assertRecordListInvariants(t, l)
+
+	assert.EqualValues(t, 3, l.Len())
+	l.ScrollUp(1)
+	assert.EqualValues(t, []string{"a", "b ×3 repeated", "c"}, l.GetLinesToRender(3))
+
+	// The merged record spans all of its repeats in the input file.
+	merged := l.RecordAt(1)
+	assert.EqualValues(t, 5, merged.byteOffset)
+	assert.EqualValues(t, 15, merged.byteLen)
+}
@@ -0,0 +1,724 @@
+package view
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/itchyny/gojq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestThis(t *testing.T) {
+	file, _ := createTestFile(t, "0123456789abcdef\nghijklmnopqrstuv\nwxyz\n")
+
+	buffer, err := NewBuffer(10, 10, false, file, context.Background())
+	assert.NoError(t, err)
+
+	// buffer.SetEagerness(10, 10)
+	err = buffer.SeekAndPopulate(17, io.SeekStart)
+	assert.NoError(t, err)
+
+	<-time.After(20 * time.Millisecond)
+
+	lines := buffer.records.GetLinesToRender(10)
+	assert.EqualValues(t, []string{"hello", "hi"}, lines)
+}
+
+func TestBuffer_StatusReportsScreenTopOffset(t *testing.T) {
+	line := `{"time":1700000000000,"name":"Pelecard","msg":"hi"}` + "\n"
+	file, _ := createTestFile(t, line+line+line)
+
+	buffer, err := NewBuffer(80, 10, false, file, context.Background())
+	assert.NoError(t, err)
+
+	err = buffer.SeekAndPopulate(0, io.SeekStart)
+	assert.NoError(t, err)
+
+	<-time.After(20 * time.Millisecond)
+
+	buffer.Scroll(2)
+	status := buffer.Status()
+	assert.EqualValues(t, 3, status.Records)
+	assert.EqualValues(t, 2*len(line), status.ByteOffset)
+	assert.EqualValues(t, 3*len(line), status.FileSize)
+}
+
+func TestBuffer_SetFollowModeFollowsNewRecords(t *testing.T) {
+	line := `{"time":1700000000000,"name":"Pelecard","msg":"hi"}` + "\n"
+	file, _ := createTestFile(t, line+line+line)
+
+	buffer, err := NewBuffer(80, 1, false, file, context.Background())
+	assert.NoError(t, err)
+
+	// While following, the forwards reader reaches the end of the input once
+	// it loaded the records before it.
+	eof := make(chan int64, 16)
+	buffer.OnEOF(func(size int64) { eof <- size })
+	waitForEOF := func(size int) {
+		t.Helper()
+		timeout := time.After(10 * time.Second)
+		for {
+			select {
+			case end := <-eof:
+				if end == int64(size) {
+					return
+				}
+			case <-timeout:
+				t.Fatalf("the forwards reader didn't reach offset %d", size)
+			}
+		}
+	}
+
+	err = buffer.SeekAndPopulate(0, io.SeekStart)
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool { return buffer.Status().Records == 3 }, 10*time.Second, 5*time.Millisecond)
+
+	buffer.SetFollowMode(true)
+
+	status := buffer.Status()
+	assert.True(t, status.FollowMode)
+	assert.EqualValues(t, 2*len(line), status.ByteOffset)
+
+	// The forwards reader polls the end of the file about once a second.
+	appendToTestFile(t, file, line)
+	waitForEOF(4 * len(line))
+
+	status = buffer.Status()
+	assert.EqualValues(t, 4, status.Records)
+	assert.EqualValues(t, 3*len(line), status.ByteOffset)
+
+	buffer.SetFollowMode(false)
+	assert.False(t, buffer.FollowMode())
+}
+
+func TestBuffer_ResizeScreenKeepsScreenTopRecord(t *testing.T) {
+	line := `{"time":1700000000000,"name":"Pelecard","msg":"hello there"}` + "\n"
+	file, _ := createTestFile(t, strings.Repeat(line, 20))
+
+	buffer, err := NewBuffer(80, 2, false, file, context.Background())
+	assert.NoError(t, err)
+
+	err = buffer.SeekAndPopulate(0, io.SeekStart)
+	assert.NoError(t, err)
+
+	<-time.After(20 * time.Millisecond)
+
+	buffer.Scroll(3)
+	assert.EqualValues(t, 3*len(line), buffer.Status().ByteOffset)
+
+	buffer.ResizeScreen(20, 10)
+	<-time.After(20 * time.Millisecond)
+
+	assert.EqualValues(t, 3*len(line), buffer.Status().ByteOffset)
+	buffer.records.WithLock(func(records *bufferRecordList) any {
+		assert.Greater(t, len(records.LinesOf(records.ScreenTopRecord())), 1)
+		_, onScreen, _ := records.CalcScreenLines(10)
+		assert.EqualValues(t, 10, onScreen)
+		assertRecordListInvariants(t, records)
+		return true
+	})
+}
+
+func TestBuffer_SetEagernessPreloadsMoreLines(t *testing.T) {
+	line := `{"time":1700000000000,"name":"Pelecard","msg":"hi"}` + "\n"
+	file, _ := createTestFile(t, strings.Repeat(line, 50))
+
+	buffer, err := NewBuffer(80, 2, false, file, context.Background())
+	assert.NoError(t, err)
+
+	err = buffer.SeekAndPopulate(0, io.SeekStart)
+	assert.NoError(t, err)
+
+	assert.Eventually(t, func() bool { return buffer.Status().Records == 6 }, time.Second, 5*time.Millisecond)
+
+	buffer.SetEagerness(20, 0)
+	assert.Eventually(t, func() bool { return buffer.Status().Records == 22 }, time.Second, 5*time.Millisecond)
+}
+
+func TestBuffer_BudgetPrunesRecordsFarFromScreen(t *testing.T) {
+	line := `{"time":1700000000000,"name":"Pelecard","msg":"hi"}` + "\n"
+	file, _ := createTestFile(t, strings.Repeat(line, 100))
+
+	buffer, err := NewBuffer(80, 2, false, file, context.Background())
+	assert.NoError(t, err)
+	buffer.SetBudget(20, 0)
+
+	err = buffer.SeekAndPopulate(0, io.SeekStart)
+	assert.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		buffer.Scroll(1)
+		return buffer.Status().ByteOffset == int64(60*len(line))
+	}, 5*time.Second, time.Millisecond)
+	assert.Eventually(t, func() bool { return buffer.Status().Records <= 20 }, time.Second, 5*time.Millisecond)
+
+	// Scrolling back up reads the pruned records again, in order.
+	assert.Eventually(t, func() bool {
+		buffer.Scroll(-1)
+		return buffer.Status().ByteOffset == int64(40*len(line))
+	}, 5*time.Second, time.Millisecond)
+	assert.EqualValues(t, 41, buffer.records.ScreenTopRecord().lineNumber)
+	assertRecordListInvariants(t, buffer.records)
+}
+
+func TestBuffer_SeekToLineNumbersRecords(t *testing.T) {
+	line := `{"time":1700000000000,"name":"Pelecard","msg":"hi"}` + "\n"
+	file, _ := createTestFile(t, strings.Repeat(line+"not a json line\n", 2000))
+
+	buffer, err := NewBuffer(80, 2, false, file, context.Background())
+	assert.NoError(t, err)
+
+	err = buffer.SeekToLine(3001)
+	assert.NoError(t, err)
+
+	assert.Eventually(t, func() bool { return buffer.records.ScreenTopRecord() != nil }, time.Second, 5*time.Millisecond)
+	assert.EqualValues(t, 3001, buffer.records.ScreenTopRecord().lineNumber)
+	assert.EqualValues(t, 1500*(len(line)+len("not a json line\n")), buffer.Status().ByteOffset)
+}
+
+func TestBuffer_NumbersLinesReadBeforeTheyWereIndexed(t *testing.T) {
+	line := `{"time":1700000000000,"name":"Pelecard","msg":"hi"}` + "\n"
+	file, _ := createTestFile(t, strings.Repeat(line, 10))
+
+	ctx, cancelCtx := context.WithCancel(context.Background())
+	defer cancelCtx()
+
+	buffer, err := NewBuffer(80, 4, true, file, ctx)
+	assert.NoError(t, err)
+	// The file is followed from its end before it is indexed.
+	startIndexing := buffer.startIndexing
+	indexed := make(chan struct{})
+	buffer.startIndexing = func() {
+		go func() {
+			<-indexed
+			startIndexing()
+		}()
+	}
+	assert.NoError(t, buffer.SeekAndPopulate(0, io.SeekEnd))
+	assert.Eventually(t, func() bool { return buffer.Status().Records == 10 }, time.Second, 5*time.Millisecond)
+	assert.Zero(t, buffer.records.RecordAt(9).lineNumber)
+
+	lineNumbers := func() []int64 {
+		var numbers []int64
+		buffer.records.WithLock(func(records *bufferRecordList) any {
+			for i := 0; i < records.Len(); i++ {
+				numbers = append(numbers, records.RecordAt(i).lineNumber)
+			}
+			return nil
+		})
+		return numbers
+	}
+	close(indexed)
+	assert.Eventually(t, func() bool {
+		return assert.ObjectsAreEqual([]int64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, lineNumbers())
+	}, time.Second, 5*time.Millisecond)
+
+	// The lines appended while following are counted on from there.
+	w, err := os.OpenFile(file.Name(), os.O_APPEND|os.O_WRONLY, 0)
+	assert.NoError(t, err)
+	defer w.Close()
+	_, err = w.WriteString(line + line)
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		return assert.ObjectsAreEqual([]int64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}, lineNumbers())
+	}, 3*time.Second, 5*time.Millisecond)
+}
+
+func TestBuffer_SeekToTailStartsBeforeTheLastRecords(t *testing.T) {
+	line := `{"time":1700000000000,"name":"Pelecard","msg":"hi"}` + "\n"
+	// Lines that aren't records aren't counted.
+	file, _ := createTestFile(t, strings.Repeat(line+"not a json line\n", 100))
+
+	buffer, err := NewBuffer(80, 2, false, file, context.Background())
+	assert.NoError(t, err)
+
+	offset, err := buffer.tailOffset(3)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 97*(len(line)+len("not a json line\n")), offset)
+	// There are fewer records than that, so all of them are.
+	offset, err = buffer.tailOffset(1000)
+	assert.NoError(t, err)
+	assert.Zero(t, offset)
+
+	assert.NoError(t, buffer.SeekToTail(3))
+	// Once the records before the last ones are loaded too, the last ones are
+	// still at the top of the screen.
+	want := int64(97 * (len(line) + len("not a json line\n")))
+	assert.Eventually(t, func() bool {
+		it := buffer.Records(context.Background())
+		return it.Next() && it.Record().Offset < want
+	}, time.Second, 5*time.Millisecond)
+	if top := buffer.records.ScreenTopRecord(); assert.NotNil(t, top) {
+		assert.EqualValues(t, want, top.byteOffset)
+	}
+}
+
+func TestBuffer_SeekToTailOfShortInputs(t *testing.T) {
+	line := `{"time":1700000000000,"name":"Pelecard","msg":"hi"}` + "\n"
+
+	// Like a spill file that nothing was piped into yet.
+	empty, _ := createTestFile(t, "")
+	buffer, err := NewBuffer(80, 2, false, empty, context.Background())
+	assert.NoError(t, err)
+	done := make(chan error, 1)
+	go func() { done <- buffer.SeekToTail(10) }()
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("seeking to the tail of an empty input didn't return")
+	}
+
+	// With fewer records than asked for, all of them are shown.
+	file, _ := createTestFile(t, line+line)
+	buffer, err = NewBuffer(80, 2, false, file, context.Background())
+	assert.NoError(t, err)
+	offset, err := buffer.tailOffset(10)
+	assert.NoError(t, err)
+	assert.Zero(t, offset)
+	assert.NoError(t, buffer.SeekToTail(10))
+	assert.Eventually(t, func() bool { return buffer.Status().Records == 2 }, time.Second, 5*time.Millisecond)
+	assert.EqualValues(t, 0, buffer.Status().ByteOffset)
+}
+
+func TestBuffer_UseMmapReadsRecords(t *testing.T) {
+	line := `{"time":1700000000000,"name":"Pelecard","msg":"hi"}` + "\n"
+	file, _ := createTestFile(t, strings.Repeat(line, 10))
+
+	ctx, cancelCtx := context.WithCancel(context.Background())
+	defer cancelCtx()
+
+	buffer, err := NewBuffer(80, 2, false, file, ctx)
+	assert.NoError(t, err)
+	assert.NoError(t, buffer.UseMmap())
+	buffer.SetEagerness(10, 10)
+
+	err = buffer.SeekAndPopulate(int64(5*len(line)), io.SeekStart)
+	assert.NoError(t, err)
+
+	// Both readers read through the mapping.
+	assert.Eventually(t, func() bool { return buffer.Status().Records == 10 }, time.Second, 5*time.Millisecond)
+	<-buffer.cancelPopulate(errors.New("test done"))
+	for i := 0; i < buffer.records.Len(); i++ {
+		assert.EqualValues(t, i*len(line), buffer.records.RecordAt(i).byteOffset)
+	}
+	assertRecordListInvariants(t, buffer.records)
+}
+
+func TestBuffer_FollowsTruncatedFileFromItsStart(t *testing.T) {
+	line := `{"time":1700000000000,"name":"Pelecard","msg":"hi"}` + "\n"
+	file, _ := createTestFile(t, line+line+line)
+
+	ctx, cancelCtx := context.WithCancel(context.Background())
+	defer cancelCtx()
+
+	buffer, err := NewBuffer(80, 2, true, file, ctx)
+	assert.NoError(t, err)
+
+	err = buffer.SeekAndPopulate(0, io.SeekEnd)
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool { return buffer.Status().Records == 3 }, time.Second, 5*time.Millisecond)
+
+	assert.NoError(t, os.Truncate(file.Name(), 0))
+	appendToTestFile(t, file, strings.Replace(line, "hi", "bye", 1))
+
+	assert.Eventually(t, func() bool {
+		status := buffer.Status()
+		return status.Records == 1 && status.ByteOffset == 0
+	}, 3*time.Second, 5*time.Millisecond)
+	assert.Contains(t, string(buffer.records.ScreenTopRecord().buf), "bye")
+}
+
+func TestBuffer_FollowNameFollowsReplacedFile(t *testing.T) {
+	line := `{"time":1700000000000,"name":"Pelecard","msg":"hi"}` + "\n"
+	file, _ := createTestFile(t, line+line)
+
+	ctx, cancelCtx := context.WithCancel(context.Background())
+	defer cancelCtx()
+
+	buffer, err := NewBuffer(80, 2, true, file, ctx)
+	assert.NoError(t, err)
+	buffer.SetFollowName(true)
+
+	err = buffer.SeekAndPopulate(0, io.SeekEnd)
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool { return buffer.Status().Records == 2 }, time.Second, 5*time.Millisecond)
+
+	// Replace the file the way log rotation does, by moving another one over
+	// its path.
+	replacement := file.Name() + ".new"
+	assert.NoError(t, os.WriteFile(replacement, []byte(strings.Replace(line, "hi", "bye", 1)), 0644))
+	assert.NoError(t, os.Rename(replacement, file.Name()))
+
+	assert.Eventually(t, func() bool {
+		status := buffer.Status()
+		return status.Records == 1 && status.ByteOffset == 0
+	}, 3*time.Second, 5*time.Millisecond)
+	assert.Contains(t, string(buffer.records.ScreenTopRecord().buf), "bye")
+}
+
+func TestBuffer_OpensAnotherFileWhileFollowingByName(t *testing.T) {
+	line := `{"time":1700000000000,"name":"Pelecard","msg":"hi"}` + "\n"
+	file, _ := createTestFile(t, line)
+	other, _ := createTestFile(t, line)
+
+	ctx, cancelCtx := context.WithCancel(context.Background())
+	defer cancelCtx()
+
+	buffer, err := NewBuffer(80, 2, true, file, ctx)
+	assert.NoError(t, err)
+	buffer.SetFollowName(true)
+	assert.NoError(t, buffer.SeekAndPopulate(0, io.SeekEnd))
+
+	// The readers check the file they follow for rotation whenever it is
+	// appended to, while another one is opened in its place.
+	for i := 0; i < 20; i++ {
+		for _, f := range []*os.File{file, other} {
+			w, err := os.OpenFile(f.Name(), os.O_APPEND|os.O_WRONLY, 0)
+			assert.NoError(t, err)
+			_, err = w.WriteString(line)
+			assert.NoError(t, err)
+			assert.NoError(t, w.Close())
+		}
+		path := file.Name()
+		if i%2 == 0 {
+			path = other.Name()
+		}
+		assert.NoError(t, buffer.Open(path))
+		time.Sleep(time.Millisecond)
+	}
+	assert.Eventually(t, func() bool { return buffer.Status().Records == 21 }, 3*time.Second, 5*time.Millisecond)
+}
+
+func TestBuffer_FollowsOriginalFileWhenRenamed(t *testing.T) {
+	line := `{"time":1700000000000,"name":"Pelecard","msg":"hi"}` + "\n"
+	file, _ := createTestFile(t, line)
+
+	ctx, cancelCtx := context.WithCancel(context.Background())
+	defer cancelCtx()
+
+	buffer, err := NewBuffer(80, 2, true, file, ctx)
+	assert.NoError(t, err)
+
+	err = buffer.SeekAndPopulate(0, io.SeekEnd)
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool { return buffer.Status().Records == 1 }, time.Second, 5*time.Millisecond)
+
+	// Rotate the file away and create a new one in its place. Without following
+	// by name, writes to the original file still show up.
+	rotated := file.Name() + ".1"
+	assert.NoError(t, os.Rename(file.Name(), rotated))
+	assert.NoError(t, os.WriteFile(file.Name(), []byte(strings.Replace(line, "hi", "bye", 1)), 0644))
+	f, err := os.OpenFile(rotated, os.O_APPEND|os.O_WRONLY, 0644)
+	assert.NoError(t, err)
+	_, err = f.WriteString(line)
+	assert.NoError(t, err)
+	assert.NoError(t, f.Close())
+
+	assert.Eventually(t, func() bool { return buffer.Status().Records == 2 }, 3*time.Second, 5*time.Millisecond)
+	<-time.After(followPollInterval + 100*time.Millisecond)
+	assert.EqualValues(t, 2, buffer.Status().Records)
+}
+
+func TestBuffer_CoalescesRenderRequests(t *testing.T) {
+	line := `{"time":1700000000000,"name":"Pelecard","msg":"hi"}` + "\n"
+	file, _ := createTestFile(t, strings.Repeat(line, 50))
+
+	buffer, err := NewBuffer(80, 100, false, file, context.Background())
+	assert.NoError(t, err)
+
+	var posted atomic.Int32
+	buffer.SetPostEventFunc(func(ev tcell.Event) error {
+		posted.Add(1)
+		return nil
+	})
+
+	err = buffer.SeekAndPopulate(0, io.SeekStart)
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool { return buffer.Status().Records == 50 }, time.Second, 5*time.Millisecond)
+
+	// Nothing was rendered, so the first request is still pending.
+	assert.EqualValues(t, 1, posted.Load())
+
+	buffer.clearRenderRequest()
+	appendToTestFile(t, file, line)
+	buffer.SetFollowMode(true)
+	assert.Eventually(t, func() bool { return posted.Load() == 2 }, time.Second, 5*time.Millisecond)
+}
+
+func TestBuffer_CollapseRepeatsMergesIdenticalRecords(t *testing.T) {
+	line := `{"time":1700000000000,"name":"Pelecard","msg":"hi"}` + "\n"
+	other := `{"time":1700000000000,"name":"Pelecard","msg":"bye"}` + "\n"
+	file, _ := createTestFile(t, strings.Repeat(line, 3)+other+strings.Repeat(line, 2))
+
+	ctx, cancelCtx := context.WithCancel(context.Background())
+	defer cancelCtx()
+
+	buffer, err := NewBuffer(80, 10, false, file, ctx)
+	assert.NoError(t, err)
+	err = buffer.SeekAndPopulate(0, io.SeekStart)
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool { return buffer.Status().Records == 6 }, time.Second, 5*time.Millisecond)
+
+	assert.NoError(t, buffer.SetCollapseRepeats(true))
+	assert.True(t, buffer.CollapseRepeats())
+	assert.Eventually(t, func() bool { return buffer.Status().Records == 3 }, time.Second, 5*time.Millisecond)
+	buffer.records.WithLock(func(records *bufferRecordList) any {
+		assert.EqualValues(t, 3, records.First().repeats+1)
+		assert.EqualValues(t, 3*len(line), records.First().byteLen)
+		assert.EqualValues(t, 2, records.Last().repeats+1)
+		assertRecordListInvariants(t, records)
+		return nil
+	})
+
+	assert.NoError(t, buffer.SetCollapseRepeats(false))
+	assert.Eventually(t, func() bool { return buffer.Status().Records == 6 }, time.Second, 5*time.Millisecond)
+}
+
+func TestBuffer_ParseLineKeepsLinesJqPassesThrough(t *testing.T) {
+	file, _ := createTestFile(t, "")
+	buffer, err := NewBuffer(80, 10, false, file, context.Background())
+	assert.NoError(t, err)
+
+	setQuery := func(query string) {
+		parsed, err := gojq.Parse(query)
+		assert.NoError(t, err)
+		buffer.jqExpr, err = gojq.Compile(parsed)
+		assert.NoError(t, err)
+	}
+	line := []byte(`{"msg":"hi", "level":"info"} `)
+
+	// Selecting records passes them through, so they keep their key order.
+	setQuery(`select(.level == "info")`)
+	r := buffer.parseLine(0, line, parseOptions{filter: buffer.jqExpr, tabWidth: defaultTabWidth})
+	assert.EqualValues(t, `{"msg":"hi", "level":"info"}`, string(r.buf))
+	assert.EqualValues(t, len(line), r.size())
+
+	setQuery(`{msg}`)
+	r = buffer.parseLine(0, line, parseOptions{filter: buffer.jqExpr, tabWidth: defaultTabWidth})
+	assert.EqualValues(t, `{"msg":"hi"}`, string(r.buf))
+	assert.EqualValues(t, len(r.buf)+len(line), r.size())
+
+	assert.Nil(t, buffer.parseLine(0, []byte("null"), parseOptions{filter: buffer.jqExpr}))
+	assert.Nil(t, buffer.parseLine(0, []byte(`["hi"]`), parseOptions{filter: buffer.jqExpr}))
+}
+
+func TestBuffer_TruncatesLongRecords(t *testing.T) {
+	line := `{"time":1700000000000,"name":"Pelecard","msg":"hi"}` + "\n"
+	long := `{"time":1700000000000,"name":"Pelecard","msg":"` + strings.Repeat("x", 100) + `"}`
+	file, _ := createTestFile(t, line+long+"\n"+line+long+"\n"+line)
+
+	ctx, cancelCtx := context.WithCancel(context.Background())
+	defer cancelCtx()
+
+	buffer, err := NewBuffer(200, 10, false, file, ctx)
+	assert.NoError(t, err)
+	buffer.SetMaxRecordSize(20)
+
+	// Start in the middle so the long lines are read in both directions.
+	err = buffer.SeekAndPopulate(int64(2*len(line)+len(long)+1), io.SeekStart)
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool { return buffer.Status().Records == 5 }, time.Second, 5*time.Millisecond)
+
+	buffer.records.WithLock(func(records *bufferRecordList) any {
+		for i, offset := range []int{0, len(line), len(line) + len(long) + 1, 2*len(line) + len(long) + 1, 2*len(line) + 2*len(long) + 2} {
+			assert.EqualValues(t, offset, records.RecordAt(i).byteOffset)
+		}
+
+		r := records.RecordAt(1)
+		assert.EqualValues(t, len(long)+1, r.byteLen)
+		assert.EqualValues(t, []string{long[:20] + fmt.Sprintf(" …truncated (%d bytes)", len(long))}, records.LinesOf(r))
+
+		full, err := buffer.ReadFullLine(r)
+		assert.NoError(t, err)
+		assert.EqualValues(t, long, string(full))
+		return nil
+	})
+}
+
+func TestBuffer_FlagsAndEscapesBinaryInput(t *testing.T) {
+	line := `{"time":1700000000000,"name":"Pelecard","msg":"ok"}` + "\n"
+	file, _ := createTestFile(t, line+"\x00\x01\x02"+strings.Repeat("x", 20)+"\n"+line)
+
+	ctx, cancelCtx := context.WithCancel(context.Background())
+	defer cancelCtx()
+
+	buffer, err := NewBuffer(200, 10, false, file, ctx)
+	assert.NoError(t, err)
+	assert.False(t, buffer.Status().Binary)
+	// Long lines are shown as they are, so the binary one is too.
+	buffer.SetMaxRecordSize(8)
+
+	err = buffer.SeekAndPopulate(0, io.SeekStart)
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool { return buffer.Status().Records == 3 }, time.Second, 5*time.Millisecond)
+	assert.True(t, buffer.Status().Binary)
+
+	buffer.records.WithLock(func(records *bufferRecordList) any {
+		assert.EqualValues(t, `\x00\x01\x02xxxxx`, string(records.RecordAt(1).buf))
+		return nil
+	})
+}
+
+func TestBuffer_ReadsCRLFLines(t *testing.T) {
+	line := `{"time":1700000000000,"name":"Pelecard","msg":"hi"}` + "\r\n"
+	file, _ := createTestFile(t, strings.Repeat(line, 4))
+
+	ctx, cancelCtx := context.WithCancel(context.Background())
+	defer cancelCtx()
+
+	buffer, err := NewBuffer(200, 10, false, file, ctx)
+	assert.NoError(t, err)
+
+	// Start in the middle so the lines are read in both directions.
+	err = buffer.SeekAndPopulate(int64(2*len(line)), io.SeekStart)
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool { return buffer.Status().Records == 4 }, time.Second, 5*time.Millisecond)
+
+	buffer.records.WithLock(func(records *bufferRecordList) any {
+		for i := 0; i < records.Len(); i++ {
+			r := records.RecordAt(i)
+			assert.EqualValues(t, i*len(line), r.byteOffset)
+			assert.EqualValues(t, len(line), r.byteLen)
+			assert.NotContains(t, string(r.buf), "\r")
+		}
+		return nil
+	})
+}
+
+func TestBuffer_StripsByteOrderMark(t *testing.T) {
+	line := `{"time":1700000000000,"name":"Pelecard","msg":"hi"}` + "\n"
+	file, _ := createTestFile(t, "\xef\xbb\xbf"+strings.Repeat(line, 3))
+
+	ctx, cancelCtx := context.WithCancel(context.Background())
+	defer cancelCtx()
+
+	buffer, err := NewBuffer(200, 10, false, file, ctx)
+	assert.NoError(t, err)
+
+	// Start after the first line so it is read backwards.
+	err = buffer.SeekAndPopulate(int64(3+len(line)), io.SeekStart)
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool { return buffer.Status().Records == 3 }, time.Second, 5*time.Millisecond)
+
+	buffer.records.WithLock(func(records *bufferRecordList) any {
+		first := records.RecordAt(0)
+		assert.EqualValues(t, 0, first.byteOffset)
+		assert.EqualValues(t, 3+len(line), first.byteLen)
+		assert.NotContains(t, string(first.raw), "\ufeff")
+		return nil
+	})
+}
+
+func TestBuffer_ReadsRecordsWithCustomDelimiter(t *testing.T) {
+	// Records may span lines when they end with another delimiter.
+	record := "{\"time\":1700000000000,\n\"name\":\"Pelecard\",\"msg\":\"hi\"}\x00"
+	file, _ := createTestFile(t, strings.Repeat(record, 4))
+
+	ctx, cancelCtx := context.WithCancel(context.Background())
+	defer cancelCtx()
+
+	buffer, err := NewBuffer(200, 10, false, file, ctx)
+	assert.NoError(t, err)
+	buffer.SetDelimiter([]byte{0})
+
+	// Start in the middle so the records are read in both directions.
+	err = buffer.SeekAndPopulate(int64(2*len(record)+5), io.SeekStart)
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool { return buffer.Status().Records == 4 }, time.Second, 5*time.Millisecond)
+	assert.False(t, buffer.Status().Binary)
+
+	buffer.records.WithLock(func(records *bufferRecordList) any {
+		for i := 0; i < records.Len(); i++ {
+			r := records.RecordAt(i)
+			assert.EqualValues(t, i*len(record), r.byteOffset)
+			assert.EqualValues(t, len(record), r.byteLen)
+			assert.Contains(t, string(r.buf), "Pelecard")
+		}
+		return nil
+	})
+}
+
+func TestBuffer_JoinsContinuationLines(t *testing.T) {
+	record := func(msg string) string {
+		return `{"time":1700000000000,"name":"Pelecard","msg":"` + msg + `"}` + "\n"
+	}
+	contents := record("a") + "  at one\n  at two\n" + record("b") + "caused by\n" + record("c")
+	file, _ := createTestFile(t, contents)
+
+	ctx, cancelCtx := context.WithCancel(context.Background())
+	defer cancelCtx()
+
+	buffer, err := NewBuffer(200, 10, true, file, ctx)
+	assert.NoError(t, err)
+	assert.NoError(t, buffer.SetRecordStart(defaultRecordStart))
+
+	// Seeking into the lines joined to the second record starts reading at
+	// the record, so they are read from both directions.
+	err = buffer.SeekAndPopulate(int64(strings.Index(contents, "caused")), io.SeekStart)
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool { return buffer.Status().Records == 3 }, time.Second, 5*time.Millisecond)
+
+	// Lines written after the last record are joined to it as they come.
+	appendToTestFile(t, file, "  at three\n")
+	assert.Eventually(t, func() bool {
+		return buffer.records.WithLock(func(records *bufferRecordList) any {
+			return records.Last().byteLen == len(record("c"))+len("  at three\n")
+		}).(bool)
+	}, followPollInterval+time.Second, 5*time.Millisecond)
+
+	buffer.records.WithLock(func(records *bufferRecordList) any {
+		assert.EqualValues(t, 3, records.Len())
+		expected := []string{"  at one\n  at two\n", "caused by\n", "  at three\n"}
+		offset := 0
+		for i, msg := range []string{"a", "b", "c"} {
+			r := records.RecordAt(i)
+			assert.EqualValues(t, offset, r.byteOffset)
+			assert.EqualValues(t, len(record(msg))+len(expected[i]), r.byteLen)
+			assert.EqualValues(t, strings.Split(strings.TrimSuffix(expected[i], "\n"), "\n"), continuationLines(r))
+			offset += r.byteLen
+		}
+		return nil
+	})
+	assertRecordListInvariants(t, buffer.records)
+}
+
+func TestNewBuffer_ShowsRecordsAsTheyAre(t *testing.T) {
+	file, _ := createTestFile(t, "")
+	buffer, err := NewBuffer(80, 10, false, file, context.Background())
+	assert.NoError(t, err)
+	assert.EqualValues(t, ".", buffer.Status().Filter)
+
+	r := buffer.parseLine(0, []byte(`{"time":1700000000000,"name":"Other","msg":"hi"}`), parseOptions{filter: buffer.jqExpr})
+	if assert.NotNil(t, r) {
+		assert.EqualValues(t, `{"time":1700000000000,"name":"Other","msg":"hi"}`, string(r.buf))
+	}
+}
+
+func TestBuffer_KeepsExcludedRecordsToDim(t *testing.T) {
+	file, _ := createTestFile(t, "")
+	buffer, err := NewBuffer(80, 10, false, file, context.Background())
+	assert.NoError(t, err)
+	assert.NoError(t, buffer.SetFilter(defaultFilter))
+
+	line := []byte(`{"time":1700000000000,"name":"Other","msg":"hi"} `)
+	assert.Nil(t, buffer.parseLine(0, line, parseOptions{filter: buffer.jqExpr}))
+
+	// Excluded records are shown as they were read.
+	r := buffer.parseLine(0, line, parseOptions{filter: buffer.jqExpr, dimExcluded: true})
+	assert.True(t, r.excluded)
+	assert.EqualValues(t, `{"time":1700000000000,"name":"Other","msg":"hi"}`, string(r.buf))
+	assert.Nil(t, r.parsed)
+
+	r = buffer.parseLine(0, []byte(`{"time":1700000000000,"name":"Pelecard","msg":"hi"}`), parseOptions{filter: buffer.jqExpr, dimExcluded: true})
+	assert.False(t, r.excluded)
+	assert.Nil(t, buffer.parseLine(0, []byte("not json"), parseOptions{filter: buffer.jqExpr, dimExcluded: true}))
+}
@@ -0,0 +1,79 @@
+package view
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// clipboardCommands are tried in order when no clipboard command was
+// configured, before asking the terminal with OSC 52.
+var clipboardCommands = [][]string{
+	{"pbcopy"},
+	{"wl-copy"},
+	{"xclip", "-selection", "clipboard"},
+	{"xsel", "--clipboard", "--input"},
+}
+
+// writeOSC52 writes the escape sequence that asks the terminal to put the given
+// text on the system clipboard.
+func writeOSC52(w io.Writer, text string) error {
+	_, err := fmt.Fprintf(w, "\x1b]52;c;%s\a", base64.StdEncoding.EncodeToString([]byte(text)))
+	return err
+}
+
+// copyToClipboard puts the given text on the system clipboard. If command is
+// set it is run with the text as its input. Otherwise the known clipboard
+// commands that are found are tried in order, and if none of them copies it,
+// like xclip without a display over SSH, the terminal is asked to with OSC 52.
+// Terminals don't tell whether they did, so OSC 52 is only the last resort.
+func copyToClipboard(text, command string) error {
+	if command != "" {
+		return runClipboardCommand(strings.Fields(command), text)
+	}
+
+	var errs []error
+	for _, args := range clipboardCommands {
+		if _, err := exec.LookPath(args[0]); err != nil {
+			continue
+		}
+		err := runClipboardCommand(args, text)
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, err)
+	}
+
+	tty, err := os.OpenFile("/dev/tty", os.O_WRONLY, 0)
+	if err == nil {
+		err = writeOSC52(tty, text)
+		if err2 := tty.Close(); err == nil {
+			err = err2
+		}
+		if err == nil {
+			return nil
+		}
+	}
+	if len(errs) == 0 {
+		return fmt.Errorf("failed to copy with OSC 52 and no clipboard command found: %w", err)
+	}
+	return fmt.Errorf("failed to copy with the clipboard commands or OSC 52: %w", errors.Join(append(errs, err)...))
+}
+
+// runClipboardCommand runs the given command with the text as its input.
+func runClipboardCommand(args []string, text string) error {
+	if len(args) == 0 {
+		return errors.New("empty clipboard command")
+	}
+
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin = strings.NewReader(text)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("clipboard command %q failed: %w: %s", args[0], err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package view
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteOSC52_EncodesText(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, writeOSC52(&buf, "hi\nya"))
+	assert.EqualValues(t, "\x1b]52;c;aGkKeWE=\a", buf.String())
+}
+
+func TestRunClipboardCommand_PipesText(t *testing.T) {
+	path := t.TempDir() + "/clipboard"
+	assert.NoError(t, runClipboardCommand([]string{"sh", "-c", "cat > " + path}, "hello"))
+	contents, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "hello", contents)
+}
+
+func TestCopyToClipboard_TriesTheClipboardCommandsFirst(t *testing.T) {
+	bin := t.TempDir()
+	path := t.TempDir() + "/clipboard"
+	// The first command found fails, like xclip without a display, so the
+	// next one is tried.
+	assert.NoError(t, os.WriteFile(bin+"/pbcopy", []byte("#!/bin/sh\nexit 1\n"), 0755))
+	assert.NoError(t, os.WriteFile(bin+"/xsel", []byte("#!/bin/sh\nexec /bin/cat > "+path+"\n"), 0755))
+	t.Setenv("PATH", bin)
+
+	assert.NoError(t, copyToClipboard("hello", ""))
+	contents, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "hello", contents)
+}
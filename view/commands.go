@@ -0,0 +1,473 @@
+package view
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// command is a command that can be run from the command prompt, like
+// ":set wrap off", for what doesn't have a key of its own.
+type command struct {
+	name string
+	// What the command is given, as shown in its usage.
+	args        string
+	description string
+	run         func(a *Application, arg string) error
+	// Returns the ways the given argument can be completed, or nil if it
+	// can't be.
+	complete func(a *Application, arg string) []string
+}
+
+// commands are the commands of the command prompt, sorted by name.
+var commands = []command{
+	{name: "filter", args: "QUERY", description: "Filter and transform the records with a jq query", run: (*Application).filterCommand},
+	{name: "goto", args: "LINE|PERCENT%|TIME|start|end", description: "Go to a line, a part of the file, the first record at a time, or the start or end", run: (*Application).gotoCommand, complete: completeWords("start", "end")},
+	{name: "help", description: "Show the key bindings", run: func(a *Application, _ string) error {
+		a.showHelp = true
+		return nil
+	}},
+	{name: "open", args: "PATH", description: "Read another file instead", run: (*Application).openCommand, complete: completePath},
+	{name: "quit", description: "Quit", run: func(a *Application, _ string) error {
+		a.quitRequested = true
+		return nil
+	}},
+	{name: "save", args: "PATH", description: "Save the records the filter matches to a file", run: (*Application).saveCommand, complete: completePath},
+	{name: "set", args: "OPTION [VALUE]", description: "Change an option, like wrap off. Without one, the options are shown", run: (*Application).setCommand, complete: completeSetting},
+}
+
+// setting is an option the set command changes.
+type setting struct {
+	values []string
+	get    func(a *Application) string
+	set    func(a *Application, value string) error
+}
+
+// settings are the options of the set command, by name.
+var settings = map[string]setting{
+	"follow": {
+		values: onOff,
+		get:    func(a *Application) string { return formatOnOff(a.followMode) },
+		set: func(a *Application, value string) error {
+			on, err := parseOnOff(value)
+			if err == nil {
+				a.setFollowMode(on)
+			}
+			return err
+		},
+	},
+	"gutter": {
+		values: []string{"none", "line", "offset"},
+		get:    func(a *Application) string { return a.gutter.String() },
+		set: func(a *Application, value string) error {
+			mode, err := parseGutterMode(value)
+			if err == nil {
+				a.setGutter(mode)
+			}
+			return err
+		},
+	},
+	"highlight": {
+		values: onOff,
+		get:    func(a *Application) string { return formatOnOff(a.highlight) },
+		set: func(a *Application, value string) error {
+			on, err := parseOnOff(value)
+			if err == nil {
+				a.highlight = on
+			}
+			return err
+		},
+	},
+	"keymap": {
+		values: keymapNames(),
+		get: func(a *Application) string {
+			if a.config.Keymap == "" {
+				return "default"
+			}
+			return a.config.Keymap
+		},
+		set: func(a *Application, value string) error {
+			km, err := lookupKeymap(value)
+			if err != nil {
+				return err
+			}
+			a.keymap = append(km[:len(km):len(km)], a.scripts.bindings()...)
+			a.pending = pendingKeys{}
+			a.config.Keymap = value
+			return nil
+		},
+	},
+	"dim": {
+		values: onOff,
+		get:    func(a *Application) string { return formatOnOff(a.buffer.DimExcluded()) },
+		set: func(a *Application, value string) error {
+			on, err := parseOnOff(value)
+			if err != nil {
+				return err
+			}
+			return a.setDimExcluded(on)
+		},
+	},
+	"sample": {
+		values: []string{"off", "10", "100", "1000", "1%"},
+		get:    func(a *Application) string { return a.buffer.Sampling().String() },
+		set: func(a *Application, value string) error {
+			s, err := parseSampling(value)
+			if err != nil {
+				return err
+			}
+			if s.on() {
+				a.config.Sample = s
+			}
+			return a.setSampling(s)
+		},
+	},
+	"repeats": {
+		values: onOff,
+		get:    func(a *Application) string { return formatOnOff(!a.buffer.CollapseRepeats()) },
+		set: func(a *Application, value string) error {
+			on, err := parseOnOff(value)
+			if err != nil {
+				return err
+			}
+			a.clearSelection()
+			return a.buffer.SetCollapseRepeats(!on)
+		},
+	},
+	"wrap": {
+		values: onOff,
+		get:    func(a *Application) string { return formatOnOff(a.buffer.Wrap()) },
+		set: func(a *Application, value string) error {
+			on, err := parseOnOff(value)
+			if err == nil {
+				a.buffer.SetWrap(on)
+			}
+			return err
+		},
+	},
+}
+
+var onOff = []string{"on", "off"}
+
+// parseOnOff parses the value of an option that is on or off. An empty value
+// turns it on.
+func parseOnOff(value string) (bool, error) {
+	switch value {
+	case "", "on", "true", "yes":
+		return true, nil
+	case "off", "false", "no":
+		return false, nil
+	}
+	return false, fmt.Errorf("invalid value %q, expected on or off", value)
+}
+
+func formatOnOff(on bool) string {
+	if on {
+		return "on"
+	}
+	return "off"
+}
+
+// lookupCommand returns the command with the given name, or the only one it is
+// the start of, like "f" for filter.
+func lookupCommand(name string) (command, error) {
+	var found []command
+	for _, c := range commands {
+		if c.name == name {
+			return c, nil
+		}
+		if strings.HasPrefix(c.name, name) {
+			found = append(found, c)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return command{}, fmt.Errorf("unknown command %q", name)
+	case 1:
+		return found[0], nil
+	}
+	names := make([]string, 0, len(found))
+	for _, c := range found {
+		names = append(names, c.name)
+	}
+	return command{}, fmt.Errorf("ambiguous command %q: %s", name, strings.Join(names, ", "))
+}
+
+// describeCommands returns a line describing each command, for the help
+// screen.
+func describeCommands() []string {
+	usages := make([]string, len(commands))
+	width := 0
+	for i, c := range commands {
+		usages[i] = strings.TrimSpace(":" + c.name + " " + c.args)
+		width = max(width, len(usages[i]))
+	}
+	lines := make([]string, len(commands))
+	for i, c := range commands {
+		lines[i] = fmt.Sprintf("%-*s  %s", width, usages[i], c.description)
+	}
+	return lines
+}
+
+// splitCommand splits the text of the command prompt into the command's name
+// and its argument.
+func splitCommand(text string) (string, string) {
+	text = strings.TrimPrefix(strings.TrimSpace(text), ":")
+	name, arg, _ := strings.Cut(text, " ")
+	return name, strings.TrimSpace(arg)
+}
+
+// startCommand prompts for a command to run, which Tab completes.
+func (a *Application) startCommand() {
+	p := &prompt{label: ":"}
+	p.submit = func(text string) {
+		name, arg := splitCommand(text)
+		if name == "" {
+			return
+		}
+		c, err := lookupCommand(name)
+		if err != nil {
+			a.message = err.Error()
+			return
+		}
+		if err := c.run(a, arg); err != nil {
+			a.notifyError(c.name + " failed: " + err.Error())
+		}
+	}
+
+	// Tab cycles through the completions of the text, which are found again
+	// once it is edited, or once there is only one to move on from.
+	var completions []string
+	next := 0
+	p.tab = func() {
+		text := string(p.text)
+		if len(completions) <= 1 || text != completions[(next+len(completions)-1)%len(completions)] {
+			completions = a.commandCompletions(text)
+			next = 0
+		}
+		if len(completions) == 0 {
+			return
+		}
+		p.text = []rune(completions[next])
+		next = (next + 1) % len(completions)
+	}
+	a.prompt = p
+}
+
+// commandCompletions returns the texts the given text of the command prompt
+// can be completed to.
+func (a *Application) commandCompletions(text string) []string {
+	name, arg, hasArg := strings.Cut(strings.TrimLeft(text, " :"), " ")
+	if !hasArg {
+		var names []string
+		for _, c := range commands {
+			if strings.HasPrefix(c.name, name) {
+				names = append(names, c.name+" ")
+			}
+		}
+		return names
+	}
+
+	c, err := lookupCommand(name)
+	if err != nil || c.complete == nil {
+		return nil
+	}
+	var completions []string
+	for _, completion := range c.complete(a, strings.TrimLeft(arg, " ")) {
+		completions = append(completions, c.name+" "+completion)
+	}
+	return completions
+}
+
+// completeWords returns a completion function that completes to the given
+// words.
+func completeWords(words ...string) func(*Application, string) []string {
+	return func(_ *Application, arg string) []string {
+		var completions []string
+		for _, word := range words {
+			if strings.HasPrefix(word, arg) {
+				completions = append(completions, word)
+			}
+		}
+		return completions
+	}
+}
+
+// completePath completes the path of a file, with the directories it may be in
+// ending with a slash.
+func completePath(_ *Application, arg string) []string {
+	matches, err := filepath.Glob(globEscape(arg) + "*")
+	if err != nil {
+		return nil
+	}
+	for i, match := range matches {
+		if info, err := os.Stat(match); err == nil && info.IsDir() {
+			matches[i] += string(filepath.Separator)
+		}
+	}
+	return matches
+}
+
+// globEscape escapes the characters of the given path that are special in glob
+// patterns.
+func globEscape(path string) string {
+	var sb strings.Builder
+	for _, r := range path {
+		if strings.ContainsRune(`*?[\`, r) {
+			sb.WriteRune('\\')
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// completeSetting completes the names of the options of the set command, and
+// then their values.
+func completeSetting(_ *Application, arg string) []string {
+	name, value, hasValue := strings.Cut(arg, " ")
+	var completions []string
+	if !hasValue {
+		for name := range settings {
+			if strings.HasPrefix(name, arg) {
+				completions = append(completions, name+" ")
+			}
+		}
+		sort.Strings(completions)
+		return completions
+	}
+	s, ok := settings[name]
+	if !ok {
+		return nil
+	}
+	for _, v := range s.values {
+		if strings.HasPrefix(v, strings.TrimSpace(value)) {
+			completions = append(completions, name+" "+v)
+		}
+	}
+	return completions
+}
+
+func (a *Application) filterCommand(query string) error {
+	if query == "" {
+		a.message = "filter: " + a.buffer.Status().Filter
+		return nil
+	}
+	return a.setFilter(query)
+}
+
+func (a *Application) gotoCommand(arg string) error {
+	switch {
+	case arg == "start":
+		a.seekToEdge(false)
+		return nil
+	case arg == "end":
+		a.seekToEdge(true)
+		return nil
+	case arg == "":
+		return errors.New("no position given")
+	}
+
+	// The position is checked before anything changes, so a mistyped one
+	// leaves the view as it was.
+	percent, isPercent := strings.CutSuffix(arg, "%")
+	var p float64
+	var t time.Time
+	line, err := strconv.ParseInt(arg, 10, 64)
+	isLine := err == nil
+	switch {
+	case isPercent:
+		if p, err = strconv.ParseFloat(percent, 64); err != nil || p < 0 || p > 100 {
+			return fmt.Errorf("invalid percentage %q", arg)
+		}
+	case !isLine:
+		if t, err = parseTimeBound(arg, time.Now()); err != nil {
+			return fmt.Errorf("invalid position %q, expected a line, a percentage, a time, start or end", arg)
+		}
+	}
+
+	a.setFollowMode(false)
+	a.clearSelection()
+	size := a.buffer.Status().FileSize
+	switch {
+	case isPercent:
+		return a.buffer.SeekAndPopulate(int64(float64(size)*p/100), io.SeekStart)
+	case isLine:
+		a.startTask("indexing lines", 0, size, a.buffer.indexedSize, func(p *scanProgress) func() {
+			offset, err := a.buffer.lineOffset(p, line)
+			return func() { a.seekFound(offset, err) }
+		})
+		return nil
+	}
+	a.startTask("finding "+t.Format(time.RFC3339), 0, size, nil, func(p *scanProgress) func() {
+		offset, err := a.buffer.timeOffset(p, t)
+		return func() { a.seekFound(offset, err) }
+	})
+	return nil
+}
+
+// seekFound seeks to the offset a goto task found, or shows why it failed.
+func (a *Application) seekFound(offset int64, err error) {
+	if err == nil {
+		err = a.buffer.SeekAndPopulate(offset, io.SeekStart)
+	}
+	if err != nil {
+		a.notifyError("goto failed: " + err.Error())
+	}
+}
+
+func (a *Application) openCommand(path string) error {
+	if path == "" {
+		return errors.New("no path given")
+	}
+	a.saveInputPosition()
+	if err := a.buffer.Open(path); err != nil {
+		return err
+	}
+	a.config.Filename = path
+	a.inputPath = ""
+	if !a.config.NoResume {
+		if abs, err := filepath.Abs(path); err == nil {
+			a.inputPath = abs
+		}
+	}
+	a.clearSelection()
+	a.setGutter(a.gutter)
+	return nil
+}
+
+func (a *Application) saveCommand(path string) error {
+	if path == "" {
+		return errors.New("no path given")
+	}
+	a.saveRecords(path, func(w io.Writer) (int, error) {
+		return a.buffer.WriteRecords(w, 0, 0)
+	})
+	return nil
+}
+
+func (a *Application) setCommand(arg string) error {
+	name, value, _ := strings.Cut(arg, " ")
+	if name == "" {
+		names := make([]string, 0, len(settings))
+		for name := range settings {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for i, name := range names {
+			names[i] = name + "=" + settings[name].get(a)
+		}
+		a.message = strings.Join(names, " ")
+		return nil
+	}
+	s, ok := settings[name]
+	if !ok {
+		return fmt.Errorf("unknown option %q", name)
+	}
+	return s.set(a, strings.TrimSpace(value))
+}
@@ -0,0 +1,121 @@
+package view
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLookupCommand(t *testing.T) {
+	c, err := lookupCommand("fil")
+	assert.NoError(t, err)
+	assert.Equal(t, "filter", c.name)
+	c, err = lookupCommand("set")
+	assert.NoError(t, err)
+	assert.Equal(t, "set", c.name)
+
+	_, err = lookupCommand("s")
+	assert.EqualError(t, err, `ambiguous command "s": save, set`)
+	_, err = lookupCommand("nope")
+	assert.Error(t, err)
+}
+
+func TestApplication_CommandCompletions(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "app.log"), nil, 0o644))
+	assert.NoError(t, os.Mkdir(filepath.Join(dir, "archive"), 0o755))
+
+	a := &Application{}
+	assert.Equal(t, []string{"save ", "set "}, a.commandCompletions("s"))
+	assert.Equal(t, []string{"set wrap "}, a.commandCompletions("set w"))
+	assert.Equal(t, []string{"set wrap on", "set wrap off"}, a.commandCompletions("set wrap "))
+	assert.Equal(t, []string{"goto end"}, a.commandCompletions(":goto e"))
+	assert.Equal(t, []string{
+		"open " + filepath.Join(dir, "app.log"),
+		"open " + filepath.Join(dir, "archive") + string(filepath.Separator),
+	}, a.commandCompletions("open "+dir+string(filepath.Separator)+"a"))
+	assert.Empty(t, a.commandCompletions("filter .a"))
+}
+
+func TestApplication_RunsCommands(t *testing.T) {
+	var lines []string
+	for i := 0; i < 30; i++ {
+		lines = append(lines, fmt.Sprintf(`{"time":%d,"name":"Pelecard","msg":"record %02d"}`, 1700000000000+i*1000, i))
+	}
+	file, _ := createTestFile(t, strings.Join(lines, "\n")+"\n")
+	offsetOf := func(i int) int64 { return int64(i * (len(lines[0]) + 1)) }
+	other, _ := createTestFile(t, `{"time":1700000000000,"name":"Pelecard","msg":"other"}`+"\n")
+
+	ctx, cancelCtx := context.WithCancel(context.Background())
+	defer cancelCtx()
+	screen := NewMemoryScreen(90, 10)
+
+	a := NewApplication(file, false, &Config{NoTutorial: true, NoResume: true})
+	assert.NoError(t, a.setup(ctx, screen))
+	run := func(text string) bool {
+		a.handleEvent(tcell.NewEventKey(tcell.KeyRune, ':', tcell.ModNone))
+		for _, ch := range text {
+			a.handleEvent(tcell.NewEventKey(tcell.KeyRune, ch, tcell.ModNone))
+		}
+		return a.handleEvent(tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModNone))
+	}
+	atTop := func(i int) func(BufferStatus) bool {
+		return func(status BufferStatus) bool { return status.ByteOffset == offsetOf(i) }
+	}
+
+	run("set wrap off")
+	assert.False(t, a.buffer.Wrap())
+	run("set")
+	assert.Equal(t, "dim=off follow=off gutter=none highlight=on keymap=default repeats=on sample=off wrap=off", a.message)
+	run("set wrap sideways")
+	assert.Equal(t, `set failed: invalid value "sideways", expected on or off`, noticeText(a))
+
+	run("goto 5")
+	waitForBuffer(t, a, screen, atTop(4))
+	run("goto 2023-11-14T22:13:30Z")
+	waitForBuffer(t, a, screen, atTop(10))
+	run("goto start")
+	waitForBuffer(t, a, screen, atTop(0))
+
+	// A position that isn't valid leaves following as it was.
+	run("set follow on")
+	run("goto 150%")
+	assert.Equal(t, `goto failed: invalid percentage "150%"`, noticeText(a))
+	assert.True(t, a.followMode)
+	run("goto yesterday-ish")
+	assert.True(t, a.followMode)
+	run("goto start")
+	waitForBuffer(t, a, screen, atTop(0))
+	assert.False(t, a.followMode)
+
+	run(`f select(.msg == "record 07")`)
+	waitForBuffer(t, a, screen, func(status BufferStatus) bool { return status.Records == 1 })
+	run("filter .")
+
+	run("open " + other.Name())
+	waitForBuffer(t, a, screen, func(BufferStatus) bool {
+		r := a.buffer.records.RecordAtScreenLine(0)
+		return r != nil && strings.Contains(string(r.buf), "other")
+	})
+	assert.Equal(t, other.Name(), a.displayName())
+
+	// Tab cycles through the completions, then completes the next word.
+	a.handleEvent(tcell.NewEventKey(tcell.KeyRune, ':', tcell.ModNone))
+	a.handleEvent(tcell.NewEventKey(tcell.KeyRune, 's', tcell.ModNone))
+	var texts []string
+	for i := 0; i < 3; i++ {
+		a.handleEvent(tcell.NewEventKey(tcell.KeyTab, 0, tcell.ModNone))
+		texts = append(texts, string(a.prompt.text))
+	}
+	assert.Equal(t, []string{"save ", "set ", "save "}, texts)
+	a.handleEvent(tcell.NewEventKey(tcell.KeyEscape, 0, tcell.ModNone))
+	assert.Nil(t, a.prompt)
+
+	assert.False(t, run("q"))
+}
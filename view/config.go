@@ -0,0 +1,443 @@
+package view
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/YLivay/gote/input"
+	"github.com/YLivay/gote/logging"
+	"golang.org/x/text/encoding"
+)
+
+// Config holds the settings the application was launched with.
+type Config struct {
+	// The name of the file to read. "-" reads from stdin. When files are
+	// merged, it names all of the inputs, and when listening, the address.
+	// When reading the journal, it is "journal".
+	Filename string
+	// The files, directories and glob patterns that were given to read. If
+	// there are several, or the one given is a directory or a pattern, the
+	// files they match are merged into one input: what they hold is read
+	// file by file, oldest modified first, and then the lines appended to any
+	// of them as they are written. Records aren't reordered by their
+	// timestamps. An HTTP, HTTPS or WebSocket URL is streamed instead, an
+	// s3:// or gs:// URL is downloaded, and the file at an ssh:// URL is
+	// tailed on its host. The topic at a kafka:// URL is consumed.
+	Inputs []string
+	// A URL like tcp://:5140 of an address to accept records on instead of
+	// reading files, or syslog messages with a scheme like syslog+udp. If
+	// empty, the inputs are read.
+	Listen string
+	// If true, an S3 or GCS object that was given to read is polled for
+	// bytes appended to it after it is downloaded.
+	FollowObject bool
+
+	// If true, the entries of the systemd journal are read instead of files,
+	// filtered to the given units and priority if they are set.
+	Journal         bool
+	JournalUnits    []string
+	JournalPriority string
+
+	// If true, the first run tutorial is never shown.
+	NoTutorial bool
+
+	// If true, where files are read isn't remembered, and reading them
+	// isn't offered to resume there.
+	NoResume bool
+
+	// If true, records are printed to stdout instead of being shown in the
+	// terminal UI, like they are when stdout isn't a terminal.
+	NoTUI bool
+	// If true, the terminal UI is shown even when stdout isn't a terminal,
+	// so the records marked in it can be piped onward.
+	TUI bool
+
+	// The number of records before the end of the input to start reading
+	// at, like tail -n. If 0, reading starts at the start of the input, or
+	// its end when following.
+	Tail int
+	// Where reading starts, as given with an argument like less's +G, +123
+	// or +/pattern, or nil to start where it otherwise would.
+	Start *startPosition
+
+	// The time range the records are restricted to, by their timestamps. A
+	// zero time leaves that end of it open.
+	Since time.Time
+	Until time.Time
+
+	// If true, records are not highlighted as JSON until toggled on.
+	NoHighlight bool
+	// If true, the records the filter excludes are shown dimmed instead of
+	// hidden, until toggled off.
+	DimExcluded bool
+	// Which records are loaded for an overview of a huge input, until
+	// switched to loading all of them.
+	Sample sampling
+
+	// The jq expression that selects records and transforms them to what is
+	// displayed, in place of ".", which shows them as they are, if it is set.
+	Filter string
+
+	// Rules of the form "EXPR -> STYLE" that style the records the jq
+	// expression selects. Earlier rules take priority.
+	HighlightRules []string
+	// Rules of the records that ring the bell and flash the screen when they
+	// are appended while following: jq expressions that select them, or
+	// /PATTERN/ regular expressions their lines match.
+	Alerts []string
+	// If true, alerts are also sent as desktop notifications while the
+	// terminal isn't focused, with notify-send or osascript, or with
+	// NotifyCommand if it is set.
+	Notify        bool
+	NotifyCommand string
+
+	// What the gutter on the left of the log lines shows.
+	Gutter gutterMode
+	// If true, the records of inputs merged from several files are prefixed
+	// with the names of the files they were read from, rather than only
+	// marked with their colors.
+	SourcePrefixes bool
+	// The unit the rate of records is shown per in a sparkline above the
+	// status bar while following. If 0, no sparkline is shown.
+	SparklineUnit time.Duration
+
+	// The name of the built-in theme to draw with. If empty, the default
+	// theme is used.
+	Theme string
+	// The name of the keymap profile keys are bound with, like "less". If
+	// empty, the default keymap is used.
+	Keymap string
+
+	// The number of columns between tab stops. If 0, the default is used.
+	TabWidth int
+	// What is done with ANSI escape sequences in records.
+	ANSIMode ansiMode
+
+	// How many lines to preload above and below the screen. If 0, twice the
+	// screen height is preloaded.
+	EagerBack    int
+	EagerForward int
+
+	// How many lines and megabytes the loaded records may hold before the
+	// ones far from the screen are pruned. A limit of 0 is unlimited.
+	MaxLines    int
+	MaxMemoryMB int
+
+	// The delimiter records end with in the input. If empty, it is a newline.
+	Delimiter []byte
+	// The pattern of the lines that start a record. Other lines are joined
+	// to the record before them. If empty, every line is read on its own.
+	RecordStart string
+	// The directory Lua scripts that filter and style records and add key
+	// actions are loaded from. If empty, the scripts directory in the state
+	// directory is used, unless NoScripts is set.
+	ScriptsDir string
+	NoScripts  bool
+	// A shell command every record is piped through before it is parsed,
+	// answering with the transformed record or an empty line to drop it. It
+	// keeps running, so it can't exit with a non-zero status to drop a
+	// record, and must flush its output after every line. If it fails,
+	// records are parsed untransformed. If empty, records are parsed as they
+	// are read.
+	Transform string
+
+	// How many kilobytes of a line are read into a record. Only the start of
+	// longer lines is shown. A limit of 0 is unlimited.
+	MaxRecordKB int
+
+	// How many megabytes of an input that can't be seeked, like stdin, are
+	// spilled into a temporary file before the spill policy applies. A limit
+	// of 0 is unlimited.
+	MaxSpillMB  int
+	SpillPolicy input.SpillPolicy
+	// The directory spill files are created in. If empty, it is the default
+	// directory for temporary files.
+	SpillDir string
+
+	// The character encoding of the input, which is transcoded to UTF-8 as
+	// it is read. If nil, the input is UTF-8.
+	Encoding encoding.Encoding
+
+	// If true, the rotated files of the input, like app.log.1 and
+	// app.log.2.gz, aren't read before it.
+	NoRotated bool
+
+	// If true, following continues with the new file at the input's path
+	// when it is rotated, instead of the originally opened file.
+	FollowName bool
+
+	// If true, regular files are read through a memory mapping instead of a
+	// system call per read.
+	Mmap bool
+	// If true, the line indexes of large files aren't kept in the user's
+	// cache directory, so they are indexed again whenever they are opened.
+	NoIndexCache bool
+
+	// The address to serve pprof profiles and internal counters on. If empty,
+	// they are not served.
+	DebugListen string
+	// The format gote's own diagnostics are written in, and the least levels
+	// of the ones that are written, by their component.
+	LogFormat logging.Format
+	LogLevels logging.Levels
+	// If true, the readers' diagnostics are written to a debug log in the
+	// user's cache directory, which is rotated once it holds DebugLogMaxMB
+	// megabytes.
+	DebugLog      bool
+	DebugLogMaxMB int
+
+	// A command that copied text is piped into. If empty, the known
+	// clipboard commands that are found, like pbcopy or xclip, are tried,
+	// and then the terminal is asked to copy it with OSC 52.
+	ClipboardCommand string
+}
+
+// stringsFlag is a flag that may be given multiple times, collecting all of
+// its values.
+type stringsFlag []string
+
+func (f *stringsFlag) String() string {
+	return strings.Join(*f, ", ")
+}
+
+func (f *stringsFlag) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
+// defaultFilter is the jq expression the gote command filters records with
+// unless -filter sets another: it shows when the Pelecard records were logged,
+// by whom and what they say.
+const defaultFilter = `. | .time /= 1000 | .time |= todateiso8601 | select(.name | test("Pelecard")) | {time, name, msg}`
+
+// ParseFlags parses the command line arguments (without the program name) into
+// a Config.
+func ParseFlags(args []string, output io.Writer) (*Config, error) {
+	config := &Config{}
+
+	flags := flag.NewFlagSet("gote", flag.ContinueOnError)
+	flags.SetOutput(output)
+	flags.Usage = func() {
+		fmt.Fprintln(output, "Usage: gote [options] [+G|+LINE|+/PATTERN] [file|directory|pattern...|url|s3://bucket/key|gs://bucket/key|ssh://host/path|kafka://broker/topic]")
+		fmt.Fprintln(output, "Several files, or a directory or pattern, are merged: what the files hold is shown file by file, oldest modified first, not interleaved by time, followed by the lines appended to any of them as they are written.")
+		flags.PrintDefaults()
+	}
+
+	flags.BoolVar(&config.NoTutorial, "no-tutorial", false, "never show the first run tutorial")
+	flags.StringVar(&config.Filter, "filter", defaultFilter, "jq expression that selects records and transforms them to what is shown, '.' to show them as they are")
+	flags.BoolVar(&config.NoTUI, "no-tui", false, "print the records to stdout, as they would be shown, instead of showing them. This is the default when stdout isn't a terminal")
+	flags.BoolVar(&config.TUI, "tui", false, "show the records even when stdout isn't a terminal, so the records marked while reading them, which are printed when quitting, can be piped onward")
+	flags.BoolVar(&config.NoResume, "no-resume", false, "don't remember where files are read, or offer to resume reading them there")
+	flags.IntVar(&config.Tail, "tail", 0, "start at the given number of records before the end of the input, like tail -n. Without the UI, only they are printed")
+	since := flags.String("since", "", "only read the records from this time on, like '2006-01-02T15:04:05Z', '2006-01-02' or '1h' for an hour ago. The input is searched for it, assuming it is in time order")
+	until := flags.String("until", "", "only read the records up to this time, in the same forms as -since")
+	flags.BoolVar(&config.NoHighlight, "no-highlight", false, "start with JSON syntax highlighting disabled")
+	flags.BoolVar(&config.DimExcluded, "dim-excluded", false, "show the records the filter excludes dimmed among the ones it matches instead of hiding them")
+	sample := flags.String("sample", "off", "for an overview of a huge input, only show every Nth line, or P% of the lines picked at random, until switched to showing all of them around the screen")
+	flags.Var((*stringsFlag)(&config.Alerts), "alert", "ring the bell and flash the screen when a record a jq expression selects, or whose line matches a /regular expression/, is appended while following, e.g. 'select(.level == \"error\")' or '/timeout/'. May be repeated")
+	flags.BoolVar(&config.Notify, "notify", false, "also send alerts as desktop notifications, with notify-send or osascript, while the terminal isn't focused. The terminal must report focus changes")
+	flags.StringVar(&config.NotifyCommand, "notify-cmd", "", "command to send desktop notifications with, given their summary and body as its last arguments. Implies -notify")
+	flags.Var((*stringsFlag)(&config.HighlightRules), "highlight-rule", "style records matching a jq expression, e.g. 'select(.level == \"error\") -> red background'. May be repeated, earlier rules take priority")
+
+	flags.StringVar(&config.ClipboardCommand, "clipboard-cmd", "", "command to pipe copied text into, e.g. 'xclip -selection clipboard'. Defaults to pbcopy, wl-copy, xclip or xsel, whichever is found and works, and then to asking the terminal with OSC 52")
+
+	flags.StringVar(&config.DebugListen, "debug-listen", "", "address to serve pprof profiles and internal counters on over HTTP, e.g. 'localhost:6060'")
+
+	flags.StringVar(&config.Theme, "theme", defaultThemeName, "color theme: "+strings.Join(themeNames(), ", "))
+	flags.StringVar(&config.Keymap, "keymap", "default", "key bindings: "+strings.Join(keymapNames(), ", ")+". The less keymap mirrors less, like space and b to page and g and G for the start and end. The vi keymap mirrors vi, with counts like 20j")
+
+	flags.IntVar(&config.TabWidth, "tab-width", defaultTabWidth, "number of columns between tab stops")
+
+	flags.IntVar(&config.EagerBack, "eager-back", 0, "lines to preload above the screen, 0 for twice the screen height")
+	flags.IntVar(&config.EagerForward, "eager-forward", 0, "lines to preload below the screen, 0 for twice the screen height")
+
+	flags.IntVar(&config.MaxLines, "max-lines", 0, "lines to keep loaded before pruning the ones far from the screen, 0 for unlimited")
+	delimiter := flags.String("delimiter", `\n`, "string records end with, with Go escapes, e.g. '\\x00' for the output of find -print0")
+	multiline := flags.Bool("multiline", false, "join lines that don't start a JSON object, like stack traces, to the record before them")
+	flags.StringVar(&config.RecordStart, "record-start", "", "regular expression matching the lines that start a record, joining the other lines to the record before them. Implies -multiline")
+	flags.StringVar(&config.ScriptsDir, "scripts", "", "directory to load Lua scripts that filter and style records and add key actions from, instead of the scripts directory in gote's config directory, like ~/.config/gote/scripts")
+	flags.BoolVar(&config.NoScripts, "no-scripts", false, "don't load Lua scripts")
+	flags.StringVar(&config.Transform, "transform", "", "shell command each record is piped through before it is parsed, answering each line with the transformed record or an empty line to drop it. It is started once and runs for as long as gote does, so an empty answer drops a record rather than a non-zero exit status, and it must flush its output after every line, like sed -u, jq --unbuffered or awk with fflush(). If it fails or doesn't answer within 5s, records are shown untransformed")
+	flags.IntVar(&config.MaxRecordKB, "max-record-size", defaultMaxRecordSize>>10, "kilobytes of a line to show, longer lines are truncated. 0 for unlimited")
+	flags.IntVar(&config.MaxMemoryMB, "max-memory", 256, "megabytes of records to keep loaded before pruning the ones far from the screen, 0 for unlimited")
+
+	flags.IntVar(&config.MaxSpillMB, "max-spill", 0, "megabytes of stdin or a pipe to spill into a temporary file before the spill policy applies, 0 for unlimited. Input that fails to be spilled, like when the disk is full, is paused")
+	flags.StringVar(&config.SpillDir, "spill-dir", "", "directory to create temporary spill files in, instead of the system's temporary directory. Spill files that crashed instances left there are removed on startup")
+	spillPolicy := flags.String("spill-policy", "pause", "what to do when the spill file is full: pause to stop reading the input for good, which fails printing the records without the terminal UI once what was spilled is printed, drop-oldest to drop its oldest half, or window to keep only its newest input without replacing it")
+
+	encodingName := flags.String("encoding", "utf-8", "character encoding of the input, e.g. utf-16le, utf-16be, latin1 or shift_jis. Other encodings are transcoded to UTF-8 through a temporary file")
+
+	flags.StringVar(&config.Listen, "listen", "", "accept records over the network instead of reading files, e.g. 'tcp://:5140' for a line per record or 'udp://:5140' for a datagram per record. 'syslog+udp://:514' or 'syslog+tcp://:514' parse syslog messages into JSON records")
+	flags.BoolVar(&config.Journal, "journal", false, "read the entries of the systemd journal as JSON records instead of files")
+	flags.Var((*stringsFlag)(&config.JournalUnits), "journal-unit", "only read the journal entries of a unit, e.g. 'nginx.service'. May be repeated. Implies -journal")
+	flags.StringVar(&config.JournalPriority, "journal-priority", "", "only read the journal entries of a priority, e.g. 'err' or 'emerg..warning'. Implies -journal")
+	flags.BoolVar(&config.NoRotated, "no-rotated", false, "don't read the rotated files of the input, like app.log.1 and app.log.2.gz, before it")
+	flags.BoolVar(&config.FollowObject, "follow-object", false, "after downloading an s3:// or gs:// object, poll it for appended bytes and read them too")
+	flags.BoolVar(&config.FollowName, "follow-name", false, "when following, reopen the file's path when it is renamed or replaced, like tail -F")
+	flags.BoolVar(&config.Mmap, "mmap", false, "read regular files through a memory mapping, faster for large files")
+	flags.BoolVar(&config.NoIndexCache, "no-index-cache", false, "don't keep the line indexes of large files in the user's cache directory, which lets them be jumped around in right away when opened again")
+
+	flags.BoolVar(&config.DebugLog, "debug-log", false, "write what the readers do to a debug log in the user's cache directory, like ~/.cache/gote/debug.log")
+	flags.IntVar(&config.DebugLogMaxMB, "debug-log-max", 10, "megabytes the debug log may hold before it is rotated, keeping the previous one with a .1 suffix. 0 for unlimited")
+	logFormatName := flags.String("log-format", "text", "the format of gote's own diagnostics: text, json for a JSON object per line that gote can read, or logfmt for key=value pairs")
+	logLevelsValue := flags.String("log-level", "info", "the least level of gote's own diagnostics that are logged: debug, info, warn or error. Components can be given their own, like 'warn,buffer.fwdReadLoop=debug'")
+	ansi := flags.String("ansi", "strip", "what to do with ANSI escape sequences in records: off, strip or color")
+	gutter := flags.String("gutter", "none", "what to show left of each record: none, line or offset")
+	flags.BoolVar(&config.SourcePrefixes, "source-prefix", false, "prefix the records of inputs merged from several files with the names of the files they were read from")
+	sparkline := flags.String("sparkline", "off", "while following, show the rate of records over time above the status bar, per second or minute as their timestamps tell: off, second or minute")
+
+	// Arguments like less's +G set where reading starts. They come before
+	// the inputs, and flags may follow them.
+	for {
+		if err := flags.Parse(args); err != nil {
+			return nil, err
+		}
+		args = flags.Args()
+		if len(args) == 0 || !strings.HasPrefix(args[0], "+") {
+			break
+		}
+		start, err := parseStartPosition(args[0])
+		if err != nil {
+			return nil, err
+		}
+		config.Start = start
+		args = args[1:]
+	}
+
+	if _, err := lookupTheme(config.Theme); err != nil {
+		return nil, err
+	}
+	if _, err := lookupKeymap(config.Keymap); err != nil {
+		return nil, err
+	}
+
+	if config.EagerBack < 0 || config.EagerForward < 0 {
+		return nil, fmt.Errorf("eagerness can't be negative")
+	}
+	if config.MaxLines < 0 || config.MaxMemoryMB < 0 {
+		return nil, fmt.Errorf("budget limits can't be negative")
+	}
+	if config.Tail < 0 {
+		return nil, fmt.Errorf("tail can't be negative")
+	}
+	if config.Tail > 0 && config.Start != nil {
+		return nil, fmt.Errorf("-tail can't be used with a start position")
+	}
+	if config.MaxRecordKB < 0 {
+		return nil, fmt.Errorf("record size limit can't be negative")
+	}
+	if config.DebugLogMaxMB < 0 {
+		return nil, fmt.Errorf("debug log limit can't be negative")
+	}
+	if config.MaxSpillMB < 0 {
+		return nil, fmt.Errorf("spill limit can't be negative")
+	}
+	if config.TUI && config.NoTUI {
+		return nil, fmt.Errorf("-tui and -no-tui can't be used together")
+	}
+	if config.TabWidth <= 0 {
+		return nil, fmt.Errorf("tab width must be positive, got %d", config.TabWidth)
+	}
+
+	var err error
+	if config.Gutter, err = parseGutterMode(*gutter); err != nil {
+		return nil, err
+	}
+	if config.SparklineUnit, err = parseSparklineUnit(*sparkline); err != nil {
+		return nil, err
+	}
+	if config.Sample, err = parseSampling(*sample); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if config.Since, err = parseTimeBound(*since, now); err != nil {
+		return nil, fmt.Errorf("invalid -since: %w", err)
+	}
+	if config.Until, err = parseTimeBound(*until, now); err != nil {
+		return nil, fmt.Errorf("invalid -until: %w", err)
+	}
+	if !config.Since.IsZero() && !config.Until.IsZero() && config.Until.Before(config.Since) {
+		return nil, fmt.Errorf("-until can't be before -since")
+	}
+	if config.ANSIMode, err = parseANSIMode(*ansi); err != nil {
+		return nil, err
+	}
+	if config.LogFormat, err = logging.ParseFormat(*logFormatName); err != nil {
+		return nil, err
+	}
+	if config.LogLevels, err = logging.ParseLevels(*logLevelsValue); err != nil {
+		return nil, err
+	}
+	if config.SpillPolicy, err = input.ParseSpillPolicy(*spillPolicy); err != nil {
+		return nil, err
+	}
+	if config.Delimiter, err = parseDelimiter(*delimiter); err != nil {
+		return nil, err
+	}
+	if config.Encoding, err = parseEncoding(*encodingName); err != nil {
+		return nil, err
+	}
+	if *multiline && config.RecordStart == "" {
+		config.RecordStart = defaultRecordStart
+	}
+	if _, err := regexp.Compile(config.RecordStart); err != nil {
+		return nil, fmt.Errorf("invalid record start pattern: %w", err)
+	}
+
+	if config.NotifyCommand != "" {
+		config.Notify = true
+	}
+	if config.Notify && len(config.Alerts) == 0 {
+		return nil, fmt.Errorf("-notify needs -alert rules to notify of")
+	}
+
+	if len(config.JournalUnits) > 0 || config.JournalPriority != "" {
+		config.Journal = true
+	}
+	if config.Journal {
+		if flags.NArg() > 0 || config.Listen != "" {
+			return nil, fmt.Errorf("the journal can't be read with other inputs")
+		}
+		if config.JournalPriority, err = input.ParseJournalPriority(config.JournalPriority); err != nil {
+			return nil, err
+		}
+		config.Filename = "journal"
+		return config, nil
+	}
+	if config.Listen != "" {
+		if flags.NArg() > 0 {
+			return nil, fmt.Errorf("files can't be read while listening")
+		}
+		if _, _, _, err := input.ParseListenAddress(config.Listen); err != nil {
+			return nil, err
+		}
+		config.Filename = config.Listen
+		return config, nil
+	}
+
+	config.Inputs = flags.Args()
+	if len(config.Inputs) == 0 {
+		config.Inputs = []string{"-"}
+	}
+	config.Filename = strings.Join(config.Inputs, " ")
+
+	return config, nil
+}
+
+// parseDelimiter parses the value of the -delimiter flag, which may have the
+// escapes a Go string can.
+func parseDelimiter(value string) ([]byte, error) {
+	delim, err := strconv.Unquote(`"` + strings.ReplaceAll(value, `"`, `\"`) + `"`)
+	if err != nil {
+		return nil, fmt.Errorf("invalid delimiter %q: %w", value, err)
+	}
+	if delim == "" {
+		return nil, fmt.Errorf("delimiter can't be empty")
+	}
+	return []byte(delim), nil
+}
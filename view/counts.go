@@ -0,0 +1,76 @@
+package view
+
+import (
+	"strconv"
+	"sync"
+)
+
+// recordCounts counts the records read from the part of the input the readers
+// went over since they last seeked, and how many of them the filter matched,
+// so an empty screen can be told apart from a filter that matches nothing. The
+// readers read outwards from where they seeked and read records again when
+// they move back to them, so only records before the first one counted or after
+// the last one are counted.
+type recordCounts struct {
+	mu sync.Mutex
+	// The offsets of the first and last records counted, or -1 if none was.
+	first, last int64
+	read        int64
+	matched     int64
+}
+
+func newRecordCounts() *recordCounts {
+	return &recordCounts{first: -1, last: -1}
+}
+
+// reset forgets the records counted so far.
+func (c *recordCounts) reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.first, c.last = -1, -1
+	c.read, c.matched = 0, 0
+}
+
+// count counts the record read at the given offset, unless it was already
+// counted.
+func (c *recordCounts) count(pos int64, matched bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch {
+	case c.first < 0:
+		c.first, c.last = pos, pos
+	case pos < c.first:
+		c.first = pos
+	case pos > c.last:
+		c.last = pos
+	default:
+		return
+	}
+	c.read++
+	if matched {
+		c.matched++
+	}
+}
+
+// get returns the number of records read and how many of them the filter
+// matched.
+func (c *recordCounts) get() (read, matched int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.read, c.matched
+}
+
+// formatCount formats a number with its thousands separated by commas.
+func formatCount(n int64) string {
+	if n < 0 {
+		return "-" + formatCount(-n)
+	}
+	s := strconv.FormatInt(n, 10)
+	for i := len(s) - 3; i > 0; i -= 3 {
+		s = s[:i] + "," + s[i:]
+	}
+	return s
+}
@@ -0,0 +1,56 @@
+package view
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordCounts_CountsRecordsOnce(t *testing.T) {
+	c := newRecordCounts()
+	c.count(100, true)
+	c.count(200, false)
+	c.count(50, true)
+	// Records read again when moving back to them aren't counted again.
+	c.count(100, true)
+	c.count(200, false)
+	read, matched := c.get()
+	assert.EqualValues(t, 3, read)
+	assert.EqualValues(t, 2, matched)
+
+	c.reset()
+	read, matched = c.get()
+	assert.EqualValues(t, 0, read)
+	assert.EqualValues(t, 0, matched)
+}
+
+func TestBuffer_CountsTheRecordsTheFilterMatches(t *testing.T) {
+	line := `{"time":1700000000000,"name":"Pelecard","msg":"hi"}` + "\n"
+	other := `{"time":1700000000000,"name":"Other","msg":"hi"}` + "\n"
+	file, _ := createTestFile(t, line+other+"not json\n"+line+other)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	buffer, err := NewBuffer(80, 10, false, file, ctx)
+	assert.NoError(t, err)
+	assert.NoError(t, buffer.SetFilter(defaultFilter))
+	assert.NoError(t, buffer.SeekAndPopulate(0, 0))
+	assert.Eventually(t, func() bool { return buffer.Status().Read == 5 }, time.Second, 5*time.Millisecond)
+	assert.EqualValues(t, 2, buffer.Status().Matched)
+
+	// A filter that excludes everything still reads the records.
+	assert.NoError(t, buffer.SetFilter("select(false)"))
+	assert.NoError(t, buffer.SeekAndPopulate(0, 0))
+	assert.Eventually(t, func() bool { return buffer.Status().Read == 5 }, time.Second, 5*time.Millisecond)
+	assert.EqualValues(t, 0, buffer.Status().Matched)
+}
+
+func TestFormatCount_SeparatesThousands(t *testing.T) {
+	assert.EqualValues(t, "0", formatCount(0))
+	assert.EqualValues(t, "999", formatCount(999))
+	assert.EqualValues(t, "1,000", formatCount(1000))
+	assert.EqualValues(t, "1,234,567", formatCount(1234567))
+	assert.EqualValues(t, "-12,345", formatCount(-12345))
+}
@@ -0,0 +1,180 @@
+package view
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/itchyny/gojq"
+)
+
+// csvField is a column of a CSV export: a jq path into the records, like
+// .time or .req.headers["user-agent"].
+type csvField struct {
+	path string
+	code *gojq.Code
+}
+
+// parseCSVFields parses the given comma separated jq paths into the columns of
+// a CSV export. Commas in strings, brackets and parentheses don't separate
+// paths.
+func parseCSVFields(value string) ([]csvField, error) {
+	var fields []csvField
+	for _, path := range splitCSVFields(value) {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+		query, err := gojq.Parse(path)
+		if err != nil {
+			return nil, fmt.Errorf("invalid field %q: %w", path, err)
+		}
+		code, err := gojq.Compile(query)
+		if err != nil {
+			return nil, fmt.Errorf("invalid field %q: %w", path, err)
+		}
+		fields = append(fields, csvField{path: path, code: code})
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("no fields to export")
+	}
+	return fields, nil
+}
+
+// splitCSVFields splits the given value on the commas that are outside of
+// strings, brackets and parentheses.
+func splitCSVFields(value string) []string {
+	var fields []string
+	depth, start := 0, 0
+	inString, escaped := false, false
+	for i, c := range value {
+		switch {
+		case escaped:
+			escaped = false
+		case inString:
+			switch c {
+			case '\\':
+				escaped = true
+			case '"':
+				inString = false
+			}
+		case c == '"':
+			inString = true
+		case c == '[' || c == '(' || c == '{':
+			depth++
+		case c == ']' || c == ')' || c == '}':
+			depth--
+		case c == ',' && depth == 0:
+			fields = append(fields, value[start:i])
+			start = i + 1
+		}
+	}
+	return append(fields, value[start:])
+}
+
+// writeCSV writes the records of the buffer between the given offsets to w as
+// CSV, with a header row of the given fields' paths and a row of their values
+// for each record. Fields are read from the records as they were before the jq
+// expression transformed them, so fields it leaves out can be exported too.
+//
+// It returns how many records were written.
+func writeCSV(w io.Writer, buffer *Buffer, fields []csvField, from, to int64) (int, error) {
+	cw := csv.NewWriter(w)
+	row := make([]string, len(fields))
+	for i, field := range fields {
+		row[i] = field.path
+	}
+	if err := cw.Write(row); err != nil {
+		return 0, err
+	}
+
+	n, err := buffer.EachRecord(from, to, func(r *record) error {
+		for i, field := range fields {
+			row[i] = csvCell(field.code, r.parsed)
+		}
+		return cw.Write(row)
+	})
+	if err != nil {
+		return n, err
+	}
+	cw.Flush()
+	return n, cw.Error()
+}
+
+// csvCell returns the value the given field has in the given record, as a CSV
+// cell. Strings are written as they are and other values as JSON. Fields the
+// record doesn't have, or that can't be read from it, are empty.
+func csvCell(code *gojq.Code, parsed any) string {
+	if parsed == nil {
+		// The record couldn't be parsed, like a line too long to read.
+		return ""
+	}
+	value, ok := code.Run(parsed).Next()
+	if !ok {
+		return ""
+	}
+	switch value := value.(type) {
+	case error, nil:
+		return ""
+	case string:
+		return value
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	}
+	data, err := marshalCell(value)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// startExportCSV prompts for the fields to export as CSV, and then for the path
+// to export them to, from the selected records, or all of them if there is no
+// selection.
+func (a *Application) startExportCSV() {
+	label := "Export CSV of the fields (e.g. .time,.msg): "
+	from, to, selected := a.selectedRange()
+	if selected {
+		label = "Export CSV of the selected records' fields (e.g. .time,.msg): "
+	}
+	a.clearSelection()
+
+	a.prompt = &prompt{
+		label: label,
+		submit: func(value string) {
+			fields, err := parseCSVFields(value)
+			if err != nil {
+				a.notifyError("exporting failed: " + err.Error())
+				return
+			}
+			a.prompt = &prompt{
+				label: "Export CSV to: ",
+				submit: func(path string) {
+					if path == "" {
+						return
+					}
+					a.saveRecords(path, func(w io.Writer) (int, error) {
+						return writeCSV(w, a.buffer, fields, from, to)
+					})
+				},
+			}
+		},
+	}
+}
+
+// marshalCell encodes the given value as JSON for a CSV cell. Cells hold the
+// values as the records do, so HTML in them isn't escaped like json.Marshal
+// does.
+func marshalCell(v any) ([]byte, error) {
+	var data bytes.Buffer
+	encoder := json.NewEncoder(&data)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(data.Bytes(), []byte{'\n'}), nil
+}
@@ -0,0 +1,44 @@
+package view
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/itchyny/gojq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCSVFields_SplitsOutsideOfStringsAndBrackets(t *testing.T) {
+	fields, err := parseCSVFields(`.time, .headers["a,b"], (.x | tostring)`)
+	assert.NoError(t, err)
+	paths := []string{}
+	for _, field := range fields {
+		paths = append(paths, field.path)
+	}
+	assert.EqualValues(t, []string{`.time`, `.headers["a,b"]`, `(.x | tostring)`}, paths)
+
+	_, err = parseCSVFields(" , ")
+	assert.Error(t, err)
+	_, err = parseCSVFields(".a[")
+	assert.Error(t, err)
+}
+
+func TestWriteCSV_WritesFieldsOfRecords(t *testing.T) {
+	file, _ := createTestFile(t, `{"msg":"hi, you","n":1.5,"tags":["a"]}`+"\nnot json\n"+`{"msg":"bye"}`+"\n")
+	buffer, err := NewBuffer(80, 2, false, file, context.Background())
+	assert.NoError(t, err)
+	parsed, err := gojq.Parse("{msg}")
+	assert.NoError(t, err)
+	buffer.jqExpr, err = gojq.Compile(parsed)
+	assert.NoError(t, err)
+
+	// Fields the filter left out of the records are exported too.
+	fields, err := parseCSVFields(".msg,.n,.tags,.missing")
+	assert.NoError(t, err)
+	var out bytes.Buffer
+	n, err := writeCSV(&out, buffer, fields, 0, 0)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, n)
+	assert.EqualValues(t, ".msg,.n,.tags,.missing\n\"hi, you\",1.5,\"[\"\"a\"\"]\",\nbye,,,\n", out.String())
+}
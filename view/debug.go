@@ -0,0 +1,90 @@
+package view
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"sync/atomic"
+	"time"
+
+	"github.com/YLivay/gote/logging"
+)
+
+// bufferStats counts what a buffer's readers did since it was created. They
+// are served by the debug server to help diagnose the readers.
+type bufferStats struct {
+	linesRead      atomic.Int64
+	recordsLoaded  atomic.Int64
+	recordsPruned  atomic.Int64
+	prunes         atomic.Int64
+	readerRestarts atomic.Int64
+}
+
+// debugCounters is a snapshot of a buffer's stats, as served by the debug
+// server.
+type debugCounters struct {
+	// The number of records loaded right now.
+	Records int `json:"records"`
+	// The number of lines the readers read, in both directions.
+	LinesRead int64 `json:"lines_read"`
+	// The number of records the readers loaded, including ones that were
+	// pruned since.
+	RecordsLoaded int64 `json:"records_loaded"`
+	// The number of records dropped by pruning.
+	RecordsPruned int64 `json:"records_pruned"`
+	// The number of times pruning was done.
+	Prunes int64 `json:"prunes"`
+	// The number of times the readers were started.
+	ReaderRestarts int64 `json:"reader_restarts"`
+}
+
+// debugCounters returns a snapshot of the buffer's stats.
+func (b *Buffer) debugCounters() debugCounters {
+	return debugCounters{
+		Records:        b.records.Len(),
+		LinesRead:      b.stats.linesRead.Load(),
+		RecordsLoaded:  b.stats.recordsLoaded.Load(),
+		RecordsPruned:  b.stats.recordsPruned.Load(),
+		Prunes:         b.stats.prunes.Load(),
+		ReaderRestarts: b.stats.readerRestarts.Load(),
+	}
+}
+
+// startDebugServer serves pprof profiles and the buffer's counters over HTTP
+// on the given address until the context is done, logging its failures to the
+// given logger. It returns the address it listens on, which is useful when the
+// given one has no port.
+func startDebugServer(ctx context.Context, addr string, buffer *Buffer, logger *logging.Logger) (net.Addr, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.HandleFunc("/debug/counters", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(buffer.debugCounters()); err != nil {
+			logger.Error("failed to write counters:", err.Error())
+		}
+	})
+
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Error("debug server failed:", err.Error())
+		}
+	}()
+	context.AfterFunc(ctx, func() {
+		server.Close()
+	})
+
+	return listener.Addr(), nil
+}
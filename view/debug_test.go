@@ -0,0 +1,46 @@
+package view
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDebugServer_ServesCounters(t *testing.T) {
+	line := `{"time":1700000000000,"name":"Pelecard","msg":"hi"}` + "\n"
+	file, _ := createTestFile(t, strings.Repeat(line, 5)+"not a json line\n")
+
+	ctx, cancelCtx := context.WithCancel(context.Background())
+	defer cancelCtx()
+
+	buffer, err := NewBuffer(80, 10, false, file, ctx)
+	assert.NoError(t, err)
+	err = buffer.SeekAndPopulate(0, io.SeekStart)
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool { return buffer.Status().Records == 5 }, time.Second, 5*time.Millisecond)
+
+	addr, err := startDebugServer(ctx, "127.0.0.1:0", buffer, buffer.logger)
+	assert.NoError(t, err)
+
+	resp, err := http.Get("http://" + addr.String() + "/debug/counters")
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	var counters debugCounters
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&counters))
+	assert.EqualValues(t, 5, counters.Records)
+	assert.EqualValues(t, 6, counters.LinesRead)
+	assert.EqualValues(t, 5, counters.RecordsLoaded)
+	assert.EqualValues(t, 1, counters.ReaderRestarts)
+
+	resp, err = http.Get("http://" + addr.String() + "/debug/pprof/goroutine?debug=1")
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
@@ -0,0 +1,22 @@
+package view
+
+import "github.com/YLivay/gote/logging"
+
+// LogRingSize is how many of the most recent lines of the readers' diagnostics
+// are kept for the debug panel.
+const LogRingSize = 1000
+
+// debugOverlay returns the overlay showing the most recent of the readers'
+// diagnostics that fit a screen of the given height.
+func debugOverlay(ring *logging.Ring, height int) *overlay {
+	// The box's border, title and footer take 6 lines.
+	lines := ring.Last(max(height-6, 1))
+	if len(lines) == 0 {
+		lines = []string{"Nothing was logged yet."}
+	}
+	return &overlay{
+		title:  "Recent internal events",
+		lines:  lines,
+		footer: "Esc: close",
+	}
+}
@@ -0,0 +1,31 @@
+package view
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplication_ShowsRecentInternalEvents(t *testing.T) {
+	file, _ := createTestFile(t, `{"time":1700000000000,"name":"Pelecard","msg":"hi"}`+"\n")
+
+	ctx, cancelCtx := context.WithCancel(context.Background())
+	defer cancelCtx()
+	screen := tcell.NewSimulationScreen("UTF-8")
+	assert.NoError(t, screen.Init())
+	defer screen.Fini()
+	screen.SetSize(80, 25)
+
+	a := NewApplication(file, false, &Config{NoTutorial: true})
+	assert.NoError(t, a.setup(ctx, screen))
+	// The readers' diagnostics are captured.
+	assert.Eventually(t, func() bool { return len(a.logRing.Last(1)) > 0 }, time.Second, 5*time.Millisecond)
+
+	a.handleEvent(tcell.NewEventKey(tcell.KeyRune, 'D', tcell.ModNone))
+	assert.True(t, a.showDebug)
+	a.handleEvent(tcell.NewEventKey(tcell.KeyEscape, 0, tcell.ModNone))
+	assert.False(t, a.showDebug)
+}
@@ -0,0 +1,130 @@
+package view
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+const (
+	// desktopNotifyInterval is the least time between desktop notifications,
+	// so a burst of alerts doesn't bury the desktop in them.
+	desktopNotifyInterval = 5 * time.Second
+	// maxNotificationLen is the most characters of a record a desktop
+	// notification shows.
+	maxNotificationLen = 200
+)
+
+// focusReporter is a screen that reports when the terminal gains or loses
+// focus, like a tcell.Screen.
+type focusReporter interface {
+	EnableFocus()
+}
+
+// desktopNotifyArgs returns the command that shows a desktop notification with
+// the given summary and body: notify-send, or osascript on macOS. If command is
+// set, it is run with them as its last arguments instead.
+func desktopNotifyArgs(command, summary, body string) ([]string, error) {
+	if command != "" {
+		return append(strings.Fields(command), summary, body), nil
+	}
+	if _, err := exec.LookPath("notify-send"); err == nil {
+		return []string{"notify-send", summary, body}, nil
+	}
+	if _, err := exec.LookPath("osascript"); err == nil {
+		script := fmt.Sprintf("display notification %s with title %s", appleScriptString(body), appleScriptString(summary))
+		return []string{"osascript", "-e", script}, nil
+	}
+	return nil, errors.New("neither notify-send nor osascript was found")
+}
+
+// appleScriptString quotes the given text as an AppleScript string.
+func appleScriptString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// desktopFlush is posted to the application once desktopNotifyInterval passed
+// since the last desktop notification, to send the one held back until then.
+type desktopFlush struct{}
+
+// countDesktopAlerts counts the records alert rules matched since it was last
+// called towards the next desktop notification, if the terminal isn't focused.
+// The ones matched while it is are seen on the screen.
+func (a *Application) countDesktopAlerts() {
+	alerts := a.buffer.Status().Alerts
+	if alerts < a.desktopSeen {
+		// Another input was opened, whose alerts are counted anew.
+		a.desktopSeen = 0
+	}
+	if a.unfocused {
+		a.desktopPending += alerts - a.desktopSeen
+	}
+	a.desktopSeen = alerts
+}
+
+// notifyDesktop sends a desktop notification of the records alert rules
+// matched while the terminal wasn't focused since the last one. Within
+// desktopNotifyInterval of the last one, it is sent once the interval passed
+// instead.
+func (a *Application) notifyDesktop() {
+	a.countDesktopAlerts()
+	if !a.unfocused || a.desktopPending <= 0 {
+		return
+	}
+	if wait := desktopNotifyInterval - time.Since(a.lastDesktopNotify); wait > 0 {
+		if !a.desktopFlushPending {
+			a.desktopFlushPending = true
+			screen := a.screen
+			time.AfterFunc(wait, func() {
+				screen.PostEvent(tcell.NewEventInterrupt(desktopFlush{}))
+			})
+		}
+		return
+	}
+	n := a.desktopPending
+	a.desktopPending = 0
+	a.lastDesktopNotify = time.Now()
+
+	summary := fmt.Sprintf("%s: %s", a.displayName(), formatAlerts(n))
+	body, _ := a.buffer.lastAlert.Load().(string)
+	if runes := []rune(body); len(runes) > maxNotificationLen {
+		body = string(runes[:maxNotificationLen-1]) + "…"
+	}
+	args, err := desktopNotifyArgs(a.config.NotifyCommand, summary, body)
+	if err != nil {
+		a.notifyError("desktop notification failed: " + err.Error())
+		return
+	}
+
+	logger := a.logger
+	go func() {
+		cmd := exec.Command(args[0], args[1:]...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			logger.Warn("desktop notification failed:", err.Error(), strings.TrimSpace(string(out)))
+		}
+	}()
+}
+
+// flushDesktop sends the desktop notification held back until
+// desktopNotifyInterval passed, if there still is one to send.
+func (a *Application) flushDesktop() {
+	a.desktopFlushPending = false
+	a.notifyDesktop()
+}
+
+// setFocused records whether the terminal is focused, as it reported. Once it
+// is, the alerts no desktop notification was sent of yet are seen on the
+// screen, so none is.
+func (a *Application) setFocused(ev *tcell.EventFocus) {
+	if a.config.Notify && a.buffer != nil {
+		a.countDesktopAlerts()
+	}
+	a.unfocused = !ev.Focused
+	if ev.Focused {
+		a.desktopPending = 0
+	}
+}
@@ -0,0 +1,89 @@
+package view
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDesktopNotifyArgs_AppendsTheNotificationToTheCommand(t *testing.T) {
+	args, err := desktopNotifyArgs("notify --urgency high", "app.log: [1 alert]", "boom")
+	assert.NoError(t, err)
+	assert.EqualValues(t, []string{"notify", "--urgency", "high", "app.log: [1 alert]", "boom"}, args)
+}
+
+func TestAppleScriptString(t *testing.T) {
+	assert.EqualValues(t, `"say \"hi\" \\ bye"`, appleScriptString(`say "hi" \ bye`))
+}
+
+func TestApplication_NotifiesTheDesktopWhileUnfocused(t *testing.T) {
+	line := `{"time":1700000000000,"name":"Pelecard","msg":"hi"}` + "\n"
+	alarming := `{"time":1700000000000,"name":"Pelecard","msg":"boom"}` + "\n"
+	file, _ := createTestFile(t, line)
+	out := filepath.Join(t.TempDir(), "notifications")
+	script := filepath.Join(t.TempDir(), "notify.sh")
+	assert.NoError(t, os.WriteFile(script, []byte("#!/bin/sh\necho \"$1|$2\" >> "+out+"\n"), 0755))
+
+	ctx, cancelCtx := context.WithCancel(context.Background())
+	defer cancelCtx()
+	screen := NewMemoryScreen(200, 10)
+	a := NewApplication(file, true, &Config{NoTutorial: true, Alerts: []string{"/boom/"}, Notify: true, NotifyCommand: script})
+	assert.NoError(t, a.setup(ctx, screen))
+	assert.Eventually(t, func() bool { return a.buffer.atEOF.Load() }, time.Second, 5*time.Millisecond)
+
+	w, err := os.OpenFile(file.Name(), os.O_APPEND|os.O_WRONLY, 0)
+	assert.NoError(t, err)
+	defer w.Close()
+	appendAndAlert := func() {
+		_, err = w.WriteString(alarming)
+		assert.NoError(t, err)
+		alerts := a.buffer.Status().Alerts
+		assert.Eventually(t, func() bool {
+			for len(screen.Events()) > 0 {
+				a.handleEvent(<-screen.Events())
+			}
+			return a.buffer.Status().Alerts > alerts && !a.buffer.alertPending.Load()
+		}, 2*time.Second, 5*time.Millisecond)
+	}
+
+	// Nothing is sent while the terminal is focused.
+	appendAndAlert()
+	time.Sleep(50 * time.Millisecond)
+	assert.NoFileExists(t, out)
+
+	a.handleEvent(tcell.NewEventFocus(false))
+	appendAndAlert()
+	sentLines := func() []string {
+		sent, _ := os.ReadFile(out)
+		return strings.Split(strings.TrimSuffix(string(sent), "\n"), "\n")
+	}
+	assert.Eventually(t, func() bool {
+		_, err := os.Stat(out)
+		return err == nil
+	}, 2*time.Second, 5*time.Millisecond)
+	// Only the alert raised while the terminal wasn't focused is counted.
+	if sent := sentLines(); assert.Len(t, sent, 1) {
+		assert.Contains(t, sent[0], ": [1 alert]|")
+		assert.Contains(t, sent[0], `"msg":"boom"`)
+	}
+
+	// The alerts raised too soon after the last notification are sent once
+	// desktopNotifyInterval passed, without waiting for another one.
+	a.lastDesktopNotify = time.Now().Add(500*time.Millisecond - desktopNotifyInterval)
+	appendAndAlert()
+	appendAndAlert()
+	assert.Len(t, sentLines(), 1)
+	assert.Eventually(t, func() bool {
+		for len(screen.Events()) > 0 {
+			a.handleEvent(<-screen.Events())
+		}
+		return len(sentLines()) == 2
+	}, 2*time.Second, 5*time.Millisecond)
+	assert.Contains(t, sentLines()[1], ": [2 alerts]|")
+}
@@ -0,0 +1,135 @@
+package view
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// detailView is a popup that shows a single record's original JSON, pretty
+// printed. It scrolls independently of the log lines behind it.
+type detailView struct {
+	// The byte offset of the record in the input file.
+	byteOffset int64
+	// The pretty printed JSON, split into lines.
+	lines []string
+	// The index of the first line shown in the popup.
+	offset int
+}
+
+// newDetailView creates a detail view for the given record, showing the given
+// original line of it followed by the lines joined to it.
+func newDetailView(r *record, raw []byte) *detailView {
+	var indented bytes.Buffer
+	var lines []string
+	if err := json.Indent(&indented, raw, "", "  "); err != nil {
+		// Records are parsed as JSON when they are loaded, unless they were
+		// truncated. Show it as is rather than nothing at all.
+		lines = []string{string(raw)}
+	} else {
+		lines = strings.Split(indented.String(), "\n")
+	}
+	lines = append(lines, continuationLines(r)...)
+
+	return &detailView{
+		byteOffset: r.byteOffset,
+		lines:      lines,
+	}
+}
+
+// scroll moves the view by the given number of lines, keeping at least one
+// page of lines visible.
+func (v *detailView) scroll(lines, pageHeight int) {
+	v.offset = min(v.offset+lines, len(v.lines)-pageHeight)
+	v.offset = max(v.offset, 0)
+}
+
+// drawDetailView draws the detail view as a popup that covers most of the
+// screen.
+func (a *Application) drawDetailView(v *detailView) {
+	left, top := 2, 1
+	right, bottom := a.width-3, a.height-2
+	if right-left < 4 || bottom-top < 4 {
+		return
+	}
+
+	style := a.theme.text
+	keyStyle := a.theme.json[tokenKey]
+	a.drawBox(left, top, right, bottom, style)
+
+	maxTextWidth := right - left - 3
+	title := fmt.Sprintf("Record at byte %d", v.byteOffset)
+	a.drawText(left+2, top, maxTextWidth, " "+title+" ", style.Bold(true))
+
+	pageHeight := a.detailPageHeight()
+	for i := 0; i < pageHeight && v.offset+i < len(v.lines); i++ {
+		line := v.lines[v.offset+i]
+		x, y := left+2, top+1+i
+
+		keyStart, keyEnd := jsonKeySpan(line)
+		if keyEnd > 0 {
+			x = a.drawText(x, y, maxTextWidth, line[:keyStart], style)
+			x = a.drawText(x, y, left+2+maxTextWidth-x, line[keyStart:keyEnd], keyStyle)
+			line = line[keyEnd:]
+		}
+		a.drawText(x, y, left+2+maxTextWidth-x, line, style)
+	}
+
+	footer := fmt.Sprintf(" %d-%d/%d  Esc: close ", v.offset+1, min(v.offset+pageHeight, len(v.lines)), len(v.lines))
+	a.drawText(left+2, bottom, maxTextWidth, footer, style.Dim(true))
+}
+
+// detailPageHeight returns the number of lines the detail view shows at once.
+func (a *Application) detailPageHeight() int {
+	// The popup leaves a row above and below it, plus its own border.
+	return max(a.height-5, 1)
+}
+
+// handleDetailKey scrolls or closes the detail view.
+func (a *Application) handleDetailKey(ev *tcell.EventKey, act action) {
+	pageHeight := a.detailPageHeight()
+	switch act {
+	case actionScrollUp:
+		a.detail.scroll(-1, pageHeight)
+	case actionScrollDown:
+		a.detail.scroll(1, pageHeight)
+	case actionPageUp:
+		a.detail.scroll(-pageHeight, pageHeight)
+	case actionPageDown:
+		a.detail.scroll(pageHeight, pageHeight)
+	case actionShowDetail, actionQuit:
+		a.detail = nil
+	default:
+		if ev.Key() == tcell.KeyEscape {
+			a.detail = nil
+		}
+	}
+}
+
+// jsonKeySpan finds the object key at the start of a pretty printed JSON line,
+// after its indentation. It returns the byte range of the key including its
+// quotes, or 0, 0 if the line doesn't start with a key.
+func jsonKeySpan(line string) (start, end int) {
+	start = len(line) - len(strings.TrimLeft(line, " "))
+	if start >= len(line) || line[start] != '"' {
+		return 0, 0
+	}
+
+	for i := start + 1; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			// Skip the escaped character.
+			i++
+		case '"':
+			// Only a string followed by a colon is a key.
+			if strings.HasPrefix(line[i+1:], ":") {
+				return start, i + 1
+			}
+			return 0, 0
+		}
+	}
+	return 0, 0
+}
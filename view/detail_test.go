@@ -0,0 +1,63 @@
+package view
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJsonKeySpan_FindsIndentedKey(t *testing.T) {
+	line := `  "name": "value"`
+	start, end := jsonKeySpan(line)
+	assert.EqualValues(t, `"name"`, line[start:end])
+}
+
+func TestJsonKeySpan_HandlesEscapedQuotes(t *testing.T) {
+	line := `"a\"b": 1`
+	start, end := jsonKeySpan(line)
+	assert.EqualValues(t, `"a\"b"`, line[start:end])
+}
+
+func TestJsonKeySpan_IgnoresArrayStrings(t *testing.T) {
+	start, end := jsonKeySpan(`    "value",`)
+	assert.EqualValues(t, 0, start)
+	assert.EqualValues(t, 0, end)
+}
+
+func TestNewDetailView_PrettyPrintsInOriginalOrder(t *testing.T) {
+	r := newRecord(10, []byte(`{"b":1}`))
+	r.raw = []byte(`{"z":1,"a":{"x":true}}`)
+
+	v := newDetailView(r, r.raw)
+	assert.EqualValues(t, []string{
+		`{`,
+		`  "z": 1,`,
+		`  "a": {`,
+		`    "x": true`,
+		`  }`,
+		`}`,
+	}, v.lines)
+}
+
+func TestDetailView_ScrollStaysInRange(t *testing.T) {
+	v := &detailView{lines: make([]string, 10)}
+
+	v.scroll(100, 4)
+	assert.EqualValues(t, 6, v.offset)
+
+	v.scroll(-100, 4)
+	assert.EqualValues(t, 0, v.offset)
+}
+
+func TestNewDetailView_ShowsJoinedLines(t *testing.T) {
+	r := newRecord(0, []byte(`{"a":1}`))
+	r.raw = r.buf
+	r = joinContinuation(r, []byte("  at one"), 8, false, parseOptions{tabWidth: defaultTabWidth, delimiter: []byte{'\n'}})
+
+	v := newDetailView(r, r.raw)
+	assert.EqualValues(t, []string{`{`, `  "a": 1`, `}`, `  at one`}, v.lines)
+	// Only the record's own text is highlighted as JSON.
+	for _, span := range r.Spans() {
+		assert.LessOrEqual(t, span.end, len(`{"a":1}`))
+	}
+}
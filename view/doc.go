@@ -0,0 +1,24 @@
+// Package view is the core of the gote log viewer: the Buffer that reads the
+// records of a log file around the screen in both directions, parses them as
+// JSON and transforms them with jq, and the terminal application that shows
+// them.
+//
+// Other tcell applications can embed a gote pane with a Viewer. It is started
+// on a region of the application's screen with Start, and is fed the events
+// the screen receives with HandleEvent, including the interrupts it posts to
+// the screen when records are read. Its region is moved or resized with
+// SetRegion.
+//
+// Library users react to what happens in a Buffer, like records being loaded
+// or the end of the input being reached, by registering hooks on it with
+// OnRecord, OnFilterChange, OnFollowChange and OnEOF. The records loaded in it
+// are iterated over with Records.
+//
+// The application draws on a Screen, which a tcell.Screen is. Frontends that
+// don't draw on a terminal, and tests, can draw on a MemoryScreen instead, and
+// read back what was drawn on it.
+//
+// The gote command parses its flags into a Config with ParseFlags, opens its
+// input with the input package, and shows it with an Application, or prints
+// it with RunHeadless without the terminal UI.
+package view
@@ -0,0 +1,36 @@
+package view
+
+import (
+	"bytes"
+	"fmt"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/htmlindex"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// parseEncoding looks up the encoding named by the -encoding flag. It returns
+// nil for UTF-8, which inputs are read in without transcoding them.
+func parseEncoding(name string) (encoding.Encoding, error) {
+	enc, err := htmlindex.Get(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown encoding %q", name)
+	}
+	if enc == unicode.UTF8 {
+		return nil, nil
+	}
+	return enc, nil
+}
+
+// utf8BOM is the byte order mark UTF-8 inputs may start with.
+var utf8BOM = []byte{0xef, 0xbb, 0xbf}
+
+// trimBOM removes the UTF-8 byte order mark from the start of a line read at
+// the given position, if it is the first line of the input. The mark is
+// invisible, but would make the line fail to parse as JSON.
+func trimBOM(pos int64, line []byte) []byte {
+	if pos != 0 {
+		return line
+	}
+	return bytes.TrimPrefix(line, utf8BOM)
+}
@@ -0,0 +1,26 @@
+package view
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseEncoding_ReadsUTF8WithoutTranscoding(t *testing.T) {
+	enc, err := parseEncoding("utf-8")
+	assert.NoError(t, err)
+	assert.Nil(t, enc)
+
+	enc, err = parseEncoding("latin1")
+	assert.NoError(t, err)
+	assert.NotNil(t, enc)
+
+	_, err = parseEncoding("klingon")
+	assert.Error(t, err)
+}
+
+func TestTrimBOM_OnlyTrimsTheFirstLine(t *testing.T) {
+	line := []byte("\xef\xbb\xbf{}")
+	assert.EqualValues(t, "{}", string(trimBOM(0, line)))
+	assert.EqualValues(t, line, trimBOM(10, line))
+}
@@ -0,0 +1,48 @@
+package view
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// estimateLines estimates the number of lines of an input of the given size
+// by the average length of the lines found in the part of it that was indexed,
+// which the estimate gets closer to the exact count as more of it is. It
+// returns 0 if nothing was indexed yet, and whether the count is exact.
+func estimateLines(indexedSize, indexedLines, size int64) (lines int64, exact bool) {
+	if indexedSize <= 0 || indexedLines <= 0 || size <= 0 {
+		return 0, false
+	}
+	if indexedSize >= size {
+		return indexedLines, true
+	}
+	return int64(float64(indexedLines) * float64(size) / float64(indexedSize)), false
+}
+
+// formatLines describes the number of lines of the input for the status bar,
+// approximately if it is estimated.
+func formatLines(lines int64, exact bool) string {
+	if exact {
+		return formatCount(lines) + " lines"
+	}
+	return "~" + formatApproxCount(lines) + " lines"
+}
+
+// formatApproxCount formats a number to two significant digits or more, with
+// the suffix of its magnitude, like 2.3M.
+func formatApproxCount(n int64) string {
+	units := []string{"", "K", "M", "B", "T"}
+	value := float64(n)
+	unit := 0
+	for value >= 1000 && unit < len(units)-1 {
+		value /= 1000
+		unit++
+	}
+	if unit == 0 {
+		return strconv.FormatInt(n, 10)
+	}
+	if value < 10 {
+		return fmt.Sprintf("%.1f%s", value, units[unit])
+	}
+	return fmt.Sprintf("%.0f%s", value, units[unit])
+}
@@ -0,0 +1,28 @@
+package view
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEstimateLines_RefinesAsMoreIsIndexed(t *testing.T) {
+	lines, exact := estimateLines(0, 0, 1000)
+	assert.Zero(t, lines)
+	assert.False(t, exact)
+
+	lines, exact = estimateLines(100, 4, 1000)
+	assert.EqualValues(t, 40, lines)
+	assert.False(t, exact)
+
+	lines, exact = estimateLines(1000, 37, 1000)
+	assert.EqualValues(t, 37, lines)
+	assert.True(t, exact)
+}
+
+func TestFormatLines(t *testing.T) {
+	assert.EqualValues(t, "~2.3M lines", formatLines(2_345_678, false))
+	assert.EqualValues(t, "~12K lines", formatLines(12_345, false))
+	assert.EqualValues(t, "~999 lines", formatLines(999, false))
+	assert.EqualValues(t, "12,345 lines", formatLines(12_345, true))
+}
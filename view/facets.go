@@ -0,0 +1,285 @@
+package view
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// maxFacetWidth is the widest the sidebar of a field's values gets, including
+// the line separating it from the records.
+const maxFacetWidth = 32
+
+// facetView is the sidebar that counts the values a field has in the loaded
+// records, and filters the records by one of them.
+type facetView struct {
+	// The field as it was given, and the keys leading to it.
+	field string
+	path  []string
+
+	// The values of the field, as they were last counted, most common first.
+	values []facetValue
+	// The index of the selected value, and of the first value shown.
+	selected int
+	top      int
+	// If true, keys move the selection instead of the records.
+	focused bool
+
+	// The value the records are filtered by, JSON encoded, if they are, and
+	// the filter before they were.
+	filtered   string
+	baseFilter string
+}
+
+// facetValue is a value of a field and the number of loaded records that have
+// it.
+type facetValue struct {
+	// The value JSON encoded, which is null for records that don't have the
+	// field, and as it is shown.
+	key   string
+	label string
+	count int
+}
+
+// parseFacetPath returns the keys leading to the given field, like ".http.status"
+// or "service".
+func parseFacetPath(field string) ([]string, error) {
+	field = strings.TrimPrefix(strings.TrimSpace(field), ".")
+	if field == "" {
+		return nil, errors.New("no field given")
+	}
+	path := strings.Split(field, ".")
+	for _, key := range path {
+		if key == "" {
+			return nil, fmt.Errorf("invalid field %q", field)
+		}
+	}
+	return path, nil
+}
+
+// lookupPath returns the value at the given path of a parsed record, or nil if
+// it has none.
+func lookupPath(value any, path []string) any {
+	for _, key := range path {
+		fields, ok := value.(map[string]any)
+		if !ok {
+			return nil
+		}
+		value = fields[key]
+	}
+	return value
+}
+
+// count counts the values of the field in the loaded records of the buffer.
+func (f *facetView) count(buffer *Buffer) {
+	counts := map[string]*facetValue{}
+	it := buffer.Records(context.Background())
+	for it.Next() {
+		value := lookupPath(it.Record().Parsed, f.path)
+		key, err := json.Marshal(value)
+		if err != nil {
+			continue
+		}
+		v, ok := counts[string(key)]
+		if !ok {
+			v = &facetValue{key: string(key), label: facetLabel(value, key)}
+			counts[string(key)] = v
+		}
+		v.count++
+	}
+
+	f.values = f.values[:0]
+	for _, v := range counts {
+		f.values = append(f.values, *v)
+	}
+	sort.Slice(f.values, func(i, j int) bool {
+		if f.values[i].count != f.values[j].count {
+			return f.values[i].count > f.values[j].count
+		}
+		return f.values[i].label < f.values[j].label
+	})
+	f.selected = min(f.selected, max(len(f.values)-1, 0))
+}
+
+// facetLabel returns how a value of a field is shown: strings as they are,
+// other values JSON encoded.
+func facetLabel(value any, key []byte) string {
+	switch value := value.(type) {
+	case nil:
+		return "(none)"
+	case string:
+		return value
+	}
+	return string(key)
+}
+
+// startFacets asks for a field and shows the sidebar of its values.
+func (a *Application) startFacets() {
+	a.prompt = &prompt{
+		label: "Count the values of field: ",
+		submit: func(field string) {
+			path, err := parseFacetPath(field)
+			if err != nil {
+				a.notifyError("counting values failed: " + err.Error())
+				return
+			}
+			a.facets = &facetView{field: field, path: path, focused: true}
+			a.resize(a.width, a.height)
+		},
+	}
+}
+
+// closeFacets hides the sidebar, and stops filtering the records by the
+// selected value.
+func (a *Application) closeFacets() {
+	f := a.facets
+	a.facets = nil
+	if f.filtered != "" {
+		if err := a.setFilter(f.baseFilter); err != nil {
+			a.notifyError("restoring the filter failed: " + err.Error())
+		}
+	}
+	a.resize(a.width, a.height)
+}
+
+// handleFacetKey handles a key press while the sidebar is focused. It returns
+// false if the key isn't the sidebar's.
+func (a *Application) handleFacetKey(ev *tcell.EventKey, act action) bool {
+	f := a.facets
+	switch {
+	case ev.Key() == tcell.KeyUp:
+		f.selected = max(f.selected-1, 0)
+	case ev.Key() == tcell.KeyDown:
+		f.selected = min(f.selected+1, max(len(f.values)-1, 0))
+	case ev.Key() == tcell.KeyEnter:
+		if f.selected < len(f.values) {
+			a.filterByFacet(f.values[f.selected])
+		}
+	case ev.Key() == tcell.KeyEscape || act == actionFocusFacets:
+		f.focused = false
+	case act == actionFacets:
+		a.closeFacets()
+	default:
+		return false
+	}
+	return true
+}
+
+// filterByFacet filters the records by the given value of the field, or stops
+// filtering by it if they already are.
+func (a *Application) filterByFacet(v facetValue) {
+	f := a.facets
+	if f.filtered == "" {
+		f.baseFilter = a.buffer.Status().Filter
+	}
+
+	query := f.baseFilter
+	filtered := ""
+	if v.key != f.filtered {
+		path, _ := json.Marshal(f.path)
+		query = fmt.Sprintf("select(getpath(%s) == %s) | %s", path, v.key, f.baseFilter)
+		filtered = v.key
+	}
+	if err := a.setFilter(query); err != nil {
+		a.notifyError("filtering failed: " + err.Error())
+		return
+	}
+	f.filtered = filtered
+}
+
+// clickFacet filters the records by the value on the given row of the sidebar.
+func (a *Application) clickFacet(y int) {
+	f := a.facets
+	// The first row is the field's name.
+	if i := f.top + y - 1; y > 0 && i < len(f.values) {
+		f.selected = i
+		a.filterByFacet(f.values[i])
+	}
+}
+
+// setFilter filters and transforms the records with the given jq query, and
+// reloads them where the screen is.
+func (a *Application) setFilter(query string) error {
+	if err := a.buffer.SetFilter(query); err != nil {
+		return err
+	}
+	return a.reload()
+}
+
+// setDimExcluded sets whether the records the filter excludes are shown dimmed
+// instead of hidden, and reloads the records where the screen is.
+func (a *Application) setDimExcluded(dim bool) error {
+	a.buffer.SetDimExcluded(dim)
+	return a.reload()
+}
+
+// reload loads the records again where the screen is, for changes to how they
+// are parsed to take effect.
+func (a *Application) reload() error {
+	a.clearSelection()
+	if a.followMode {
+		return a.buffer.SeekAndPopulate(0, io.SeekEnd)
+	}
+	return a.buffer.SeekAndPopulate(max(a.buffer.Status().ByteOffset, 0), io.SeekStart)
+}
+
+// facetWidth returns the width of the sidebar, or 0 if it isn't shown.
+func (a *Application) facetWidth() int {
+	if a.facets == nil {
+		return 0
+	}
+	return min(maxFacetWidth, a.width/3)
+}
+
+// drawFacets draws the sidebar at the right edge of the screen.
+func (a *Application) drawFacets() {
+	width := a.facetWidth()
+	height := a.viewHeight()
+	if width < 3 || height < 1 {
+		return
+	}
+	f := a.facets
+	f.count(a.buffer)
+
+	x := a.width - width
+	for y := 0; y < height; y++ {
+		a.screen.SetContent(x, y, tcell.RuneVLine, nil, a.theme.dim)
+	}
+	x++
+	width--
+
+	a.drawText(x, 0, width, fmt.Sprintf("%s (%d)", strings.TrimPrefix(f.field, "."), len(f.values)), a.theme.text.Bold(true))
+
+	// Keep the selected value on screen.
+	rows := height - 1
+	f.top = min(f.top, f.selected)
+	if f.selected >= f.top+rows {
+		f.top = f.selected - rows + 1
+	}
+	for i := f.top; i < len(f.values) && i < f.top+rows; i++ {
+		v := f.values[i]
+		y := i - f.top + 1
+		style := a.theme.text
+		if v.key == f.filtered {
+			style = style.Bold(true)
+		}
+		if f.focused && i == f.selected {
+			style = style.Reverse(true)
+		}
+
+		count := " " + strconv.Itoa(v.count)
+		labelWidth := max(width-len(count), 0)
+		end := a.drawText(x, y, labelWidth, v.label, style)
+		for ; end < x+labelWidth; end++ {
+			a.screen.SetContent(end, y, ' ', nil, style)
+		}
+		a.drawText(x+labelWidth, y, width-labelWidth, count, style)
+	}
+}
@@ -0,0 +1,70 @@
+package view
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFacets_CountValuesAndFilterByThem(t *testing.T) {
+	lines := []string{
+		`{"time":1700000000000,"name":"Pelecard","msg":"a","http":{"status":200}}`,
+		`{"time":1700000000000,"name":"Pelecard","msg":"b","http":{"status":500}}`,
+		`{"time":1700000000000,"name":"Pelecard","msg":"c","http":{"status":200}}`,
+		`{"time":1700000000000,"name":"Pelecard","msg":"d"}`,
+	}
+	file, _ := createTestFile(t, strings.Join(lines, "\n")+"\n")
+
+	ctx, cancelCtx := context.WithCancel(context.Background())
+	defer cancelCtx()
+	screen := NewMemoryScreen(90, 25)
+
+	a := NewApplication(file, false, &Config{NoTutorial: true})
+	assert.NoError(t, a.setup(ctx, screen))
+	assert.Eventually(t, func() bool { return a.buffer.Status().Records == 4 }, time.Second, 5*time.Millisecond)
+	filter := a.buffer.Status().Filter
+
+	a.handleEvent(tcell.NewEventKey(tcell.KeyRune, 'f', tcell.ModNone))
+	for _, ch := range ".http.status" {
+		a.handleEvent(tcell.NewEventKey(tcell.KeyRune, ch, tcell.ModNone))
+	}
+	a.handleEvent(tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModNone))
+	assert.NotNil(t, a.facets)
+	assert.EqualValues(t, []facetValue{
+		{key: "200", label: "200", count: 2},
+		{key: "null", label: "(none)", count: 1},
+		{key: "500", label: "500", count: 1},
+	}, a.facets.values)
+	// The sidebar takes the right of the screen.
+	assert.EqualValues(t, 90-scrollbarWidth-30, a.viewWidth())
+	assert.Contains(t, screen.Line(1), "│200")
+
+	// Filter by the most common value.
+	a.handleEvent(tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModNone))
+	assert.Eventually(t, func() bool { return a.buffer.Status().Records == 2 }, time.Second, 5*time.Millisecond)
+	assert.EqualValues(t, `select(getpath(["http","status"]) == 200) | `+filter, a.buffer.Status().Filter)
+
+	// Closing the sidebar stops filtering.
+	a.handleEvent(tcell.NewEventKey(tcell.KeyRune, 'f', tcell.ModNone))
+	assert.Nil(t, a.facets)
+	assert.EqualValues(t, filter, a.buffer.Status().Filter)
+	assert.Eventually(t, func() bool { return a.buffer.Status().Records == 4 }, time.Second, 5*time.Millisecond)
+	assert.EqualValues(t, 90-scrollbarWidth, a.viewWidth())
+}
+
+func TestParseFacetPath(t *testing.T) {
+	path, err := parseFacetPath(" .http.status")
+	assert.NoError(t, err)
+	assert.EqualValues(t, []string{"http", "status"}, path)
+	path, err = parseFacetPath("service")
+	assert.NoError(t, err)
+	assert.EqualValues(t, []string{"service"}, path)
+	_, err = parseFacetPath(".")
+	assert.Error(t, err)
+	_, err = parseFacetPath("a..b")
+	assert.Error(t, err)
+}
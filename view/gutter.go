@@ -0,0 +1,104 @@
+package view
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// gutterMode selects what the gutter on the left of the log lines shows.
+type gutterMode int
+
+const (
+	gutterNone gutterMode = iota
+	// Show the line number of each record in the input file.
+	gutterLineNumber
+	// Show the byte offset of each record in the input file.
+	gutterByteOffset
+)
+
+// The narrowest the gutter gets, including the space separating it from the
+// log lines.
+const minGutterWidth = 6
+
+// parseGutterMode parses a gutter mode as given on the command line.
+func parseGutterMode(value string) (gutterMode, error) {
+	switch value {
+	case "none":
+		return gutterNone, nil
+	case "line":
+		return gutterLineNumber, nil
+	case "offset":
+		return gutterByteOffset, nil
+	}
+	return gutterNone, fmt.Errorf("unknown gutter mode %q, expected none, line or offset", value)
+}
+
+// String returns the mode as it is given on the command line.
+func (m gutterMode) String() string {
+	switch m {
+	case gutterLineNumber:
+		return "line"
+	case gutterByteOffset:
+		return "offset"
+	}
+	return "none"
+}
+
+// next returns the mode that follows this one when cycling through them.
+func (m gutterMode) next() gutterMode {
+	return (m + 1) % 3
+}
+
+// gutterWidthFor returns the width of a gutter that fits the line numbers or
+// byte offsets of a file of the given size.
+func gutterWidthFor(mode gutterMode, fileSize int64) int {
+	if mode == gutterNone {
+		return 0
+	}
+	// A file can't have more lines than bytes, so its size bounds both.
+	return max(len(strconv.FormatInt(max(fileSize, 0), 10))+1, minGutterWidth)
+}
+
+// gutterLabel returns the text of the gutter for a line of the given record.
+// Only the first line of each record is labeled, the rest are marked as
+// continuation lines.
+func gutterLabel(mode gutterMode, width int, r *record, first bool) string {
+	if !first {
+		return fmt.Sprintf("%*s ", width-1, "↪")
+	}
+
+	var value int64
+	switch mode {
+	case gutterLineNumber:
+		value = r.lineNumber
+	case gutterByteOffset:
+		value = r.byteOffset
+	default:
+		return ""
+	}
+
+	// Line numbers are 0 when unknown, and byte offsets are negative.
+	if value < 0 || (mode == gutterLineNumber && value == 0) {
+		return fmt.Sprintf("%*s ", width-1, "?")
+	}
+	return fmt.Sprintf("%*d ", width-1, value)
+}
+
+// drawGutter draws the gutter of a single screen line.
+func (a *Application) drawGutter(y int, line renderLine) {
+	if a.gutter == gutterNone {
+		return
+	}
+
+	width := a.gutterWidth - a.sourceWidth()
+	label := gutterLabel(a.gutter, width, line.record, line.first)
+	a.drawText(0, y, width, label, a.theme.gutter)
+}
+
+// setGutter changes the gutter mode, and lays out the records again to fit
+// the width that is left for them next to it and the source column.
+func (a *Application) setGutter(mode gutterMode) {
+	a.gutter = mode
+	a.gutterWidth = gutterWidthFor(mode, a.buffer.Status().FileSize) + a.sourceWidth()
+	a.buffer.SetWidth(a.textWidth())
+}
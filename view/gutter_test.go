@@ -0,0 +1,35 @@
+package view
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGutterWidthFor_FitsFileSize(t *testing.T) {
+	assert.EqualValues(t, 0, gutterWidthFor(gutterNone, 1000))
+	assert.EqualValues(t, minGutterWidth, gutterWidthFor(gutterLineNumber, 1000))
+	assert.EqualValues(t, 9, gutterWidthFor(gutterByteOffset, 12345678))
+}
+
+func TestGutterLabel_LabelsFirstLine(t *testing.T) {
+	r := &record{byteOffset: 120, lineNumber: 7}
+	assert.EqualValues(t, "    7 ", gutterLabel(gutterLineNumber, 6, r, true))
+	assert.EqualValues(t, "  120 ", gutterLabel(gutterByteOffset, 6, r, true))
+	assert.EqualValues(t, "    ↪ ", gutterLabel(gutterLineNumber, 6, r, false))
+}
+
+func TestGutterLabel_UnknownPosition(t *testing.T) {
+	r := &record{byteOffset: -1}
+	assert.EqualValues(t, "    ? ", gutterLabel(gutterLineNumber, 6, r, true))
+	assert.EqualValues(t, "    ? ", gutterLabel(gutterByteOffset, 6, r, true))
+}
+
+func TestParseGutterMode(t *testing.T) {
+	mode, err := parseGutterMode("offset")
+	assert.NoError(t, err)
+	assert.EqualValues(t, gutterByteOffset, mode)
+
+	_, err = parseGutterMode("bytes")
+	assert.Error(t, err)
+}
@@ -0,0 +1,65 @@
+package view
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"os"
+
+	"github.com/YLivay/gote/input"
+	"github.com/YLivay/gote/logging"
+)
+
+// RunHeadless prints the records of the input file to w, each on its own line,
+// as they would be shown, without showing the terminal UI. This makes gote a
+// filter in shell pipelines.
+//
+// Regular files are printed up to their end. Spooled input, like stdin, is
+// printed as it is spilled until it ends, and it fails with
+// input.ErrInputPaused if the spool stops reading it before then. The buffer
+// reading them writes its diagnostics to debugLog.
+func RunHeadless(ctx context.Context, inputReader *os.File, spool *input.Spool, config *Config, debugLog, w io.Writer) error {
+	buffer, err := NewBuffer(80, 1, false, inputReader, ctx)
+	if err != nil {
+		return err
+	}
+	if err := configureBuffer(buffer, config, spool, logging.New(debugLog, config.LogFormat, config.LogLevels)); err != nil {
+		return err
+	}
+
+	out := bufio.NewWriter(w)
+	print := func(r *record) error {
+		out.Write(r.buf)
+		if err := out.WriteByte('\n'); err != nil {
+			return err
+		}
+		if spool != nil && spool.Status().Pending == 0 {
+			// The input may not be written to for a while, so what
+			// was printed of it so far isn't held back.
+			return out.Flush()
+		}
+		return nil
+	}
+	from := int64(0)
+	if config.Tail > 0 {
+		if from, err = buffer.tailOffset(config.Tail); err != nil {
+			return err
+		}
+	} else if config.Start != nil {
+		if from, err = config.Start.offset(buffer); err != nil {
+			return err
+		}
+	}
+	if spool != nil {
+		_, err = buffer.FollowRecords(from, spool.Changed(), print)
+		if err == nil && spool.Status().Paused {
+			err = input.ErrInputPaused
+		}
+	} else {
+		_, err = buffer.EachRecord(from, 0, print)
+	}
+	if flushErr := out.Flush(); err == nil {
+		err = flushErr
+	}
+	return err
+}
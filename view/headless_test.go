@@ -0,0 +1,124 @@
+package view
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/YLivay/gote/input"
+	"github.com/YLivay/gote/logging"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunHeadless_PrintsRecordsOfFile(t *testing.T) {
+	line := `{"time":1700000000000,"name":"Pelecard","msg":"hi"}`
+	other := `{"time":1700000000000,"name":"other","msg":"hi"}`
+	// The last line isn't ended by a newline.
+	file, _ := createTestFile(t, line+"\n"+other+"\n"+line)
+
+	var out bytes.Buffer
+	err := RunHeadless(context.Background(), file, nil, &Config{Filter: defaultFilter}, io.Discard, &out)
+	assert.NoError(t, err)
+	record := `{"msg":"hi","name":"Pelecard","time":"2023-11-14T22:13:20Z"}` + "\n"
+	assert.EqualValues(t, record+record, out.String())
+}
+
+func TestRunHeadless_PrintsTheLastRecords(t *testing.T) {
+	var lines []string
+	for i := 0; i < 10; i++ {
+		lines = append(lines, fmt.Sprintf(`{"time":1700000000000,"name":"Pelecard","msg":"%d"}`, i))
+	}
+	file, _ := createTestFile(t, strings.Join(lines, "\n")+"\n")
+
+	var out bytes.Buffer
+	err := RunHeadless(context.Background(), file, nil, &Config{Filter: defaultFilter, Tail: 2}, io.Discard, &out)
+	assert.NoError(t, err)
+	assert.EqualValues(t, `{"msg":"8","name":"Pelecard","time":"2023-11-14T22:13:20Z"}`+"\n"+`{"msg":"9","name":"Pelecard","time":"2023-11-14T22:13:20Z"}`+"\n", out.String())
+}
+
+func TestRunHeadless_PrintsTheRecordsWithinTheTimeRange(t *testing.T) {
+	var lines []string
+	for i := 0; i < 10; i++ {
+		lines = append(lines, fmt.Sprintf(`{"time":%d,"name":"Pelecard","msg":"%d"}`, 1700000000000+i*1000, i))
+	}
+	file, _ := createTestFile(t, strings.Join(lines, "\n")+"\n")
+
+	config := &Config{Filter: defaultFilter, Since: time.UnixMilli(1700000003000), Until: time.UnixMilli(1700000004000)}
+	var out bytes.Buffer
+	err := RunHeadless(context.Background(), file, nil, config, io.Discard, &out)
+	assert.NoError(t, err)
+	assert.EqualValues(t, `{"msg":"3","name":"Pelecard","time":"2023-11-14T22:13:23Z"}`+"\n"+`{"msg":"4","name":"Pelecard","time":"2023-11-14T22:13:24Z"}`+"\n", out.String())
+}
+
+func TestRunHeadless_PrintsSpooledInputUntilItEnds(t *testing.T) {
+	piped, pipedWriter := io.Pipe()
+	w, err := input.NewSpillFile(t.TempDir(), logging.New(io.Discard, logging.Text, logging.Levels{}))
+	assert.NoError(t, err)
+	spool := input.NewSpool(piped, w, 0, input.SpillPause, logging.New(io.Discard, logging.Text, logging.Levels{}))
+	defer spool.Close()
+	file, err := os.Open(w.Name())
+	assert.NoError(t, err)
+	defer file.Close()
+
+	line := `{"time":1700000000000,"name":"Pelecard","msg":"hi"}`
+	go func() {
+		pipedWriter.Write([]byte(line + "\n"))
+		time.Sleep(50 * time.Millisecond)
+		pipedWriter.Write([]byte(line))
+		pipedWriter.Close()
+	}()
+
+	var out bytes.Buffer
+	done := make(chan error)
+	go func() {
+		done <- RunHeadless(context.Background(), file, spool, &Config{Filter: defaultFilter}, io.Discard, &out)
+	}()
+
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the input to end")
+	}
+	record := `{"msg":"hi","name":"Pelecard","time":"2023-11-14T22:13:20Z"}` + "\n"
+	assert.EqualValues(t, record+record, out.String())
+}
+
+func TestRunHeadless_FailsWhenTheSpoolPausesTheInput(t *testing.T) {
+	piped, pipedWriter := io.Pipe()
+	defer pipedWriter.Close()
+	w, err := input.NewSpillFile(t.TempDir(), logging.New(io.Discard, logging.Text, logging.Levels{}))
+	assert.NoError(t, err)
+	line := `{"time":1700000000000,"name":"Pelecard","msg":"hi"}` + "\n"
+	spool := input.NewSpool(piped, w, int64(len(line)), input.SpillPause, logging.New(io.Discard, logging.Text, logging.Levels{}))
+	defer spool.Close()
+	file, err := os.Open(w.Name())
+	assert.NoError(t, err)
+	defer file.Close()
+
+	// The second line doesn't fit in the spill file, so the input is paused
+	// before it ends.
+	go func() {
+		pipedWriter.Write([]byte(line))
+		pipedWriter.Write([]byte(line))
+	}()
+
+	var out bytes.Buffer
+	done := make(chan error)
+	go func() {
+		done <- RunHeadless(context.Background(), file, spool, &Config{Filter: defaultFilter}, io.Discard, &out)
+	}()
+
+	select {
+	case err := <-done:
+		assert.ErrorIs(t, err, input.ErrInputPaused)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the input to be paused")
+	}
+	assert.EqualValues(t, `{"msg":"hi","name":"Pelecard","time":"2023-11-14T22:13:20Z"}`+"\n", out.String())
+}
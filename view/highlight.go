@@ -0,0 +1,98 @@
+package view
+
+// tokenClass classifies a span of a JSON document for highlighting.
+type tokenClass int
+
+const (
+	tokenNone tokenClass = iota
+	tokenKey
+	tokenString
+	tokenNumber
+	tokenLiteral
+)
+
+// jsonSpan is a span of bytes of a JSON document that belong to one token.
+type jsonSpan struct {
+	start, end int
+	class      tokenClass
+}
+
+// tokenizeJSON splits a JSON document into the spans that should be
+// highlighted, in order. It is lenient: bytes it doesn't recognize are skipped,
+// so invalid or truncated documents are highlighted as far as possible.
+func tokenizeJSON(buf []byte) []jsonSpan {
+	var spans []jsonSpan
+	for i := 0; i < len(buf); {
+		c := buf[i]
+		switch {
+		case c == '"':
+			end := i + 1
+			for end < len(buf) && buf[end] != '"' {
+				if buf[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			end = min(end+1, len(buf))
+
+			// A string followed by a colon is an object key.
+			class := tokenString
+			next := end
+			for next < len(buf) && isJSONSpace(buf[next]) {
+				next++
+			}
+			if next < len(buf) && buf[next] == ':' {
+				class = tokenKey
+			}
+
+			spans = append(spans, jsonSpan{start: i, end: end, class: class})
+			i = end
+		case c == '-' || (c >= '0' && c <= '9'):
+			end := i + 1
+			for end < len(buf) && isJSONNumberByte(buf[end]) {
+				end++
+			}
+			spans = append(spans, jsonSpan{start: i, end: end, class: tokenNumber})
+			i = end
+		case c >= 'a' && c <= 'z':
+			end := i + 1
+			for end < len(buf) && buf[end] >= 'a' && buf[end] <= 'z' {
+				end++
+			}
+			switch string(buf[i:end]) {
+			case "true", "false", "null":
+				spans = append(spans, jsonSpan{start: i, end: end, class: tokenLiteral})
+			}
+			i = end
+		default:
+			i++
+		}
+	}
+	return spans
+}
+
+func isJSONSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+func isJSONNumberByte(c byte) bool {
+	return (c >= '0' && c <= '9') || c == '.' || c == 'e' || c == 'E' || c == '+' || c == '-'
+}
+
+// classAt returns the class of the token at the given byte offset. spans must
+// be sorted, as returned by tokenizeJSON.
+func classAt(spans []jsonSpan, offset int) tokenClass {
+	lo, hi := 0, len(spans)
+	for lo < hi {
+		mid := (lo + hi) / 2
+		switch {
+		case offset < spans[mid].start:
+			hi = mid
+		case offset >= spans[mid].end:
+			lo = mid + 1
+		default:
+			return spans[mid].class
+		}
+	}
+	return tokenNone
+}
@@ -0,0 +1,154 @@
+package view
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/itchyny/gojq"
+)
+
+// highlightRule styles the records a jq expression selects.
+type highlightRule struct {
+	// The rule as it was given.
+	source string
+	// The compiled jq expression. A record matches if the expression yields a
+	// value other than false or null for it.
+	expr *gojq.Code
+	// The style to apply to matching records.
+	style tcell.Style
+}
+
+// parseHighlightRules parses rules of the form "EXPR -> STYLE". Rules are
+// returned in the order they were given, which is also their priority.
+func parseHighlightRules(sources []string) ([]highlightRule, error) {
+	rules := make([]highlightRule, 0, len(sources))
+	for _, source := range sources {
+		rule, err := parseHighlightRule(source)
+		if err != nil {
+			return nil, fmt.Errorf("invalid highlight rule %q: %w", source, err)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+func parseHighlightRule(source string) (highlightRule, error) {
+	idx := strings.LastIndex(source, "->")
+	if idx == -1 {
+		return highlightRule{}, fmt.Errorf("expected EXPR -> STYLE")
+	}
+
+	query, err := gojq.Parse(strings.TrimSpace(source[:idx]))
+	if err != nil {
+		return highlightRule{}, err
+	}
+	expr, err := gojq.Compile(query)
+	if err != nil {
+		return highlightRule{}, err
+	}
+
+	style, err := parseStyle(source[idx+2:])
+	if err != nil {
+		return highlightRule{}, err
+	}
+
+	return highlightRule{source: source, expr: expr, style: style}, nil
+}
+
+// parseStyle parses a style description such as "bold red", "red background"
+// or "white on red". A color followed by "background" or preceded by "on" is
+// the background color, other colors are the foreground color.
+func parseStyle(spec string) (tcell.Style, error) {
+	words := strings.Fields(strings.ToLower(spec))
+	if len(words) == 0 {
+		return tcell.StyleDefault, fmt.Errorf("empty style")
+	}
+
+	style := tcell.StyleDefault
+	for i := 0; i < len(words); i++ {
+		switch words[i] {
+		case "bold":
+			style = style.Bold(true)
+		case "dim":
+			style = style.Dim(true)
+		case "italic":
+			style = style.Italic(true)
+		case "underline":
+			style = style.Underline(true)
+		case "reverse":
+			style = style.Reverse(true)
+		case "blink":
+			style = style.Blink(true)
+		case "on":
+			if i+1 >= len(words) {
+				return style, fmt.Errorf("expected a color after \"on\"")
+			}
+			color := tcell.GetColor(words[i+1])
+			if color == tcell.ColorDefault {
+				return style, fmt.Errorf("unknown color %q", words[i+1])
+			}
+			style = style.Background(color)
+			i++
+		default:
+			color := tcell.GetColor(words[i])
+			if color == tcell.ColorDefault {
+				return style, fmt.Errorf("unknown style %q", words[i])
+			}
+			if i+1 < len(words) && words[i+1] == "background" {
+				style = style.Background(color)
+				i++
+			} else {
+				style = style.Foreground(color)
+			}
+		}
+	}
+	return style, nil
+}
+
+// matches returns true if the rule's expression selects the given value.
+func (r *highlightRule) matches(value any) bool {
+	return selects(r.expr, value)
+}
+
+// selects returns true if the given jq expression yields a value other than
+// false or null for the given value.
+func selects(expr *gojq.Code, value any) bool {
+	iter := expr.Run(value)
+	for {
+		result, ok := iter.Next()
+		if !ok {
+			return false
+		}
+		if _, isErr := result.(error); isErr {
+			return false
+		}
+		if result != nil && result != false {
+			return true
+		}
+	}
+}
+
+// matchHighlightRules returns the style of the first rule that matches the
+// given value.
+func matchHighlightRules(rules []highlightRule, value any) (tcell.Style, bool) {
+	for i := range rules {
+		if rules[i].matches(value) {
+			return rules[i].style, true
+		}
+	}
+	return tcell.StyleDefault, false
+}
+
+// mergeStyle applies the colors and attributes set in overlay on top of base.
+func mergeStyle(base, overlay tcell.Style) tcell.Style {
+	fg, bg, attrs := overlay.Decompose()
+	if fg != tcell.ColorDefault {
+		base = base.Foreground(fg)
+	}
+	if bg != tcell.ColorDefault {
+		base = base.Background(bg)
+	}
+	_, _, baseAttrs := base.Decompose()
+	return base.Attributes(baseAttrs | attrs)
+}
@@ -0,0 +1,53 @@
+package view
+
+import (
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseStyle_ParsesColorsAndAttributes(t *testing.T) {
+	style, err := parseStyle("bold white on red")
+	assert.NoError(t, err)
+	assert.EqualValues(t, tcell.StyleDefault.Bold(true).Foreground(tcell.ColorWhite).Background(tcell.ColorRed), style)
+
+	style, err = parseStyle("red background")
+	assert.NoError(t, err)
+	assert.EqualValues(t, tcell.StyleDefault.Background(tcell.ColorRed), style)
+}
+
+func TestParseStyle_RejectsUnknownWords(t *testing.T) {
+	_, err := parseStyle("sparkly")
+	assert.Error(t, err)
+}
+
+func TestParseHighlightRules_RequiresArrow(t *testing.T) {
+	_, err := parseHighlightRules([]string{`select(.level == "error")`})
+	assert.Error(t, err)
+}
+
+func TestMatchHighlightRules_FirstMatchWins(t *testing.T) {
+	rules, err := parseHighlightRules([]string{
+		`select(.level == "error") -> red background`,
+		`.level -> yellow`,
+	})
+	assert.NoError(t, err)
+
+	style, ok := matchHighlightRules(rules, map[string]any{"level": "error"})
+	assert.True(t, ok)
+	assert.EqualValues(t, tcell.StyleDefault.Background(tcell.ColorRed), style)
+
+	style, ok = matchHighlightRules(rules, map[string]any{"level": "info"})
+	assert.True(t, ok)
+	assert.EqualValues(t, tcell.StyleDefault.Foreground(tcell.ColorYellow), style)
+
+	_, ok = matchHighlightRules(rules, map[string]any{"msg": "no level"})
+	assert.False(t, ok)
+}
+
+func TestMergeStyle_KeepsUnsetParts(t *testing.T) {
+	base := tcell.StyleDefault.Foreground(tcell.ColorGreen)
+	merged := mergeStyle(base, tcell.StyleDefault.Background(tcell.ColorRed).Bold(true))
+	assert.EqualValues(t, tcell.StyleDefault.Foreground(tcell.ColorGreen).Background(tcell.ColorRed).Bold(true), merged)
+}
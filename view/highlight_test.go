@@ -0,0 +1,42 @@
+package view
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenizeJSON_ClassifiesTokens(t *testing.T) {
+	buf := []byte(`{"a":"b","n":-1.5e3,"t":true,"z":null}`)
+	spans := tokenizeJSON(buf)
+
+	var got []string
+	var classes []tokenClass
+	for _, span := range spans {
+		got = append(got, string(buf[span.start:span.end]))
+		classes = append(classes, span.class)
+	}
+
+	assert.EqualValues(t, []string{`"a"`, `"b"`, `"n"`, `-1.5e3`, `"t"`, `true`, `"z"`, `null`}, got)
+	assert.EqualValues(t, []tokenClass{tokenKey, tokenString, tokenKey, tokenNumber, tokenKey, tokenLiteral, tokenKey, tokenLiteral}, classes)
+}
+
+func TestTokenizeJSON_HandlesEscapesAndTruncation(t *testing.T) {
+	buf := []byte(`{"a\"b": "unterminated`)
+	spans := tokenizeJSON(buf)
+
+	assert.Len(t, spans, 2)
+	assert.EqualValues(t, `"a\"b"`, string(buf[spans[0].start:spans[0].end]))
+	assert.EqualValues(t, tokenKey, spans[0].class)
+	assert.EqualValues(t, `"unterminated`, string(buf[spans[1].start:spans[1].end]))
+}
+
+func TestClassAt_FindsSpan(t *testing.T) {
+	spans := tokenizeJSON([]byte(`{"a": 12}`))
+
+	assert.EqualValues(t, tokenNone, classAt(spans, 0))
+	assert.EqualValues(t, tokenKey, classAt(spans, 2))
+	assert.EqualValues(t, tokenNone, classAt(spans, 5))
+	assert.EqualValues(t, tokenNumber, classAt(spans, 7))
+	assert.EqualValues(t, tokenNone, classAt(spans, 8))
+}
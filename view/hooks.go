@@ -0,0 +1,104 @@
+package view
+
+import (
+	"sync"
+)
+
+// hooks are the functions registered to be called with a kind of event. The
+// zero value has no hooks registered.
+type hooks[T any] struct {
+	mu    sync.Mutex
+	next  int
+	hooks []hook[T]
+}
+
+type hook[T any] struct {
+	id int
+	fn func(T)
+}
+
+// add registers fn and returns a function that unregisters it.
+func (h *hooks[T]) add(fn func(T)) func() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	id := h.next
+	h.next++
+	h.hooks = append(h.hooks, hook[T]{id: id, fn: fn})
+	return func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+
+		for i := range h.hooks {
+			if h.hooks[i].id == id {
+				h.hooks = append(h.hooks[:i:i], h.hooks[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// registered returns true if any hooks are registered, so events that are
+// costly to create aren't created for no one.
+func (h *hooks[T]) registered() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return len(h.hooks) > 0
+}
+
+// call calls the registered hooks with the given event, in the order they were
+// registered. Hooks registered or unregistered meanwhile don't affect it.
+func (h *hooks[T]) call(event T) {
+	h.mu.Lock()
+	registered := h.hooks
+	h.mu.Unlock()
+
+	for _, hook := range registered {
+		hook.fn(event)
+	}
+}
+
+// OnRecord registers fn to be called with every record the buffer's readers
+// load, in the order they are loaded in. Records are loaded again when the
+// buffer moves back to them, so a record may be given more than once. It
+// returns a function that unregisters fn.
+//
+// Hooks are called by the readers, which wait for them to return, so they must
+// not block for long nor call back into the buffer.
+func (b *Buffer) OnRecord(fn func(Record)) (unregister func()) {
+	return b.recordHooks.add(fn)
+}
+
+// OnFilterChange registers fn to be called with the filter's query when it is
+// changed with SetFilter. It returns a function that unregisters fn.
+func (b *Buffer) OnFilterChange(fn func(filter string)) (unregister func()) {
+	return b.filterHooks.add(fn)
+}
+
+// OnFollowChange registers fn to be called with the follow mode when it is
+// changed with SetFollowMode. It returns a function that unregisters fn.
+func (b *Buffer) OnFollowChange(fn func(followMode bool)) (unregister func()) {
+	return b.followHooks.add(fn)
+}
+
+// OnEOF registers fn to be called with the input's size when the forwards
+// reader reaches its end. When following, it is called again every time the
+// reader reaches the end of what was appended since. It returns a function that
+// unregisters fn. Like OnRecord's, hooks are called by the readers.
+func (b *Buffer) OnEOF(fn func(size int64)) (unregister func()) {
+	return b.eofHooks.add(fn)
+}
+
+// exportRecords calls the record hooks with the given records, which were just
+// loaded.
+func (b *Buffer) exportRecords(records []*record) {
+	if !b.recordHooks.registered() {
+		return
+	}
+	for _, r := range records {
+		if !r.excluded {
+			b.recordHooks.call(r.export())
+		}
+	}
+}
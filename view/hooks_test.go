@@ -0,0 +1,63 @@
+package view
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuffer_CallsHooks(t *testing.T) {
+	line := `{"time":1700000000000,"name":"Pelecard","msg":"hi"}`
+	file, _ := createTestFile(t, line+"\n"+line+"\n")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	buffer, err := NewBuffer(80, 10, false, file, ctx)
+	assert.NoError(t, err)
+	assert.NoError(t, buffer.SetFilter(defaultFilter))
+
+	var mu sync.Mutex
+	var records []Record
+	var eofs []int64
+	buffer.OnRecord(func(r Record) {
+		mu.Lock()
+		defer mu.Unlock()
+		records = append(records, r)
+	})
+	buffer.OnEOF(func(size int64) {
+		mu.Lock()
+		defer mu.Unlock()
+		eofs = append(eofs, size)
+	})
+	var filters []string
+	buffer.OnFilterChange(func(filter string) { filters = append(filters, filter) })
+	var follows []bool
+	unregister := buffer.OnFollowChange(func(follow bool) { follows = append(follows, follow) })
+
+	assert.NoError(t, buffer.SeekAndPopulate(0, 0))
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(records) == 2 && len(eofs) == 1
+	}, time.Second, 5*time.Millisecond)
+	mu.Lock()
+	assert.EqualValues(t, []int64{0, int64(len(line) + 1)}, []int64{records[0].Offset, records[1].Offset})
+	assert.EqualValues(t, line, records[0].Raw)
+	assert.EqualValues(t, `{"msg":"hi","name":"Pelecard","time":"2023-11-14T22:13:20Z"}`, records[0].Text)
+	assert.EqualValues(t, "hi", records[0].Parsed.(map[string]any)["msg"])
+	assert.EqualValues(t, []int64{2 * int64(len(line)+1)}, eofs)
+	mu.Unlock()
+
+	assert.NoError(t, buffer.SetFilter(".msg"))
+	assert.Error(t, buffer.SetFilter(".msg |"))
+	assert.EqualValues(t, []string{".msg"}, filters)
+	assert.EqualValues(t, ".msg", buffer.Status().Filter)
+
+	buffer.SetFollowMode(true)
+	unregister()
+	buffer.SetFollowMode(false)
+	assert.EqualValues(t, []bool{true}, follows)
+}
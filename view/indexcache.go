@@ -0,0 +1,167 @@
+package view
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/YLivay/gote/logging"
+	"github.com/YLivay/gote/reader"
+)
+
+// The name of the directory the line indexes of large files are kept in,
+// within the cache directory.
+const indexCacheDirName = "index"
+
+// minIndexCacheSize is the smallest file whose line index is kept. Smaller
+// ones are indexed again quickly enough.
+const minIndexCacheSize = 32 << 20
+
+// maxCachedIndexes is how many files' line indexes are kept. The ones written
+// the longest ago are removed first.
+const maxCachedIndexes = 100
+
+// indexCacheDir returns the directory the line indexes of large files are kept
+// in, in the user's cache directory.
+func indexCacheDir() (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to determine user cache directory: %w", err)
+	}
+	return filepath.Join(cacheDir, "gote", indexCacheDirName), nil
+}
+
+// indexCachePath returns the path the line index of the given file is kept at
+// in the given directory. Files are told apart by their device and inode
+// rather than their paths, so a file that is renamed keeps its index and a new
+// file in its place doesn't take it. It returns false if the file can't be
+// told apart like that.
+func indexCachePath(dir string, info os.FileInfo) (string, bool) {
+	key, ok := fileKey(info)
+	if !ok {
+		return "", false
+	}
+	return filepath.Join(dir, key+".idx"), true
+}
+
+// loadLineIndex reads the line index kept for the given file into index. An
+// index kept for what was in the file before it was rewritten is removed.
+func loadLineIndex(dir string, index *reader.LineIndex, file *os.File) error {
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	path, ok := indexCachePath(dir, info)
+	if !ok || info.Size() < minIndexCacheSize {
+		return nil
+	}
+
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	_, err = index.ReadFrom(f)
+	f.Close()
+	if errors.Is(err, reader.ErrIndexMismatch) {
+		os.Remove(path)
+		return nil
+	}
+	return err
+}
+
+// saveLineIndex keeps the line index of the given file, if it is large enough
+// to be worth it.
+func saveLineIndex(dir string, index *reader.LineIndex, file *os.File) error {
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	path, ok := indexCachePath(dir, info)
+	if !ok || info.Size() < minIndexCacheSize {
+		return nil
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create index directory: %w", err)
+	}
+
+	// Write a new file in place of the old one, so other instances never
+	// read a partial index.
+	f, err := os.CreateTemp(dir, filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	_, err = index.WriteTo(f)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return fmt.Errorf("failed to write line index: %w", err)
+	}
+	return pruneIndexCache(dir)
+}
+
+// pruneIndexCache removes the line indexes written the longest ago, beyond
+// maxCachedIndexes.
+func pruneIndexCache(dir string) error {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.idx"))
+	if err != nil || len(paths) <= maxCachedIndexes {
+		return err
+	}
+	infos := make(map[string]os.FileInfo, len(paths))
+	for _, path := range paths {
+		if info, err := os.Stat(path); err == nil {
+			infos[path] = info
+		}
+	}
+	paths = slices.DeleteFunc(paths, func(path string) bool { return infos[path] == nil })
+	slices.SortFunc(paths, func(a, b string) int {
+		return infos[b].ModTime().Compare(infos[a].ModTime())
+	})
+	for _, path := range paths[min(maxCachedIndexes, len(paths)):] {
+		os.Remove(path)
+	}
+	return nil
+}
+
+// SetIndexCache keeps the line indexes of large input files in the given
+// directory, so opening them again doesn't index them again. Spooled inputs
+// aren't kept. It must be set before the buffer is first populated, when the
+// input starts being indexed.
+func (b *Buffer) SetIndexCache(dir string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.indexCache = dir
+}
+
+// buildLineIndex builds the line index of the given input file. With an index
+// cache directory, it goes on from the index kept there, and keeps the index
+// there once it is built.
+func buildLineIndex(ctx context.Context, index *reader.LineIndex, file *os.File, cacheDir string, logger *logging.Logger) {
+	if cacheDir != "" {
+		if err := loadLineIndex(cacheDir, index, file); err != nil {
+			logger.Warn("failed to read kept line index:", err.Error())
+		}
+	}
+	loaded, _ := index.Indexed()
+	if err := index.Build(ctx); err != nil {
+		if ctx.Err() == nil {
+			logger.Error("failed to build line index:", err.Error())
+		}
+		return
+	}
+	if size, _ := index.Indexed(); cacheDir != "" && size > loaded {
+		if err := saveLineIndex(cacheDir, index, file); err != nil {
+			logger.Warn("failed to keep line index:", err.Error())
+		}
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !unix
+
+package view
+
+import "os"
+
+// fileKey returns false, since files have no inodes to tell them apart by.
+func fileKey(info os.FileInfo) (string, bool) {
+	return "", false
+}
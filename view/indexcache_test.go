@@ -0,0 +1,113 @@
+package view
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/YLivay/gote/reader"
+	"github.com/stretchr/testify/assert"
+)
+
+// createLargeTestFile creates a file just large enough for its line index to be
+// kept, most of which is a hole, with lines at its start and at its end.
+func createLargeTestFile(t *testing.T) *os.File {
+	file, _ := createTestFile(t, "first\nsecond\n")
+	w, err := os.OpenFile(file.Name(), os.O_WRONLY, 0)
+	assert.NoError(t, err)
+	defer w.Close()
+	assert.NoError(t, w.Truncate(minIndexCacheSize))
+	_, err = w.WriteAt([]byte("\nlast\n"), minIndexCacheSize)
+	assert.NoError(t, err)
+	return file
+}
+
+func TestLineIndexCache_KeepsTheIndexesOfLargeFiles(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("files are told apart by their inodes")
+	}
+	dir := t.TempDir()
+	file := createLargeTestFile(t)
+
+	built := reader.NewLineIndex(file, lineIndexInterval)
+	assert.NoError(t, built.Build(context.Background()))
+	assert.NoError(t, saveLineIndex(dir, built, file))
+	paths, _ := filepath.Glob(filepath.Join(dir, "*.idx"))
+	assert.Len(t, paths, 1)
+
+	index := reader.NewLineIndex(file, lineIndexInterval)
+	assert.NoError(t, loadLineIndex(dir, index, file))
+	size, lines := index.Indexed()
+	assert.EqualValues(t, minIndexCacheSize+6, size)
+	assert.EqualValues(t, 4, lines)
+
+	// The index of what was in the file before it was rewritten is removed.
+	w, err := os.OpenFile(file.Name(), os.O_WRONLY, 0)
+	assert.NoError(t, err)
+	_, err = w.WriteAt([]byte("FIRST"), 0)
+	assert.NoError(t, err)
+	w.Close()
+	index = reader.NewLineIndex(file, lineIndexInterval)
+	assert.NoError(t, loadLineIndex(dir, index, file))
+	size, _ = index.Indexed()
+	assert.EqualValues(t, 0, size)
+	assert.NoFileExists(t, paths[0])
+}
+
+func TestLineIndexCache_PrunesTheOldestIndexes(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	for i := 0; i < maxCachedIndexes+2; i++ {
+		path := filepath.Join(dir, fmt.Sprintf("%d.idx", i))
+		assert.NoError(t, os.WriteFile(path, nil, 0644))
+		mtime := now.Add(time.Duration(i) * time.Minute)
+		assert.NoError(t, os.Chtimes(path, mtime, mtime))
+	}
+
+	assert.NoError(t, pruneIndexCache(dir))
+	paths, _ := filepath.Glob(filepath.Join(dir, "*.idx"))
+	assert.Len(t, paths, maxCachedIndexes)
+	assert.NoFileExists(t, filepath.Join(dir, "0.idx"))
+	assert.NoFileExists(t, filepath.Join(dir, "1.idx"))
+	assert.FileExists(t, filepath.Join(dir, "2.idx"))
+}
+
+func TestBuffer_KeepsTheLineIndexOfItsInput(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("files are told apart by their inodes")
+	}
+	dir := t.TempDir()
+	file := createLargeTestFile(t)
+	ctx, cancelCtx := context.WithCancel(context.Background())
+	defer cancelCtx()
+
+	buffer, err := NewBuffer(80, 10, false, file, ctx)
+	assert.NoError(t, err)
+	buffer.SetIndexCache(dir)
+	assert.NoError(t, buffer.SeekAndPopulate(0, io.SeekStart))
+	assert.Eventually(t, func() bool {
+		paths, _ := filepath.Glob(filepath.Join(dir, "*.idx"))
+		return len(paths) == 1
+	}, 10*time.Second, 5*time.Millisecond)
+
+	// Opening the file again reads the index back.
+	again, err := os.Open(file.Name())
+	assert.NoError(t, err)
+	defer again.Close()
+	buffer, err = NewBuffer(80, 10, false, again, ctx)
+	assert.NoError(t, err)
+	buffer.SetIndexCache(dir)
+	assert.NoError(t, buffer.SeekAndPopulate(0, io.SeekStart))
+	assert.Eventually(t, func() bool {
+		size, _ := buffer.index.Indexed()
+		return size == minIndexCacheSize+6
+	}, 10*time.Second, 5*time.Millisecond)
+	offset, err := buffer.lineOffset(nil, 4)
+	assert.NoError(t, err)
+	assert.EqualValues(t, minIndexCacheSize+1, offset)
+}
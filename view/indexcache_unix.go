@@ -0,0 +1,19 @@
+//go:build unix
+
+package view
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// fileKey returns what tells the given file apart from others, which is its
+// device and inode.
+func fileKey(info os.FileInfo) (string, bool) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return "", false
+	}
+	return fmt.Sprintf("%x-%x", uint64(st.Dev), uint64(st.Ino)), true
+}
@@ -0,0 +1,324 @@
+package view
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// action is something the user can trigger through a key binding.
+type action int
+
+const (
+	actionNone action = iota
+	actionScrollUp
+	actionScrollDown
+	actionPageUp
+	actionPageDown
+	actionTop
+	actionBottom
+	actionSearch
+	actionSearchBackwards
+	actionNextMatch
+	actionPrevMatch
+	actionCount
+	actionToggleWrap
+	actionToggleHighlight
+	actionToggleFollow
+	actionTogglePause
+	actionCycleGutter
+	actionShowDetail
+	actionToggleCollapse
+	actionToggleRepeats
+	actionToggleDimExcluded
+	actionToggleSampling
+	actionVisualSelect
+	actionYank
+	actionYankRecord
+	actionSave
+	actionPipe
+	actionExportCSV
+	actionShell
+	actionCommand
+	actionToggleMark
+	actionPrintMarks
+	actionToggleHelp
+	actionToggleDebug
+	actionToggleStats
+	actionFacets
+	actionFocusFacets
+	actionScript
+	actionQuit
+)
+
+// Topics group key bindings in the help screen and the tutorial. They are
+// listed in the order they should be presented.
+const (
+	topicScrolling  = "Scrolling"
+	topicFiltering  = "Filtering"
+	topicSearch     = "Search"
+	topicFollowMode = "Follow mode"
+	topicDisplay    = "Display"
+	topicSelection  = "Selection"
+	topicScripts    = "Scripts"
+	topicGeneral    = "General"
+)
+
+var topics = []string{topicScrolling, topicFiltering, topicSearch, topicFollowMode, topicDisplay, topicSelection, topicScripts, topicGeneral}
+
+// keyBinding maps a key to an action, along with the metadata needed to
+// present it to the user.
+type keyBinding struct {
+	// The key that triggers the action. For printable characters this is
+	// tcell.KeyRune and ch holds the character.
+	key tcell.Key
+	ch  rune
+	// If set, the binding is for the characters from ch through lastCh,
+	// like the digits of a count.
+	lastCh rune
+	// If set, the character that is typed before the key for the two of
+	// them to trigger the action, like the first g of gg.
+	prefix rune
+
+	action action
+
+	// The topic this binding is listed under.
+	topic string
+	// A short description of what the binding does.
+	description string
+}
+
+type keymap []keyBinding
+
+var defaultKeymap = keymap{
+	{key: tcell.KeyUp, action: actionScrollUp, topic: topicScrolling, description: "Scroll up one line"},
+	{key: tcell.KeyDown, action: actionScrollDown, topic: topicScrolling, description: "Scroll down one line"},
+	{key: tcell.KeyPgUp, action: actionPageUp, topic: topicScrolling, description: "Scroll up one page"},
+	{key: tcell.KeyPgDn, action: actionPageDown, topic: topicScrolling, description: "Scroll down one page"},
+	{key: tcell.KeyHome, action: actionTop, topic: topicScrolling, description: "Go to the start of the file"},
+	{key: tcell.KeyEnd, action: actionBottom, topic: topicScrolling, description: "Go to the end of the file"},
+	{key: tcell.KeyRune, ch: 'f', action: actionFacets, topic: topicFiltering, description: "Count the values of a field in the loaded records in a sidebar, to filter by one of them. Closing it stops filtering"},
+	{key: tcell.KeyTab, action: actionFocusFacets, topic: topicFiltering, description: "Move between the records and the sidebar, where Up, Down and Enter filter by a value"},
+	{key: tcell.KeyRune, ch: '/', action: actionSearch, topic: topicSearch, description: "Search forwards for a record matching a regular expression, or the last one if none is given"},
+	{key: tcell.KeyRune, ch: 'n', action: actionNextMatch, topic: topicSearch, description: "Go to the next match"},
+	{key: tcell.KeyRune, ch: 'N', action: actionPrevMatch, topic: topicSearch, description: "Go to the previous match"},
+	{key: tcell.KeyRune, ch: 'F', action: actionToggleFollow, topic: topicFollowMode, description: "Start or stop following new records at the end of the file"},
+	{key: tcell.KeyRune, ch: 'p', action: actionTogglePause, topic: topicFollowMode, description: "Freeze the screen while following, counting the records appended meanwhile, or show them and follow again"},
+	{key: tcell.KeyEnter, action: actionShowDetail, topic: topicDisplay, description: "Show the record under the cursor in detail"},
+	{key: tcell.KeyRune, ch: 'c', action: actionToggleCollapse, topic: topicDisplay, description: "Collapse or expand the record under the cursor"},
+	{key: tcell.KeyRune, ch: 'd', action: actionToggleRepeats, topic: topicDisplay, description: "Collapse or expand runs of repeated records"},
+	{key: tcell.KeyRune, ch: 'Z', action: actionToggleSampling, topic: topicDisplay, description: "Show all of the records around the screen when they are sampled with -sample, or sample them again"},
+	{key: tcell.KeyRune, ch: 'X', action: actionToggleDimExcluded, topic: topicDisplay, description: "Show the records the filter excludes dimmed among the ones it matches, or hide them"},
+	{key: tcell.KeyRune, ch: 'w', action: actionToggleWrap, topic: topicDisplay, description: "Toggle wrapping long records"},
+	{key: tcell.KeyRune, ch: 'h', action: actionToggleHighlight, topic: topicDisplay, description: "Toggle JSON syntax highlighting"},
+	{key: tcell.KeyRune, ch: 'g', action: actionCycleGutter, topic: topicDisplay, description: "Cycle the gutter between none, line numbers and byte offsets"},
+	{key: tcell.KeyRune, ch: 'S', action: actionToggleStats, topic: topicDisplay, description: "Show the number of loaded records by level and by time, and optionally of all the records of the input"},
+	{key: tcell.KeyRune, ch: 'v', action: actionVisualSelect, topic: topicSelection, description: "Start or stop selecting lines, move with the arrow keys to extend"},
+	{key: tcell.KeyRune, ch: 'y', action: actionYank, topic: topicSelection, description: "Copy the selected text to the clipboard"},
+	{key: tcell.KeyRune, ch: 'Y', action: actionYankRecord, topic: topicSelection, description: "Copy the original line of the record under the cursor to the clipboard"},
+	{key: tcell.KeyRune, ch: 's', action: actionSave, topic: topicSelection, description: "Save the records the filter matches to a file, or only the selected ones"},
+	{key: tcell.KeyRune, ch: 'E', action: actionExportCSV, topic: topicSelection, description: "Export fields of the records the filter matches, or of the selected ones, to a CSV file"},
+	{key: tcell.KeyRune, ch: 'm', action: actionToggleMark, topic: topicSelection, description: "Mark or unmark the record under the cursor. Marked records are printed when quitting"},
+	{key: tcell.KeyRune, ch: 'M', action: actionPrintMarks, topic: topicSelection, description: "Show the original lines of the marked records on the terminal"},
+	{key: tcell.KeyRune, ch: '|', action: actionPipe, topic: topicSelection, description: "Pipe the record under the cursor, the selected records or all of them to a shell command"},
+	{key: tcell.KeyRune, ch: ':', action: actionCommand, topic: topicGeneral, description: "Run a command, like :filter, :goto, :open or :set wrap off. Tab completes it"},
+	{key: tcell.KeyRune, ch: '!', action: actionShell, topic: topicGeneral, description: "Run a shell command, or a shell if none is given, and return here once it exits"},
+	{key: tcell.KeyRune, ch: '?', action: actionToggleHelp, topic: topicGeneral, description: "Show or hide this help"},
+	{key: tcell.KeyRune, ch: 'D', action: actionToggleDebug, topic: topicGeneral, description: "Show or hide the recent internal events of the readers, like restarts and errors"},
+	{key: tcell.KeyRune, ch: 'q', action: actionQuit, topic: topicGeneral, description: "Quit"},
+	{key: tcell.KeyCtrlC, action: actionQuit, topic: topicGeneral, description: "Quit"},
+}
+
+// lessKeymap mirrors the keys of less, for those who have them in their
+// fingers. The keys less doesn't use keep their default bindings, and the
+// actions whose keys less uses for something else get other keys.
+var lessKeymap = overrideKeymap(defaultKeymap, keymap{
+	{key: tcell.KeyRune, ch: ' ', action: actionPageDown, topic: topicScrolling, description: "Scroll down one page"},
+	{key: tcell.KeyRune, ch: 'b', action: actionPageUp, topic: topicScrolling, description: "Scroll up one page"},
+	{key: tcell.KeyRune, ch: 'j', action: actionScrollDown, topic: topicScrolling, description: "Scroll down one line"},
+	{key: tcell.KeyRune, ch: 'e', action: actionScrollDown, topic: topicScrolling, description: "Scroll down one line"},
+	{key: tcell.KeyRune, ch: 'k', action: actionScrollUp, topic: topicScrolling, description: "Scroll up one line"},
+	{key: tcell.KeyRune, ch: 'g', action: actionTop, topic: topicScrolling, description: "Go to the start of the file"},
+	{key: tcell.KeyRune, ch: '<', action: actionTop, topic: topicScrolling, description: "Go to the start of the file"},
+	{key: tcell.KeyRune, ch: 'G', action: actionBottom, topic: topicScrolling, description: "Go to the end of the file"},
+	{key: tcell.KeyRune, ch: '>', action: actionBottom, topic: topicScrolling, description: "Go to the end of the file"},
+	{key: tcell.KeyRune, ch: '?', action: actionSearchBackwards, topic: topicSearch, description: "Search backwards for a record matching a regular expression, or the last one if none is given"},
+	{key: tcell.KeyRune, ch: 'n', action: actionNextMatch, topic: topicSearch, description: "Go to the next match, in the direction of the last search"},
+	{key: tcell.KeyRune, ch: 'N', action: actionPrevMatch, topic: topicSearch, description: "Go to the next match, in the opposite direction of the last search"},
+	{key: tcell.KeyRune, ch: '#', action: actionCycleGutter, topic: topicDisplay, description: "Cycle the gutter between none, line numbers and byte offsets"},
+	{key: tcell.KeyRune, ch: 'H', action: actionToggleHighlight, topic: topicDisplay, description: "Toggle JSON syntax highlighting"},
+	{key: tcell.KeyRune, ch: 'h', action: actionToggleHelp, topic: topicGeneral, description: "Show or hide this help"},
+})
+
+// keymaps are the keymap profiles, by the names they are selected with.
+var keymaps = map[string]keymap{
+	"default": defaultKeymap,
+	"less":    lessKeymap,
+	"vi":      viKeymap,
+}
+
+// keymapNames returns the names of the keymap profiles, sorted.
+func keymapNames() []string {
+	names := make([]string, 0, len(keymaps))
+	for name := range keymaps {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// lookupKeymap returns the keymap profile with the given name, or the default
+// keymap if the name is empty.
+func lookupKeymap(name string) (keymap, error) {
+	if name == "" {
+		return defaultKeymap, nil
+	}
+	km, ok := keymaps[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown keymap %q, expected one of %s", name, strings.Join(keymapNames(), ", "))
+	}
+	return km, nil
+}
+
+// overrideKeymap returns the given base keymap with the given bindings taking
+// the place of the ones bound to the same keys, and of the ones bound to the
+// keys that start their sequences. The bindings of keys the base keymap doesn't
+// bind are added after its own.
+func overrideKeymap(base, overrides keymap) keymap {
+	var result keymap
+	used := make([]bool, len(overrides))
+	for _, b := range base {
+		if b.key == tcell.KeyRune && overrides.isPrefix(tcell.NewEventKey(tcell.KeyRune, b.ch, tcell.ModNone)) {
+			continue
+		}
+		i := slices.IndexFunc(overrides, func(o keyBinding) bool {
+			return o.key == b.key && o.ch == b.ch && o.prefix == b.prefix
+		})
+		if i < 0 {
+			result = append(result, b)
+			continue
+		}
+		result = append(result, overrides[i])
+		used[i] = true
+	}
+	for i, o := range overrides {
+		if !used[i] {
+			result = append(result, o)
+		}
+	}
+	return result
+}
+
+// viKeymap mirrors the keys of vi. Actions can be given a count before them,
+// like 20j to scroll down 20 lines or 5n to go to the fifth match. Like in
+// less's keymap, the actions whose keys vi uses for something else get other
+// keys.
+var viKeymap = overrideKeymap(defaultKeymap, keymap{
+	{key: tcell.KeyRune, ch: 'j', action: actionScrollDown, topic: topicScrolling, description: "Scroll down one line, or a count of lines"},
+	{key: tcell.KeyRune, ch: 'k', action: actionScrollUp, topic: topicScrolling, description: "Scroll up one line, or a count of lines"},
+	{key: tcell.KeyCtrlE, action: actionScrollDown, topic: topicScrolling, description: "Scroll down one line, or a count of lines"},
+	{key: tcell.KeyCtrlY, action: actionScrollUp, topic: topicScrolling, description: "Scroll up one line, or a count of lines"},
+	{key: tcell.KeyCtrlF, action: actionPageDown, topic: topicScrolling, description: "Scroll down one page, or a count of pages"},
+	{key: tcell.KeyCtrlB, action: actionPageUp, topic: topicScrolling, description: "Scroll up one page, or a count of pages"},
+	{key: tcell.KeyRune, ch: 'g', prefix: 'g', action: actionTop, topic: topicScrolling, description: "Go to the start of the file, or to a line given as a count"},
+	{key: tcell.KeyRune, ch: 'G', action: actionBottom, topic: topicScrolling, description: "Go to the end of the file, or to a line given as a count"},
+	{key: tcell.KeyRune, ch: '?', action: actionSearchBackwards, topic: topicSearch, description: "Search backwards for a record matching a regular expression, or the last one if none is given"},
+	{key: tcell.KeyRune, ch: 'n', action: actionNextMatch, topic: topicSearch, description: "Go to the next match in the direction of the last search, or a count of matches ahead"},
+	{key: tcell.KeyRune, ch: 'N', action: actionPrevMatch, topic: topicSearch, description: "Go to the next match in the opposite direction, or a count of matches ahead"},
+	{key: tcell.KeyRune, ch: '#', action: actionCycleGutter, topic: topicDisplay, description: "Cycle the gutter between none, line numbers and byte offsets"},
+	{key: tcell.KeyRune, ch: '0', lastCh: '9', action: actionCount, topic: topicGeneral, description: "Type a count before an action to repeat it, like 20j"},
+	{key: tcell.KeyF1, action: actionToggleHelp, topic: topicGeneral, description: "Show or hide this help"},
+})
+
+// lookup returns the action bound to the given key event, or actionNone if
+// there is none.
+func (km keymap) lookup(ev *tcell.EventKey) action {
+	return km.lookupAfter(0, ev)
+}
+
+// lookupAfter returns the action bound to the given key event when it is typed
+// after the given prefix character, or actionNone if there is none. A prefix of
+// 0 looks up the bindings of single keys.
+func (km keymap) lookupAfter(prefix rune, ev *tcell.EventKey) action {
+	for _, binding := range km {
+		if binding.prefix == prefix && binding.matches(ev) {
+			return binding.action
+		}
+	}
+	return actionNone
+}
+
+// isPrefix returns true if the given key event starts a sequence of keys.
+func (km keymap) isPrefix(ev *tcell.EventKey) bool {
+	if ev.Key() != tcell.KeyRune {
+		return false
+	}
+	for _, binding := range km {
+		if binding.prefix == ev.Rune() {
+			return true
+		}
+	}
+	return false
+}
+
+// matches returns true if the given key event is the binding's key, regardless
+// of its prefix.
+func (b keyBinding) matches(ev *tcell.EventKey) bool {
+	if b.key != ev.Key() {
+		return false
+	}
+	if b.key != tcell.KeyRune {
+		return true
+	}
+	if b.lastCh != 0 {
+		return b.ch <= ev.Rune() && ev.Rune() <= b.lastCh
+	}
+	return b.ch == ev.Rune()
+}
+
+// byTopic returns the bindings listed under the given topic, in keymap order.
+func (km keymap) byTopic(topic string) []keyBinding {
+	var result []keyBinding
+	for _, binding := range km {
+		if binding.topic == topic {
+			result = append(result, binding)
+		}
+	}
+	return result
+}
+
+// keyName returns a human readable name of the binding's key.
+func (b keyBinding) keyName() string {
+	if b.key == tcell.KeyRune {
+		name := string(b.ch)
+		if b.lastCh != 0 {
+			name += "-" + string(b.lastCh)
+		}
+		if b.prefix != 0 {
+			name = string(b.prefix) + name
+		}
+		return name
+	}
+	if name, ok := tcell.KeyNames[b.key]; ok {
+		return name
+	}
+	return fmt.Sprintf("Key[%d]", b.key)
+}
+
+// describeBindings formats the given bindings as aligned "key  description"
+// lines.
+func describeBindings(bindings []keyBinding) []string {
+	keyWidth := 0
+	for _, binding := range bindings {
+		keyWidth = max(keyWidth, len(binding.keyName()))
+	}
+
+	lines := make([]string, 0, len(bindings))
+	for _, binding := range bindings {
+		lines = append(lines, fmt.Sprintf("%-*s  %s", keyWidth, binding.keyName(), binding.description))
+	}
+	return lines
+}
@@ -0,0 +1,57 @@
+package view
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLookupKeymap(t *testing.T) {
+	km, err := lookupKeymap("")
+	assert.NoError(t, err)
+	assert.Equal(t, len(defaultKeymap), len(km))
+
+	km, err = lookupKeymap("less")
+	assert.NoError(t, err)
+	rune := func(ch rune) action { return km.lookup(tcell.NewEventKey(tcell.KeyRune, ch, tcell.ModNone)) }
+	assert.Equal(t, actionPageDown, rune(' '))
+	assert.Equal(t, actionTop, rune('g'))
+	assert.Equal(t, actionSearchBackwards, rune('?'))
+	// Keys less doesn't use keep their default bindings.
+	assert.Equal(t, actionToggleWrap, rune('w'))
+	assert.Equal(t, actionPageDown, km.lookup(tcell.NewEventKey(tcell.KeyPgDn, 0, tcell.ModNone)))
+
+	_, err = lookupKeymap("emacs")
+	assert.Error(t, err)
+}
+
+func TestOverrideKeymap(t *testing.T) {
+	base := keymap{
+		{key: tcell.KeyRune, ch: 'a', action: actionScrollUp},
+		{key: tcell.KeyRune, ch: 'b', action: actionScrollDown},
+	}
+	km := overrideKeymap(base, keymap{
+		{key: tcell.KeyRune, ch: 'c', action: actionQuit},
+		{key: tcell.KeyRune, ch: 'b', action: actionPageDown},
+	})
+	assert.Equal(t, keymap{
+		{key: tcell.KeyRune, ch: 'a', action: actionScrollUp},
+		{key: tcell.KeyRune, ch: 'b', action: actionPageDown},
+		{key: tcell.KeyRune, ch: 'c', action: actionQuit},
+	}, km)
+}
+
+func TestViKeymap_BindsSequences(t *testing.T) {
+	km, err := lookupKeymap("vi")
+	assert.NoError(t, err)
+	g := tcell.NewEventKey(tcell.KeyRune, 'g', tcell.ModNone)
+	assert.True(t, km.isPrefix(g))
+	assert.Equal(t, actionNone, km.lookup(g))
+	assert.Equal(t, actionTop, km.lookupAfter('g', g))
+	assert.Equal(t, actionCount, km.lookup(tcell.NewEventKey(tcell.KeyRune, '7', tcell.ModNone)))
+	// The gutter's key starts a sequence, so it was moved.
+	assert.Equal(t, actionCycleGutter, km.lookup(tcell.NewEventKey(tcell.KeyRune, '#', tcell.ModNone)))
+	assert.Equal(t, []string{"gg  Go to the start of the file, or to a line given as a count"}, describeBindings(keymap{km[slices.IndexFunc(km, func(b keyBinding) bool { return b.prefix == 'g' })]}))
+}
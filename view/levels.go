@@ -0,0 +1,66 @@
+package view
+
+import "strings"
+
+// logLevel is the normalized severity of a record.
+type logLevel string
+
+const (
+	levelUnknown logLevel = ""
+	levelTrace   logLevel = "trace"
+	levelDebug   logLevel = "debug"
+	levelInfo    logLevel = "info"
+	levelWarn    logLevel = "warn"
+	levelError   logLevel = "error"
+	levelFatal   logLevel = "fatal"
+)
+
+// levelKeys are the fields a record's level is looked up in, in order.
+var levelKeys = []string{"level", "lvl", "severity"}
+
+// levelNames maps the level names loggers commonly use to a logLevel.
+var levelNames = map[string]logLevel{
+	"trace":    levelTrace,
+	"debug":    levelDebug,
+	"info":     levelInfo,
+	"notice":   levelInfo,
+	"warn":     levelWarn,
+	"warning":  levelWarn,
+	"err":      levelError,
+	"error":    levelError,
+	"fatal":    levelFatal,
+	"crit":     levelFatal,
+	"critical": levelFatal,
+	"alert":    levelFatal,
+	"emerg":    levelFatal,
+	"panic":    levelFatal,
+}
+
+// recordLevel returns the level of the given parsed record. Levels can be
+// names, or numbers as written by bunyan and pino.
+func recordLevel(parsed map[string]any) logLevel {
+	for _, key := range levelKeys {
+		switch value := parsed[key].(type) {
+		case string:
+			if level, ok := levelNames[strings.ToLower(value)]; ok {
+				return level
+			}
+		case float64:
+			switch {
+			case value >= 60:
+				return levelFatal
+			case value >= 50:
+				return levelError
+			case value >= 40:
+				return levelWarn
+			case value >= 30:
+				return levelInfo
+			case value >= 20:
+				return levelDebug
+			case value >= 10:
+				return levelTrace
+			}
+		}
+	}
+	return levelUnknown
+}
@@ -0,0 +1,20 @@
+package view
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordLevel_ReadsNames(t *testing.T) {
+	assert.EqualValues(t, levelWarn, recordLevel(map[string]any{"level": "WARNING"}))
+	assert.EqualValues(t, levelError, recordLevel(map[string]any{"severity": "error"}))
+	assert.EqualValues(t, levelError, recordLevel(map[string]any{"level": "err"}))
+	assert.EqualValues(t, levelUnknown, recordLevel(map[string]any{"level": "loud"}))
+}
+
+func TestRecordLevel_ReadsNumbers(t *testing.T) {
+	assert.EqualValues(t, levelInfo, recordLevel(map[string]any{"level": float64(30)}))
+	assert.EqualValues(t, levelFatal, recordLevel(map[string]any{"level": float64(60)}))
+	assert.EqualValues(t, levelUnknown, recordLevel(map[string]any{"level": float64(5)}))
+}
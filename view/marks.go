@@ -0,0 +1,91 @@
+package view
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+)
+
+// toggleMark marks the record under the cursor, or unmarks it if it is
+// marked. The original line of the record is kept, so it can be printed even
+// if the input file is gone by then.
+func (a *Application) toggleMark() {
+	r := a.recordUnderCursor()
+	if r == nil || r.raw == nil || r.byteOffset < 0 {
+		a.message = "no record to mark"
+		return
+	}
+
+	a.muMarks.Lock()
+	defer a.muMarks.Unlock()
+
+	if _, ok := a.marks[r.byteOffset]; ok {
+		delete(a.marks, r.byteOffset)
+		a.message = fmt.Sprintf("unmarked the record (%d marked)", len(a.marks))
+		return
+	}
+
+	raw, err := a.rawLine(r)
+	if err != nil {
+		a.notifyError("reading the whole record failed: " + err.Error())
+		return
+	}
+	if a.marks == nil {
+		a.marks = make(map[int64][]byte)
+	}
+	a.marks[r.byteOffset] = raw
+	a.message = fmt.Sprintf("marked the record (%d marked)", len(a.marks))
+}
+
+// isMarked reports whether the given record is marked.
+func (a *Application) isMarked(r *record) bool {
+	a.muMarks.Lock()
+	defer a.muMarks.Unlock()
+
+	_, ok := a.marks[r.byteOffset]
+	return ok && r.byteOffset >= 0
+}
+
+// drawMark draws a mark over the space separating the gutter from the given
+// screen line, or over its first column if there is no gutter, if it is the
+// first line of a marked record.
+func (a *Application) drawMark(y int, line renderLine) {
+	if line.first && a.isMarked(line.record) {
+		a.screen.SetContent(max(a.gutterWidth-1, 0), y, '●', nil, a.theme.gutter)
+	}
+}
+
+// showMarks suspends the screen to print the original lines of the marked
+// records on the terminal, and restores it once a key is pressed.
+func (a *Application) showMarks() {
+	err := a.onTerminal(func() error {
+		err := a.PrintMarks(os.Stdout)
+		waitForEnter()
+		return err
+	})
+	if err != nil {
+		a.notifyError("printing marked records failed: " + err.Error())
+	}
+}
+
+// PrintMarks writes the original lines of the marked records to w, in the
+// order they are in the input file.
+func (a *Application) PrintMarks(w io.Writer) error {
+	a.muMarks.Lock()
+	defer a.muMarks.Unlock()
+
+	offsets := make([]int64, 0, len(a.marks))
+	for offset := range a.marks {
+		offsets = append(offsets, offset)
+	}
+	slices.Sort(offsets)
+
+	out := bufio.NewWriter(w)
+	for _, offset := range offsets {
+		out.Write(a.marks[offset])
+		out.WriteByte('\n')
+	}
+	return out.Flush()
+}
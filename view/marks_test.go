@@ -0,0 +1,43 @@
+package view
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarks_PrintsTheOriginalLinesOfMarkedRecords(t *testing.T) {
+	line := `{"time":1700000000000, "name":"Pelecard", "msg":"hi"}`
+	file, _ := createTestFile(t, line+"\n")
+
+	ctx, cancelCtx := context.WithCancel(context.Background())
+	defer cancelCtx()
+	screen := tcell.NewSimulationScreen("UTF-8")
+	assert.NoError(t, screen.Init())
+	defer screen.Fini()
+	screen.SetSize(80, 25)
+
+	a := NewApplication(file, false, &Config{NoTutorial: true})
+	assert.NoError(t, a.setup(ctx, screen))
+	assert.Eventually(t, func() bool { return a.buffer.records.RecordAtScreenLine(0) != nil }, time.Second, 5*time.Millisecond)
+
+	a.handleEvent(tcell.NewEventKey(tcell.KeyRune, 'm', tcell.ModNone))
+	assert.EqualValues(t, "marked the record (1 marked)", a.message)
+	assert.True(t, a.isMarked(a.recordUnderCursor()))
+
+	a.handleEvent(tcell.NewEventKey(tcell.KeyRune, 'm', tcell.ModNone))
+	assert.EqualValues(t, "unmarked the record (0 marked)", a.message)
+	var out bytes.Buffer
+	assert.NoError(t, a.PrintMarks(&out))
+	assert.EqualValues(t, "", out.String())
+
+	a.handleEvent(tcell.NewEventKey(tcell.KeyRune, 'm', tcell.ModNone))
+	// Marked records are printed as they are in the input file, not as
+	// they are displayed.
+	assert.NoError(t, a.PrintMarks(&out))
+	assert.EqualValues(t, line+"\n", out.String())
+}
@@ -0,0 +1,165 @@
+package view
+
+import (
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// How many lines a single step of the mouse wheel scrolls.
+const wheelScrollLines = 3
+
+// selection is a range of screen cells selected with the mouse. A click places
+// an empty selection that acts as a cursor, and dragging extends it.
+type selection struct {
+	// The cell the selection started at.
+	startX, startY int
+	// The cell the selection currently ends at. It may come before the start.
+	endX, endY int
+}
+
+// ordered returns the selection's bounds so that the first cell comes before
+// the last one in reading order.
+func (s *selection) ordered() (x1, y1, x2, y2 int) {
+	if s.startY < s.endY || (s.startY == s.endY && s.startX <= s.endX) {
+		return s.startX, s.startY, s.endX, s.endY
+	}
+	return s.endX, s.endY, s.startX, s.startY
+}
+
+// contains returns true if the given cell is within the selection.
+func (s *selection) contains(x, y int) bool {
+	x1, y1, x2, y2 := s.ordered()
+	if y < y1 || y > y2 {
+		return false
+	}
+	if y == y1 && x < x1 {
+		return false
+	}
+	if y == y2 && x > x2 {
+		return false
+	}
+	return true
+}
+
+// isEmpty returns true if the selection is just a cursor.
+func (s *selection) isEmpty() bool {
+	return s.startX == s.endX && s.startY == s.endY
+}
+
+// handleMouse processes a mouse event. Wheel events scroll the buffer, and
+// holding the primary button places or extends the selection.
+func (a *Application) handleMouse(ev *tcell.EventMouse) {
+	buttons := ev.Buttons()
+	x, y := ev.Position()
+	if a.facets != nil && x >= a.width-a.facetWidth() {
+		if buttons&tcell.Button1 != 0 && !a.dragging {
+			a.clickFacet(y)
+			a.render()
+		}
+		return
+	}
+	y = min(max(y, 0), a.viewHeight()-1)
+	x = min(max(x, 0), a.viewWidth()-1)
+
+	switch {
+	case buttons&tcell.WheelUp != 0:
+		a.scroll(-wheelScrollLines)
+	case buttons&tcell.WheelDown != 0:
+		a.scroll(wheelScrollLines)
+	case buttons&tcell.Button1 != 0:
+		if a.dragging && a.selection != nil {
+			a.selection.endX, a.selection.endY = x, y
+		} else {
+			a.selection = &selection{startX: x, startY: y, endX: x, endY: y}
+			a.dragging = true
+			a.visual = false
+		}
+	case buttons == tcell.ButtonNone:
+		a.dragging = false
+		return
+	default:
+		return
+	}
+
+	a.render()
+}
+
+// scroll scrolls the buffer. The selection is bound to screen cells, so it is
+// cleared when the content under it moves.
+//
+// Scrolling up while following pauses following, and scrolling back down to the
+// end resumes it.
+func (a *Application) scroll(lines int) {
+	moved := a.buffer.Scroll(lines)
+	if moved != 0 {
+		a.clearSelection()
+	}
+	switch {
+	case lines < 0 && moved != 0 && a.followMode:
+		a.pauseFollow(false)
+	case lines > 0 && moved < lines && a.pause != nil && !a.pause.manual && a.buffer.atEOF.Load():
+		a.setFollowMode(true)
+	case lines > 0 && moved == 0 && !a.followMode && a.buffer.atEOF.Load():
+		a.notify("end of file reached")
+	}
+}
+
+// clearSelection removes the selection, ending any drag or line selection
+// that extends it.
+func (a *Application) clearSelection() {
+	a.selection = nil
+	a.dragging = false
+	a.visual = false
+}
+
+// drawSelection highlights the selected cells on screen.
+func (a *Application) drawSelection() {
+	if a.selection == nil {
+		return
+	}
+
+	_, y1, _, y2 := a.selection.ordered()
+	for y := max(y1, 0); y <= y2 && y < a.viewHeight(); y++ {
+		for x := 0; x < a.viewWidth(); x++ {
+			if !a.selection.contains(x, y) {
+				continue
+			}
+			mainc, combc, style, _ := a.screen.GetContent(x, y)
+			a.screen.SetContent(x, y, mainc, combc, style.Reverse(true))
+		}
+	}
+}
+
+// selectedText returns the text displayed within the selection, one line per
+// screen row, with the gutter and trailing spaces removed.
+func (a *Application) selectedText() string {
+	if a.selection == nil || a.selection.isEmpty() {
+		return ""
+	}
+
+	var lines []string
+	x1, y1, x2, y2 := a.selection.ordered()
+	for y := y1; y <= y2; y++ {
+		fromX, toX := a.gutterWidth, a.viewWidth()-1
+		if y == y1 {
+			fromX = max(x1, a.gutterWidth)
+		}
+		if y == y2 {
+			toX = x2
+		}
+
+		var sb strings.Builder
+		for x := fromX; x <= toX; {
+			mainc, combc, _, width := a.screen.GetContent(x, y)
+			sb.WriteRune(mainc)
+			for _, r := range combc {
+				sb.WriteRune(r)
+			}
+			x += max(width, 1)
+		}
+		lines = append(lines, strings.TrimRight(sb.String(), " "))
+	}
+
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,25 @@
+package view
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSelection_ContainsCellsInReadingOrder(t *testing.T) {
+	// Dragging backwards selects the same cells as dragging forwards.
+	s := &selection{startX: 2, startY: 3, endX: 5, endY: 1}
+
+	assert.False(t, s.contains(4, 1))
+	assert.True(t, s.contains(5, 1))
+	assert.True(t, s.contains(0, 2))
+	assert.True(t, s.contains(79, 2))
+	assert.True(t, s.contains(2, 3))
+	assert.False(t, s.contains(3, 3))
+	assert.False(t, s.contains(0, 4))
+}
+
+func TestSelection_IsEmptyForAClick(t *testing.T) {
+	s := &selection{startX: 2, startY: 3, endX: 2, endY: 3}
+	assert.True(t, s.isEmpty())
+}
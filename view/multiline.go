@@ -0,0 +1,63 @@
+package view
+
+import "strings"
+
+// defaultRecordStart matches the lines that start a record when continuation
+// lines are joined and no other pattern was given: lines that start a JSON
+// object.
+const defaultRecordStart = `^\s*\{`
+
+// maxOrientLines is the most continuation lines that are read back over to find
+// the line that starts a record when orienting. A position further into a
+// record than that starts reading at the last line read back to, as if it
+// started a record.
+const maxOrientLines = 1000
+
+// continuation is a line that continues the record before it. The backwards
+// reader holds on to them until it reads the line that starts their record.
+type continuation struct {
+	line    []byte
+	lineLen int
+	crlf    bool
+}
+
+// joinContinuation returns a copy of the given record with a continuation line
+// joined to it. The line must follow the record in the input file, and is shown
+// on a line of its own after the record's text.
+func joinContinuation(r *record, line []byte, lineLen int, crlf bool, opts parseOptions) *record {
+	text, ansiSpans := displayText(trimPartialRune(line), opts)
+
+	joined := *r
+	if joined.textLen == 0 {
+		joined.textLen = len(r.buf)
+	}
+	buf := make([]byte, 0, len(r.buf)+1+len(text))
+	buf = append(buf, r.buf...)
+	buf = append(buf, '\n')
+	joined.buf = append(buf, text...)
+	joined.bufInRaw = false
+
+	// The line's spans are shifted past the text it was joined to.
+	joined.ansiSpans = make([]styleSpan, 0, len(r.ansiSpans)+len(ansiSpans))
+	joined.ansiSpans = append(joined.ansiSpans, r.ansiSpans...)
+	for _, span := range ansiSpans {
+		span.start += len(r.buf) + 1
+		span.end += len(r.buf) + 1
+		joined.ansiSpans = append(joined.ansiSpans, span)
+	}
+
+	joined.byteLen += lineLen + lineEndLen(opts.delimiter, crlf)
+	// The copy must not share the original's lines or highlighting.
+	joined.laidOut = nil
+	joined.spans, joined.hasSpans = nil, false
+	return &joined
+}
+
+// continuationLines returns the continuation lines joined to the given record,
+// as they are displayed.
+func continuationLines(r *record) []string {
+	if r.textLen == 0 {
+		return nil
+	}
+	return strings.Split(string(r.buf[r.textLen+1:]), "\n")
+}
@@ -0,0 +1,74 @@
+package view
+
+import (
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// noticeDuration is how long a notice is shown in the status bar.
+const noticeDuration = 3 * time.Second
+
+// notice is a transient message shown in the status bar until it expires,
+// unlike the message, which is cleared by the next key press. Notices tell of
+// what happened in the background too, such as the filter failing on records
+// or the input failing to be read.
+type notice struct {
+	text string
+	// If true, the notice tells of a failure and is shown highlighted.
+	err     bool
+	expires time.Time
+}
+
+// noticeExpired is posted to the application to redraw the status bar when a
+// notice expires.
+type noticeExpired struct{}
+
+// notify shows the given text in the status bar for noticeDuration.
+func (a *Application) notify(text string) {
+	a.showNotice(&notice{text: text})
+}
+
+// notifyError shows the given failure in the status bar for noticeDuration.
+func (a *Application) notifyError(text string) {
+	a.showNotice(&notice{text: text, err: true})
+}
+
+// showNotice shows the given notice in place of the one shown, if any, and
+// redraws the status bar once it expires.
+func (a *Application) showNotice(n *notice) {
+	n.expires = time.Now().Add(noticeDuration)
+	a.notice = n
+	screen := a.screen
+	time.AfterFunc(noticeDuration, func() {
+		screen.PostEvent(tcell.NewEventInterrupt(noticeExpired{}))
+	})
+}
+
+// currentNotice returns the notice to show in the status bar, or nil if there
+// is none or it expired.
+func (a *Application) currentNotice() *notice {
+	if a.notice != nil && !time.Now().Before(a.notice.expires) {
+		a.notice = nil
+	}
+	return a.notice
+}
+
+// notify posts a notice for the application to show in the status bar. It is
+// safe to call from the readers.
+func (b *Buffer) notify(text string, err bool) {
+	b.postEvent(tcell.NewEventInterrupt(&notice{text: text, err: err}))
+}
+
+// notifyFilterError tells of a record the jq filter failed on. A filter may
+// fail on every record, so it is told of at most once per noticeDuration.
+func (b *Buffer) notifyFilterError(err error) {
+	now := time.Now().UnixNano()
+	last := b.lastFilterError.Load()
+	if last != 0 && now-last < int64(noticeDuration) {
+		return
+	}
+	if b.lastFilterError.CompareAndSwap(last, now) {
+		b.notify("filter error: "+err.Error(), true)
+	}
+}
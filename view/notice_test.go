@@ -0,0 +1,114 @@
+package view
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+// noticeText returns the text of the notice shown in the status bar, or an
+// empty string if there is none.
+func noticeText(a *Application) string {
+	if n := a.currentNotice(); n != nil {
+		return n.text
+	}
+	return ""
+}
+
+func TestNotice_Expires(t *testing.T) {
+	a := &Application{screen: NewMemoryScreen(40, 5)}
+
+	a.notifyError("copy failed")
+	assert.Equal(t, "copy failed", noticeText(a))
+	assert.True(t, a.notice.err)
+
+	a.notice.expires = time.Now().Add(-time.Millisecond)
+	assert.Nil(t, a.currentNotice())
+	assert.Nil(t, a.notice)
+}
+
+func TestNotice_OutlivesKeyPresses(t *testing.T) {
+	file, _ := createTestFile(t, `{"time":1700000000000,"name":"Pelecard","msg":"hi"}`+"\n")
+
+	ctx, cancelCtx := context.WithCancel(context.Background())
+	defer cancelCtx()
+	screen := NewMemoryScreen(40, 5)
+	a := NewApplication(file, false, &Config{NoTutorial: true})
+	assert.NoError(t, a.setup(ctx, screen))
+
+	a.notify("copied the record")
+	a.message = "marked the record"
+	a.handleEvent(tcell.NewEventKey(tcell.KeyRune, 'j', tcell.ModNone))
+	assert.Empty(t, a.message)
+	assert.Equal(t, "copied the record", noticeText(a))
+}
+
+func TestApplication_NotifiesOfFailedPrompts(t *testing.T) {
+	file, _ := createTestFile(t, `{"time":1700000000000,"name":"Pelecard","msg":"hi"}`+"\n")
+
+	ctx, cancelCtx := context.WithCancel(context.Background())
+	defer cancelCtx()
+	screen := NewMemoryScreen(80, 5)
+	a := NewApplication(file, false, &Config{NoTutorial: true})
+	assert.NoError(t, a.setup(ctx, screen))
+	submit := func(text string) {
+		for _, ch := range text {
+			a.handleEvent(tcell.NewEventKey(tcell.KeyRune, ch, tcell.ModNone))
+		}
+		a.handleEvent(tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModNone))
+	}
+
+	a.startSearch(false)
+	submit("(")
+	assert.True(t, strings.HasPrefix(noticeText(a), "invalid pattern: "), noticeText(a))
+	assert.True(t, a.notice.err)
+	assert.Empty(t, a.message)
+
+	a.startExportCSV()
+	submit(".[")
+	assert.True(t, strings.HasPrefix(noticeText(a), "exporting failed: "), noticeText(a))
+	assert.True(t, a.notice.err)
+}
+
+func TestBuffer_NotifiesOfFilterErrorsOnce(t *testing.T) {
+	var lines strings.Builder
+	for i := 0; i < 5; i++ {
+		lines.WriteString(`{"time":1700000000000,"name":"Pelecard","msg":"hi"}` + "\n")
+	}
+	file, _ := createTestFile(t, lines.String())
+
+	ctx, cancelCtx := context.WithCancel(context.Background())
+	defer cancelCtx()
+	screen := NewMemoryScreen(40, 5)
+	a := NewApplication(file, false, &Config{NoTutorial: true})
+	assert.NoError(t, a.setup(ctx, screen))
+
+	// Every record fails the filter, but the failure is told of once.
+	assert.NoError(t, a.setFilter(".msg | tonumber"))
+	var notices []*notice
+	assert.Eventually(t, func() bool {
+		for len(screen.Events()) > 0 {
+			if ev, ok := (<-screen.Events()).(*tcell.EventInterrupt); ok {
+				if n, ok := ev.Data().(*notice); ok {
+					notices = append(notices, n)
+				}
+			}
+		}
+		return len(notices) > 0
+	}, time.Second, 5*time.Millisecond)
+	assert.True(t, notices[0].err)
+	assert.True(t, strings.HasPrefix(notices[0].text, "filter error: "), notices[0].text)
+
+	a.handleEvent(tcell.NewEventInterrupt(notices[0]))
+	assert.Equal(t, notices[0].text, noticeText(a))
+	for len(screen.Events()) > 0 {
+		if ev, ok := (<-screen.Events()).(*tcell.EventInterrupt); ok {
+			_, isNotice := ev.Data().(*notice)
+			assert.False(t, isNotice)
+		}
+	}
+}
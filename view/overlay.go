@@ -0,0 +1,94 @@
+package view
+
+import (
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/uniseg"
+)
+
+// overlay is a box of text drawn in the middle of the screen on top of the log
+// lines, such as the help screen or a tutorial page.
+type overlay struct {
+	title  string
+	lines  []string
+	footer string
+}
+
+// drawOverlay draws the given overlay centered on the screen. Lines that do
+// not fit the screen are cut off.
+func (a *Application) drawOverlay(o *overlay) {
+	contentWidth := uniseg.StringWidth(o.title)
+	contentWidth = max(contentWidth, uniseg.StringWidth(o.footer))
+	for _, line := range o.lines {
+		contentWidth = max(contentWidth, uniseg.StringWidth(line))
+	}
+
+	// Leave a column of padding on each side, plus the border.
+	boxWidth := min(contentWidth+4, a.width)
+	// The title and footer each take a line and are separated by a blank one.
+	boxHeight := min(len(o.lines)+6, a.height)
+	if boxWidth < 3 || boxHeight < 3 {
+		return
+	}
+
+	left := (a.width - boxWidth) / 2
+	top := (a.height - boxHeight) / 2
+	right := left + boxWidth - 1
+	bottom := top + boxHeight - 1
+
+	style := a.theme.overlay
+	a.drawBox(left, top, right, bottom, style)
+
+	maxTextWidth := boxWidth - 4
+	a.drawText(left+2, top+1, maxTextWidth, o.title, style.Bold(true))
+	for i, line := range o.lines {
+		y := top + 3 + i
+		if y >= bottom-2 {
+			break
+		}
+		a.drawText(left+2, y, maxTextWidth, line, style)
+	}
+	a.drawText(left+2, bottom-1, maxTextWidth, o.footer, style.Dim(true))
+}
+
+// drawBox fills the given rectangle of cells with the given style and draws a
+// border around it.
+func (a *Application) drawBox(left, top, right, bottom int, style tcell.Style) {
+	for y := top; y <= bottom; y++ {
+		for x := left; x <= right; x++ {
+			a.screen.SetContent(x, y, ' ', nil, style)
+		}
+	}
+	for x := left + 1; x < right; x++ {
+		a.screen.SetContent(x, top, tcell.RuneHLine, nil, style)
+		a.screen.SetContent(x, bottom, tcell.RuneHLine, nil, style)
+	}
+	for y := top + 1; y < bottom; y++ {
+		a.screen.SetContent(left, y, tcell.RuneVLine, nil, style)
+		a.screen.SetContent(right, y, tcell.RuneVLine, nil, style)
+	}
+	a.screen.SetContent(left, top, tcell.RuneULCorner, nil, style)
+	a.screen.SetContent(right, top, tcell.RuneURCorner, nil, style)
+	a.screen.SetContent(left, bottom, tcell.RuneLLCorner, nil, style)
+	a.screen.SetContent(right, bottom, tcell.RuneLRCorner, nil, style)
+}
+
+// drawText draws a single line of text starting at the given cell, cutting it
+// off once it exceeds maxWidth cells. It returns the column after the last cell
+// that was drawn.
+func (a *Application) drawText(x, y, maxWidth int, text string, style tcell.Style) int {
+	var state *stepState
+	end := x + maxWidth
+	for len(text) > 0 {
+		var ch string
+		ch, text, state = step(text, state)
+		w := state.Width()
+		if x+w > end {
+			return x
+		}
+
+		runes := []rune(ch)
+		a.screen.SetContent(x, y, runes[0], runes[1:], style)
+		x += w
+	}
+	return x
+}
@@ -0,0 +1,108 @@
+package view
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// pauseCheckInterval is how often the input is checked for records appended
+// to it while following is paused.
+const pauseCheckInterval = 500 * time.Millisecond
+
+// followPause is following that was paused by scrolling up while following,
+// or with the pause key, and is resumed by going back to the end. The records
+// appended meanwhile are counted in the background.
+type followPause struct {
+	// If true, following was paused with the pause key, so scrolling back to
+	// the end doesn't resume it.
+	manual bool
+	// The number of records appended since following was paused.
+	newRecords atomic.Int64
+	// Stops counting them.
+	cancel context.CancelFunc
+}
+
+// pauseUpdate is posted to the application to redraw the status bar when
+// records were appended while following is paused.
+type pauseUpdate struct{}
+
+// pauseFollow stops following so the screen stays where it is, and starts
+// counting the records appended after what was read. If manual is true, it
+// was paused with the pause key.
+func (a *Application) pauseFollow(manual bool) {
+	from := a.buffer.Status().FileSize
+	a.setFollowMode(false)
+
+	ctx, cancel := context.WithCancel(a.buffer.ctx)
+	p := &followPause{manual: manual, cancel: cancel}
+	a.pause = p
+	if from < 0 {
+		// There is no telling where the appended records start.
+		return
+	}
+
+	buffer, screen := a.buffer, a.screen
+	changed := make(chan struct{}, 1)
+	go func() {
+		defer close(changed)
+		ticker := time.NewTicker(pauseCheckInterval)
+		defer ticker.Stop()
+		var counted int64
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			select {
+			case changed <- struct{}{}:
+			default:
+			}
+			if n := p.newRecords.Load(); n != counted {
+				counted = n
+				screen.PostEvent(tcell.NewEventInterrupt(pauseUpdate{}))
+			}
+		}
+	}()
+	go buffer.FollowRecords(from, changed, func(*record) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		p.newRecords.Add(1)
+		return nil
+	})
+}
+
+// togglePause freezes the screen while following, or shows the records
+// appended while it was frozen and follows them again.
+func (a *Application) togglePause() {
+	switch {
+	case a.pause != nil:
+		a.seekToEdge(true)
+	case a.followMode:
+		a.pauseFollow(true)
+	default:
+		a.notify("only following can be paused")
+	}
+}
+
+// stopPause forgets that following was paused, if it was.
+func (a *Application) stopPause() {
+	if a.pause != nil {
+		a.pause.cancel()
+		a.pause = nil
+	}
+}
+
+// formatPause describes the paused following for the status bar.
+func formatPause(p *followPause) string {
+	n := p.newRecords.Load()
+	if n == 1 {
+		return "[paused, 1 new record]"
+	}
+	return fmt.Sprintf("[paused, %s new records]", formatCount(n))
+}
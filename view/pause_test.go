@@ -0,0 +1,108 @@
+package view
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplication_PausesFollowingWhenScrollingUp(t *testing.T) {
+	record := func(i int) string {
+		return fmt.Sprintf(`{"time":1700000000000,"name":"Pelecard","msg":"record %02d"}`+"\n", i)
+	}
+	var lines strings.Builder
+	for i := 0; i < 30; i++ {
+		lines.WriteString(record(i))
+	}
+	file, _ := createTestFile(t, lines.String())
+
+	ctx, cancelCtx := context.WithCancel(context.Background())
+	defer cancelCtx()
+	screen := NewMemoryScreen(200, 10)
+	a := NewApplication(file, true, &Config{NoTutorial: true})
+	assert.NoError(t, a.setup(ctx, screen))
+	atEnd := func() bool {
+		status := a.buffer.Status()
+		return status.EndByteOffset == status.FileSize
+	}
+	assert.Eventually(t, atEnd, time.Second, 5*time.Millisecond)
+
+	a.handleEvent(tcell.NewEventKey(tcell.KeyUp, 0, tcell.ModNone))
+	assert.False(t, a.followMode)
+	assert.NotNil(t, a.pause)
+
+	// Records appended meanwhile are counted, not shown.
+	top := a.buffer.Status().ByteOffset
+	w, err := os.OpenFile(file.Name(), os.O_APPEND|os.O_WRONLY, 0)
+	assert.NoError(t, err)
+	defer w.Close()
+	_, err = w.WriteString(record(30) + record(31) + record(32))
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool { return a.pause.newRecords.Load() == 3 }, 2*time.Second, 5*time.Millisecond)
+	a.render()
+	assert.Contains(t, screen.Line(9), "[paused, 3 new records]")
+	assert.EqualValues(t, top, a.buffer.Status().ByteOffset)
+
+	// Scrolling back to the end resumes following.
+	assert.Eventually(t, func() bool {
+		a.handleEvent(tcell.NewEventKey(tcell.KeyDown, 0, tcell.ModNone))
+		return a.followMode
+	}, time.Second, 5*time.Millisecond)
+	assert.Nil(t, a.pause)
+	assert.Eventually(t, atEnd, time.Second, 5*time.Millisecond)
+
+	// So does going to the end.
+	a.handleEvent(tcell.NewEventKey(tcell.KeyPgUp, 0, tcell.ModNone))
+	assert.NotNil(t, a.pause)
+	a.handleEvent(tcell.NewEventKey(tcell.KeyEnd, 0, tcell.ModNone))
+	assert.True(t, a.followMode)
+	assert.Nil(t, a.pause)
+}
+
+func TestApplication_PauseKeyFreezesTheScreen(t *testing.T) {
+	var lines strings.Builder
+	for i := 0; i < 30; i++ {
+		fmt.Fprintf(&lines, `{"time":1700000000000,"name":"Pelecard","msg":"record %02d"}`+"\n", i)
+	}
+	file, _ := createTestFile(t, lines.String())
+
+	ctx, cancelCtx := context.WithCancel(context.Background())
+	defer cancelCtx()
+	screen := NewMemoryScreen(200, 10)
+	a := NewApplication(file, true, &Config{NoTutorial: true})
+	assert.NoError(t, a.setup(ctx, screen))
+	assert.Eventually(t, func() bool { return a.buffer.Status().Records > 0 }, time.Second, 5*time.Millisecond)
+
+	pause := tcell.NewEventKey(tcell.KeyRune, 'p', tcell.ModNone)
+	a.handleEvent(pause)
+	assert.False(t, a.followMode)
+	assert.True(t, a.pause.manual)
+
+	// Only the pause key or going to the end resumes following, not
+	// scrolling down at the end.
+	a.handleEvent(tcell.NewEventKey(tcell.KeyDown, 0, tcell.ModNone))
+	assert.NotNil(t, a.pause)
+	a.handleEvent(pause)
+	assert.True(t, a.followMode)
+	assert.Nil(t, a.pause)
+
+	a.handleEvent(tcell.NewEventKey(tcell.KeyRune, 'F', tcell.ModNone))
+	a.handleEvent(pause)
+	assert.Nil(t, a.pause)
+	assert.Equal(t, "only following can be paused", noticeText(a))
+}
+
+func TestFormatPause(t *testing.T) {
+	p := &followPause{}
+	assert.EqualValues(t, "[paused, 0 new records]", formatPause(p))
+	p.newRecords.Store(1)
+	assert.EqualValues(t, "[paused, 1 new record]", formatPause(p))
+	p.newRecords.Store(1234)
+	assert.EqualValues(t, "[paused, 1,234 new records]", formatPause(p))
+}
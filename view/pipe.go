@@ -0,0 +1,127 @@
+package view
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"syscall"
+)
+
+// pipeScope is which records are piped to a command.
+type pipeScope int
+
+const (
+	// The record under the cursor.
+	pipeRecord pipeScope = iota
+	// The records the filter matches in the whole input file.
+	pipeAll
+	// The selected records.
+	pipeSelected
+)
+
+// startPipe prompts for the shell command to pipe records to: the selected
+// ones if there is a selection, or else the record under the cursor, which Tab
+// switches to all of them.
+func (a *Application) startPipe() {
+	scope := pipeRecord
+	r := a.recordUnderCursor()
+	from, to, selected := a.selectedRange()
+	if selected {
+		scope = pipeSelected
+	} else if r == nil {
+		scope = pipeAll
+	}
+	a.clearSelection()
+
+	labels := map[pipeScope]string{
+		pipeRecord:   "Pipe the record to (Tab for all): ",
+		pipeAll:      "Pipe all records to (Tab for one): ",
+		pipeSelected: "Pipe selected records to: ",
+	}
+	p := &prompt{label: labels[scope]}
+	p.tab = func() {
+		switch {
+		case scope == pipeRecord:
+			scope = pipeAll
+		case scope == pipeAll && r != nil:
+			scope = pipeRecord
+		}
+		p.label = labels[scope]
+	}
+	p.submit = func(command string) {
+		if command == "" {
+			return
+		}
+		records := func(w io.Writer) (int, error) {
+			if scope == pipeRecord {
+				_, err := w.Write(append(r.buf[:len(r.buf):len(r.buf)], '\n'))
+				return 1, err
+			}
+			return a.buffer.WriteRecords(w, from, to)
+		}
+		a.runPipe(command, records)
+	}
+	a.prompt = p
+}
+
+// runPipe suspends the screen to run the given shell command on the terminal,
+// with the records written to its input, and restores the screen once the
+// command exits and a key is pressed.
+func (a *Application) runPipe(command string, records func(io.Writer) (int, error)) {
+	err := a.onTerminal(func() error {
+		err := pipeToCommand(command, records, os.Stdout, os.Stderr)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "gote:", err)
+		}
+		waitForEnter()
+		return err
+	})
+	if err != nil {
+		a.notifyError("pipe failed: " + err.Error())
+	}
+}
+
+// pipeToCommand runs the given shell command with the records written to its
+// input, and the given output and error output. A command that exits before
+// reading all of the records isn't an error.
+func pipeToCommand(command string, records func(io.Writer) (int, error), stdout, stderr io.Writer) error {
+	cmd := shellCommand(command)
+	cmd.Stdout, cmd.Stderr = stdout, stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(stdin)
+	_, writeErr := records(w)
+	if writeErr == nil {
+		writeErr = w.Flush()
+	}
+	stdin.Close()
+
+	if err := cmd.Wait(); err != nil {
+		return err
+	}
+	if writeErr != nil && !errors.Is(writeErr, os.ErrClosed) && !errors.Is(writeErr, syscall.EPIPE) {
+		return writeErr
+	}
+	return nil
+}
+
+// waitForEnter asks on the terminal for Enter to be pressed and waits for it,
+// so the output of a command can be read before the screen is restored.
+func waitForEnter() {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return
+	}
+	defer tty.Close()
+
+	fmt.Fprint(tty, "\n[Press Enter to return to gote]")
+	bufio.NewReader(tty).ReadString('\n')
+}
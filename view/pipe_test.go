@@ -0,0 +1,40 @@
+package view
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPipeToCommand_WritesRecordsToItsInput(t *testing.T) {
+	t.Setenv("SHELL", "/bin/sh")
+	var stdout, stderr bytes.Buffer
+	records := func(w io.Writer) (int, error) {
+		_, err := io.WriteString(w, "one\ntwo\n")
+		return 2, err
+	}
+
+	assert.NoError(t, pipeToCommand("tr a-z A-Z", records, &stdout, &stderr))
+	assert.EqualValues(t, "ONE\nTWO\n", stdout.String())
+
+	assert.Error(t, pipeToCommand("exit 3", records, &stdout, &stderr))
+}
+
+func TestPipeToCommand_AllowsCommandsThatStopReading(t *testing.T) {
+	t.Setenv("SHELL", "/bin/sh")
+	var stdout bytes.Buffer
+	records := func(w io.Writer) (int, error) {
+		for i := 0; i < 100000; i++ {
+			if _, err := io.WriteString(w, strings.Repeat("x", 100)+"\n"); err != nil {
+				return i, err
+			}
+		}
+		return 100000, nil
+	}
+
+	assert.NoError(t, pipeToCommand("head -n 1", records, &stdout, io.Discard))
+	assert.EqualValues(t, strings.Repeat("x", 100)+"\n", stdout.String())
+}
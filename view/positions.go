@@ -0,0 +1,205 @@
+package view
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// The name of the file the reading positions are persisted in, within the XDG
+// state directory.
+const positionsFname = "positions.json"
+
+// maxSavedPositions is how many files' reading positions are remembered. The
+// ones saved the longest ago are forgotten first.
+const maxSavedPositions = 500
+
+// filePosition is where a file was being read when it was last closed.
+type filePosition struct {
+	// The offset of the record at the top of the screen.
+	Offset int64 `json:"offset"`
+	// If true, the file was being followed, so reading continues at its end.
+	Follow bool `json:"follow"`
+	// The size of the file, which a file that was replaced since is likely
+	// smaller than.
+	Size int64 `json:"size"`
+	// When the position was saved.
+	Saved time.Time `json:"saved"`
+}
+
+// xdgStateDir returns the directory gote persists state in that is not worth
+// backing up, like reading positions, following the XDG base directory
+// specification.
+func xdgStateDir() (string, error) {
+	if dir := os.Getenv("XDG_STATE_HOME"); filepath.IsAbs(dir) {
+		return filepath.Join(dir, "gote"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to determine user home directory: %w", err)
+	}
+	return filepath.Join(home, ".local", "state", "gote"), nil
+}
+
+// loadPositions reads the persisted reading positions, by the absolute paths
+// of their files. If none were persisted, it returns an empty map.
+func loadPositions() (map[string]filePosition, error) {
+	dir, err := xdgStateDir()
+	if err != nil {
+		return nil, err
+	}
+
+	positions := make(map[string]filePosition)
+	data, err := os.ReadFile(filepath.Join(dir, positionsFname))
+	if errors.Is(err, os.ErrNotExist) {
+		return positions, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &positions); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", positionsFname, err)
+	}
+	return positions, nil
+}
+
+// loadPosition returns the persisted reading position of the file at the given
+// path, or false if there is none.
+func loadPosition(path string) (filePosition, bool) {
+	positions, err := loadPositions()
+	if err != nil {
+		return filePosition{}, false
+	}
+	pos, ok := positions[path]
+	return pos, ok
+}
+
+// savePosition persists the reading position of the file at the given path.
+func savePosition(path string, pos filePosition) error {
+	positions, err := loadPositions()
+	if err != nil {
+		// A state file that can't be parsed is started over.
+		positions = make(map[string]filePosition)
+	}
+	positions[path] = pos
+
+	if len(positions) > maxSavedPositions {
+		paths := make([]string, 0, len(positions))
+		for path := range positions {
+			paths = append(paths, path)
+		}
+		slices.SortFunc(paths, func(a, b string) int {
+			return positions[b].Saved.Compare(positions[a].Saved)
+		})
+		for _, path := range paths[maxSavedPositions:] {
+			delete(positions, path)
+		}
+	}
+
+	data, err := json.Marshal(positions)
+	if err != nil {
+		return err
+	}
+
+	dir, err := xdgStateDir()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+
+	// Write a new file in place of the old one, so other instances never
+	// read a partial file.
+	f, err := os.CreateTemp(dir, positionsFname+".*")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), filepath.Join(dir, positionsFname))
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return fmt.Errorf("failed to write %s: %w", positionsFname, err)
+	}
+	return nil
+}
+
+// offerResume offers to resume reading the input file where it was last
+// closed, if it was remembered and wasn't followed. Being followed, it is
+// already read where it was.
+func (a *Application) offerResume() {
+	// Starting at the tail or at a start position is asked for explicitly,
+	// unlike the remembered position.
+	if a.inputPath == "" || a.config.Tail > 0 || a.config.Start != nil {
+		return
+	}
+	pos, ok := loadPosition(a.inputPath)
+	if !ok || pos.Follow || pos.Offset <= 0 {
+		return
+	}
+	info, err := a.inputReader.Stat()
+	if err != nil || info.Size() < pos.Size {
+		// The file was truncated or replaced, so the position is of
+		// other records.
+		return
+	}
+	a.resume = &pos
+}
+
+// resumeOverlay returns the overlay offering to resume reading at the given
+// position.
+func resumeOverlay(pos *filePosition) *overlay {
+	return &overlay{
+		title: "Resume reading?",
+		lines: []string{
+			"This file was last read " + formatBytes(pos.Offset) + " into it,",
+			"without following it, " + pos.Saved.Format(time.DateTime) + ".",
+		},
+		footer: "Enter/y: Resume  Any other key: Start at the end",
+	}
+}
+
+// handleResumeKey resumes reading at the offered position if the key accepts
+// it, and dismisses the offer either way.
+func (a *Application) handleResumeKey(ev *tcell.EventKey) {
+	pos := a.resume
+	a.resume = nil
+	if ev.Key() != tcell.KeyEnter && (ev.Key() != tcell.KeyRune || (ev.Rune() != 'y' && ev.Rune() != 'Y')) {
+		return
+	}
+
+	a.setFollowMode(false)
+	a.clearSelection()
+	if err := a.buffer.SeekAndPopulate(pos.Offset, io.SeekStart); err != nil {
+		a.notifyError("resuming failed: " + err.Error())
+	}
+}
+
+// saveInputPosition remembers where the input file is being read, so reading
+// can resume there when it is opened again.
+func (a *Application) saveInputPosition() {
+	if a.inputPath == "" || a.buffer == nil {
+		return
+	}
+	pos := filePosition{Follow: a.followMode, Saved: time.Now()}
+	if r := a.buffer.records.RecordAtScreenLine(0); r != nil && r.byteOffset >= 0 {
+		pos.Offset = r.byteOffset
+	}
+	if info, err := a.inputReader.Stat(); err == nil {
+		pos.Size = info.Size()
+	}
+	if err := savePosition(a.inputPath, pos); err != nil {
+		a.logger.Error("failed to save reading position:", err.Error())
+	}
+}
@@ -0,0 +1,76 @@
+package view
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSavePosition_KeepsOtherFiles(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	_, ok := loadPosition("/var/log/a.log")
+	assert.False(t, ok)
+
+	saved := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	assert.NoError(t, savePosition("/var/log/a.log", filePosition{Offset: 10, Size: 20, Saved: saved}))
+	assert.NoError(t, savePosition("/var/log/b.log", filePosition{Follow: true, Saved: saved}))
+	assert.NoError(t, savePosition("/var/log/a.log", filePosition{Offset: 15, Size: 30, Saved: saved}))
+
+	pos, ok := loadPosition("/var/log/a.log")
+	assert.True(t, ok)
+	assert.EqualValues(t, filePosition{Offset: 15, Size: 30, Saved: saved}, pos)
+	pos, ok = loadPosition("/var/log/b.log")
+	assert.True(t, ok)
+	assert.True(t, pos.Follow)
+}
+
+func TestApplication_ResumesWhereFileWasRead(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	line := `{"time":1700000000000,"name":"Pelecard","msg":"m"}` + "\n"
+	file, _ := createTestFile(t, strings.Repeat(line, 100))
+	offset := int64(25 * len(line))
+	assert.NoError(t, savePosition(file.Name(), filePosition{Offset: offset, Size: int64(100 * len(line)), Saved: time.Now()}))
+
+	ctx, cancelCtx := context.WithCancel(context.Background())
+	defer cancelCtx()
+	screen := tcell.NewSimulationScreen("UTF-8")
+	assert.NoError(t, screen.Init())
+	defer screen.Fini()
+	screen.SetSize(80, 25)
+
+	a := NewApplication(file, true, &Config{NoTutorial: true})
+	a.inputPath = file.Name()
+	assert.NoError(t, a.setup(ctx, screen))
+	assert.NotNil(t, a.resume)
+
+	a.handleEvent(tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModNone))
+	assert.Nil(t, a.resume)
+	assert.False(t, a.followMode)
+	// The readers may have scrolled up before the record was read, so look
+	// for it among the loaded records.
+	assert.Eventually(t, func() bool {
+		found := false
+		a.buffer.records.WithLock(func(records *bufferRecordList) any {
+			for i := 0; i < records.Len(); i++ {
+				found = found || records.RecordAt(i).byteOffset == offset
+			}
+			return nil
+		})
+		return found
+	}, time.Second, 5*time.Millisecond)
+
+	// The record at the top of the screen is remembered.
+	<-a.buffer.cancelPopulate(errors.New("test done"))
+	a.saveInputPosition()
+	pos, ok := loadPosition(a.inputPath)
+	assert.True(t, ok)
+	assert.EqualValues(t, a.buffer.records.RecordAtScreenLine(0).byteOffset, pos.Offset)
+	assert.False(t, pos.Follow)
+}
@@ -0,0 +1,164 @@
+package view
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+const (
+	// taskProgressDelay is how long a task may take before it is left to run
+	// in the background with its progress shown. Quicker ones finish before
+	// the next event is handled, as if they ran in the foreground.
+	taskProgressDelay = 200 * time.Millisecond
+	// taskProgressInterval is how often the progress of a task running in
+	// the background is redrawn.
+	taskProgressInterval = 100 * time.Millisecond
+	// progressBarWidth is the width of the bar showing how far a task got.
+	progressBarWidth = 20
+)
+
+// scanProgress is how far a scan of the input got, and the context that stops
+// it. A nil scanProgress never stops.
+type scanProgress struct {
+	ctx context.Context
+	// The offset of the input the scan got to.
+	pos atomic.Int64
+}
+
+// advance records that the scan got to the given offset, and returns the
+// context's error once it is done.
+func (p *scanProgress) advance(pos int64) error {
+	if p == nil {
+		return nil
+	}
+	p.pos.Store(pos)
+	return p.ctx.Err()
+}
+
+// task is a long scan of the input, like a search through a huge file, that
+// runs in the background while the status bar shows how far it got, instead of
+// freezing the screen. Escape cancels it.
+type task struct {
+	// What the task does, like "searching for /timeout/".
+	label string
+	// The span of the input the task scans.
+	from, to int64
+	// Returns the offset it got to.
+	pos      func() int64
+	progress *scanProgress
+	cancel   context.CancelFunc
+}
+
+// taskUpdate is posted to the application to redraw the progress of the task
+// running in the background.
+type taskUpdate struct{}
+
+// taskDone is posted to the application when the task running in the
+// background finishes, with what to do with its result.
+type taskDone struct {
+	task  *task
+	apply func()
+}
+
+// startTask runs a scan of the given span of the input, which run does and
+// then returns what to do with its result on the event loop. If it doesn't
+// finish within taskProgressDelay, it goes on in the background until it does
+// or is canceled. pos, if not nil, returns how far the scan got, for scans that
+// don't advance the progress they are given. Only one task runs at a time.
+func (a *Application) startTask(label string, from, to int64, pos func() int64, run func(p *scanProgress) func()) {
+	if a.task != nil {
+		a.notify("still " + a.task.label + ", Escape cancels it")
+		return
+	}
+
+	ctx, cancel := context.WithCancel(a.buffer.ctx)
+	p := &scanProgress{ctx: ctx}
+	p.pos.Store(from)
+	if pos == nil {
+		pos = p.pos.Load
+	}
+	done := make(chan func(), 1)
+	go func() {
+		done <- run(p)
+	}()
+
+	select {
+	case apply := <-done:
+		cancel()
+		apply()
+		return
+	case <-time.After(taskProgressDelay):
+	}
+
+	t := &task{label: label, from: from, to: to, pos: pos, progress: p, cancel: cancel}
+	a.task = t
+	screen := a.screen
+	go func() {
+		ticker := time.NewTicker(taskProgressInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case apply := <-done:
+				screen.PostEvent(tcell.NewEventInterrupt(&taskDone{task: t, apply: apply}))
+				return
+			case <-ticker.C:
+				screen.PostEvent(tcell.NewEventInterrupt(taskUpdate{}))
+			}
+		}
+	}()
+}
+
+// finishTask applies the result of the task that finished in the background,
+// unless it was canceled.
+func (a *Application) finishTask(done *taskDone) {
+	if done.task != a.task {
+		return
+	}
+	a.task = nil
+	canceled := done.task.progress.ctx.Err() != nil
+	done.task.cancel()
+	if canceled {
+		return
+	}
+	done.apply()
+}
+
+// cancelTask stops the task running in the background.
+func (a *Application) cancelTask() {
+	a.task.cancel()
+	a.notify(a.task.label + " canceled")
+	a.task = nil
+}
+
+// drawTask draws the progress of the task running in the background over the
+// status bar.
+func (a *Application) drawTask() {
+	if a.task == nil || a.height < 1 {
+		return
+	}
+
+	text := " " + formatTaskProgress(a.task.label, a.task.pos()-a.task.from, a.task.to-a.task.from) + "  Escape cancels"
+	y := a.height - 1
+	style := a.theme.statusBar
+	for x := 0; x < a.width; x++ {
+		a.screen.SetContent(x, y, ' ', nil, style)
+	}
+	a.drawText(0, y, a.width, text, style)
+}
+
+// formatTaskProgress describes how far a task got through the bytes it scans,
+// with a bar.
+func formatTaskProgress(label string, done, total int64) string {
+	done = min(max(done, 0), max(total, 0))
+	filled := 0
+	if total > 0 {
+		filled = int(done * progressBarWidth / total)
+	}
+	bar := strings.Repeat("█", filled) + strings.Repeat("░", progressBarWidth-filled)
+	return fmt.Sprintf("%s  %s  %s / %s", label, bar, formatBytes(done), formatBytes(total))
+}
@@ -0,0 +1,65 @@
+package view
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatTaskProgress(t *testing.T) {
+	assert.EqualValues(t, "searching  █████░░░░░░░░░░░░░░░  256B / 1.0KiB", formatTaskProgress("searching", 256, 1024))
+	assert.EqualValues(t, "searching  ████████████████████  1.0KiB / 1.0KiB", formatTaskProgress("searching", 4096, 1024))
+	assert.EqualValues(t, "searching  ░░░░░░░░░░░░░░░░░░░░  0B / 0B", formatTaskProgress("searching", 10, 0))
+}
+
+func TestApplication_ShowsTheProgressOfLongTasksUntilCanceled(t *testing.T) {
+	line := `{"time":1700000000000,"name":"Pelecard","msg":"hi"}` + "\n"
+	file, _ := createTestFile(t, line)
+
+	ctx, cancelCtx := context.WithCancel(context.Background())
+	defer cancelCtx()
+	screen := NewMemoryScreen(120, 10)
+	a := NewApplication(file, false, &Config{NoTutorial: true})
+	assert.NoError(t, a.setup(ctx, screen))
+
+	// Quick tasks finish before startTask returns.
+	applied := false
+	a.startTask("scanning", 0, 100, nil, func(p *scanProgress) func() {
+		return func() { applied = true }
+	})
+	assert.True(t, applied)
+	assert.Nil(t, a.task)
+
+	applied = false
+	a.startTask("scanning", 0, 100, nil, func(p *scanProgress) func() {
+		p.advance(50)
+		<-p.ctx.Done()
+		return func() { applied = true }
+	})
+	assert.NotNil(t, a.task)
+	a.handleEvent(tcell.NewEventInterrupt(taskUpdate{}))
+	status := screenText(screen, 9, 0, 120)
+	assert.True(t, strings.HasPrefix(status, " scanning  ██████████░░░░░░░░░░  50B / 100B  Escape cancels"), status)
+
+	// Other keys are ignored while the task runs.
+	a.handleEvent(tcell.NewEventKey(tcell.KeyRune, 'G', tcell.ModNone))
+	assert.NotNil(t, a.task)
+
+	a.handleEvent(tcell.NewEventKey(tcell.KeyEscape, 0, tcell.ModNone))
+	assert.Nil(t, a.task)
+	assert.EqualValues(t, "scanning canceled", noticeText(a))
+
+	// The result of the canceled task is dropped.
+	for deadline := time.Now().Add(3 * taskProgressInterval); time.Now().Before(deadline); {
+		for len(screen.Events()) > 0 {
+			a.handleEvent(<-screen.Events())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	assert.False(t, applied)
+	assert.Nil(t, a.task)
+}
@@ -0,0 +1,56 @@
+package view
+
+import "github.com/gdamore/tcell/v2"
+
+// prompt is a line of text typed into the status bar, like the path to save
+// records to.
+type prompt struct {
+	// What is asked for, shown before the text.
+	label string
+	text  []rune
+	// Called with the text when it is submitted with Enter.
+	submit func(string)
+	// Called when Tab is pressed, if it is set.
+	tab func()
+}
+
+// handlePromptKey edits the prompt's text, or submits or cancels it.
+func (a *Application) handlePromptKey(ev *tcell.EventKey) {
+	p := a.prompt
+	switch ev.Key() {
+	case tcell.KeyEnter:
+		a.prompt = nil
+		p.submit(string(p.text))
+	case tcell.KeyEscape:
+		a.prompt = nil
+	case tcell.KeyBackspace, tcell.KeyBackspace2:
+		if len(p.text) > 0 {
+			p.text = p.text[:len(p.text)-1]
+		}
+	case tcell.KeyCtrlU:
+		p.text = nil
+	case tcell.KeyTab:
+		if p.tab != nil {
+			p.tab()
+		}
+	case tcell.KeyRune:
+		p.text = append(p.text, ev.Rune())
+	}
+}
+
+// drawPrompt draws the prompt over the status bar, with the cursor after its
+// text.
+func (a *Application) drawPrompt(p *prompt) {
+	if a.height < 1 {
+		return
+	}
+
+	y := a.height - 1
+	style := a.theme.statusBar
+	for x := 0; x < a.width; x++ {
+		a.screen.SetContent(x, y, ' ', nil, style)
+	}
+
+	x := a.drawText(0, y, a.width, " "+p.label+string(p.text), style)
+	a.screen.ShowCursor(min(x, a.width-1), y)
+}
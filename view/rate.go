@@ -0,0 +1,99 @@
+package view
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// rateUpdateInterval is how often the status bar is redrawn while following,
+// so the ingest rate drops when the input goes quiet.
+const rateUpdateInterval = time.Second
+
+// ingestRate measures how many lines a second are appended to the input, by
+// the lines the forwards reader reads past where it reached the end of the
+// input while following.
+type ingestRate struct {
+	mu sync.Mutex
+	// The second being counted, in seconds since the epoch, and the lines
+	// read in it so far.
+	second int64
+	count  int64
+	// The lines read in the last full second, and the most read in any
+	// second since the rate was reset.
+	last int64
+	peak int64
+}
+
+// add counts a line read at the given time.
+func (r *ingestRate) add(now time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.roll(now.Unix())
+	r.count++
+}
+
+// get returns the lines read in the last full second before the given time, and
+// the most read in any second.
+func (r *ingestRate) get(now time.Time) (current, peak int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.roll(now.Unix())
+	return r.last, r.peak
+}
+
+// reset forgets the lines counted so far.
+func (r *ingestRate) reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.second, r.count = 0, 0
+	r.last, r.peak = 0, 0
+}
+
+// roll moves on to counting the given second if it is a later one. The lock
+// must be held.
+func (r *ingestRate) roll(second int64) {
+	if second <= r.second {
+		return
+	}
+	if second == r.second+1 {
+		r.last = r.count
+	} else {
+		// No line was read in the seconds between.
+		r.last = 0
+	}
+	r.peak = max(r.peak, r.last)
+	r.second, r.count = second, 0
+}
+
+// rateUpdate is posted to the application to redraw the ingest rate while
+// following.
+type rateUpdate struct{}
+
+// updateRate posts rateUpdate every rateUpdateInterval while the buffer follows
+// the input, until the context is done.
+func updateRate(ctx context.Context, buffer *Buffer, screen Screen) {
+	ticker := time.NewTicker(rateUpdateInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if buffer.FollowMode() {
+			screen.PostEvent(tcell.NewEventInterrupt(rateUpdate{}))
+		}
+	}
+}
+
+// formatRate describes the ingest rate for the status bar.
+func formatRate(current, peak int64) string {
+	return fmt.Sprintf("%s lines/s (peak %s)", formatCount(current), formatCount(peak))
+}
@@ -0,0 +1,35 @@
+package view
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIngestRate_CountsFullSeconds(t *testing.T) {
+	var r ingestRate
+	start := time.Unix(1700000000, 0)
+	for i := 0; i < 5; i++ {
+		r.add(start)
+	}
+	// The second being counted isn't over yet.
+	current, peak := r.get(start.Add(500 * time.Millisecond))
+	assert.EqualValues(t, 0, current)
+	assert.EqualValues(t, 0, peak)
+
+	r.add(start.Add(time.Second))
+	current, peak = r.get(start.Add(time.Second))
+	assert.EqualValues(t, 5, current)
+	assert.EqualValues(t, 5, peak)
+
+	// The input went quiet, so the rate drops but the peak stays.
+	current, peak = r.get(start.Add(3 * time.Second))
+	assert.EqualValues(t, 0, current)
+	assert.EqualValues(t, 5, peak)
+
+	r.reset()
+	current, peak = r.get(start.Add(4 * time.Second))
+	assert.EqualValues(t, 0, current)
+	assert.EqualValues(t, 0, peak)
+}
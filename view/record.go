@@ -0,0 +1,245 @@
+package view
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/uniseg"
+)
+
+type record struct {
+	// Byte offset of the start of the record in the input file.
+	byteOffset int64
+	// Number of bytes the record spans in the input file, including its
+	// trailing newline.
+	byteLen int
+	// The line number of the record in the input file, counting from 1, or 0
+	// if unknown.
+	lineNumber int64
+
+	// The original line the record was parsed from, as read from the input
+	// file.
+	raw []byte
+	// The length of the line the record was read from if it was too long to
+	// read whole, so raw holds only its start. It is 0 if raw is the whole
+	// line.
+	fullLen int
+
+	// The buffer that holds the record as it is displayed, after it was
+	// transformed by the jq expression.
+	buf []byte
+	// If true, buf is a part of raw, so it holds on to no memory of its own.
+	bufInRaw bool
+	// The length of the start of buf that the record's own line is displayed
+	// as, if continuation lines were joined to it after a line break. It is 0
+	// if no lines were joined.
+	textLen int
+
+	// The record laid out into lines in the layouts it was recently shown in,
+	// most recent first. Laying out is done lazily by layOut, and only the
+	// last few layouts are kept so switching between them, like toggling
+	// wrapping or resizing back, doesn't lay the record out again.
+	laidOut []*laidOutRecord
+
+	// If true, the record is collapsed to a single summary line if it spans
+	// more than one line.
+	collapsed bool
+
+	// The number of records right after this one that display the same, and
+	// were collapsed into it. The record spans them in the input file too.
+	repeats int
+
+	// The spans of buf to highlight as JSON tokens. They are computed lazily by
+	// Spans.
+	spans []jsonSpan
+	// If true, spans were computed.
+	hasSpans bool
+
+	// The spans of buf styled by the ANSI escape sequences that were removed
+	// from it, sorted by offset.
+	ansiSpans []styleSpan
+
+	// A struct that holds the parsed record.
+	parsed any
+	// The level of the record, or levelUnknown if it has none.
+	level logLevel
+
+	// The style of the first highlight rule that matched the record, if
+	// hasRuleStyle is true.
+	ruleStyle    tcell.Style
+	hasRuleStyle bool
+
+	// If true, the filter excluded the record, which is shown dimmed as it was
+	// read instead of hidden.
+	excluded bool
+}
+
+// lineLayout describes how records are laid out into screen lines.
+type lineLayout struct {
+	// The width of the screen lines.
+	width int
+	// If true, records are wrapped into as many lines as needed to fit the
+	// width. Otherwise each record is truncated to a single line.
+	wrap bool
+}
+
+// maxLaidOutLayouts is the number of layouts a record keeps its lines for.
+const maxLaidOutLayouts = 3
+
+// laidOutRecord holds the lines a record is made of in one layout.
+type laidOutRecord struct {
+	// The layout the lines were computed for.
+	layout lineLayout
+	// Whether the record was collapsed, and how many repeats it had, when the
+	// lines were computed.
+	collapsed bool
+	repeats   int
+
+	// The lines that make up the record.
+	lines []string
+	// The byte offset of each of the lines within the record's buffer.
+	offsets []int
+	// The length of the marker at the end of the record's last line, such as
+	// the number of lines hidden in a collapsed record, or 0 if it has none.
+	markerLen int
+}
+
+func newRecord(byteOffset int64, buf []byte) *record {
+	return &record{
+		byteOffset: byteOffset,
+		buf:        buf,
+	}
+}
+
+// Lines returns the lines that make up the record in the given layout. A
+// record always spans at least one line, even if it is empty or there is no
+// room to display it.
+func (r *record) Lines(layout lineLayout) []string {
+	return r.layOut(layout).lines
+}
+
+// layOut returns the record laid out in the given layout, reusing the lines
+// computed the last time it was laid out the same way.
+func (r *record) layOut(layout lineLayout) *laidOutRecord {
+	for i, laidOut := range r.laidOut {
+		if laidOut.layout != layout || laidOut.collapsed != r.collapsed || laidOut.repeats != r.repeats {
+			continue
+		}
+		// Move it to the front so the least recently used one is evicted
+		// first.
+		copy(r.laidOut[1:i+1], r.laidOut[:i])
+		r.laidOut[0] = laidOut
+		return laidOut
+	}
+
+	var lines []string
+	if layout.wrap {
+		lines = WordWrap(string(r.buf), layout.width)
+	} else {
+		lines = []string{Truncate(string(r.buf), layout.width)}
+	}
+	if len(lines) == 0 {
+		lines = []string{""}
+	}
+
+	// Lines are consecutive substrings of buf, except for line breaks that
+	// may be dropped between them, so look for each one after the previous.
+	text := string(r.buf)
+	offsets := make([]int, len(lines))
+	pos := 0
+	for i, line := range lines {
+		if idx := strings.Index(text[pos:], line); idx >= 0 {
+			pos += idx
+		}
+		offsets[i] = pos
+		pos += len(line)
+	}
+
+	marker := ""
+	if r.collapsed && len(lines) > 1 {
+		marker = fmt.Sprintf(" [+%d lines]", len(lines)-1)
+		lines, offsets = lines[:1], offsets[:1]
+	}
+	if r.fullLen > 0 {
+		marker += fmt.Sprintf(" …truncated (%d bytes)", r.fullLen)
+	}
+	if r.repeats > 0 {
+		marker += fmt.Sprintf(" ×%d repeated", r.repeats+1)
+	}
+
+	if marker != "" {
+		last := len(lines) - 1
+		markerWidth := uniseg.StringWidth(marker)
+		switch {
+		case len(lines) == 1 && (!layout.wrap || r.collapsed):
+			// Summarize the record with as much of its line as fits next to
+			// the marker.
+			lines[0] = Truncate(lines[0], max(layout.width-markerWidth, 0)) + marker
+		case uniseg.StringWidth(lines[last])+markerWidth <= layout.width:
+			lines[last] += marker
+		default:
+			// There's no room for the marker after the last line, so it goes
+			// on a line of its own.
+			lines = append(lines, marker)
+			offsets = append(offsets, len(r.buf))
+		}
+	}
+
+	laidOut := &laidOutRecord{
+		layout:    layout,
+		collapsed: r.collapsed,
+		repeats:   r.repeats,
+		lines:     lines,
+		offsets:   offsets,
+		markerLen: len(marker),
+	}
+	if len(r.laidOut) < maxLaidOutLayouts {
+		r.laidOut = append(r.laidOut, nil)
+	}
+	copy(r.laidOut[1:], r.laidOut)
+	r.laidOut[0] = laidOut
+	return laidOut
+}
+
+// displaysSame returns true if the given records are displayed the same, so
+// one repeats the other.
+func displaysSame(r, other *record) bool {
+	return r.excluded == other.excluded && bytes.Equal(r.buf, other.buf)
+}
+
+// mergeRepeat returns a record that stands for the given record and the later
+// one that repeats it, which must display the same.
+func mergeRepeat(r, later *record) *record {
+	merged := *r
+	merged.byteLen = int(later.byteOffset-r.byteOffset) + later.byteLen
+	merged.repeats = r.repeats + later.repeats + 1
+	// The lines were laid out without the new marker, and the copy must not
+	// share the original's cache.
+	merged.laidOut = nil
+	return &merged
+}
+
+// Spans returns the spans of the record's buffer to highlight as JSON tokens.
+func (r *record) Spans() []jsonSpan {
+	if !r.hasSpans {
+		// Continuation lines aren't JSON, so they aren't highlighted.
+		text := r.buf
+		if r.textLen > 0 {
+			text = text[:r.textLen]
+		}
+		r.spans = tokenizeJSON(text)
+		r.hasSpans = true
+	}
+	return r.spans
+}
+
+// size returns the approximate number of bytes the record holds on to. It
+// counts the record's text and original line, which dominate its footprint.
+func (r *record) size() int {
+	if r.bufInRaw {
+		return len(r.raw)
+	}
+	return len(r.buf) + len(r.raw)
+}
@@ -0,0 +1,205 @@
+package view
+
+// minRecordDequeCap is the capacity a record deque starts with once a record is
+// added to it.
+const minRecordDequeCap = 16
+
+// recordDeque holds records in a ring buffer along with the number of lines
+// each of them spans, so records can be added and removed at both ends and
+// looked up by index in constant time.
+//
+// The line counts are kept in a Fenwick tree over the ring's slots, so the
+// number of lines before a record, and the record a given line belongs to, are
+// found in logarithmic time.
+type recordDeque struct {
+	// The ring of records. Its length is the capacity of the deque, which is
+	// always a power of 2 so indices wrap around with a mask.
+	records []*record
+	// The number of lines the record in each slot spans, 0 for empty slots.
+	lines []int
+	// Fenwick tree over lines, indexed from 1.
+	tree []int
+
+	// The slot of the first record.
+	start int
+	// The number of records in the deque.
+	count int
+}
+
+// slot returns the slot of the record at the given index.
+func (d *recordDeque) slot(i int) int {
+	return (d.start + i) & (len(d.records) - 1)
+}
+
+// at returns the record at the given index.
+func (d *recordDeque) at(i int) *record {
+	return d.records[d.slot(i)]
+}
+
+// linesAt returns the number of lines the record at the given index spans.
+func (d *recordDeque) linesAt(i int) int {
+	return d.lines[d.slot(i)]
+}
+
+// set replaces the record at the given index, keeping its number of lines.
+func (d *recordDeque) set(i int, r *record) {
+	d.records[d.slot(i)] = r
+}
+
+// pushBack adds a record spanning the given number of lines after the last
+// one.
+func (d *recordDeque) pushBack(r *record, lines int) {
+	d.grow()
+	s := d.slot(d.count)
+	d.records[s] = r
+	d.lines[s] = lines
+	d.add(s, lines)
+	d.count++
+}
+
+// pushFront adds a record spanning the given number of lines before the first
+// one.
+func (d *recordDeque) pushFront(r *record, lines int) {
+	d.grow()
+	d.start = (d.start - 1) & (len(d.records) - 1)
+	d.records[d.start] = r
+	d.lines[d.start] = lines
+	d.add(d.start, lines)
+	d.count++
+}
+
+// popFront removes the first record and returns it along with the number of
+// lines it spanned. The deque must not be empty.
+func (d *recordDeque) popFront() (*record, int) {
+	r, lines := d.clearSlot(d.start)
+	d.start = (d.start + 1) & (len(d.records) - 1)
+	d.count--
+	return r, lines
+}
+
+// popBack removes the last record and returns it along with the number of
+// lines it spanned. The deque must not be empty.
+func (d *recordDeque) popBack() (*record, int) {
+	r, lines := d.clearSlot(d.slot(d.count - 1))
+	d.count--
+	return r, lines
+}
+
+// clearSlot empties the given slot and returns what it held.
+func (d *recordDeque) clearSlot(s int) (*record, int) {
+	r, lines := d.records[s], d.lines[s]
+	d.add(s, -lines)
+	// Don't keep the record alive after it is removed.
+	d.records[s] = nil
+	d.lines[s] = 0
+	return r, lines
+}
+
+// clear removes all the records and releases the ring.
+func (d *recordDeque) clear() {
+	*d = recordDeque{}
+}
+
+// setLines changes the number of lines the record at the given index spans.
+func (d *recordDeque) setLines(i int, lines int) {
+	s := d.slot(i)
+	d.add(s, lines-d.lines[s])
+	d.lines[s] = lines
+}
+
+// setAllLines sets the number of lines every record spans to what the given
+// function returns for it.
+func (d *recordDeque) setAllLines(linesOf func(*record) int) {
+	for i := 0; i < d.count; i++ {
+		s := d.slot(i)
+		d.lines[s] = linesOf(d.records[s])
+	}
+	d.rebuild()
+}
+
+// linesBefore returns the number of lines the records before the given index
+// span.
+func (d *recordDeque) linesBefore(i int) int {
+	if i <= 0 {
+		return 0
+	}
+
+	end := d.start + i
+	if end <= len(d.records) {
+		return d.prefix(end) - d.prefix(d.start)
+	}
+	// The records wrap around the end of the ring.
+	return d.prefix(len(d.records)) - d.prefix(d.start) + d.prefix(end-len(d.records))
+}
+
+// find returns the index of the record the given line belongs to, and the
+// offset of the line within the record. The line must be one of the lines the
+// records span.
+func (d *recordDeque) find(line int) (int, int) {
+	// Look for the line as if the ring was unrolled from its start slot, and
+	// wrap around if it's within the records that wrapped.
+	target := d.prefix(d.start) + line
+	if untilEnd := d.prefix(len(d.records)); target >= untilEnd {
+		target -= untilEnd
+	}
+
+	// Find the last slot whose preceding slots span no more than the target
+	// lines. Empty slots span no lines, so it is never one of them.
+	s, offset := 0, target
+	for step := len(d.records); step > 0; step >>= 1 {
+		if next := s + step; next < len(d.tree) && d.tree[next] <= offset {
+			s = next
+			offset -= d.tree[next]
+		}
+	}
+	return (s - d.start) & (len(d.records) - 1), offset
+}
+
+// grow doubles the ring's capacity if it is full, unrolling it so the first
+// record is in the first slot.
+func (d *recordDeque) grow() {
+	if d.count < len(d.records) {
+		return
+	}
+
+	capacity := max(minRecordDequeCap, 2*len(d.records))
+	records := make([]*record, capacity)
+	lines := make([]int, capacity)
+	for i := 0; i < d.count; i++ {
+		s := d.slot(i)
+		records[i] = d.records[s]
+		lines[i] = d.lines[s]
+	}
+	d.records = records
+	d.lines = lines
+	d.start = 0
+	d.rebuild()
+}
+
+// rebuild builds the Fenwick tree from the line counts.
+func (d *recordDeque) rebuild() {
+	d.tree = make([]int, len(d.lines)+1)
+	for i, lines := range d.lines {
+		j := i + 1
+		d.tree[j] += lines
+		if parent := j + j&-j; parent < len(d.tree) {
+			d.tree[parent] += d.tree[j]
+		}
+	}
+}
+
+// add adds delta to the lines of the given slot in the Fenwick tree.
+func (d *recordDeque) add(s int, delta int) {
+	for j := s + 1; j < len(d.tree); j += j & -j {
+		d.tree[j] += delta
+	}
+}
+
+// prefix returns the number of lines the slots before the given one span.
+func (d *recordDeque) prefix(s int) int {
+	sum := 0
+	for j := s; j > 0; j -= j & -j {
+		sum += d.tree[j]
+	}
+	return sum
+}
@@ -0,0 +1,38 @@
+package view
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordDeque_FindsLinesAcrossTheRingsEnd(t *testing.T) {
+	var d recordDeque
+	records := make([]*record, 0)
+	// Prepend and append past the initial capacity so the records wrap around
+	// the end of the ring, and the ring grows while they do.
+	for i := 0; i < minRecordDequeCap+3; i++ {
+		front, back := newRecord(-1, nil), newRecord(-1, nil)
+		d.pushFront(front, 1+i%3)
+		d.pushBack(back, 1+i%2)
+		records = append([]*record{front}, records...)
+		records = append(records, back)
+	}
+	d.popFront()
+	d.popBack()
+	records = records[1 : len(records)-1]
+
+	assert.EqualValues(t, len(records), d.count)
+	line := 0
+	for i, r := range records {
+		assert.Same(t, r, d.at(i))
+		assert.EqualValues(t, line, d.linesBefore(i))
+		for offset := 0; offset < d.linesAt(i); offset++ {
+			index, found := d.find(line + offset)
+			assert.EqualValues(t, i, index)
+			assert.EqualValues(t, offset, found)
+		}
+		line += d.linesAt(i)
+	}
+	assert.EqualValues(t, line, d.linesBefore(d.count))
+}
@@ -0,0 +1,71 @@
+package view
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecord_LinesTrackOffsetsInBuffer(t *testing.T) {
+	r := newRecord(0, []byte("ab cd\nef"))
+
+	laidOut := r.layOut(lineLayout{width: 3, wrap: true})
+	assert.EqualValues(t, []string{"ab ", "cd", "ef"}, laidOut.lines)
+	assert.EqualValues(t, []int{0, 3, 6}, laidOut.offsets)
+}
+
+func TestRecord_TruncatedRecordSpansOneLine(t *testing.T) {
+	r := newRecord(0, []byte("abcdef"))
+
+	laidOut := r.layOut(lineLayout{width: 3, wrap: false})
+	assert.EqualValues(t, []string{"abc"}, laidOut.lines)
+	assert.EqualValues(t, []int{0}, laidOut.offsets)
+}
+
+func TestRecord_EmptyRecordSpansOneLine(t *testing.T) {
+	r := newRecord(0, []byte("abc"))
+
+	lines := r.Lines(lineLayout{width: 0, wrap: true})
+	assert.EqualValues(t, []string{""}, lines)
+}
+
+func TestRecord_KeepsLinesOfRecentLayouts(t *testing.T) {
+	r := newRecord(0, []byte("ab cd ef"))
+
+	narrow := r.layOut(lineLayout{width: 3, wrap: true})
+	wide := r.layOut(lineLayout{width: 80, wrap: true})
+	assert.EqualValues(t, []string{"ab cd ef"}, wide.lines)
+
+	// Going back to a recent layout reuses its lines.
+	assert.Same(t, narrow, r.layOut(lineLayout{width: 3, wrap: true}))
+
+	// Collapsing lays the record out again.
+	r.collapsed = true
+	assert.NotSame(t, narrow, r.layOut(lineLayout{width: 3, wrap: true}))
+
+	// Only the most recent layouts are kept.
+	for width := 4; width < 4+maxLaidOutLayouts; width++ {
+		r.layOut(lineLayout{width: width, wrap: true})
+	}
+	assert.Len(t, r.laidOut, maxLaidOutLayouts)
+	assert.NotSame(t, wide, r.layOut(lineLayout{width: 80, wrap: true}))
+}
+
+func TestRecord_RepeatMarkerFollowsLastLine(t *testing.T) {
+	r := newRecord(0, []byte("ab cd"))
+	r.repeats = 1
+
+	// It fits after the last line.
+	laidOut := r.layOut(lineLayout{width: 20, wrap: true})
+	assert.EqualValues(t, []string{"ab cd ×2 repeated"}, laidOut.lines)
+	assert.EqualValues(t, len(" ×2 repeated"), laidOut.markerLen)
+
+	// It doesn't, so it goes on a line of its own.
+	laidOut = r.layOut(lineLayout{width: 3, wrap: true})
+	assert.EqualValues(t, []string{"ab ", "cd", " ×2 repeated"}, laidOut.lines)
+	assert.EqualValues(t, []int{0, 3, 5}, laidOut.offsets)
+
+	// A truncated record makes room for it.
+	laidOut = r.layOut(lineLayout{width: 14, wrap: false})
+	assert.EqualValues(t, []string{"ab ×2 repeated"}, laidOut.lines)
+}
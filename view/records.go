@@ -0,0 +1,107 @@
+package view
+
+import (
+	"context"
+)
+
+// Record is a record loaded into a buffer, as it is given to library users.
+type Record struct {
+	// The offset of the record in the input, and its line number counting
+	// from 1, or 0 if unknown.
+	Offset int64
+	Line   int64
+	// The number of bytes the record spans in the input, including the
+	// delimiter it ends with.
+	Size int
+	// The line the record was parsed from, as it was read. It may be only the
+	// start of a line that was too long to read whole. It is shared with the
+	// buffer, so it must not be modified.
+	Raw []byte
+	// The record as it was parsed from its line, before the filter
+	// transformed it.
+	Parsed any
+	// The record as it is displayed.
+	Text string
+}
+
+// export returns the record as it is given to library users.
+func (r *record) export() Record {
+	return Record{
+		Offset: r.byteOffset,
+		Line:   r.lineNumber,
+		Size:   r.byteLen,
+		Raw:    r.raw,
+		Parsed: r.parsed,
+		Text:   string(r.buf),
+	}
+}
+
+// RecordIterator iterates over the records that were loaded in a buffer when
+// it was created. It is used like a bufio.Scanner:
+//
+//	it := buffer.Records(ctx)
+//	for it.Next() {
+//		r := it.Record()
+//		...
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type RecordIterator struct {
+	ctx     context.Context
+	records []*record
+	next    int
+	current Record
+	err     error
+}
+
+// Records returns an iterator over the records loaded in the buffer, in the
+// order they are in the input. It iterates over a snapshot of them, so the
+// readers loading and pruning records meanwhile don't affect it, and stops
+// once the context is done.
+func (b *Buffer) Records(ctx context.Context) *RecordIterator {
+	snapshot := b.records.WithLock(func(records *bufferRecordList) any {
+		snapshot := make([]*record, 0, records.Len())
+		for i := 0; i < records.Len(); i++ {
+			// Records the filter excluded are only shown, dimmed.
+			if r := records.RecordAt(i); !r.excluded {
+				snapshot = append(snapshot, r)
+			}
+		}
+		return snapshot
+	}).([]*record)
+	return &RecordIterator{ctx: ctx, records: snapshot}
+}
+
+// Next advances the iterator to the next record, which Record then returns. It
+// returns false when there are no more records or the context is done, which
+// Err then returns.
+func (it *RecordIterator) Next() bool {
+	if it.err != nil || it.next >= len(it.records) {
+		return false
+	}
+	if err := it.ctx.Err(); err != nil {
+		it.err = err
+		return false
+	}
+	it.current = it.records[it.next].export()
+	it.next++
+	return true
+}
+
+// Record returns the record Next advanced to.
+func (it *RecordIterator) Record() Record {
+	return it.current
+}
+
+// Len returns the number of records in the snapshot, including the ones
+// already iterated over.
+func (it *RecordIterator) Len() int {
+	return len(it.records)
+}
+
+// Err returns the context's error if it was done before all the records were
+// iterated over.
+func (it *RecordIterator) Err() error {
+	return it.err
+}
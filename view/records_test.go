@@ -0,0 +1,67 @@
+package view
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuffer_RecordsIteratesOverASnapshot(t *testing.T) {
+	line := `{"time":1700000000000,"name":"Pelecard","msg":"hi"}`
+	file, _ := createTestFile(t, line+"\n"+line+"\n"+line+"\n")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	buffer, err := NewBuffer(80, 10, false, file, ctx)
+	assert.NoError(t, err)
+	assert.NoError(t, buffer.SeekAndPopulate(0, 0))
+	assert.Eventually(t, func() bool { return buffer.Status().Records == 3 }, time.Second, 5*time.Millisecond)
+
+	iterCtx, cancelIter := context.WithCancel(ctx)
+	cancelIter()
+	canceled := buffer.Records(iterCtx)
+	it := buffer.Records(ctx)
+	// Records pruned after the snapshot was taken are still iterated over.
+	buffer.records.WithLock(func(records *bufferRecordList) any {
+		records.Clear()
+		return nil
+	})
+	var offsets []int64
+	for it.Next() {
+		r := it.Record()
+		assert.EqualValues(t, line, r.Raw)
+		assert.EqualValues(t, len(line)+1, r.Size)
+		offsets = append(offsets, r.Offset)
+	}
+	assert.NoError(t, it.Err())
+	assert.EqualValues(t, []int64{0, int64(len(line) + 1), 2 * int64(len(line)+1)}, offsets)
+	assert.EqualValues(t, 3, it.Len())
+
+	// Iterating stops once the context is done.
+	assert.False(t, canceled.Next())
+	assert.ErrorIs(t, canceled.Err(), context.Canceled)
+}
+
+func TestBuffer_RecordsSkipsExcludedRecords(t *testing.T) {
+	line := `{"time":1700000000000,"name":"Pelecard","msg":"hi"}`
+	other := `{"time":1700000000000,"name":"Other","msg":"hi"}`
+	file, _ := createTestFile(t, line+"\n"+other+"\n"+line+"\n")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	buffer, err := NewBuffer(80, 10, false, file, ctx)
+	assert.NoError(t, err)
+	assert.NoError(t, buffer.SetFilter(defaultFilter))
+	buffer.SetDimExcluded(true)
+	assert.NoError(t, buffer.SeekAndPopulate(0, 0))
+	assert.Eventually(t, func() bool { return buffer.Status().Records == 3 }, time.Second, 5*time.Millisecond)
+
+	var offsets []int64
+	it := buffer.Records(ctx)
+	for it.Next() {
+		offsets = append(offsets, it.Record().Offset)
+	}
+	assert.EqualValues(t, []int64{0, int64(len(line) + len(other) + 2)}, offsets)
+}
@@ -0,0 +1,128 @@
+package view
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// sampling selects the records that are loaded for an overview of a huge
+// input, rather than all of them. The zero value loads all of them.
+type sampling struct {
+	// Load every Nth line, if more than 1.
+	every int64
+	// Otherwise, load each line with this probability, if less than 1.
+	rate float64
+}
+
+// parseSampling parses the value of the -sample flag: N for every Nth line,
+// P% for P percent of the lines picked at random, or off.
+func parseSampling(value string) (sampling, error) {
+	value = strings.TrimSpace(value)
+	if value == "" || value == "off" {
+		return sampling{}, nil
+	}
+	if percent, ok := strings.CutSuffix(value, "%"); ok {
+		p, err := strconv.ParseFloat(percent, 64)
+		if err != nil || p <= 0 || p > 100 {
+			return sampling{}, fmt.Errorf("invalid sample %q, expected a percentage above 0 and up to 100", value)
+		}
+		return sampling{rate: p / 100}, nil
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || n < 1 {
+		return sampling{}, fmt.Errorf("invalid sample %q, expected N for every Nth line, P%% or off", value)
+	}
+	return sampling{every: n}, nil
+}
+
+// on returns true if only some of the records are loaded.
+func (s sampling) on() bool {
+	return s.every > 1 || (s.rate > 0 && s.rate < 1)
+}
+
+// String returns the sampling as it is given on the command line.
+func (s sampling) String() string {
+	switch {
+	case s.every > 1:
+		return strconv.FormatInt(s.every, 10)
+	case s.rate > 0 && s.rate < 1:
+		return strconv.FormatFloat(s.rate*100, 'g', -1, 64) + "%"
+	}
+	return "off"
+}
+
+// keeps returns true if the line at the given offset, with the given line
+// number or 0 if it isn't known, is sampled. Lines are picked by where they are
+// rather than in the order they are read, so the readers pick the same ones
+// whichever way and from wherever they read.
+func (s sampling) keeps(pos, lineNumber int64) bool {
+	switch {
+	case s.every > 1 && lineNumber > 0:
+		return (lineNumber-1)%s.every == 0
+	case s.every > 1:
+		// Without line numbers, every Nth line is approximated by picking
+		// one in N at random.
+		return sampleKey(pos) < 1/float64(s.every)
+	case s.rate > 0 && s.rate < 1:
+		return sampleKey(pos) < s.rate
+	}
+	return true
+}
+
+// sampleKey maps the given offset to a number in [0, 1) that is spread evenly
+// across offsets, but always the same for the same one.
+func sampleKey(pos int64) float64 {
+	// The finalizer of SplitMix64.
+	z := uint64(pos) + 0x9e3779b97f4a7c15
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	z ^= z >> 31
+	return float64(z>>11) / (1 << 53)
+}
+
+// formatSampling describes the sampling for the status bar.
+func formatSampling(s sampling) string {
+	if s.every > 1 {
+		return fmt.Sprintf("[sampled 1 in %s]", formatCount(s.every))
+	}
+	return "[sampled " + s.String() + "]"
+}
+
+// SetSampling sets which records are loaded for an overview of a huge input.
+// It takes effect for records loaded after the next call to SeekAndPopulate.
+// Records that aren't sampled are still saved and searched.
+func (b *Buffer) SetSampling(s sampling) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.sample = s
+}
+
+// Sampling returns which records are loaded.
+func (b *Buffer) Sampling() sampling {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.sample
+}
+
+// setSampling sets which records are loaded, and reloads them where the screen
+// is.
+func (a *Application) setSampling(s sampling) error {
+	a.buffer.SetSampling(s)
+	return a.reload()
+}
+
+// toggleSampling loads all of the records around the screen when they are
+// sampled, or samples them again as configured.
+func (a *Application) toggleSampling() error {
+	if a.buffer.Sampling().on() {
+		return a.setSampling(sampling{})
+	}
+	if !a.config.Sample.on() {
+		a.notify("no sample was given with -sample or :set sample")
+		return nil
+	}
+	return a.setSampling(a.config.Sample)
+}
@@ -0,0 +1,101 @@
+package view
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSampling(t *testing.T) {
+	s, err := parseSampling("100")
+	assert.NoError(t, err)
+	assert.EqualValues(t, sampling{every: 100}, s)
+	assert.EqualValues(t, "[sampled 1 in 100]", formatSampling(s))
+
+	s, err = parseSampling("2.5%")
+	assert.NoError(t, err)
+	assert.EqualValues(t, "2.5%", s.String())
+	assert.True(t, s.on())
+
+	for _, value := range []string{"off", "1", "100%"} {
+		s, err = parseSampling(value)
+		assert.NoError(t, err)
+		assert.False(t, s.on(), value)
+	}
+	for _, value := range []string{"0", "-3", "x", "0%", "101%"} {
+		_, err = parseSampling(value)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestSampling_KeepsTheSameLinesWhereverTheyAreReadFrom(t *testing.T) {
+	every := sampling{every: 10}
+	assert.True(t, every.keeps(500, 1))
+	assert.False(t, every.keeps(500, 2))
+	assert.True(t, every.keeps(0, 21))
+
+	// Without line numbers, lines are picked by their offsets at about the
+	// same rate.
+	kept := 0
+	for pos := int64(0); pos < 100000; pos += 100 {
+		if every.keeps(pos, 0) {
+			kept++
+		}
+		assert.EqualValues(t, every.keeps(pos, 0), every.keeps(pos, 0))
+	}
+	assert.InDelta(t, 100, kept, 30)
+
+	kept = 0
+	rate := sampling{rate: 0.25}
+	for pos := int64(0); pos < 100000; pos += 100 {
+		if rate.keeps(pos, 0) {
+			kept++
+		}
+	}
+	assert.InDelta(t, 250, kept, 50)
+}
+
+func TestApplication_SamplingShowsEveryNthLineUntilToggled(t *testing.T) {
+	var lines strings.Builder
+	for i := 0; i < 100; i++ {
+		fmt.Fprintf(&lines, `{"time":1700000000000,"name":"Pelecard","msg":"%d"}`+"\n", i)
+	}
+	file, _ := createTestFile(t, lines.String())
+
+	ctx, cancelCtx := context.WithCancel(context.Background())
+	defer cancelCtx()
+	screen := NewMemoryScreen(80, 10)
+	a := NewApplication(file, false, &Config{NoTutorial: true, Sample: sampling{every: 10}})
+	assert.NoError(t, a.setup(ctx, screen))
+
+	row := func(y int) string {
+		return screenText(screen, y, 0, 80)
+	}
+	assert.Eventually(t, func() bool {
+		for len(screen.Events()) > 0 {
+			a.handleEvent(<-screen.Events())
+		}
+		return strings.Contains(row(1), `"msg":"10"`)
+	}, time.Second, 5*time.Millisecond)
+	assert.Contains(t, row(0), `"msg":"0"`)
+	assert.Contains(t, row(2), `"msg":"20"`)
+	assert.Contains(t, row(9), "[sampled 1 in 10]")
+
+	// Switching back to full fidelity shows all of the records from there.
+	a.handleEvent(tcell.NewEventKey(tcell.KeyRune, 'Z', tcell.ModNone))
+	assert.Eventually(t, func() bool {
+		for len(screen.Events()) > 0 {
+			a.handleEvent(<-screen.Events())
+		}
+		return strings.Contains(row(1), `"msg":"1"`)
+	}, time.Second, 5*time.Millisecond)
+	assert.NotContains(t, row(9), "sampled")
+
+	a.handleEvent(tcell.NewEventKey(tcell.KeyRune, 'Z', tcell.ModNone))
+	assert.EqualValues(t, sampling{every: 10}, a.buffer.Sampling())
+}
@@ -0,0 +1,254 @@
+package view
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+
+	"github.com/YLivay/gote/reader"
+	"github.com/gdamore/tcell/v2"
+)
+
+// saveResult is the outcome of saving records to a file, which is posted to the
+// application when the save is done.
+type saveResult struct {
+	path    string
+	records int
+	err     error
+}
+
+// message describes the result for the status bar.
+func (r *saveResult) message() string {
+	if r.err != nil {
+		return "saving failed: " + r.err.Error()
+	}
+	if r.records == 1 {
+		return "saved 1 record to " + r.path
+	}
+	return fmt.Sprintf("saved %d records to %s", r.records, r.path)
+}
+
+// WriteRecords writes the records of the input file between the given offsets
+// to w, each on its own line, as they are displayed after the jq expression
+// transformed them. Lines that aren't records are left out. A to of 0 writes up
+// to the end of the input file as it is when writing starts, so input written
+// meanwhile isn't.
+//
+// It returns how many records were written.
+func (b *Buffer) WriteRecords(w io.Writer, from, to int64) (int, error) {
+	return b.EachRecord(from, to, func(r *record) error {
+		_, err := w.Write(append(r.buf, '\n'))
+		return err
+	})
+}
+
+// EachRecord calls fn with each record of the input file between the given
+// offsets, until it returns an error. Lines that aren't records are skipped. A
+// to of 0 reads up to the end of the input file as it is when reading starts.
+//
+// The input file is read with a scanner of its own, so records are streamed to
+// fn without being loaded, and the whole of long lines is read. It returns how
+// many records fn was called with.
+func (b *Buffer) EachRecord(from, to int64, fn func(*record) error) (int, error) {
+	return b.eachRecord(from, to, nil, nil, fn)
+}
+
+// scanRecords is like EachRecord, except that it advances the given progress
+// as it reads, and stops once its context is done.
+func (b *Buffer) scanRecords(p *scanProgress, from, to int64, fn func(*record) error) (int, error) {
+	return b.eachRecord(from, to, nil, p, fn)
+}
+
+// FollowRecords is like EachRecord from the given offset, except that at the
+// end of the input file it waits for more to be written to it, every time a
+// value is received from changed, until changed is closed.
+func (b *Buffer) FollowRecords(from int64, changed <-chan struct{}, fn func(*record) error) (int, error) {
+	return b.eachRecord(from, math.MaxInt64, changed, nil, fn)
+}
+
+func (b *Buffer) eachRecord(from, to int64, changed <-chan struct{}, p *scanProgress, fn func(*record) error) (int, error) {
+	b.mu.Lock()
+	input, inputFile := b.input, b.inputFile
+	opts := []reader.Option{reader.WithDelimiter(b.delimiter)}
+	if start := b.start(); start != nil {
+		opts = append(opts, reader.WithStart(start))
+		from = max(from, start())
+	}
+	parseOpts := b.parseOptions()
+	recordStart := b.recordStart
+	rangeEnd := b.rangeEnd
+	b.mu.Unlock()
+
+	if rangeEnd >= 0 {
+		// The rest of the input is after the time range, so there is
+		// nothing to wait for.
+		opts = append(opts, reader.WithEnd(rangeEnd))
+		changed = nil
+		if to <= 0 || to > rangeEnd {
+			to = rangeEnd
+		}
+	}
+	if changed != nil {
+		opts = append(opts, reader.WithFollow(b.ctx, changed))
+	}
+	if to <= 0 {
+		info, err := inputFile.Stat()
+		if err != nil {
+			return 0, err
+		}
+		to = info.Size()
+	}
+
+	scanner := reader.NewForwardsLineScanner(input, from, opts...)
+	defer scanner.Close()
+
+	count := 0
+	var pending *record
+	flush := func() error {
+		if pending == nil {
+			return nil
+		}
+		r := pending
+		pending = nil
+		if err := fn(r); err != nil {
+			return err
+		}
+		count++
+		return nil
+	}
+	next := func(pos int64, line []byte, lineLen int, crlf bool) error {
+		if recordStart != nil && !recordStart.Match(line) {
+			// Lines of records that are skipped are skipped along with
+			// them.
+			if pending != nil {
+				pending = joinContinuation(pending, line, lineLen, crlf, parseOpts)
+			}
+			return nil
+		}
+		if err := flush(); err != nil {
+			return err
+		}
+		pending = b.readRecord(pos, line, lineLen, crlf, parseOpts)
+		if recordStart == nil {
+			// No lines are joined to the record, so there is no need to
+			// hold on to it, which would keep it from a follower until the
+			// next record is written.
+			return flush()
+		}
+		return nil
+	}
+
+	for scanner.Scan() {
+		if err := b.ctx.Err(); err != nil {
+			return count, err
+		}
+		pos := scanner.Pos()
+		if pos >= to {
+			break
+		}
+		if err := p.advance(pos); err != nil {
+			return count, err
+		}
+		if err := next(pos, scanner.Bytes(), scanner.LineLen(), scanner.CRLF()); err != nil {
+			return count, err
+		}
+	}
+	if err := scanner.Err(); err != nil && !errors.Is(err, io.EOF) {
+		return count, err
+	}
+
+	if to == math.MaxInt64 {
+		info, err := inputFile.Stat()
+		if err != nil {
+			return count, err
+		}
+		to = info.Size()
+	}
+	// The scanner holds on to a last line that no delimiter ends, which is
+	// a record all the same.
+	if pos := scanner.NextPos(); pos < to {
+		line := make([]byte, to-pos)
+		n, err := input.ReadAt(line, pos)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return count, err
+		}
+		if n > 0 && !bytes.Contains(line[:n], parseOpts.delimiter) {
+			if err := next(pos, line[:n], n, false); err != nil {
+				return count, err
+			}
+		}
+	}
+	return count, flush()
+}
+
+// selectedRange returns the offsets of the input file that the selected
+// records span, or false if there is no selection.
+func (a *Application) selectedRange() (from, to int64, ok bool) {
+	if a.selection == nil || a.selection.isEmpty() {
+		return 0, 0, false
+	}
+	_, y1, _, y2 := a.selection.ordered()
+	first, last := a.buffer.records.RecordAtScreenLine(y1), a.buffer.records.RecordAtScreenLine(y2)
+	if first == nil || last == nil || first.byteOffset < 0 || last.byteOffset < 0 {
+		return 0, 0, false
+	}
+	return first.byteOffset, recordEnd(last), true
+}
+
+// startSave prompts for the path to save records to: the selected ones, or all
+// of them if there is no selection.
+func (a *Application) startSave() {
+	label := "Save records to: "
+	from, to, selected := a.selectedRange()
+	if selected {
+		label = "Save selected records to: "
+	}
+	a.clearSelection()
+
+	a.prompt = &prompt{
+		label: label,
+		submit: func(path string) {
+			if path == "" {
+				return
+			}
+			a.saveRecords(path, func(w io.Writer) (int, error) {
+				return a.buffer.WriteRecords(w, from, to)
+			})
+		},
+	}
+}
+
+// saveRecords writes records to the file at the given path with the given
+// function in the background, and reports the outcome in the status bar when
+// it is done.
+func (a *Application) saveRecords(path string, write func(io.Writer) (int, error)) {
+	a.message = "saving to " + path + "..."
+	screen := a.screen
+	go func() {
+		result := &saveResult{path: path}
+		result.records, result.err = saveToFile(path, write)
+		screen.PostEvent(tcell.NewEventInterrupt(result))
+	}()
+}
+
+// saveToFile writes records to the file at the given path with the given
+// function, replacing the file.
+func saveToFile(path string, write func(io.Writer) (int, error)) (int, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return 0, err
+	}
+	w := bufio.NewWriter(f)
+	n, err := write(w)
+	if err == nil {
+		err = w.Flush()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return n, err
+}
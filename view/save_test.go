@@ -0,0 +1,96 @@
+package view
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/itchyny/gojq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuffer_WriteRecordsFiltersAndTransforms(t *testing.T) {
+	line := `{"time":1700000000000,"name":"Pelecard","msg":"hi"}` + "\n"
+	other := `{"time":1700000000000,"name":"other","msg":"hi"}` + "\n"
+	file, _ := createTestFile(t, line+"not a json line\n"+other+line)
+
+	buffer, err := NewBuffer(80, 2, false, file, context.Background())
+	assert.NoError(t, err)
+	assert.NoError(t, buffer.SetFilter(defaultFilter))
+
+	var out bytes.Buffer
+	n, err := buffer.WriteRecords(&out, 0, 0)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, n)
+	record := `{"msg":"hi","name":"Pelecard","time":"2023-11-14T22:13:20Z"}` + "\n"
+	assert.EqualValues(t, record+record, out.String())
+
+	// Only the records that start in the range are written.
+	out.Reset()
+	n, err = buffer.WriteRecords(&out, 1, int64(len(line)+len("not a json line\n")+len(other)+1))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, n)
+	assert.EqualValues(t, record, out.String())
+}
+
+func TestBuffer_WriteRecordsJoinsContinuationLines(t *testing.T) {
+	file, _ := createTestFile(t, "{\"a\":1}\n  trace\n{\"b\":2}\n")
+
+	buffer, err := NewBuffer(80, 2, false, file, context.Background())
+	assert.NoError(t, err)
+	assert.NoError(t, buffer.SetRecordStart(defaultRecordStart))
+	parsed, err := gojq.Parse(".")
+	assert.NoError(t, err)
+	buffer.jqExpr, err = gojq.Compile(parsed)
+	assert.NoError(t, err)
+
+	var out bytes.Buffer
+	n, err := buffer.WriteRecords(&out, 0, 0)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, n)
+	assert.EqualValues(t, "{\"a\":1}\n  trace\n{\"b\":2}\n", out.String())
+}
+
+func TestApplication_SavesRecordsToTypedPath(t *testing.T) {
+	line := `{"time":1700000000000,"name":"Pelecard","msg":"hi"}` + "\n"
+	file, _ := createTestFile(t, strings.Repeat(line, 3))
+	path := filepath.Join(t.TempDir(), "saved.log")
+
+	ctx, cancelCtx := context.WithCancel(context.Background())
+	defer cancelCtx()
+	screen := tcell.NewSimulationScreen("UTF-8")
+	assert.NoError(t, screen.Init())
+	defer screen.Fini()
+	screen.SetSize(80, 25)
+
+	a := NewApplication(file, false, &Config{NoTutorial: true})
+	assert.NoError(t, a.setup(ctx, screen))
+
+	a.handleEvent(tcell.NewEventKey(tcell.KeyRune, 's', tcell.ModNone))
+	assert.NotNil(t, a.prompt)
+	for _, r := range path {
+		a.handleEvent(tcell.NewEventKey(tcell.KeyRune, r, tcell.ModNone))
+	}
+	a.handleEvent(tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModNone))
+	assert.Nil(t, a.prompt)
+
+	// The result is posted to the application once the records are saved.
+	var result *saveResult
+	assert.Eventually(t, func() bool {
+		if ev, ok := screen.PollEvent().(*tcell.EventInterrupt); ok {
+			result, _ = ev.Data().(*saveResult)
+		}
+		return result != nil
+	}, time.Second, 5*time.Millisecond)
+	assert.NoError(t, result.err)
+	assert.EqualValues(t, 3, result.records)
+
+	saved, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3, strings.Count(string(saved), "\n"))
+}
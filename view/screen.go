@@ -0,0 +1,212 @@
+package view
+
+import (
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// Screen is what the application draws on, and posts the events of the
+// buffer to. A tcell.Screen is one, and other frontends provide one to drive
+// the application with the events they receive.
+type Screen interface {
+	// Size returns the width and height of the screen in cells.
+	Size() (width, height int)
+	// SetContent sets the contents of a cell, which may be a wide
+	// character. Cells outside of the screen are ignored.
+	SetContent(x, y int, primary rune, combining []rune, style tcell.Style)
+	// GetContent returns the contents of a cell and how wide it is.
+	GetContent(x, y int) (primary rune, combining []rune, style tcell.Style, width int)
+	// Clear empties every cell.
+	Clear()
+	ShowCursor(x, y int)
+	HideCursor()
+	// Show presents what was drawn since it was last shown, and Sync all of
+	// it.
+	Show()
+	Sync()
+	// Colors returns how many colors the screen shows, which the theme is
+	// degraded to.
+	Colors() int
+	// PostEvent queues an event for the application to handle.
+	PostEvent(ev tcell.Event) error
+	EnableMouse(flags ...tcell.MouseFlags)
+	// Suspend hands the terminal over to commands run on it until Resume
+	// is called.
+	Suspend() error
+	Resume() error
+}
+
+// ErrEventQueueFull is returned by MemoryScreen.PostEvent when events aren't
+// received as fast as they are posted.
+var ErrEventQueueFull = errors.New("event queue is full")
+
+// memoryScreenEvents is how many events a MemoryScreen queues before posting
+// more fails.
+const memoryScreenEvents = 128
+
+// MemoryScreen is a Screen that keeps what is drawn on it in memory instead of
+// showing it, for tests and for frontends that present it themselves.
+type MemoryScreen struct {
+	mu            sync.Mutex
+	width, height int
+	cells         []memoryCell
+	cursorX       int
+	cursorY       int
+	cursorShown   bool
+	beeps         int
+	events        chan tcell.Event
+}
+
+type memoryCell struct {
+	primary   rune
+	combining []rune
+	style     tcell.Style
+}
+
+// NewMemoryScreen returns an empty screen of the given size.
+func NewMemoryScreen(width, height int) *MemoryScreen {
+	s := &MemoryScreen{events: make(chan tcell.Event, memoryScreenEvents)}
+	s.SetSize(width, height)
+	return s
+}
+
+// SetSize resizes the screen, emptying it, and posts a resize event.
+func (s *MemoryScreen) SetSize(width, height int) {
+	s.mu.Lock()
+	s.width, s.height = width, height
+	s.cells = make([]memoryCell, width*height)
+	s.clear()
+	s.mu.Unlock()
+
+	s.PostEvent(tcell.NewEventResize(width, height))
+}
+
+// Events returns the channel the events posted to the screen are received
+// from.
+func (s *MemoryScreen) Events() <-chan tcell.Event {
+	return s.events
+}
+
+// Line returns the text of the given row of the screen, without the spaces at
+// its end.
+func (s *MemoryScreen) Line(y int) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if y < 0 || y >= s.height {
+		return ""
+	}
+	var line strings.Builder
+	for _, cell := range s.cells[y*s.width : (y+1)*s.width] {
+		line.WriteRune(cell.primary)
+		for _, r := range cell.combining {
+			line.WriteRune(r)
+		}
+	}
+	return strings.TrimRight(line.String(), " ")
+}
+
+// Cursor returns where the cursor is shown, or false if it is hidden.
+func (s *MemoryScreen) Cursor() (x, y int, shown bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.cursorX, s.cursorY, s.cursorShown
+}
+
+func (s *MemoryScreen) Size() (int, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.width, s.height
+}
+
+func (s *MemoryScreen) SetContent(x, y int, primary rune, combining []rune, style tcell.Style) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if x < 0 || y < 0 || x >= s.width || y >= s.height {
+		return
+	}
+	s.cells[y*s.width+x] = memoryCell{primary: primary, combining: append([]rune(nil), combining...), style: style}
+}
+
+func (s *MemoryScreen) GetContent(x, y int) (rune, []rune, tcell.Style, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if x < 0 || y < 0 || x >= s.width || y >= s.height {
+		return ' ', nil, tcell.StyleDefault, 1
+	}
+	cell := s.cells[y*s.width+x]
+	return cell.primary, cell.combining, cell.style, 1
+}
+
+func (s *MemoryScreen) Clear() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.clear()
+}
+
+func (s *MemoryScreen) clear() {
+	for i := range s.cells {
+		s.cells[i] = memoryCell{primary: ' ', style: tcell.StyleDefault}
+	}
+}
+
+func (s *MemoryScreen) ShowCursor(x, y int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.cursorX, s.cursorY, s.cursorShown = x, y, true
+}
+
+func (s *MemoryScreen) HideCursor() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.cursorShown = false
+}
+
+func (s *MemoryScreen) PostEvent(ev tcell.Event) error {
+	select {
+	case s.events <- ev:
+		return nil
+	default:
+		return ErrEventQueueFull
+	}
+}
+
+// Beep counts the bell being rung, which frontends may ring themselves.
+func (s *MemoryScreen) Beep() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.beeps++
+	return nil
+}
+
+// Beeps returns how many times the bell was rung.
+func (s *MemoryScreen) Beeps() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.beeps
+}
+
+// Colors returns 256, so themes are shown as they would be on most terminals.
+func (s *MemoryScreen) Colors() int {
+	return 256
+}
+
+// What is drawn is kept as it is drawn, and there is no terminal to hand over
+// or mouse to report, so these do nothing.
+func (s *MemoryScreen) Show()                           {}
+func (s *MemoryScreen) Sync()                           {}
+func (s *MemoryScreen) EnableMouse(...tcell.MouseFlags) {}
+func (s *MemoryScreen) Suspend() error                  { return nil }
+func (s *MemoryScreen) Resume() error                   { return nil }
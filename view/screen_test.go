@@ -0,0 +1,50 @@
+package view
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryScreen_KeepsWhatIsDrawn(t *testing.T) {
+	screen := NewMemoryScreen(5, 2)
+	assert.IsType(t, &tcell.EventResize{}, <-screen.Events())
+
+	screen.SetContent(1, 1, 'h', nil, tcell.StyleDefault)
+	screen.SetContent(2, 1, 'e', []rune{'́'}, tcell.StyleDefault.Bold(true))
+	// Cells outside of the screen are ignored.
+	screen.SetContent(5, 1, 'x', nil, tcell.StyleDefault)
+	assert.EqualValues(t, "", screen.Line(0))
+	assert.EqualValues(t, " hé", screen.Line(1))
+	_, _, style, _ := screen.GetContent(2, 1)
+	assert.EqualValues(t, tcell.StyleDefault.Bold(true), style)
+
+	screen.Clear()
+	assert.EqualValues(t, "", screen.Line(1))
+}
+
+func TestMemoryScreen_DrivesTheApplication(t *testing.T) {
+	file, _ := createTestFile(t, `{"time":1700000000000,"name":"Pelecard","msg":"hi"}`+"\n")
+
+	ctx, cancelCtx := context.WithCancel(context.Background())
+	defer cancelCtx()
+	screen := NewMemoryScreen(80, 25)
+
+	a := NewApplication(file, false, &Config{NoTutorial: true, Filter: defaultFilter})
+	assert.NoError(t, a.setup(ctx, screen))
+
+	// The buffer posts an event once it read the record, which renders it.
+	assert.Eventually(t, func() bool {
+		select {
+		case ev := <-screen.Events():
+			a.handleEvent(ev)
+		default:
+		}
+		return strings.HasPrefix(screen.Line(0), `{"msg":"hi","name":"Pelecard"`)
+	}, time.Second, 5*time.Millisecond)
+	assert.Contains(t, screen.Line(24), "1 / 1 records")
+}
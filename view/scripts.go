@@ -0,0 +1,287 @@
+package view
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/YLivay/gote/logging"
+	"github.com/gdamore/tcell/v2"
+	lua "github.com/yuin/gopher-lua"
+)
+
+// The name of the directory Lua scripts are loaded from, within the state
+// directory.
+const scriptsDirName = "scripts"
+
+// scripts are Lua scripts that filter records, style them and add key actions,
+// for what jq expressions and highlight rules can't do. A script registers its
+// hooks through the gote table:
+//
+//	gote.filter(function(record) return record.level ~= "debug" end)
+//	gote.highlight(function(record) if record.status >= 500 then return "bold red" end end)
+//	gote.key("x", "Show the status of the record under the cursor", function(record)
+//		return "status " .. record.status
+//	end)
+//	gote.log("loaded")
+//
+// Hooks are given the records as they were read, before the jq filter
+// transforms them, as tables. A key action's record is nil if there is none
+// under the cursor, and the string it returns, if any, is shown in the status
+// bar.
+type scripts struct {
+	// Guards the Lua state, which the readers use concurrently to parse
+	// records.
+	mu sync.Mutex
+	L  *lua.LState
+
+	filters    []*lua.LFunction
+	highlights []*lua.LFunction
+	keys       []scriptKey
+	// The styles highlight hooks returned, by their descriptions.
+	styles map[string]tcell.Style
+
+	logger *logging.Logger
+}
+
+// scriptKey is a key action a script added.
+type scriptKey struct {
+	ch          rune
+	description string
+	fn          *lua.LFunction
+}
+
+// defaultScriptsDir returns the directory scripts are loaded from unless
+// another one is given.
+func defaultScriptsDir() (string, error) {
+	dir, err := stateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, scriptsDirName), nil
+}
+
+// loadScripts runs the .lua files in the given directory in the order of their
+// names. It returns nil if the directory is empty or doesn't exist. Key actions
+// can't be added on the keys of the given keymap. Errors the hooks raise once
+// loaded are logged to the given logger.
+func loadScripts(dir string, km keymap, logger *logging.Logger) (*scripts, error) {
+	if dir == "" {
+		return nil, nil
+	}
+	paths, err := filepath.Glob(filepath.Join(dir, "*.lua"))
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, nil
+	}
+	sort.Strings(paths)
+
+	s := &scripts{
+		L:      lua.NewState(),
+		styles: map[string]tcell.Style{},
+		logger: logger,
+	}
+	s.register(km)
+	for _, path := range paths {
+		if err := s.L.DoFile(path); err != nil {
+			s.L.Close()
+			return nil, fmt.Errorf("failed to load script %s: %w", filepath.Base(path), err)
+		}
+	}
+	return s, nil
+}
+
+// register adds the gote table scripts register their hooks through.
+func (s *scripts) register(km keymap) {
+	gote := s.L.NewTable()
+	s.L.SetFuncs(gote, map[string]lua.LGFunction{
+		"filter": func(L *lua.LState) int {
+			s.filters = append(s.filters, L.CheckFunction(1))
+			return 0
+		},
+		"highlight": func(L *lua.LState) int {
+			s.highlights = append(s.highlights, L.CheckFunction(1))
+			return 0
+		},
+		"key": func(L *lua.LState) int {
+			key := []rune(L.CheckString(1))
+			if len(key) != 1 {
+				L.ArgError(1, "expected a single character")
+			}
+			ev := tcell.NewEventKey(tcell.KeyRune, key[0], tcell.ModNone)
+			if km.lookup(ev) != actionNone || km.isPrefix(ev) {
+				L.ArgError(1, fmt.Sprintf("%q is already bound", key[0]))
+			}
+			s.keys = append(s.keys, scriptKey{ch: key[0], description: L.CheckString(2), fn: L.CheckFunction(3)})
+			return 0
+		},
+		"log": func(L *lua.LState) int {
+			s.logger.Info(L.CheckString(1))
+			return 0
+		},
+	})
+	s.L.SetGlobal("gote", gote)
+}
+
+// call calls the given hook with the given record and returns what it returned.
+// The Lua state must be locked.
+func (s *scripts) call(fn *lua.LFunction, record any) (lua.LValue, error) {
+	if err := s.L.CallByParam(lua.P{Fn: fn, NRet: 1, Protect: true}, toLua(s.L, record)); err != nil {
+		return lua.LNil, err
+	}
+	ret := s.L.Get(-1)
+	s.L.Pop(1)
+	return ret, nil
+}
+
+// keep returns false if a filter hook rejects the given record. Records that
+// fail a filter are kept.
+func (s *scripts) keep(record any) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, fn := range s.filters {
+		ret, err := s.call(fn, record)
+		if err != nil {
+			s.logger.Warn("filter failed:", err.Error())
+			continue
+		}
+		if !lua.LVAsBool(ret) {
+			return false
+		}
+	}
+	return true
+}
+
+// style returns the style of the first highlight hook that returns one for the
+// given record, and false if none does.
+func (s *scripts) style(record any) (tcell.Style, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, fn := range s.highlights {
+		ret, err := s.call(fn, record)
+		if err != nil {
+			s.logger.Warn("highlight failed:", err.Error())
+			continue
+		}
+		spec, ok := ret.(lua.LString)
+		if !ok {
+			continue
+		}
+		if style, ok := s.styles[string(spec)]; ok {
+			return style, true
+		}
+		style, err := parseStyle(string(spec))
+		if err != nil {
+			s.logger.Warn("highlight returned an invalid style:", err.Error())
+			continue
+		}
+		s.styles[string(spec)] = style
+		return style, true
+	}
+	return tcell.StyleDefault, false
+}
+
+// bindings returns the key bindings of the key actions scripts added.
+func (s *scripts) bindings() []keyBinding {
+	if s == nil {
+		return nil
+	}
+	bindings := make([]keyBinding, 0, len(s.keys))
+	for _, key := range s.keys {
+		bindings = append(bindings, keyBinding{key: tcell.KeyRune, ch: key.ch, action: actionScript, topic: topicScripts, description: key.description})
+	}
+	return bindings
+}
+
+// runKey runs the key action bound to the given character with the given
+// record, and returns the message it returned, if any.
+func (s *scripts) runKey(ch rune, record any) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, key := range s.keys {
+		if key.ch != ch {
+			continue
+		}
+		ret, err := s.call(key.fn, record)
+		if err != nil {
+			return "", err
+		}
+		if ret == lua.LNil {
+			return "", nil
+		}
+		return ret.String(), nil
+	}
+	return "", errors.New("no script action is bound to this key")
+}
+
+// toLua converts a value decoded from JSON to a Lua value.
+func toLua(L *lua.LState, value any) lua.LValue {
+	switch value := value.(type) {
+	case nil:
+		return lua.LNil
+	case bool:
+		return lua.LBool(value)
+	case float64:
+		return lua.LNumber(value)
+	case string:
+		return lua.LString(value)
+	case []any:
+		table := L.CreateTable(len(value), 0)
+		for _, elem := range value {
+			table.Append(toLua(L, elem))
+		}
+		return table
+	case map[string]any:
+		table := L.CreateTable(0, len(value))
+		for k, v := range value {
+			table.RawSetString(k, toLua(L, v))
+		}
+		return table
+	default:
+		return lua.LString(fmt.Sprint(value))
+	}
+}
+
+// runScriptKey runs the script key action bound to the given character on the
+// record under the cursor, showing what it returned in the status bar.
+func (a *Application) runScriptKey(ch rune) {
+	var record any
+	if r := a.recordUnderCursor(); r != nil {
+		record = r.parsed
+	}
+	message, err := a.scripts.runKey(ch, record)
+	if err != nil {
+		a.notifyError("script failed: " + strings.TrimSpace(err.Error()))
+		return
+	}
+	a.message = message
+}
+
+// scriptsFromConfig loads the scripts of the given config, logging as the
+// scripts component of the given logger.
+func scriptsFromConfig(config *Config, logger *logging.Logger) (*scripts, error) {
+	if config.NoScripts {
+		return nil, nil
+	}
+	dir := config.ScriptsDir
+	if dir == "" {
+		var err error
+		if dir, err = defaultScriptsDir(); err != nil {
+			// Without a config directory there are no scripts to load.
+			return nil, nil
+		}
+	}
+	km, err := lookupKeymap(config.Keymap)
+	if err != nil {
+		return nil, err
+	}
+	return loadScripts(dir, km, logger.Named("scripts"))
+}
@@ -0,0 +1,103 @@
+package view
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/YLivay/gote/logging"
+	"github.com/gdamore/tcell/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+// createScriptsDir creates a scripts directory holding a script with the
+// given source.
+func createScriptsDir(t *testing.T, source string) string {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "test.lua"), []byte(source), 0o644))
+	return dir
+}
+
+func TestScripts_FilterStyleAndRunKeyActions(t *testing.T) {
+	dir := createScriptsDir(t, `
+gote.filter(function(r) return r.msg ~= "noise" end)
+gote.highlight(function(r) if r.status >= 500 then return "bold red" end end)
+gote.key("x", "Show the status", function(r)
+	if r == nil then return "no record" end
+	return "status " .. r.status .. " of " .. #r.tags
+end)
+`)
+	s, err := loadScripts(dir, defaultKeymap, logging.New(io.Discard, logging.Text, logging.Levels{}))
+	assert.NoError(t, err)
+
+	assert.True(t, s.keep(map[string]any{"msg": "hi"}))
+	assert.False(t, s.keep(map[string]any{"msg": "noise"}))
+
+	style, ok := s.style(map[string]any{"status": float64(503)})
+	assert.True(t, ok)
+	assert.EqualValues(t, tcell.StyleDefault.Bold(true).Foreground(tcell.ColorRed), style)
+	_, ok = s.style(map[string]any{"status": float64(200)})
+	assert.False(t, ok)
+	// A hook that fails styles nothing.
+	_, ok = s.style(map[string]any{})
+	assert.False(t, ok)
+
+	message, err := s.runKey('x', map[string]any{"status": float64(200), "tags": []any{"a", "b"}})
+	assert.NoError(t, err)
+	assert.EqualValues(t, "status 200 of 2", message)
+	message, err = s.runKey('x', nil)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "no record", message)
+
+	bindings := s.bindings()
+	assert.Len(t, bindings, 1)
+	assert.EqualValues(t, 'x', bindings[0].ch)
+	assert.EqualValues(t, topicScripts, bindings[0].topic)
+}
+
+func TestLoadScripts_RejectsBoundKeys(t *testing.T) {
+	dir := createScriptsDir(t, `gote.key("q", "Not quit", function() end)`)
+	_, err := loadScripts(dir, defaultKeymap, logging.New(io.Discard, logging.Text, logging.Levels{}))
+	assert.ErrorContains(t, err, "already bound")
+}
+
+func TestLoadScripts_NoScripts(t *testing.T) {
+	s, err := loadScripts(filepath.Join(t.TempDir(), "missing"), defaultKeymap, logging.New(io.Discard, logging.Text, logging.Levels{}))
+	assert.NoError(t, err)
+	assert.Nil(t, s)
+}
+
+func TestRunHeadless_FiltersRecordsWithScripts(t *testing.T) {
+	line := `{"time":1700000000000,"name":"Pelecard","msg":"hi"}`
+	noise := `{"time":1700000000000,"name":"Pelecard","msg":"noise"}`
+	file, _ := createTestFile(t, line+"\n"+noise+"\n"+line+"\n")
+
+	config := &Config{Filter: defaultFilter, ScriptsDir: createScriptsDir(t, `gote.filter(function(r) return r.msg ~= "noise" end)`)}
+	var out bytes.Buffer
+	err := RunHeadless(context.Background(), file, nil, config, io.Discard, &out)
+	assert.NoError(t, err)
+	record := `{"msg":"hi","name":"Pelecard","time":"2023-11-14T22:13:20Z"}` + "\n"
+	assert.EqualValues(t, record+record, out.String())
+}
+
+func TestApplication_RunsScriptKeyActions(t *testing.T) {
+	file, _ := createTestFile(t, `{"time":1700000000000,"name":"Pelecard","msg":"hi"}`+"\n")
+
+	ctx, cancelCtx := context.WithCancel(context.Background())
+	defer cancelCtx()
+	screen := NewMemoryScreen(80, 25)
+
+	config := &Config{NoTutorial: true, ScriptsDir: createScriptsDir(t, `gote.key("x", "Shout", function(r) return r.msg:upper() end)`)}
+	a := NewApplication(file, false, config)
+	assert.NoError(t, a.setup(ctx, screen))
+	assert.Eventually(t, func() bool { return a.buffer.records.RecordAtScreenLine(0) != nil }, time.Second, 5*time.Millisecond)
+
+	a.handleEvent(tcell.NewEventKey(tcell.KeyRune, 'x', tcell.ModNone))
+	assert.EqualValues(t, "HI", a.message)
+	// The action is listed in the help.
+	assert.Contains(t, helpOverlay(a.keymap).lines, "Scripts")
+}
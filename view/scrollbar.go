@@ -0,0 +1,45 @@
+package view
+
+import "github.com/gdamore/tcell/v2"
+
+// The number of columns on the right edge of the screen reserved for the
+// scrollbar.
+const scrollbarWidth = 1
+
+// drawScrollbar draws a scrollbar on the right edge of the log lines. Its thumb
+// covers the part of the input file that is shown on screen.
+func (a *Application) drawScrollbar() {
+	x := a.width - scrollbarWidth - a.facetWidth()
+	height := a.viewHeight()
+	if x < 0 || height <= 0 {
+		return
+	}
+
+	status := a.buffer.Status()
+	first, last := scrollbarThumb(height, status.ByteOffset, status.EndByteOffset, status.FileSize)
+
+	for y := 0; y < height; y++ {
+		if y >= first && y <= last {
+			a.screen.SetContent(x, y, tcell.RuneBlock, nil, a.theme.scrollbarThumb)
+		} else {
+			a.screen.SetContent(x, y, tcell.RuneVLine, nil, a.theme.scrollbarTrack)
+		}
+	}
+}
+
+// scrollbarThumb calculates the first and last rows of a scrollbar's thumb on a
+// track of the given height, for a view of the bytes between start and end in
+// a file of the given size. If the position is unknown it returns -1, -1.
+func scrollbarThumb(trackHeight int, start, end, size int64) (first, last int) {
+	if trackHeight <= 0 || size <= 0 || start < 0 {
+		return -1, -1
+	}
+
+	start = min(start, size)
+	end = min(max(end, start), size)
+
+	first = min(int(start*int64(trackHeight)/size), trackHeight-1)
+	last = int((end*int64(trackHeight) - 1) / size)
+	last = min(max(last, first), trackHeight-1)
+	return first, last
+}
@@ -0,0 +1,31 @@
+package view
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScrollbarThumb_CoversWholeTrackForWholeFile(t *testing.T) {
+	first, last := scrollbarThumb(10, 0, 1000, 1000)
+	assert.EqualValues(t, 0, first)
+	assert.EqualValues(t, 9, last)
+}
+
+func TestScrollbarThumb_CoversVisiblePart(t *testing.T) {
+	first, last := scrollbarThumb(10, 500, 700, 1000)
+	assert.EqualValues(t, 5, first)
+	assert.EqualValues(t, 6, last)
+}
+
+func TestScrollbarThumb_IsAtLeastOneRow(t *testing.T) {
+	first, last := scrollbarThumb(10, 999, 999, 1000)
+	assert.EqualValues(t, 9, first)
+	assert.EqualValues(t, 9, last)
+}
+
+func TestScrollbarThumb_UnknownPosition(t *testing.T) {
+	first, last := scrollbarThumb(10, -1, -1, 1000)
+	assert.EqualValues(t, -1, first)
+	assert.EqualValues(t, -1, last)
+}
@@ -0,0 +1,147 @@
+package view
+
+import (
+	"errors"
+	"io"
+	"regexp"
+)
+
+// errNoMatch is returned when no record matches a pattern searched for.
+var errNoMatch = errors.New("pattern not found")
+
+// search is the pattern last searched for, which the next and previous match
+// actions search for again.
+type search struct {
+	pattern *regexp.Regexp
+	// If true, the pattern was searched for towards the start of the input,
+	// which is the direction next searches in.
+	backwards bool
+}
+
+// findMatch returns the offset of the first record after the given offset whose
+// text matches the given pattern, or of the last one before it if backwards is
+// true. It returns errNoMatch if there is none. Records are matched as they are
+// shown, so the jq filter applies. The search advances the given progress, and
+// stops once its context is done.
+func (b *Buffer) findMatch(p *scanProgress, pattern *regexp.Regexp, from int64, backwards bool) (int64, error) {
+	offset := int64(-1)
+	if backwards {
+		if from <= 0 {
+			return 0, errNoMatch
+		}
+		// Records are only read forwards, so the last match before the
+		// offset is the last one found on the way to it.
+		if _, err := b.scanRecords(p, 0, from, func(r *record) error {
+			if pattern.Match(r.buf) {
+				offset = r.byteOffset
+			}
+			return nil
+		}); err != nil {
+			return 0, err
+		}
+	} else {
+		found := errors.New("found")
+		_, err := b.scanRecords(p, max(from, 0), 0, func(r *record) error {
+			if r.byteOffset <= from || !pattern.Match(r.buf) {
+				return nil
+			}
+			offset = r.byteOffset
+			return found
+		})
+		if err != nil && err != found {
+			return 0, err
+		}
+	}
+	if offset < 0 {
+		return 0, errNoMatch
+	}
+	return offset, nil
+}
+
+// startSearch asks for a pattern and moves to the next record that matches it,
+// or the previous one if backwards is true. An empty pattern searches for the
+// last one again.
+func (a *Application) startSearch(backwards bool) {
+	label := "/"
+	if backwards {
+		label = "?"
+	}
+	a.prompt = &prompt{
+		label: label,
+		submit: func(value string) {
+			if value == "" {
+				if a.search == nil {
+					a.message = "no previous pattern"
+					return
+				}
+				a.search.backwards = backwards
+				a.findNext(false, 1)
+				return
+			}
+			pattern, err := regexp.Compile(value)
+			if err != nil {
+				a.notifyError("invalid pattern: " + err.Error())
+				return
+			}
+			a.search = &search{pattern: pattern, backwards: backwards}
+			a.findNext(false, 1)
+		},
+	}
+}
+
+// findNext moves the screen to the next record that matches the last pattern
+// searched for, in the direction it was searched in, or the opposite one if
+// reverse is true. With a count, it moves that many matches ahead, and
+// doesn't move if there aren't as many. Following stops, so the match stays on
+// screen.
+func (a *Application) findNext(reverse bool, count int) {
+	if a.search == nil {
+		a.message = "no previous pattern"
+		return
+	}
+	backwards := a.search.backwards != reverse
+	pattern := a.search.pattern
+	status := a.buffer.Status()
+	from, to := status.ByteOffset, status.FileSize
+	if backwards {
+		from, to = 0, status.ByteOffset
+	}
+	a.startTask("searching for /"+pattern.String()+"/", max(from, 0), to, nil, func(p *scanProgress) func() {
+		offset := status.ByteOffset
+		for i := 0; i < max(count, 1); i++ {
+			var err error
+			offset, err = a.buffer.findMatch(p, pattern, offset, backwards)
+			if errors.Is(err, errNoMatch) {
+				return func() { a.notifyError("pattern not found: " + pattern.String()) }
+			}
+			if err != nil {
+				return func() { a.notifyError("searching failed: " + err.Error()) }
+			}
+		}
+		return func() {
+			a.setFollowMode(false)
+			if err := a.buffer.SeekAndPopulate(offset, io.SeekStart); err != nil {
+				a.notifyError("moving to the match failed: " + err.Error())
+			}
+		}
+	})
+}
+
+// seekToEdge moves the screen to the start of the input, or to its end if end
+// is true. Following stops at the start, and paused following resumes at the
+// end.
+func (a *Application) seekToEdge(end bool) {
+	whence := io.SeekStart
+	if end {
+		whence = io.SeekEnd
+		if a.pause != nil {
+			a.setFollowMode(true)
+		}
+	} else {
+		a.setFollowMode(false)
+	}
+	a.clearSelection()
+	if err := a.buffer.SeekAndPopulate(0, whence); err != nil {
+		a.notifyError("moving failed: " + err.Error())
+	}
+}
@@ -0,0 +1,168 @@
+package view
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuffer_FindMatch(t *testing.T) {
+	line := `{"time":1700000000000,"name":"Pelecard","msg":"hi"}` + "\n"
+	match := `{"time":1700000000000,"name":"Pelecard","msg":"there"}` + "\n"
+	file, _ := createTestFile(t, match+line+match+line)
+
+	buffer, err := NewBuffer(80, 2, false, file, context.Background())
+	assert.NoError(t, err)
+	pattern := regexp.MustCompile("there")
+
+	offset, err := buffer.findMatch(nil, pattern, -1, false)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 0, offset)
+	// The record at the offset itself isn't a match.
+	offset, err = buffer.findMatch(nil, pattern, 0, false)
+	assert.NoError(t, err)
+	assert.EqualValues(t, len(match)+len(line), offset)
+	_, err = buffer.findMatch(nil, pattern, offset, false)
+	assert.ErrorIs(t, err, errNoMatch)
+
+	offset, err = buffer.findMatch(nil, pattern, int64(2*len(match)+2*len(line)), true)
+	assert.NoError(t, err)
+	assert.EqualValues(t, len(match)+len(line), offset)
+	_, err = buffer.findMatch(nil, pattern, 0, true)
+	assert.ErrorIs(t, err, errNoMatch)
+}
+
+// waitForBuffer handles the events the application posts, like the results of
+// the tasks it ran in the background, until none runs and cond holds for the
+// buffer's status. cond is checked again whenever the buffer's readers load
+// records or an event is handled, so the wait lasts as long as the buffer takes
+// to get there, up to a generous limit for slow runs like under the race
+// detector.
+func waitForBuffer(t *testing.T, a *Application, screen *MemoryScreen, cond func(status BufferStatus) bool) {
+	t.Helper()
+	loaded := make(chan struct{}, 1)
+	unregister := a.buffer.OnRecord(func(Record) {
+		select {
+		case loaded <- struct{}{}:
+		default:
+		}
+	})
+	defer unregister()
+
+	timeout := time.After(30 * time.Second)
+	for a.task != nil || !cond(a.buffer.Status()) {
+		select {
+		case ev := <-screen.Events():
+			a.handleEvent(ev)
+		case <-loaded:
+		case <-timeout:
+			t.Fatalf("timed out waiting for the buffer, which is at %+v", a.buffer.Status())
+		}
+	}
+}
+
+func TestApplication_LessKeymapMovesAndSearches(t *testing.T) {
+	var lines []string
+	// The records are all the same length, so their offsets are known.
+	for i := 0; i < 50; i++ {
+		lines = append(lines, fmt.Sprintf(`{"time":1700000000000,"name":"Pelecard","msg":"record %02d"}`, i))
+	}
+	file, _ := createTestFile(t, strings.Join(lines, "\n")+"\n")
+	offsetOf := func(i int) int64 { return int64(i * (len(lines[0]) + 1)) }
+
+	ctx, cancelCtx := context.WithCancel(context.Background())
+	defer cancelCtx()
+	screen := NewMemoryScreen(90, 10)
+
+	a := NewApplication(file, false, &Config{NoTutorial: true, Keymap: "less"})
+	assert.NoError(t, a.setup(ctx, screen))
+	press := func(ch rune) { a.handleEvent(tcell.NewEventKey(tcell.KeyRune, ch, tcell.ModNone)) }
+	atTop := func(i int) func(BufferStatus) bool {
+		return func(status BufferStatus) bool { return status.ByteOffset == offsetOf(i) }
+	}
+	settled := func(BufferStatus) bool { return true }
+
+	press('G')
+	waitForBuffer(t, a, screen, func(status BufferStatus) bool { return status.EndByteOffset == offsetOf(50) })
+	press('g')
+	waitForBuffer(t, a, screen, atTop(0))
+
+	press('/')
+	for _, ch := range "record [0-9]5" {
+		press(ch)
+	}
+	a.handleEvent(tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModNone))
+	waitForBuffer(t, a, screen, atTop(5))
+	press('n')
+	waitForBuffer(t, a, screen, atTop(15))
+	press('N')
+	waitForBuffer(t, a, screen, atTop(5))
+
+	// Searching backwards for the same pattern reverses what n and N do.
+	press('?')
+	a.handleEvent(tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModNone))
+	waitForBuffer(t, a, screen, settled)
+	assert.Equal(t, "pattern not found: record [0-9]5", noticeText(a))
+	press('N')
+	waitForBuffer(t, a, screen, atTop(15))
+
+	// h shows the help, which ? does in the default keymap.
+	press('h')
+	assert.True(t, a.showHelp)
+}
+
+func TestApplication_ViKeymapTakesCounts(t *testing.T) {
+	var lines []string
+	for i := 0; i < 100; i++ {
+		lines = append(lines, fmt.Sprintf(`{"time":1700000000000,"name":"Pelecard","msg":"record %02d"}`, i))
+	}
+	file, _ := createTestFile(t, strings.Join(lines, "\n")+"\n")
+	offsetOf := func(i int) int64 { return int64(i * (len(lines[0]) + 1)) }
+
+	ctx, cancelCtx := context.WithCancel(context.Background())
+	defer cancelCtx()
+	screen := NewMemoryScreen(90, 10)
+
+	a := NewApplication(file, false, &Config{NoTutorial: true, Keymap: "vi"})
+	assert.NoError(t, a.setup(ctx, screen))
+	keys := func(s string) {
+		for _, ch := range s {
+			a.handleEvent(tcell.NewEventKey(tcell.KeyRune, ch, tcell.ModNone))
+		}
+	}
+	atTop := func(i int) func(BufferStatus) bool {
+		return func(status BufferStatus) bool { return status.ByteOffset == offsetOf(i) }
+	}
+	settled := func(BufferStatus) bool { return true }
+	waitForBuffer(t, a, screen, func(status BufferStatus) bool { return status.Records > 10 })
+
+	keys("12")
+	assert.Equal(t, "12", a.message)
+	keys("j")
+	waitForBuffer(t, a, screen, atTop(12))
+	keys("3k")
+	waitForBuffer(t, a, screen, atTop(9))
+
+	keys("gg")
+	waitForBuffer(t, a, screen, atTop(0))
+	// With a count, G goes to that line.
+	keys("21G")
+	waitForBuffer(t, a, screen, atTop(20))
+
+	keys("/5")
+	a.handleEvent(tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModNone))
+	waitForBuffer(t, a, screen, atTop(25))
+	keys("2n")
+	waitForBuffer(t, a, screen, atTop(45))
+	// There aren't 20 more matches, so the screen stays.
+	keys("20n")
+	waitForBuffer(t, a, screen, settled)
+	assert.Equal(t, "pattern not found: 5", noticeText(a))
+	assert.EqualValues(t, offsetOf(45), a.buffer.Status().ByteOffset)
+}
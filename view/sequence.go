@@ -0,0 +1,90 @@
+package view
+
+import (
+	"strconv"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// maxCount is the largest count an action can be given. Digits typed past it
+// are ignored.
+const maxCount = 1_000_000
+
+// pendingKeys are the keys of a sequence that were typed so far, like the
+// count before an action or the first g of gg.
+type pendingKeys struct {
+	count  int
+	prefix rune
+}
+
+// sequenceKey handles a key press that may be a part of a sequence of keys. It
+// returns the action the sequence ends with and the count typed before it, or
+// 0 if none was, or false if the key only continued the sequence.
+func (a *Application) sequenceKey(ev *tcell.EventKey, act action) (action, int, bool) {
+	p := &a.pending
+	switch {
+	case p.prefix != 0:
+		act = a.keymap.lookupAfter(p.prefix, ev)
+		p.prefix = 0
+	case act == actionCount && (ev.Rune() != '0' || p.count > 0):
+		p.count = min(p.count*10+int(ev.Rune()-'0'), maxCount)
+		a.message = a.pendingText()
+		return actionNone, 0, false
+	case act == actionCount:
+		// A count doesn't start with 0.
+		return actionNone, 0, false
+	case a.keymap.isPrefix(ev):
+		p.prefix = ev.Rune()
+		a.message = a.pendingText()
+		return actionNone, 0, false
+	}
+
+	count := p.count
+	p.count = 0
+	return act, count, true
+}
+
+// pendingText returns the keys of the pending sequence, as they are shown in
+// the status bar.
+func (a *Application) pendingText() string {
+	text := ""
+	if a.pending.count > 0 {
+		text = strconv.Itoa(a.pending.count)
+	}
+	if a.pending.prefix != 0 {
+		text += string(a.pending.prefix)
+	}
+	return text
+}
+
+// performCounted performs the given action with the count typed before it. The
+// actions that move move that many times as far, and the ones that go to the
+// start or end of the file go to the line of the count instead. Other actions
+// ignore the count. It returns false if the application should quit.
+func (a *Application) performCounted(act action, count int) bool {
+	switch act {
+	case actionScrollUp:
+		a.scroll(-count)
+	case actionScrollDown:
+		a.scroll(count)
+	case actionPageUp:
+		a.scroll(-count * a.viewHeight())
+	case actionPageDown:
+		a.scroll(count * a.viewHeight())
+	case actionTop, actionBottom:
+		a.setFollowMode(false)
+		a.clearSelection()
+		if err := a.buffer.SeekToLine(int64(count)); err != nil {
+			a.notifyError("going to line failed: " + err.Error())
+		}
+	case actionNextMatch:
+		a.findNext(false, count)
+	case actionPrevMatch:
+		a.findNext(true, count)
+	default:
+		return a.performAction(act)
+	}
+
+	a.render()
+	return true
+}
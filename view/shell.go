@@ -0,0 +1,88 @@
+package view
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"sync/atomic"
+)
+
+// ignoreInterrupts is set while a command runs on the terminal, so Ctrl+C
+// interrupts the command rather than quitting gote.
+var ignoreInterrupts atomic.Bool
+
+// InterruptsIgnored reports whether a command gote runs on the terminal is
+// interrupted by Ctrl+C, rather than gote itself.
+func InterruptsIgnored() bool {
+	return ignoreInterrupts.Load()
+}
+
+// startShell prompts for a shell command to run on the terminal, or runs an
+// interactive shell if none is given.
+func (a *Application) startShell() {
+	a.prompt = &prompt{
+		label: "Run (empty for a shell): ",
+		submit: func(command string) {
+			a.runShell(command)
+		},
+	}
+}
+
+// runShell runs the given shell command on the terminal, or an interactive
+// shell if it is empty, and returns to gote once it exits. The output of a
+// command is kept on the terminal until a key is pressed.
+func (a *Application) runShell(command string) {
+	err := a.onTerminal(func() error {
+		cmd := shellCommand(command)
+		cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+		if command == "" {
+			fmt.Fprintln(os.Stderr, "Exit the shell to return to gote.")
+			return cmd.Run()
+		}
+
+		err := cmd.Run()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "gote:", err)
+		}
+		waitForEnter()
+		return err
+	})
+	if err != nil {
+		a.notifyError("command failed: " + err.Error())
+	}
+}
+
+// onTerminal suspends the screen to run fn with the terminal to itself, and
+// restores the screen once it returns.
+func (a *Application) onTerminal(fn func() error) error {
+	if err := a.screen.Suspend(); err != nil {
+		return err
+	}
+
+	ignoreInterrupts.Store(true)
+	err := fn()
+	ignoreInterrupts.Store(false)
+
+	if err := a.screen.Resume(); err != nil {
+		a.logger.Error("failed to resume the screen:", err.Error())
+	}
+	if width, height := a.screen.Size(); width != a.width || height != a.height {
+		// The terminal was resized meanwhile.
+		a.resize(width, height)
+	}
+	a.screen.Sync()
+	return err
+}
+
+// shellCommand returns the command that runs the given command with the
+// user's shell, or runs the shell interactively if it is empty.
+func shellCommand(command string) *exec.Cmd {
+	shell := os.Getenv("SHELL")
+	if shell == "" {
+		shell = "/bin/sh"
+	}
+	if command == "" {
+		return exec.Command(shell)
+	}
+	return exec.Command(shell, "-c", command)
+}
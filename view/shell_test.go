@@ -0,0 +1,16 @@
+package view
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShellCommand_RunsTheUsersShell(t *testing.T) {
+	t.Setenv("SHELL", "/bin/bash")
+	assert.EqualValues(t, []string{"/bin/bash", "-c", "ls -l"}, shellCommand("ls -l").Args)
+	assert.EqualValues(t, []string{"/bin/bash"}, shellCommand("").Args)
+
+	t.Setenv("SHELL", "")
+	assert.EqualValues(t, []string{"/bin/sh", "-c", "ls"}, shellCommand("ls").Args)
+}
@@ -0,0 +1,86 @@
+package view
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/rivo/uniseg"
+)
+
+// maxSourcePrefixLen is the widest the prefix naming the source of each record
+// of a merged input gets.
+const maxSourcePrefixLen = 12
+
+// sourceMarker is what the source column shows when the records aren't
+// prefixed with the names of their sources.
+const sourceMarker = "▌"
+
+// sourcePrefix returns the prefix naming the source of the given name: the name
+// without its extension, cut off at maxSourcePrefixLen.
+func sourcePrefix(name string) string {
+	if trimmed := strings.TrimSuffix(name, filepath.Ext(name)); trimmed != "" {
+		name = trimmed
+	}
+	if uniseg.StringWidth(name) > maxSourcePrefixLen {
+		name = Truncate(name, maxSourcePrefixLen-1) + "…"
+	}
+	return name
+}
+
+// sourceColumnWidth returns the width of the source column for the given
+// sources, including the space separating it from the log lines. There is no
+// column unless there are several sources to tell apart.
+func sourceColumnWidth(names []string, prefixes bool) int {
+	if len(names) < 2 {
+		return 0
+	}
+	if !prefixes {
+		return uniseg.StringWidth(sourceMarker) + 1
+	}
+	width := 0
+	for _, name := range names {
+		width = max(width, uniseg.StringWidth(sourcePrefix(name)))
+	}
+	return width + 1
+}
+
+// layoutSources makes room for the source column next to the gutter, if the
+// sources the input is merged from changed since it was last laid out.
+func (a *Application) layoutSources() {
+	names := a.spool.Sources()
+	if names == nil {
+		return
+	}
+	if slices.Equal(names, a.sourceNames) {
+		return
+	}
+	a.sourceNames = names
+	a.setGutter(a.gutter)
+}
+
+// sourceWidth returns the width of the source column.
+func (a *Application) sourceWidth() int {
+	return sourceColumnWidth(a.sourceNames, a.config.SourcePrefixes)
+}
+
+// drawSource draws the source column of a single screen line, in the color of
+// the record's source. Only the first line of each record is prefixed with the
+// source's name.
+func (a *Application) drawSource(y int, line renderLine) {
+	width := a.sourceWidth()
+	if width == 0 || (a.config.SourcePrefixes && !line.first) {
+		return
+	}
+	source, ok := a.spool.SourceAt(line.record.byteOffset)
+	if !ok || source >= len(a.sourceNames) {
+		return
+	}
+
+	label := sourceMarker
+	if a.config.SourcePrefixes {
+		label = sourcePrefix(a.sourceNames[source])
+	}
+	style := a.theme.sources[source%len(a.theme.sources)]
+	a.drawText(a.gutterWidth-width, y, width-1, label, style)
+}
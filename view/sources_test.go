@@ -0,0 +1,66 @@
+package view
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/YLivay/gote/input"
+	"github.com/YLivay/gote/logging"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSourcePrefix(t *testing.T) {
+	assert.EqualValues(t, "app", sourcePrefix("app.log"))
+	assert.EqualValues(t, ".env", sourcePrefix(".env"))
+	assert.EqualValues(t, "payments-se…", sourcePrefix("payments-service.log"))
+
+	assert.EqualValues(t, 0, sourceColumnWidth([]string{"app.log"}, true))
+	assert.EqualValues(t, 2, sourceColumnWidth([]string{"app.log", "db.log"}, false))
+	assert.EqualValues(t, 4, sourceColumnWidth([]string{"app.log", "db.log"}, true))
+}
+
+func TestApplication_PrefixesMergedRecordsWithTheirSources(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	for i, name := range []string{"api.log", "db.log"} {
+		path := filepath.Join(dir, name)
+		line := `{"time":1700000000000,"name":"Pelecard","msg":"` + name + `"}` + "\n"
+		assert.NoError(t, os.WriteFile(path, []byte(line), 0644))
+		mtime := now.Add(time.Duration(i-2) * time.Hour)
+		assert.NoError(t, os.Chtimes(path, mtime, mtime))
+	}
+
+	file, spool, cleanup, err := input.Open(input.Options{Inputs: []string{filepath.Join(dir, "*.log")}, SpillDir: t.TempDir()}, logging.New(io.Discard, logging.Text, logging.Levels{}), nil)
+	assert.NoError(t, err)
+	defer cleanup()
+
+	ctx, cancelCtx := context.WithCancel(context.Background())
+	defer cancelCtx()
+	screen := NewMemoryScreen(80, 10)
+	a := NewApplication(file, true, &Config{NoTutorial: true, Filter: defaultFilter, SourcePrefixes: true})
+	a.spool = spool
+	assert.NoError(t, a.setup(ctx, screen))
+
+	assert.Eventually(t, func() bool {
+		for len(screen.Events()) > 0 {
+			a.handleEvent(<-screen.Events())
+		}
+		return strings.HasPrefix(screenText(screen, 1, 0, 80), `db  {"msg":"db.log"`)
+	}, 2*time.Second, 5*time.Millisecond)
+	assert.True(t, strings.HasPrefix(screenText(screen, 0, 0, 80), `api {"msg":"api.log"`))
+	_, _, api, _ := screen.GetContent(0, 0)
+	_, _, db, _ := screen.GetContent(0, 1)
+	assert.EqualValues(t, a.theme.sources[0], api)
+	assert.EqualValues(t, a.theme.sources[1], db)
+
+	// Without prefixes, the sources are only marked with their colors.
+	a.config.SourcePrefixes = false
+	a.setGutter(a.gutter)
+	a.render()
+	assert.True(t, strings.HasPrefix(screenText(screen, 1, 0, 80), `▌ {"msg":"db.log"`))
+}
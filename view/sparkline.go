@@ -0,0 +1,107 @@
+package view
+
+import (
+	"fmt"
+	"time"
+)
+
+// sparklineScanLimit is the most records, from the last loaded one, that the
+// record rate is counted from.
+const sparklineScanLimit = 10000
+
+// sparklineLabelWidth is the width of the label before the sparkline, which
+// shows the rate of the newest unit.
+const sparklineLabelWidth = 9
+
+// sparklineBlocks are the characters of the sparkline's bars, from the lowest.
+var sparklineBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// parseSparklineUnit parses the unit the record rate is shown per, as given on
+// the command line. It returns 0 if the sparkline is off.
+func parseSparklineUnit(value string) (time.Duration, error) {
+	switch value {
+	case "off":
+		return 0, nil
+	case "second":
+		return time.Second, nil
+	case "minute":
+		return time.Minute, nil
+	}
+	return 0, fmt.Errorf("unknown sparkline unit %q, expected off, second or minute", value)
+}
+
+// recordRate returns the number of loaded records whose timestamps are within
+// each of the given number of units, oldest first. The last unit is the one
+// of the newest timestamp, rather than the current time, so the rate of input
+// that is written late, or replayed, is shown too. Only the newest records are
+// counted.
+func (b *Buffer) recordRate(unit time.Duration, units int) []int {
+	counts := make([]int, units)
+	b.records.WithLock(func(records *bufferRecordList) any {
+		var newest time.Time
+		for i, scanned := records.Len()-1, 0; i >= 0 && scanned < sparklineScanLimit; i, scanned = i-1, scanned+1 {
+			fields, _ := records.RecordAt(i).parsed.(map[string]any)
+			t, ok := recordTime(fields)
+			if !ok {
+				continue
+			}
+			if newest.IsZero() {
+				newest = t.Truncate(unit)
+			}
+			back := int(newest.Sub(t.Truncate(unit)) / unit)
+			if back >= units {
+				// Records are in the order they were written, so the
+				// ones before are older still.
+				break
+			}
+			if back >= 0 {
+				counts[units-1-back]++
+			}
+		}
+		return nil
+	})
+	return counts
+}
+
+// showsSparkline returns true if the sparkline of the record rate is shown
+// above the status bar, which it is while following.
+func (a *Application) showsSparkline() bool {
+	return a.followMode && a.config != nil && a.config.SparklineUnit > 0
+}
+
+// drawSparkline draws the rate of the loaded records over time above the
+// status bar, one unit a column, with the rate of the newest unit at its
+// start.
+func (a *Application) drawSparkline() {
+	if !a.showsSparkline() || a.height < 2 {
+		return
+	}
+	y := a.height - 2
+
+	unitName := "s"
+	if a.config.SparklineUnit == time.Minute {
+		unitName = "m"
+	}
+	// The label is as wide for any count of records, so the sparkline doesn't
+	// shift as the rate changes.
+	labelWidth := min(sparklineLabelWidth, a.width)
+	counts := a.buffer.recordRate(a.config.SparklineUnit, max(a.width-labelWidth, 1))
+	label := fmt.Sprintf("%*d/%s ", sparklineLabelWidth-3, counts[len(counts)-1], unitName)
+	a.drawText(0, y, labelWidth, label, a.theme.statusBar)
+
+	peak := 0
+	for _, count := range counts {
+		peak = max(peak, count)
+	}
+	for i, count := range counts {
+		x := labelWidth + i
+		if x >= a.width {
+			break
+		}
+		ch := ' '
+		if count > 0 {
+			ch = sparklineBlocks[(count*len(sparklineBlocks)-1)/peak]
+		}
+		a.screen.SetContent(x, y, ch, nil, a.theme.statusBar)
+	}
+}
@@ -0,0 +1,51 @@
+package view
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplication_DrawsSparklineWhileFollowing(t *testing.T) {
+	// 1, 2, 3 and 4 records in 4 consecutive seconds.
+	var lines []string
+	for second := 0; second < 4; second++ {
+		for i := 0; i <= second; i++ {
+			lines = append(lines, fmt.Sprintf(`{"time":%d,"name":"Pelecard","msg":"hi"}`, 1700000000000+int64(second)*1000+int64(i)))
+		}
+	}
+	file, _ := createTestFile(t, strings.Join(lines, "\n")+"\n")
+
+	ctx, cancelCtx := context.WithCancel(context.Background())
+	defer cancelCtx()
+	screen := NewMemoryScreen(20, 25)
+
+	a := NewApplication(file, true, &Config{NoTutorial: true, SparklineUnit: time.Second})
+	assert.NoError(t, a.setup(ctx, screen))
+	assert.EqualValues(t, 23, a.viewHeight())
+	assert.Eventually(t, func() bool { return a.buffer.Status().Records == len(lines) }, time.Second, 5*time.Millisecond)
+
+	assert.EqualValues(t, []int{0, 0, 1, 2, 3, 4}, a.buffer.recordRate(time.Second, 6))
+
+	a.render()
+	assert.EqualValues(t, "     4/s        ▂▄▆█", screen.Line(23))
+
+	// The sparkline is only shown while following.
+	a.performAction(actionToggleFollow)
+	assert.EqualValues(t, 24, a.viewHeight())
+}
+
+func TestParseSparklineUnit(t *testing.T) {
+	unit, err := parseSparklineUnit("minute")
+	assert.NoError(t, err)
+	assert.EqualValues(t, time.Minute, unit)
+	unit, err = parseSparklineUnit("off")
+	assert.NoError(t, err)
+	assert.Zero(t, unit)
+	_, err = parseSparklineUnit("hour")
+	assert.Error(t, err)
+}
@@ -0,0 +1,86 @@
+package view
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/YLivay/gote/reader"
+)
+
+// startPosition is where reading the input starts, given with an argument like
+// less's: +G for its end, +123 for a line and +/pattern for the first record
+// whose text matches a regular expression.
+type startPosition struct {
+	end     bool
+	line    int64
+	pattern *regexp.Regexp
+}
+
+// parseStartPosition parses an argument like +G, +123 or +/pattern.
+func parseStartPosition(arg string) (*startPosition, error) {
+	value, ok := strings.CutPrefix(arg, "+")
+	if !ok {
+		return nil, fmt.Errorf("invalid start position %q", arg)
+	}
+	switch {
+	case value == "G":
+		return &startPosition{end: true}, nil
+	case strings.HasPrefix(value, "/"):
+		pattern, err := regexp.Compile(value[1:])
+		if err != nil {
+			return nil, fmt.Errorf("invalid start pattern: %w", err)
+		}
+		return &startPosition{pattern: pattern}, nil
+	}
+	line, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || line < 1 {
+		return nil, fmt.Errorf("invalid start position %q, expected +G, +LINE or +/PATTERN", arg)
+	}
+	return &startPosition{line: line}, nil
+}
+
+// offset returns the offset in the input of the given buffer that the position
+// is at. A line past the end of the input is at its end.
+func (p *startPosition) offset(b *Buffer) (int64, error) {
+	switch {
+	case p.end:
+		b.mu.Lock()
+		inputFile := b.inputFile
+		b.mu.Unlock()
+		info, err := inputFile.Stat()
+		if err != nil {
+			return 0, err
+		}
+		return info.Size(), nil
+	case p.pattern != nil:
+		return b.findMatch(nil, p.pattern, -1, false)
+	}
+	offset, err := b.lineOffset(nil, p.line)
+	if errors.Is(err, reader.ErrNotIndexed) {
+		return (&startPosition{end: true}).offset(b)
+	}
+	return offset, err
+}
+
+// seekToStart populates the buffer from the configured start position. If no
+// record matches its pattern, it is populated from the start of the input, and
+// the status bar says so, like less does.
+func (a *Application) seekToStart() error {
+	start := a.config.Start
+	if start.end {
+		return a.buffer.SeekAndPopulate(0, io.SeekEnd)
+	}
+	offset, err := start.offset(a.buffer)
+	if errors.Is(err, errNoMatch) {
+		a.notifyError("pattern not found: " + start.pattern.String())
+		offset, err = 0, nil
+	}
+	if err != nil {
+		return err
+	}
+	return a.buffer.SeekAndPopulate(offset, io.SeekStart)
+}
@@ -0,0 +1,76 @@
+package view
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseStartPosition(t *testing.T) {
+	start, err := parseStartPosition("+G")
+	assert.NoError(t, err)
+	assert.True(t, start.end)
+
+	start, err = parseStartPosition("+123")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 123, start.line)
+
+	start, err = parseStartPosition("+/level.*error")
+	assert.NoError(t, err)
+	assert.Equal(t, "level.*error", start.pattern.String())
+
+	for _, arg := range []string{"+", "+0", "+x", "+/("} {
+		_, err := parseStartPosition(arg)
+		assert.Error(t, err, arg)
+	}
+}
+
+func TestStartPosition_Offset(t *testing.T) {
+	line := `{"time":1700000000000,"name":"Pelecard","msg":"hi"}` + "\n"
+	match := `{"time":1700000000000,"name":"Pelecard","msg":"there"}` + "\n"
+	content := strings.Repeat(line, 3) + match + line
+	file, _ := createTestFile(t, content)
+
+	buffer, err := NewBuffer(80, 2, false, file, context.Background())
+	assert.NoError(t, err)
+	assert.NoError(t, buffer.SetFilter(defaultFilter))
+
+	for arg, want := range map[string]int{
+		"+G":       len(content),
+		"+2":       len(line),
+		"+100":     len(content),
+		"+/there":  3 * len(line),
+		"+/^{.msg": 0,
+	} {
+		start, err := parseStartPosition(arg)
+		assert.NoError(t, err)
+		offset, err := start.offset(buffer)
+		assert.NoError(t, err, arg)
+		assert.EqualValues(t, want, offset, arg)
+	}
+
+	start, err := parseStartPosition("+/nowhere")
+	assert.NoError(t, err)
+	_, err = start.offset(buffer)
+	assert.ErrorIs(t, err, errNoMatch)
+}
+
+func TestApplication_StartsAtTheTopWhenThePatternIsNotFound(t *testing.T) {
+	line := `{"time":1700000000000,"name":"Pelecard","msg":"hi"}` + "\n"
+	file, _ := createTestFile(t, strings.Repeat(line, 5))
+
+	ctx, cancelCtx := context.WithCancel(context.Background())
+	defer cancelCtx()
+	screen := NewMemoryScreen(90, 10)
+
+	start, err := parseStartPosition("+/nowhere")
+	assert.NoError(t, err)
+	a := NewApplication(file, false, &Config{NoTutorial: true, Start: start})
+	assert.NoError(t, a.setup(ctx, screen))
+	assert.Equal(t, "pattern not found: nowhere", noticeText(a))
+	assert.Eventually(t, func() bool { return a.buffer.Status().Records == 5 }, time.Second, 5*time.Millisecond)
+	assert.Zero(t, a.buffer.Status().ByteOffset)
+}
@@ -0,0 +1,52 @@
+package view
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// The name of the file that marks the tutorial as seen, within the state
+// directory.
+const tutorialSeenFname = "tutorial-seen"
+
+// stateDir returns the directory gote persists its state in.
+func stateDir() (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to determine user config directory: %w", err)
+	}
+	return filepath.Join(configDir, "gote"), nil
+}
+
+// isFirstRun returns true if the tutorial was never dismissed. If the state
+// directory can't be determined this returns false, so the tutorial doesn't
+// show up on every launch.
+func isFirstRun() bool {
+	dir, err := stateDir()
+	if err != nil {
+		return false
+	}
+
+	_, err = os.Stat(filepath.Join(dir, tutorialSeenFname))
+	return errors.Is(err, os.ErrNotExist)
+}
+
+// markTutorialSeen persists that the tutorial was dismissed so it isn't shown
+// on the next launch.
+func markTutorialSeen() error {
+	dir, err := stateDir()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, tutorialSeenFname), nil, 0644); err != nil {
+		return fmt.Errorf("failed to write tutorial marker: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,294 @@
+package view
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+const (
+	// statsBuckets is the most time buckets the stats overlay shows.
+	statsBuckets = 12
+	// statsBarWidth is the width of the bars of the largest counts.
+	statsBarWidth = 30
+	// statsUpdateInterval is how often the stats overlay is redrawn while the
+	// whole input is scanned.
+	statsUpdateInterval = 250 * time.Millisecond
+)
+
+// statsBucketWidths are the widths time buckets can have, from narrowest to
+// widest. The narrowest that fits the records in statsBuckets is used.
+var statsBucketWidths = []time.Duration{
+	time.Second, 5 * time.Second, 10 * time.Second, 30 * time.Second,
+	time.Minute, 5 * time.Minute, 10 * time.Minute, 30 * time.Minute,
+	time.Hour, 3 * time.Hour, 6 * time.Hour, 12 * time.Hour,
+	24 * time.Hour, 7 * 24 * time.Hour, 30 * 24 * time.Hour, 365 * 24 * time.Hour,
+}
+
+// statsLevels are the levels the stats overlay counts records of, from the
+// most severe.
+var statsLevels = []logLevel{levelFatal, levelError, levelWarn, levelInfo, levelDebug, levelTrace, levelUnknown}
+
+// recordStats counts records by their level and by the second of their
+// timestamp.
+type recordStats struct {
+	total  int
+	levels map[logLevel]int
+	// The number of records, and of the ones that are errors or worse, by
+	// the unix second of their timestamp.
+	seconds      map[int64]int
+	errorSeconds map[int64]int
+	// The number of records that have no timestamp.
+	untimed int
+}
+
+func newRecordStats() *recordStats {
+	return &recordStats{
+		levels:       map[logLevel]int{},
+		seconds:      map[int64]int{},
+		errorSeconds: map[int64]int{},
+	}
+}
+
+// add counts the given parsed record.
+func (s *recordStats) add(parsed any) {
+	fields, _ := parsed.(map[string]any)
+	level := recordLevel(fields)
+	s.total++
+	s.levels[level]++
+
+	t, ok := recordTime(fields)
+	if !ok {
+		s.untimed++
+		return
+	}
+	s.seconds[t.Unix()]++
+	if level == levelError || level == levelFatal {
+		s.errorSeconds[t.Unix()]++
+	}
+}
+
+// timeBucket is the number of records whose timestamps are within width of
+// start.
+type timeBucket struct {
+	start  time.Time
+	count  int
+	errors int
+}
+
+// buckets returns the records counted by the time bucket their timestamps fall
+// in, oldest first, along with the buckets' width. Buckets are as narrow as
+// they can be for them to be at most max.
+func (s *recordStats) buckets(max int) ([]timeBucket, time.Duration) {
+	if len(s.seconds) == 0 {
+		return nil, 0
+	}
+	seconds := make([]int64, 0, len(s.seconds))
+	for second := range s.seconds {
+		seconds = append(seconds, second)
+	}
+	slices.Sort(seconds)
+	first, last := seconds[0], seconds[len(seconds)-1]
+
+	width := statsBucketWidths[len(statsBucketWidths)-1]
+	for _, w := range statsBucketWidths {
+		if (last/int64(w.Seconds()))-(first/int64(w.Seconds())) < int64(max) {
+			width = w
+			break
+		}
+	}
+
+	step := int64(width.Seconds())
+	buckets := make([]timeBucket, last/step-first/step+1)
+	for i := range buckets {
+		buckets[i].start = time.Unix((first/step+int64(i))*step, 0).UTC()
+	}
+	for _, second := range seconds {
+		i := second/step - first/step
+		buckets[i].count += s.seconds[second]
+		buckets[i].errors += s.errorSeconds[second]
+	}
+	return buckets, width
+}
+
+// statsView is the overlay showing the stats of the loaded records, or of all
+// the records of the input once it was scanned for them.
+type statsView struct {
+	// Guards the fields below, which the scan updates in the background.
+	mu    sync.Mutex
+	stats *recordStats
+	// If true, the stats are of the whole input rather than of the loaded
+	// records.
+	whole bool
+	// If true, the whole input is being scanned, and stats are of the part
+	// scanned so far.
+	scanning bool
+	err      error
+
+	// Stops the scan, if one was started.
+	cancel context.CancelFunc
+}
+
+// statsUpdate is posted to the application to redraw the stats overlay while
+// the input is scanned.
+type statsUpdate struct{}
+
+// showStats shows the stats of the records loaded in the buffer.
+func (a *Application) showStats() {
+	stats := newRecordStats()
+	it := a.buffer.Records(context.Background())
+	for it.Next() {
+		stats.add(it.Record().Parsed)
+	}
+	a.stats = &statsView{stats: stats}
+}
+
+// closeStats hides the stats overlay, stopping the scan of the input if there
+// is one.
+func (a *Application) closeStats() {
+	if a.stats.cancel != nil {
+		a.stats.cancel()
+	}
+	a.stats = nil
+}
+
+// scanStats replaces the stats of the loaded records with the stats of all
+// the records of the input, which are counted in the background.
+func (a *Application) scanStats(ctx context.Context) {
+	v := a.stats
+	if v.whole {
+		return
+	}
+	ctx, v.cancel = context.WithCancel(ctx)
+	v.mu.Lock()
+	v.stats, v.whole, v.scanning = newRecordStats(), true, true
+	v.mu.Unlock()
+
+	buffer, screen := a.buffer, a.screen
+	go func() {
+		lastUpdate := time.Now()
+		_, err := buffer.EachRecord(0, 0, func(r *record) error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			v.mu.Lock()
+			v.stats.add(r.parsed)
+			v.mu.Unlock()
+			if time.Since(lastUpdate) >= statsUpdateInterval {
+				lastUpdate = time.Now()
+				screen.PostEvent(tcell.NewEventInterrupt(statsUpdate{}))
+			}
+			return nil
+		})
+		if ctx.Err() != nil {
+			// The overlay was closed.
+			return
+		}
+		v.mu.Lock()
+		v.scanning, v.err = false, err
+		v.mu.Unlock()
+		screen.PostEvent(tcell.NewEventInterrupt(statsUpdate{}))
+	}()
+}
+
+// handleStatsKey handles a key press while the stats overlay is shown.
+func (a *Application) handleStatsKey(ev *tcell.EventKey, act action) {
+	switch {
+	case act == actionToggleStats || act == actionQuit || ev.Key() == tcell.KeyEscape:
+		a.closeStats()
+	case ev.Key() == tcell.KeyRune && ev.Rune() == 'a':
+		a.scanStats(a.buffer.ctx)
+	}
+}
+
+// overlay returns the overlay showing the stats.
+func (v *statsView) overlay() *overlay {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	s := v.stats
+	var lines []string
+	switch {
+	case v.err != nil:
+		lines = append(lines, "Scanning the input failed: "+v.err.Error())
+	case v.scanning:
+		lines = append(lines, fmt.Sprintf("%d records in the input so far, scanning...", s.total))
+	case v.whole:
+		lines = append(lines, fmt.Sprintf("%d records in the input", s.total))
+	default:
+		lines = append(lines, fmt.Sprintf("%d records loaded", s.total))
+	}
+
+	lines = append(lines, "", "By level")
+	maxLevel := 0
+	for _, level := range statsLevels {
+		maxLevel = max(maxLevel, s.levels[level])
+	}
+	for _, level := range statsLevels {
+		name := string(level)
+		if level == levelUnknown {
+			name = "none"
+		}
+		lines = append(lines, fmt.Sprintf("  %-5s %8d %s", name, s.levels[level], statsBar(s.levels[level], maxLevel)))
+	}
+
+	buckets, width := s.buckets(statsBuckets)
+	if len(buckets) > 0 {
+		lines = append(lines, "", "By time, "+formatBucketWidth(width)+" each")
+		layout := "2006-01-02 15:04:05"
+		if width >= 24*time.Hour {
+			layout = "2006-01-02"
+		} else if width >= time.Minute {
+			layout = "2006-01-02 15:04"
+		}
+		maxCount := 0
+		for _, bucket := range buckets {
+			maxCount = max(maxCount, bucket.count)
+		}
+		for _, bucket := range buckets {
+			line := fmt.Sprintf("  %-19s %8d %-*s", bucket.start.Format(layout), bucket.count, statsBarWidth, statsBar(bucket.count, maxCount))
+			if bucket.errors > 0 {
+				line += fmt.Sprintf(" %d errors", bucket.errors)
+			}
+			lines = append(lines, strings.TrimRight(line, " "))
+		}
+	}
+	if s.untimed > 0 {
+		lines = append(lines, "", fmt.Sprintf("%d records have no timestamp", s.untimed))
+	}
+
+	footer := "a: whole input  Esc: close"
+	if v.whole {
+		footer = "Esc: close"
+	}
+	return &overlay{title: "Stats", lines: lines, footer: footer}
+}
+
+// statsBar returns a bar as long relative to statsBarWidth as count is
+// relative to max.
+func statsBar(count, max int) string {
+	if count == 0 || max == 0 {
+		return ""
+	}
+	return strings.Repeat("█", (count*statsBarWidth+max-1)/max)
+}
+
+// formatBucketWidth returns a short description of a time bucket's width, like
+// "5m".
+func formatBucketWidth(width time.Duration) string {
+	switch {
+	case width >= 24*time.Hour:
+		return fmt.Sprintf("%dd", int(width.Hours()/24))
+	case width >= time.Hour:
+		return fmt.Sprintf("%dh", int(width.Hours()))
+	case width >= time.Minute:
+		return fmt.Sprintf("%dm", int(width.Minutes()))
+	default:
+		return fmt.Sprintf("%ds", int(width.Seconds()))
+	}
+}
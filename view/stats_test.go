@@ -0,0 +1,73 @@
+package view
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordStats_Buckets(t *testing.T) {
+	stats := newRecordStats()
+	start := time.Date(2023, 11, 14, 22, 0, 0, 0, time.UTC)
+	for i := 0; i < 30; i++ {
+		level := "info"
+		if i%10 == 0 {
+			level = "error"
+		}
+		stats.add(map[string]any{"time": float64(start.Add(time.Duration(i) * time.Minute).UnixMilli()), "level": level})
+	}
+	stats.add(map[string]any{"msg": "no time"})
+
+	assert.EqualValues(t, 31, stats.total)
+	assert.EqualValues(t, 3, stats.levels[levelError])
+	assert.EqualValues(t, 27, stats.levels[levelInfo])
+	assert.EqualValues(t, 1, stats.untimed)
+
+	// 30 minutes don't fit 12 one minute buckets, but fit 5 minute ones.
+	buckets, width := stats.buckets(12)
+	assert.EqualValues(t, 5*time.Minute, width)
+	assert.Len(t, buckets, 6)
+	for i, bucket := range buckets {
+		assert.True(t, start.Add(time.Duration(i)*5*time.Minute).Equal(bucket.start))
+		assert.EqualValues(t, 5, bucket.count)
+	}
+	assert.EqualValues(t, []int{1, 0, 1, 0, 1, 0}, []int{buckets[0].errors, buckets[1].errors, buckets[2].errors, buckets[3].errors, buckets[4].errors, buckets[5].errors})
+}
+
+func TestStats_ShowsLoadedAndWholeInputStats(t *testing.T) {
+	var lines []string
+	for i := 0; i < 50; i++ {
+		lines = append(lines, fmt.Sprintf(`{"time":%d,"name":"Pelecard","level":"warn","msg":"hi"}`, 1700000000000+int64(i)*1000))
+	}
+	file, _ := createTestFile(t, strings.Join(lines, "\n")+"\n")
+
+	ctx, cancelCtx := context.WithCancel(context.Background())
+	defer cancelCtx()
+	screen := NewMemoryScreen(100, 40)
+
+	config := &Config{NoTutorial: true, MaxLines: 20}
+	a := NewApplication(file, false, config)
+	assert.NoError(t, a.setup(ctx, screen))
+	assert.Eventually(t, func() bool { return a.buffer.records.RecordAtScreenLine(0) != nil }, time.Second, 5*time.Millisecond)
+
+	a.handleEvent(tcell.NewEventKey(tcell.KeyRune, 'S', tcell.ModNone))
+	assert.NotNil(t, a.stats)
+	loaded := a.stats.overlay().lines[0]
+	assert.Contains(t, loaded, "records loaded")
+
+	a.handleEvent(tcell.NewEventKey(tcell.KeyRune, 'a', tcell.ModNone))
+	assert.Eventually(t, func() bool {
+		return a.stats.overlay().lines[0] == "50 records in the input"
+	}, time.Second, 5*time.Millisecond)
+	o := a.stats.overlay()
+	assert.Contains(t, o.lines, "  warn        50 "+strings.Repeat("█", statsBarWidth))
+	assert.Contains(t, o.lines, "By time, 5s each")
+
+	a.handleEvent(tcell.NewEventKey(tcell.KeyEscape, 0, tcell.ModNone))
+	assert.Nil(t, a.stats)
+}
@@ -0,0 +1,124 @@
+package view
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/YLivay/gote/input"
+	"github.com/rivo/uniseg"
+)
+
+// drawStatusBar draws the status bar on the bottom row of the screen.
+func (a *Application) drawStatusBar() {
+	if a.height < 1 {
+		return
+	}
+
+	left, right := formatStatus(a.displayName(), a.buffer.Status())
+	if a.spool != nil {
+		if ingestion := formatSpoolStatus(a.spool.Status()); ingestion != "" {
+			left += "  " + ingestion
+		}
+	}
+	if a.pause != nil {
+		left += "  " + formatPause(a.pause)
+	}
+	if a.visual {
+		left += "  [visual]"
+	}
+	if a.message != "" {
+		left += "  " + a.message
+	}
+
+	y := a.height - 1
+	style := a.theme.statusBar
+	for x := 0; x < a.width; x++ {
+		a.screen.SetContent(x, y, ' ', nil, style)
+	}
+
+	// The right part is more useful when space runs out, so it takes priority.
+	rightWidth := min(uniseg.StringWidth(right), a.width)
+	a.drawText(a.width-rightWidth, y, rightWidth, right, style)
+	leftWidth := max(a.width-rightWidth-1, 0)
+	end := a.drawText(0, y, leftWidth, left, style)
+	if n := a.currentNotice(); n != nil {
+		noticeStyle := style
+		if n.err {
+			noticeStyle = style.Bold(true).Reverse(true)
+		}
+		a.drawText(end+2, y, max(leftWidth-end-2, 0), n.text, noticeStyle)
+	}
+}
+
+// displayName returns the name of the input to show to the user.
+func (a *Application) displayName() string {
+	if a.config.Filename == "-" {
+		return "stdin"
+	}
+	return a.config.Filename
+}
+
+// formatStatus formats the given buffer status into the left and right aligned
+// parts of the status bar.
+func formatStatus(name string, status BufferStatus) (left, right string) {
+	leftParts := []string{name}
+	if status.FollowMode {
+		leftParts = append(leftParts, "[follow]", formatRate(status.IngestRate, status.IngestPeak))
+	}
+	if status.Alerts > 0 {
+		leftParts = append(leftParts, formatAlerts(status.Alerts))
+	}
+	if status.Sample.on() {
+		leftParts = append(leftParts, formatSampling(status.Sample))
+	}
+	if status.Filter != "" {
+		leftParts = append(leftParts, "filter: "+status.Filter)
+	}
+	if status.Binary {
+		leftParts = append(leftParts, "[may be a binary file]")
+	}
+
+	rightParts := []string{fmt.Sprintf("%s / %s records", formatCount(status.Matched), formatCount(status.Read))}
+	if status.ByteOffset >= 0 && status.FileSize >= 0 {
+		position := fmt.Sprintf("byte %d/%d", status.ByteOffset, status.FileSize)
+		if status.FileSize > 0 && status.Lines > 0 {
+			position += fmt.Sprintf("  %s, %d%%", formatLines(status.Lines, status.LinesExact), status.ByteOffset*100/status.FileSize)
+		} else if status.FileSize > 0 {
+			position += fmt.Sprintf(" (%d%%)", status.ByteOffset*100/status.FileSize)
+		}
+		rightParts = append(rightParts, position)
+	}
+
+	return " " + strings.Join(leftParts, "  "), strings.Join(rightParts, "  ") + " "
+}
+
+// formatSpoolStatus describes how far the spill file lags behind its input, or
+// returns an empty string if it keeps up.
+func formatSpoolStatus(status input.SpoolStatus) string {
+	var parts []string
+	if status.Paused {
+		parts = append(parts, "[input paused]")
+	}
+	if status.Pending > 0 {
+		parts = append(parts, formatBytes(status.Pending)+" pending")
+	}
+	if status.Dropped > 0 {
+		parts = append(parts, formatBytes(status.Dropped)+" dropped")
+	}
+	return strings.Join(parts, "  ")
+}
+
+// formatBytes formats a number of bytes in the largest unit that keeps it
+// above 1.
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%dB", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,49 @@
+package view
+
+import (
+	"testing"
+
+	"github.com/YLivay/gote/input"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatStatus_ShowsAllParts(t *testing.T) {
+	left, right := formatStatus("app.log", BufferStatus{
+		ByteOffset: 250,
+		FileSize:   1000,
+		FollowMode: true,
+		Filter:     ".msg",
+		Records:    12,
+		Read:       56789,
+		Matched:    1234,
+		IngestRate: 12,
+		IngestPeak: 3400,
+	})
+	assert.EqualValues(t, " app.log  [follow]  12 lines/s (peak 3,400)  filter: .msg", left)
+	assert.EqualValues(t, "1,234 / 56,789 records  byte 250/1000 (25%) ", right)
+}
+
+func TestFormatStatus_ShowsTheEstimatedLines(t *testing.T) {
+	_, right := formatStatus("app.log", BufferStatus{ByteOffset: 470, FileSize: 1000, Lines: 2_300_000})
+	assert.EqualValues(t, "0 / 0 records  byte 470/1000  ~2.3M lines, 47% ", right)
+}
+
+func TestFormatStatus_OmitsUnknownPosition(t *testing.T) {
+	left, right := formatStatus("stdin", BufferStatus{ByteOffset: -1, FileSize: 1000})
+	assert.EqualValues(t, " stdin", left)
+	assert.EqualValues(t, "0 / 0 records ", right)
+}
+
+func TestFormatStatus_WarnsAboutBinaryFiles(t *testing.T) {
+	left, _ := formatStatus("core", BufferStatus{ByteOffset: -1, FileSize: -1, Binary: true})
+	assert.EqualValues(t, " core  [may be a binary file]", left)
+}
+
+func TestFormatSpoolStatus_DescribesLag(t *testing.T) {
+	assert.EqualValues(t, "", formatSpoolStatus(input.SpoolStatus{}))
+	assert.EqualValues(t, "[input paused]  1.5KiB pending  3.0MiB dropped", formatSpoolStatus(input.SpoolStatus{
+		Pending: 1536,
+		Dropped: 3 << 20,
+		Paused:  true,
+	}))
+}
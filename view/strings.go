@@ -1,4 +1,4 @@
-package main
+package view
 
 import (
 	"strings"
@@ -126,3 +126,105 @@ func WordWrap(text string, width int) (lines []string) {
 
 	return
 }
+
+// The number of columns between tab stops if none is configured.
+const defaultTabWidth = 8
+
+// ExpandTabs replaces the tabs in text with enough spaces to reach the next tab
+// stop. Columns are counted in cells from the start of each line.
+func ExpandTabs(text string, tabWidth int) string {
+	tabs := tabExpansions(text, tabWidth)
+	if len(tabs) == 0 {
+		return text
+	}
+
+	var sb strings.Builder
+	last := 0
+	for _, tab := range tabs {
+		sb.WriteString(text[last:tab.offset])
+		sb.WriteString(strings.Repeat(" ", tab.spaces))
+		last = tab.offset + 1
+	}
+	sb.WriteString(text[last:])
+	return sb.String()
+}
+
+// tabExpansion is a tab in a text and the number of spaces it expands to.
+type tabExpansion struct {
+	offset int
+	spaces int
+}
+
+// tabExpansions returns the tabs in text in order, along with the number of
+// spaces each one expands to.
+func tabExpansions(text string, tabWidth int) []tabExpansion {
+	if tabWidth <= 0 || !strings.Contains(text, "\t") {
+		return nil
+	}
+
+	var tabs []tabExpansion
+	var state *stepState
+	offset, column := 0, 0
+	for str := text; len(str) > 0; {
+		var cluster string
+		cluster, str, state = step(str, state)
+		switch cluster {
+		case "\t":
+			spaces := tabWidth - column%tabWidth
+			tabs = append(tabs, tabExpansion{offset: offset, spaces: spaces})
+			column += spaces
+		case "\n", "\r\n":
+			column = 0
+		default:
+			column += state.Width()
+		}
+		offset += len(cluster)
+	}
+	return tabs
+}
+
+// expandTabsInSpans moves the bounds of the given spans of text to where they
+// end up after its tabs are expanded.
+func expandTabsInSpans(spans []styleSpan, text string, tabWidth int) {
+	tabs := tabExpansions(text, tabWidth)
+	shift := func(offset int) int {
+		shifted := offset
+		for _, tab := range tabs {
+			if tab.offset >= offset {
+				break
+			}
+			shifted += tab.spaces - 1
+		}
+		return shifted
+	}
+
+	for i := range spans {
+		spans[i].start, spans[i].end = shift(spans[i].start), shift(spans[i].end)
+	}
+}
+
+// Truncate returns the first line of text, cut off so it doesn't exceed the
+// given width.
+func Truncate(text string, width int) string {
+	var state *stepState
+	str := text
+	lineWidth, lineLength := 0, 0
+	for len(str) > 0 {
+		_, str, state = step(str, state)
+		cWidth := state.Width()
+
+		if lineWidth+cWidth > width {
+			break
+		}
+
+		// Stop at a mandatory line break, without including it.
+		if lineBreak, optional := state.LineBreak(); lineBreak && !optional {
+			return strings.TrimRight(text[:lineLength+state.GrossLength()], "\n\r")
+		}
+
+		lineWidth += cWidth
+		lineLength += state.GrossLength()
+	}
+
+	return text[:lineLength]
+}
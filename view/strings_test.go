@@ -0,0 +1,42 @@
+package view
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTruncate_CutsAtWidth(t *testing.T) {
+	assert.EqualValues(t, "hel", Truncate("hello", 3))
+}
+
+func TestTruncate_KeepsShortText(t *testing.T) {
+	assert.EqualValues(t, "hi", Truncate("hi", 10))
+}
+
+func TestTruncate_StopsAtNewLine(t *testing.T) {
+	assert.EqualValues(t, "hi", Truncate("hi\nthere", 10))
+}
+
+func TestTruncate_DoesNotSplitWideCharacters(t *testing.T) {
+	assert.EqualValues(t, "日", Truncate("日本", 3))
+}
+
+func TestExpandTabs_AlignsToTabStops(t *testing.T) {
+	assert.EqualValues(t, "a   bc  d", ExpandTabs("a\tbc\td", 4))
+	assert.EqualValues(t, "    x", ExpandTabs("\tx", 4))
+}
+
+func TestExpandTabs_RestartsColumnsOnNewLine(t *testing.T) {
+	assert.EqualValues(t, "ab  c\n    d", ExpandTabs("ab\tc\n\td", 4))
+}
+
+func TestExpandTabs_CountsWideCharacters(t *testing.T) {
+	assert.EqualValues(t, "日  x", ExpandTabs("日\tx", 4))
+}
+
+func TestExpandTabsInSpans_ShiftsBounds(t *testing.T) {
+	spans := []styleSpan{{start: 2, end: 3}}
+	expandTabsInSpans(spans, "a\tb", 4)
+	assert.EqualValues(t, []styleSpan{{start: 4, end: 5}}, spans)
+}
@@ -0,0 +1,150 @@
+package view
+
+import (
+	"io"
+	"os"
+	"path"
+	"testing"
+)
+
+// CreateTestFiles creates a temporary test file with the given contents and
+// seek. It returns the open file handle and the seek position from the start of
+// the file. If no seek was given, it defaults to the start of the file.
+func createTestFile(t *testing.T, contents string, seekStuff ...int) (*os.File, int64) {
+	filepath := path.Join(t.TempDir(), "test.txt")
+	if err := os.WriteFile(filepath, []byte(contents), 0644); err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+
+	f, err := os.Open(filepath)
+	if err != nil {
+		t.Fatalf("Failed to open temp file: %v", err)
+	}
+
+	var seek, whence int
+	switch len(seekStuff) {
+	case 0:
+		seek = 0
+		whence = io.SeekStart
+	case 1:
+		seek = seekStuff[0]
+		if seek >= 0 {
+			whence = io.SeekStart
+		} else {
+			whence = io.SeekEnd
+			seek = -seek
+		}
+	case 2:
+		seek = seekStuff[0]
+		whence = seekStuff[1]
+	default:
+		panic("Too many arguments")
+	}
+
+	var pos int64
+	if seek != 0 || whence != io.SeekStart {
+		pos, err = f.Seek(int64(seek), whence)
+		if err != nil {
+			t.Fatalf("Failed to seek temp file: %v", err)
+		}
+	} else {
+		pos = 0
+	}
+
+	t.Cleanup(func() {
+		if err := f.Close(); err != nil {
+			t.Fatalf("Failed to close temp file: %v", err)
+		}
+	})
+
+	return f, pos
+}
+
+func appendToTestFile(t *testing.T, f *os.File, contents string) {
+	f2, err := os.OpenFile(f.Name(), os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if _, err = f2.WriteString(contents); err != nil {
+		t.Fatal(err.Error())
+	}
+	if err = f2.Close(); err != nil {
+		t.Fatal(err.Error())
+	}
+}
+
+// assertRecordListInvariants walks the given record list and fails the test if
+// its ring of records or its cached line counters are inconsistent with its
+// records.
+func assertRecordListInvariants(t *testing.T, l *bufferRecordList) {
+	t.Helper()
+
+	l.WithLock(func(l *bufferRecordList) any {
+		d := &l.records
+		if d.count == 0 {
+			if l.screenTop != 0 || l.screenTopOffset != 0 || l.linesAboveScreenTop != 0 || l.linesBelowScreenTop != 0 || l.linesTotal != 0 || l.size != 0 {
+				t.Fatalf("empty list has non zero counters: screenTop = %d, screenTopOffset = %d, linesAboveScreenTop = %d, linesBelowScreenTop = %d, linesTotal = %d", l.screenTop, l.screenTopOffset, l.linesAboveScreenTop, l.linesBelowScreenTop, l.linesTotal)
+			}
+			return nil
+		}
+
+		if l.screenTop < 0 || l.screenTop >= d.count {
+			t.Fatalf("screenTop %d out of range for %d records", l.screenTop, d.count)
+		}
+
+		linesTotal, linesAbove, count, size := 0, -1, 0, 0
+		for i := 0; i < d.count; i++ {
+			r := d.at(i)
+			if r == nil {
+				t.Fatalf("record %d is nil", i)
+			}
+			lines := r.Lines(l.layout)
+			if len(lines) == 0 {
+				t.Fatalf("record %d spans no lines", i)
+			}
+			if d.linesAt(i) != len(lines) {
+				t.Fatalf("record %d is counted as %d lines, expected %d", i, d.linesAt(i), len(lines))
+			}
+			if before := d.linesBefore(i); before != linesTotal {
+				t.Fatalf("record %d has %d lines before it, expected %d", i, before, linesTotal)
+			}
+			if index, offset := d.find(linesTotal + len(lines) - 1); index != i || offset != len(lines)-1 {
+				t.Fatalf("last line of record %d found at record %d offset %d", i, index, offset)
+			}
+			if i == l.screenTop {
+				linesAbove = linesTotal + l.screenTopOffset
+				if l.screenTopOffset < 0 || l.screenTopOffset >= len(lines) {
+					t.Fatalf("screenTopOffset %d out of range for a record of %d lines", l.screenTopOffset, len(lines))
+				}
+			}
+			linesTotal += len(lines)
+			count++
+			size += r.size()
+		}
+
+		// Slots outside of the records must be empty so the tree doesn't count
+		// them.
+		for i := d.count; i < len(d.records); i++ {
+			if d.at(i) != nil || d.linesAt(i) != 0 {
+				t.Fatalf("slot %d past the records is not empty", d.slot(i))
+			}
+		}
+
+		if count != d.count {
+			t.Fatalf("count is %d, expected %d", d.count, count)
+		}
+		if size != l.size {
+			t.Fatalf("size is %d, expected %d", l.size, size)
+		}
+		if linesTotal != l.linesTotal {
+			t.Fatalf("linesTotal is %d, expected %d", l.linesTotal, linesTotal)
+		}
+		if linesAbove != l.linesAboveScreenTop {
+			t.Fatalf("linesAboveScreenTop is %d, expected %d", l.linesAboveScreenTop, linesAbove)
+		}
+		if linesTotal-linesAbove != l.linesBelowScreenTop {
+			t.Fatalf("linesBelowScreenTop is %d, expected %d", l.linesBelowScreenTop, linesTotal-linesAbove)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,227 @@
+package view
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// theme is a named palette every part of the UI takes its styles from.
+type theme struct {
+	name string
+	// The number of colors the theme was degraded to, or 0 if it wasn't.
+	colors int
+
+	// Plain log text, and the dim text shown around it such as markers.
+	text tcell.Style
+	dim  tcell.Style
+	// The styles of JSON tokens when highlighting is on.
+	json map[tokenClass]tcell.Style
+	// The styles of records by their level. Levels without a style are shown
+	// as plain text.
+	levels map[logLevel]tcell.Style
+	// The styles the sources of merged inputs are marked with, in turn.
+	sources []tcell.Style
+
+	gutter         tcell.Style
+	statusBar      tcell.Style
+	overlay        tcell.Style
+	scrollbarTrack tcell.Style
+	scrollbarThumb tcell.Style
+}
+
+// The theme used when none is configured.
+const defaultThemeName = "dark"
+
+var themes = map[string]*theme{
+	"dark": {
+		name: "dark",
+		text: tcell.StyleDefault,
+		dim:  tcell.StyleDefault.Dim(true),
+		json: map[tokenClass]tcell.Style{
+			tokenNone:    tcell.StyleDefault,
+			tokenKey:     tcell.StyleDefault.Foreground(tcell.ColorBlue).Bold(true),
+			tokenString:  tcell.StyleDefault.Foreground(tcell.ColorGreen),
+			tokenNumber:  tcell.StyleDefault.Foreground(tcell.ColorTeal),
+			tokenLiteral: tcell.StyleDefault.Foreground(tcell.ColorPurple),
+		},
+		levels: map[logLevel]tcell.Style{
+			levelTrace: tcell.StyleDefault.Dim(true),
+			levelDebug: tcell.StyleDefault.Dim(true),
+			levelWarn:  tcell.StyleDefault.Foreground(tcell.ColorYellow),
+			levelError: tcell.StyleDefault.Foreground(tcell.ColorRed),
+			levelFatal: tcell.StyleDefault.Foreground(tcell.ColorWhite).Background(tcell.ColorMaroon).Bold(true),
+		},
+		sources: []tcell.Style{
+			tcell.StyleDefault.Foreground(tcell.ColorAqua),
+			tcell.StyleDefault.Foreground(tcell.ColorFuchsia),
+			tcell.StyleDefault.Foreground(tcell.ColorLime),
+			tcell.StyleDefault.Foreground(tcell.ColorYellow),
+			tcell.StyleDefault.Foreground(tcell.ColorBlue),
+			tcell.StyleDefault.Foreground(tcell.ColorRed),
+		},
+		gutter:         tcell.StyleDefault.Dim(true),
+		statusBar:      tcell.StyleDefault.Reverse(true),
+		overlay:        tcell.StyleDefault.Reverse(true),
+		scrollbarTrack: tcell.StyleDefault.Dim(true),
+		scrollbarThumb: tcell.StyleDefault,
+	},
+	"light": {
+		name: "light",
+		text: tcell.StyleDefault,
+		dim:  tcell.StyleDefault.Foreground(tcell.ColorGray),
+		json: map[tokenClass]tcell.Style{
+			tokenNone:    tcell.StyleDefault,
+			tokenKey:     tcell.StyleDefault.Foreground(tcell.ColorNavy).Bold(true),
+			tokenString:  tcell.StyleDefault.Foreground(tcell.ColorGreen),
+			tokenNumber:  tcell.StyleDefault.Foreground(tcell.ColorTeal),
+			tokenLiteral: tcell.StyleDefault.Foreground(tcell.ColorPurple),
+		},
+		levels: map[logLevel]tcell.Style{
+			levelTrace: tcell.StyleDefault.Foreground(tcell.ColorGray),
+			levelDebug: tcell.StyleDefault.Foreground(tcell.ColorGray),
+			levelWarn:  tcell.StyleDefault.Foreground(tcell.ColorOlive),
+			levelError: tcell.StyleDefault.Foreground(tcell.ColorMaroon),
+			levelFatal: tcell.StyleDefault.Foreground(tcell.ColorWhite).Background(tcell.ColorRed).Bold(true),
+		},
+		sources: []tcell.Style{
+			tcell.StyleDefault.Foreground(tcell.ColorTeal),
+			tcell.StyleDefault.Foreground(tcell.ColorPurple),
+			tcell.StyleDefault.Foreground(tcell.ColorGreen),
+			tcell.StyleDefault.Foreground(tcell.ColorOlive),
+			tcell.StyleDefault.Foreground(tcell.ColorNavy),
+			tcell.StyleDefault.Foreground(tcell.ColorMaroon),
+		},
+		gutter:         tcell.StyleDefault.Foreground(tcell.ColorGray),
+		statusBar:      tcell.StyleDefault.Foreground(tcell.ColorWhite).Background(tcell.ColorNavy),
+		overlay:        tcell.StyleDefault.Foreground(tcell.ColorBlack).Background(tcell.ColorSilver),
+		scrollbarTrack: tcell.StyleDefault.Foreground(tcell.ColorSilver),
+		scrollbarThumb: tcell.StyleDefault.Foreground(tcell.ColorNavy),
+	},
+	"solarized": {
+		name: "solarized",
+		text: tcell.StyleDefault.Foreground(tcell.NewHexColor(0x839496)),
+		dim:  tcell.StyleDefault.Foreground(tcell.NewHexColor(0x586e75)),
+		json: map[tokenClass]tcell.Style{
+			tokenNone:    tcell.StyleDefault.Foreground(tcell.NewHexColor(0x839496)),
+			tokenKey:     tcell.StyleDefault.Foreground(tcell.NewHexColor(0x268bd2)).Bold(true),
+			tokenString:  tcell.StyleDefault.Foreground(tcell.NewHexColor(0x859900)),
+			tokenNumber:  tcell.StyleDefault.Foreground(tcell.NewHexColor(0x2aa198)),
+			tokenLiteral: tcell.StyleDefault.Foreground(tcell.NewHexColor(0x6c71c4)),
+		},
+		levels: map[logLevel]tcell.Style{
+			levelTrace: tcell.StyleDefault.Foreground(tcell.NewHexColor(0x586e75)),
+			levelDebug: tcell.StyleDefault.Foreground(tcell.NewHexColor(0x586e75)),
+			levelWarn:  tcell.StyleDefault.Foreground(tcell.NewHexColor(0xb58900)),
+			levelError: tcell.StyleDefault.Foreground(tcell.NewHexColor(0xdc322f)),
+			levelFatal: tcell.StyleDefault.Foreground(tcell.NewHexColor(0xfdf6e3)).Background(tcell.NewHexColor(0xdc322f)).Bold(true),
+		},
+		sources: []tcell.Style{
+			tcell.StyleDefault.Foreground(tcell.NewHexColor(0x2aa198)),
+			tcell.StyleDefault.Foreground(tcell.NewHexColor(0xd33682)),
+			tcell.StyleDefault.Foreground(tcell.NewHexColor(0x859900)),
+			tcell.StyleDefault.Foreground(tcell.NewHexColor(0xb58900)),
+			tcell.StyleDefault.Foreground(tcell.NewHexColor(0x268bd2)),
+			tcell.StyleDefault.Foreground(tcell.NewHexColor(0xcb4b16)),
+		},
+		gutter:         tcell.StyleDefault.Foreground(tcell.NewHexColor(0x586e75)),
+		statusBar:      tcell.StyleDefault.Foreground(tcell.NewHexColor(0x93a1a1)).Background(tcell.NewHexColor(0x073642)),
+		overlay:        tcell.StyleDefault.Foreground(tcell.NewHexColor(0x93a1a1)).Background(tcell.NewHexColor(0x073642)),
+		scrollbarTrack: tcell.StyleDefault.Foreground(tcell.NewHexColor(0x073642)),
+		scrollbarThumb: tcell.StyleDefault.Foreground(tcell.NewHexColor(0x586e75)),
+	},
+}
+
+// themeNames returns the names of the built-in themes, sorted.
+func themeNames() []string {
+	names := make([]string, 0, len(themes))
+	for name := range themes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// lookupTheme returns the built-in theme with the given name, or the default
+// theme if the name is empty.
+func lookupTheme(name string) (*theme, error) {
+	if name == "" {
+		name = defaultThemeName
+	}
+	t, ok := themes[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown theme %q, expected one of %s", name, strings.Join(themeNames(), ", "))
+	}
+	return t, nil
+}
+
+// degrade returns a copy of the theme with every color replaced by the closest
+// one a terminal that supports the given number of colors can show.
+func (t *theme) degrade(colors int) *theme {
+	d := *t
+	// Monochrome terminals report 0 colors, which would read as not degraded.
+	d.colors = max(colors, 1)
+	degradeStyle := d.degradeStyle
+
+	d.text = degradeStyle(t.text)
+	d.dim = degradeStyle(t.dim)
+	d.json = make(map[tokenClass]tcell.Style, len(t.json))
+	for class, style := range t.json {
+		d.json[class] = degradeStyle(style)
+	}
+	d.levels = make(map[logLevel]tcell.Style, len(t.levels))
+	for level, style := range t.levels {
+		d.levels[level] = degradeStyle(style)
+	}
+	d.sources = make([]tcell.Style, len(t.sources))
+	for i, style := range t.sources {
+		d.sources[i] = degradeStyle(style)
+	}
+	d.gutter = degradeStyle(t.gutter)
+	d.statusBar = degradeStyle(t.statusBar)
+	d.overlay = degradeStyle(t.overlay)
+	d.scrollbarTrack = degradeStyle(t.scrollbarTrack)
+	d.scrollbarThumb = degradeStyle(t.scrollbarThumb)
+	return &d
+}
+
+// degradeStyle returns the given style with its colors replaced by the closest
+// ones the theme was degraded to. Styles that don't come from the theme, such
+// as the ones records set, go through this before they are drawn.
+func (t *theme) degradeStyle(s tcell.Style) tcell.Style {
+	if t.colors == 0 {
+		return s
+	}
+
+	fg, bg, attrs := s.Decompose()
+	return tcell.StyleDefault.
+		Foreground(degradeColor(fg, t.colors)).
+		Background(degradeColor(bg, t.colors)).
+		Attributes(attrs)
+}
+
+// degradeColor returns the closest color to c a terminal that supports the
+// given number of colors can show. Terminals with true color support report
+// 1<<24 colors and show every color as is, and monochrome terminals only show
+// the default color.
+func degradeColor(c tcell.Color, colors int) tcell.Color {
+	if c == tcell.ColorDefault || !c.Valid() || colors >= 1<<24 {
+		return c
+	}
+	if colors <= 1 {
+		return tcell.ColorDefault
+	}
+
+	colors = min(colors, 256)
+	if !c.IsRGB() && int(c-tcell.ColorValid) < colors {
+		return c
+	}
+
+	palette := make([]tcell.Color, colors)
+	for i := range palette {
+		palette[i] = tcell.PaletteColor(i)
+	}
+	return tcell.FindColor(c, palette)
+}
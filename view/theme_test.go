@@ -0,0 +1,48 @@
+package view
+
+import (
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLookupTheme_DefaultsToDark(t *testing.T) {
+	theme, err := lookupTheme("")
+	assert.NoError(t, err)
+	assert.EqualValues(t, "dark", theme.name)
+
+	_, err = lookupTheme("neon")
+	assert.Error(t, err)
+}
+
+func TestThemes_StyleEveryTokenClass(t *testing.T) {
+	for _, name := range themeNames() {
+		for _, class := range []tokenClass{tokenNone, tokenKey, tokenString, tokenNumber, tokenLiteral} {
+			_, ok := themes[name].json[class]
+			assert.True(t, ok, "theme %s has no style for token class %d", name, class)
+		}
+	}
+}
+
+func TestDegradeColor_KeepsSupportedColors(t *testing.T) {
+	rgb := tcell.NewHexColor(0x268bd2)
+	assert.EqualValues(t, rgb, degradeColor(rgb, 1<<24))
+	assert.EqualValues(t, tcell.ColorMaroon, degradeColor(tcell.ColorMaroon, 8))
+	assert.EqualValues(t, tcell.ColorMaroon, degradeColor(tcell.ColorRed, 8))
+	assert.EqualValues(t, tcell.ColorDefault, degradeColor(tcell.ColorDefault, 8))
+}
+
+func TestDegradeColor_FindsClosestPaletteColor(t *testing.T) {
+	assert.EqualValues(t, tcell.ColorRed, degradeColor(tcell.NewHexColor(0xfe0000), 16))
+	assert.False(t, degradeColor(tcell.NewHexColor(0x268bd2), 256).IsRGB())
+	assert.EqualValues(t, tcell.ColorMaroon, degradeColor(tcell.PaletteColor(88), 8))
+	assert.EqualValues(t, tcell.ColorDefault, degradeColor(tcell.ColorRed, 0))
+}
+
+func TestTheme_DegradeKeepsAttributes(t *testing.T) {
+	degraded := themes["solarized"].degrade(16)
+	fg, _, attrs := degraded.json[tokenKey].Decompose()
+	assert.False(t, fg.IsRGB())
+	assert.NotZero(t, attrs&tcell.AttrBold)
+}
@@ -0,0 +1,228 @@
+package view
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/YLivay/gote/reader"
+)
+
+// timeSearchSpan is the span of the input that is bisected down to when
+// searching it for a time, before it is read through line by line.
+const timeSearchSpan = 64 << 10
+
+// parseTimeBound parses a -since or -until flag. It is either a timestamp in
+// one of the layouts records' timestamps are parsed with, a date, or a duration
+// like 1h30m to go back from now. Times without a time zone are in UTC, like
+// records' timestamps.
+func parseTimeBound(value string, now time.Time) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		if d < 0 {
+			return time.Time{}, fmt.Errorf("duration %q can't be negative", value)
+		}
+		return now.Add(-d), nil
+	}
+	for _, layout := range slices.Concat(timeLayouts, []string{time.DateOnly}) {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q, expected a timestamp like 2006-01-02T15:04:05Z, a date or a duration like 1h", value)
+}
+
+// inTimeRange returns false if the given parsed record's time is outside the
+// time range. Records without a time are kept.
+func (opts parseOptions) inTimeRange(parsed map[string]any) bool {
+	if opts.since.IsZero() && opts.until.IsZero() {
+		return true
+	}
+	t, ok := recordTime(parsed)
+	if !ok {
+		return true
+	}
+	return !t.Before(opts.since) && (opts.until.IsZero() || !t.After(opts.until))
+}
+
+// SetTimeRange restricts the buffer to the records whose time is within the
+// given range. A zero time leaves that end of the range open.
+//
+// The input file is assumed to be in time order, and is bisected for where the
+// range starts and ends, so the readers don't go through the records outside
+// it. Records that are out of order are dropped as they are read. It takes
+// effect after the next call to SeekAndPopulate.
+func (b *Buffer) SetTimeRange(since, until time.Time) error {
+	if !since.IsZero() && !until.IsZero() && until.Before(since) {
+		return errors.New("the time range ends before it starts")
+	}
+
+	b.mu.Lock()
+	input, inputFile := b.input, b.inputFile
+	opts := []reader.Option{reader.WithDelimiter(b.delimiter)}
+	lo := int64(0)
+	if b.inputStart != nil {
+		lo = b.inputStart()
+	}
+	b.mu.Unlock()
+
+	info, err := inputFile.Stat()
+	if err != nil {
+		return err
+	}
+
+	start, end := int64(0), int64(-1)
+	if !since.IsZero() {
+		start, _, err = searchTime(nil, input, lo, info.Size(), opts, func(t time.Time) bool {
+			return !t.Before(since)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to find where the time range starts: %w", err)
+		}
+	}
+	if !until.IsZero() {
+		var found bool
+		end, found, err = searchTime(nil, input, max(lo, start), info.Size(), opts, func(t time.Time) bool {
+			return t.After(until)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to find where the time range ends: %w", err)
+		}
+		if !found {
+			// Records after the range may still be appended, and are
+			// dropped as they are read.
+			end = -1
+		}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.since, b.until = since, until
+	b.rangeStart, b.rangeEnd = start, end
+	return nil
+}
+
+// SeekToTime populates the buffer with records from the first one whose time
+// isn't before the given one, or from the end of the input if there is none.
+// The input is assumed to be in time order, and is bisected for the record.
+func (b *Buffer) SeekToTime(t time.Time) error {
+	offset, err := b.timeOffset(nil, t)
+	if err != nil {
+		return err
+	}
+	return b.SeekAndPopulate(offset, io.SeekStart)
+}
+
+// timeOffset returns the offset of the first record at or after the given
+// time, searching for it until the given progress's context is done.
+func (b *Buffer) timeOffset(p *scanProgress, t time.Time) (int64, error) {
+	b.mu.Lock()
+	input, inputFile := b.input, b.inputFile
+	opts := []reader.Option{reader.WithDelimiter(b.delimiter)}
+	lo := int64(0)
+	if start := b.start(); start != nil {
+		lo = start()
+	}
+	b.mu.Unlock()
+
+	info, err := inputFile.Stat()
+	if err != nil {
+		return 0, err
+	}
+	offset, _, err := searchTime(p, input, lo, info.Size(), opts, func(rt time.Time) bool {
+		return !rt.Before(t)
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to find %s: %w", t.Format(time.RFC3339), err)
+	}
+	return offset, nil
+}
+
+// searchTime returns the offset of the first line of the input from lo whose
+// record's time matches, and false if there is none, in which case the offset
+// is where the last complete line ends. match must be false for the times
+// before some time and true for the ones after it, and the input in time order.
+// The span between lo and hi is bisected before it is read through. The search
+// advances the given progress by the part of the span it ruled out, and stops
+// once its context is done.
+func searchTime(p *scanProgress, input io.ReaderAt, lo, hi int64, opts []reader.Option, match func(time.Time) bool) (int64, bool, error) {
+	// The progress is the offset into the span that is as far from its start
+	// as the part of it that was ruled out is long.
+	start, end := lo, hi
+	for hi-lo > timeSearchSpan {
+		if err := p.advance(start + (end - start) - (hi - lo)); err != nil {
+			return 0, false, err
+		}
+		mid := lo + (hi-lo)/2
+		pos, next, t, ok, err := nextTime(p, input, mid, hi, opts)
+		switch {
+		case err != nil:
+			return 0, false, err
+		case !ok:
+			// Nothing after mid tells which side the time is on, so the
+			// records before it are searched, and the ones after it read
+			// through if none of them match.
+			hi = mid
+		case match(t):
+			hi = pos
+		default:
+			lo = next
+		}
+	}
+
+	scanner := reader.NewForwardsLineScanner(input, lo, opts...)
+	defer scanner.Close()
+	for scanner.Scan() {
+		if err := p.advance(end - hi + scanner.Pos()); err != nil {
+			return 0, false, err
+		}
+		if t, ok := lineTime(scanner.Bytes()); ok && match(t) {
+			return scanner.Pos(), true, nil
+		}
+	}
+	if err := scanner.Err(); err != nil && !errors.Is(err, io.EOF) {
+		return 0, false, err
+	}
+	return scanner.NextPos(), false, nil
+}
+
+// nextTime returns the offset of the first line that starts after pos and
+// before hi whose record has a time, the offset of the line after it, and the
+// time, or false if there is none.
+func nextTime(p *scanProgress, input io.ReaderAt, pos, hi int64, opts []reader.Option) (int64, int64, time.Time, bool, error) {
+	scanner := reader.NewForwardsLineScanner(input, pos, opts...)
+	defer scanner.Close()
+
+	// pos may be in the middle of a line, so the line it is in is skipped.
+	scanner.Scan()
+	for scanner.NextPos() < hi && scanner.Scan() {
+		if p != nil && p.ctx.Err() != nil {
+			return 0, 0, time.Time{}, false, p.ctx.Err()
+		}
+		if t, ok := lineTime(scanner.Bytes()); ok {
+			return scanner.Pos(), scanner.NextPos(), t, true, nil
+		}
+	}
+	if err := scanner.Err(); err != nil && !errors.Is(err, io.EOF) {
+		return 0, 0, time.Time{}, false, err
+	}
+	return 0, 0, time.Time{}, false, nil
+}
+
+// lineTime returns the time of the record on the given line, and false if it
+// isn't a record or has no time.
+func lineTime(line []byte) (time.Time, bool) {
+	var parsed map[string]any
+	if err := json.Unmarshal(line, &parsed); err != nil {
+		return time.Time{}, false
+	}
+	return recordTime(parsed)
+}
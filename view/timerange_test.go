@@ -0,0 +1,91 @@
+package view
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTimeBound(t *testing.T) {
+	now := time.Date(2024, 1, 2, 12, 0, 0, 0, time.UTC)
+	for value, want := range map[string]time.Time{
+		"":                     {},
+		"2024-01-01T10:00:00Z": time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC),
+		"2024-01-01 10:00:00":  time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC),
+		"2024-01-01":           time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		"90m":                  time.Date(2024, 1, 2, 10, 30, 0, 0, time.UTC),
+	} {
+		got, err := parseTimeBound(value, now)
+		assert.NoError(t, err, value)
+		assert.True(t, want.Equal(got), "%s: got %s", value, got)
+	}
+
+	for _, value := range []string{"yesterday", "-1h", "2024-13-01"} {
+		_, err := parseTimeBound(value, now)
+		assert.Error(t, err, value)
+	}
+}
+
+// timedRecords returns n records a second apart, all the same length.
+func timedRecords(n int) (string, int) {
+	var sb strings.Builder
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&sb, `{"time":%d,"name":"Pelecard","msg":"record %05d"}`+"\n", 1700000000000+i*1000, i)
+	}
+	return sb.String(), sb.Len() / n
+}
+
+func TestBuffer_SetTimeRangeReadsOnlyTheRecordsWithinIt(t *testing.T) {
+	// Enough records for the input to be bisected before it is read through.
+	content, lineLen := timedRecords(5000)
+	file, _ := createTestFile(t, content)
+
+	buffer, err := NewBuffer(80, 2, false, file, context.Background())
+	assert.NoError(t, err)
+	since := time.UnixMilli(1700000000000 + 1000*1000)
+	until := time.UnixMilli(1700000000000 + 3999*1000)
+	assert.NoError(t, buffer.SetTimeRange(since, until))
+	assert.EqualValues(t, 1000*lineLen, buffer.rangeStart)
+	assert.EqualValues(t, 4000*lineLen, buffer.rangeEnd)
+
+	var offsets []int64
+	count, err := buffer.EachRecord(0, 0, func(r *record) error {
+		offsets = append(offsets, r.byteOffset)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 3000, count)
+	assert.EqualValues(t, 1000*lineLen, offsets[0])
+	assert.EqualValues(t, 3999*lineLen, offsets[len(offsets)-1])
+
+	offset, err := buffer.tailOffset(2)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3998*lineLen, offset)
+}
+
+func TestBuffer_SetTimeRangeDropsRecordsOutOfOrder(t *testing.T) {
+	content := `{"time":1700000000000,"name":"Pelecard","msg":"before"}` + "\n" +
+		`{"time":1700000002000,"name":"Pelecard","msg":"within"}` + "\n" +
+		`{"time":1700000000000,"name":"Pelecard","msg":"before again"}` + "\n"
+	file, _ := createTestFile(t, content)
+
+	buffer, err := NewBuffer(80, 2, false, file, context.Background())
+	assert.NoError(t, err)
+	assert.NoError(t, buffer.SetTimeRange(time.UnixMilli(1700000001000), time.Time{}))
+	// There is no record after the range to end it at.
+	assert.EqualValues(t, -1, buffer.rangeEnd)
+
+	var msgs []any
+	_, err = buffer.EachRecord(0, 0, func(r *record) error {
+		msgs = append(msgs, r.parsed.(map[string]any)["msg"])
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []any{"within"}, msgs)
+
+	assert.Error(t, buffer.SetTimeRange(time.UnixMilli(1700000001000), time.UnixMilli(1700000000000)))
+}
@@ -0,0 +1,49 @@
+package view
+
+import (
+	"math"
+	"time"
+)
+
+// timeKeys are the fields a record's timestamp is looked up in, in order.
+var timeKeys = []string{"time", "timestamp", "ts", "@timestamp", "t"}
+
+// timeLayouts are the layouts of the timestamp strings records are parsed
+// with.
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+}
+
+// recordTime returns the timestamp of the given parsed record, and false if it
+// has none. Timestamps can be strings, or numbers of seconds, milliseconds,
+// microseconds or nanoseconds since the epoch, told apart by their magnitude.
+func recordTime(parsed map[string]any) (time.Time, bool) {
+	for _, key := range timeKeys {
+		switch value := parsed[key].(type) {
+		case string:
+			for _, layout := range timeLayouts {
+				if t, err := time.Parse(layout, value); err == nil {
+					return t, true
+				}
+			}
+		case float64:
+			if value <= 0 || math.IsInf(value, 0) || math.IsNaN(value) {
+				continue
+			}
+			switch {
+			case value < 1e11:
+				return time.Unix(0, int64(value*1e9)), true
+			case value < 1e14:
+				return time.UnixMilli(int64(value)), true
+			case value < 1e17:
+				return time.UnixMicro(int64(value)), true
+			default:
+				return time.Unix(0, int64(value)), true
+			}
+		}
+	}
+	return time.Time{}, false
+}
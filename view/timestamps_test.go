@@ -0,0 +1,29 @@
+package view
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordTime(t *testing.T) {
+	want := time.Date(2023, 11, 14, 22, 13, 20, 0, time.UTC)
+	for _, parsed := range []map[string]any{
+		{"time": float64(1700000000)},
+		{"time": float64(1700000000000)},
+		{"ts": float64(1700000000000000)},
+		{"timestamp": float64(1700000000000000000)},
+		{"@timestamp": "2023-11-14T22:13:20Z"},
+		{"t": "2023-11-14 22:13:20"},
+		// Fields that aren't timestamps are skipped.
+		{"time": "soon", "ts": "2023-11-15T00:13:20+02:00"},
+	} {
+		got, ok := recordTime(parsed)
+		assert.True(t, ok, parsed)
+		assert.True(t, want.Equal(got), "%v: got %v", parsed, got)
+	}
+
+	_, ok := recordTime(map[string]any{"msg": "hi"})
+	assert.False(t, ok)
+}
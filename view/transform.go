@@ -0,0 +1,329 @@
+package view
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/YLivay/gote/logging"
+	"github.com/YLivay/gote/reader"
+)
+
+const (
+	// transformInFlight is how many lines may be written to a transform
+	// command before its answers to them are read.
+	transformInFlight = 256
+	// transformBatchSize is how many lines are transformed in one batch: the
+	// line a reader parses and the ones it reads after it.
+	transformBatchSize = 64
+	// transformTimeout is how long a transform command may take to answer a
+	// batch of lines.
+	transformTimeout = 5 * time.Second
+)
+
+// errTransformClosed is returned for lines transformed after the transformer
+// was closed.
+var errTransformClosed = errors.New("transform command was closed")
+
+// errTransformTimeout is returned for lines the transform command didn't answer
+// in time, which is what commands that buffer their output do.
+var errTransformTimeout = fmt.Errorf("transform command didn't answer within %v, it must flush its output after every line", transformTimeout)
+
+// lineTransformer transforms lines with an external command that keeps running
+// for as long as it is used, so it isn't started for every line. The command
+// answers every line written to its input with a line on its output: the line
+// transformed, or an empty line to drop it. A command that keeps running can't
+// exit with a status for every line, so dropping lines takes an empty answer
+// rather than a non-zero one.
+//
+// Lines are written to the command in batches, and the ones that queue up from
+// concurrent callers meanwhile are written along with them.
+type lineTransformer struct {
+	// Keeps lines and the replies waiting for them in the same order.
+	mu sync.Mutex
+	// The lines to write to the command, and the replies waiting for its
+	// answers, in the order the lines were written.
+	lines   chan []byte
+	replies chan chan transformResult
+
+	// How long the command may take to answer a batch of lines.
+	timeout time.Duration
+	// The answers to the lines read ahead of the ones the readers parse.
+	cache transformCache
+
+	// Closed once the transformer failed, with err set to why.
+	done     chan struct{}
+	err      error
+	failOnce sync.Once
+}
+
+type transformResult struct {
+	line []byte
+	err  error
+}
+
+// newLineTransformer starts the given shell command to transform lines with,
+// until the context is done. Its failure is logged to the given logger.
+func newLineTransformer(ctx context.Context, command string, logger *logging.Logger) (*lineTransformer, error) {
+	cmd := shellCommand(command)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start transform command: %w", err)
+	}
+
+	t := &lineTransformer{
+		lines:   make(chan []byte, transformInFlight),
+		replies: make(chan chan transformResult, transformInFlight),
+		timeout: transformTimeout,
+		done:    make(chan struct{}),
+	}
+	go t.write(stdin)
+	go func() {
+		err := t.read(stdout)
+		if waitErr := cmd.Wait(); waitErr != nil && !errors.Is(err, errTransformClosed) {
+			err = waitErr
+		}
+		if !errors.Is(err, errTransformClosed) {
+			logger.Error("transform command stopped:", err.Error())
+		}
+	}()
+	context.AfterFunc(ctx, t.Close)
+	return t, nil
+}
+
+// Transform returns the lines as the command transformed them, in the same
+// order, with the ones it dropped empty. It fails if the context is done or the
+// command doesn't answer all of them in time.
+func (t *lineTransformer) Transform(ctx context.Context, lines [][]byte) ([][]byte, error) {
+	replies := make([]chan transformResult, len(lines))
+	for i := range replies {
+		replies[i] = make(chan transformResult, 1)
+	}
+	if err := t.send(lines, replies); err != nil {
+		return nil, err
+	}
+
+	timeout := time.NewTimer(t.timeout)
+	defer timeout.Stop()
+	answers := make([][]byte, len(lines))
+	for i, reply := range replies {
+		select {
+		case result := <-reply:
+			answers[i] = result.line
+		case <-t.done:
+			return nil, t.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-timeout.C:
+			return nil, errTransformTimeout
+		}
+	}
+	return answers, nil
+}
+
+// send queues the lines to be written to the command, and the replies to their
+// answers.
+func (t *lineTransformer) send(lines [][]byte, replies []chan transformResult) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for i, line := range lines {
+		// Lines are answered line by line, so newlines within them, which
+		// other delimiters allow, are sent as spaces.
+		line = bytes.ReplaceAll(line, []byte{'\n'}, []byte{' '})
+
+		select {
+		case t.replies <- replies[i]:
+		case <-t.done:
+			return t.err
+		}
+		select {
+		case t.lines <- line:
+		case <-t.done:
+			return t.err
+		}
+	}
+	return nil
+}
+
+// write writes the lines to the command's input until the transformer fails.
+func (t *lineTransformer) write(stdin io.WriteCloser) {
+	defer stdin.Close()
+
+	w := bufio.NewWriter(stdin)
+	for {
+		select {
+		case line := <-t.lines:
+			w.Write(line)
+			w.WriteByte('\n')
+			// Lines that queued up while writing are written along
+			// with this one.
+			if len(t.lines) == 0 {
+				if err := w.Flush(); err != nil {
+					// The command exited, which reading its
+					// output reports.
+					return
+				}
+			}
+		case <-t.done:
+			return
+		}
+	}
+}
+
+// read reads the command's answers and replies with them until its output
+// ends or the transformer fails, and returns why.
+func (t *lineTransformer) read(stdout io.Reader) error {
+	r := bufio.NewReader(stdout)
+	for {
+		line, err := r.ReadBytes('\n')
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				err = errors.New("transform command exited")
+			}
+			return t.fail(err)
+		}
+
+		select {
+		case reply := <-t.replies:
+			line = bytes.TrimSuffix(bytes.TrimSuffix(line, []byte{'\n'}), []byte{'\r'})
+			reply <- transformResult{line: line}
+		case <-t.done:
+			return t.err
+		}
+	}
+}
+
+// fail fails the lines waiting to be transformed, and the ones transformed
+// from now on, with the given error, unless the transformer already failed.
+// It returns the error it failed with.
+func (t *lineTransformer) fail(err error) error {
+	t.failOnce.Do(func() {
+		t.err = err
+		close(t.done)
+	})
+	return t.err
+}
+
+// Close stops the command, failing the lines that weren't transformed yet.
+func (t *lineTransformer) Close() {
+	t.fail(errTransformClosed)
+}
+
+// transformCache keeps what the transform command answered for the lines read
+// ahead of the ones the readers parse, by their positions. It keeps the last
+// two batches, so the forwards and backwards readers don't evict each other's.
+type transformCache struct {
+	mu      sync.Mutex
+	batches [2]map[int64]transformedLine
+	next    int
+}
+
+type transformedLine struct {
+	line, answer []byte
+}
+
+// get returns the answer to the given line at the given position, if it is
+// kept. Lines are compared too, since the input may have changed since.
+func (c *transformCache) get(pos int64, line []byte) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, batch := range c.batches {
+		if t, ok := batch[pos]; ok && bytes.Equal(t.line, line) {
+			return t.answer, true
+		}
+	}
+	return nil, false
+}
+
+// put keeps the answers to a batch of lines at the given positions, in place of
+// the oldest batch kept.
+func (c *transformCache) put(positions []int64, lines, answers [][]byte) {
+	batch := make(map[int64]transformedLine, len(positions))
+	for i, pos := range positions {
+		batch[pos] = transformedLine{line: lines[i], answer: answers[i]}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.batches[c.next] = batch
+	c.next = 1 - c.next
+}
+
+// transformLine returns the line at the given position as the transform command
+// transformed it, or false if the command dropped it. A line that wasn't
+// answered yet is transformed in one batch with the lines read after it, in
+// the direction of the reader, whose answers are then ready when it gets to
+// them.
+func (b *Buffer) transformLine(transform *lineTransformer, pos int64, line []byte, opts parseOptions) ([]byte, bool, error) {
+	if answer, ok := transform.cache.get(pos, line); ok {
+		return answer, len(answer) > 0, nil
+	}
+
+	positions, lines := linesAhead(pos, line, opts)
+	answers, err := transform.Transform(opts.ctx, lines)
+	if err != nil {
+		return nil, false, err
+	}
+	transform.cache.put(positions, lines, answers)
+	return answers[0], len(answers[0]) > 0, nil
+}
+
+// linesAhead returns the given line at the given position, followed by the
+// lines the reader that read it reads next, up to transformBatchSize of them.
+// Lines that were only partly read aren't parsed, so they are skipped.
+func linesAhead(pos int64, line []byte, opts parseOptions) ([]int64, [][]byte) {
+	positions, lines := []int64{pos}, [][]byte{bytes.Clone(line)}
+	if opts.input == nil {
+		return positions, lines
+	}
+
+	var scanner reader.LineScanner
+	if opts.backwards {
+		bkd, err := reader.NewBackwardsLineScanner(opts.input, pos, opts.scannerOptions...)
+		if err != nil {
+			return positions, lines
+		}
+		scanner = bkd
+	} else {
+		scanner = reader.NewForwardsLineScanner(opts.input, pos, opts.scannerOptions...)
+	}
+	defer scanner.Close()
+
+	for len(lines) < transformBatchSize && scanner.Scan() {
+		// The line at the position itself is the given one, or the empty
+		// rest of the line before it when reading backwards.
+		next := scanner.Pos()
+		if next == pos || scanner.Truncated() {
+			continue
+		}
+		positions = append(positions, next)
+		lines = append(lines, bytes.Clone(trimBOM(next, scanner.Bytes())))
+	}
+	return positions, lines
+}
+
+// disableTransform stops piping lines through the given transform command,
+// which failed, so records are shown as they are read rather than not at all.
+func (b *Buffer) disableTransform(transform *lineTransformer, err error) {
+	if !b.transform.CompareAndSwap(transform, nil) {
+		return
+	}
+	transform.Close()
+	b.logger.Named("transform").Error("transform command failed, showing records untransformed:", err.Error())
+	b.notify("transform command failed, showing records untransformed: "+err.Error(), true)
+}
@@ -0,0 +1,180 @@
+package view
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/YLivay/gote/logging"
+	"github.com/YLivay/gote/reader"
+	"github.com/gdamore/tcell/v2"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testTransform uppercases the lines it is given, and drops the ones that
+// mention "drop".
+const testTransform = `while IFS= read -r l; do case "$l" in *drop*) echo;; *) echo "$l" | tr a-z A-Z;; esac; done`
+
+func TestLineTransformer_TransformsAndDropsLines(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	transform, err := newLineTransformer(ctx, testTransform, logging.New(io.Discard, logging.Text, logging.Levels{}))
+	assert.NoError(t, err)
+
+	// Lines transformed concurrently are answered in the order they were
+	// written in.
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			line := []byte{'a' + byte(i)}
+			got, err := transform.Transform(ctx, [][]byte{line, line})
+			assert.NoError(t, err)
+			assert.EqualValues(t, [][]byte{bytes.ToUpper(line), bytes.ToUpper(line)}, got)
+		}(i)
+	}
+	wg.Wait()
+
+	// Dropped lines are answered with empty ones.
+	got, err := transform.Transform(ctx, [][]byte{[]byte("a"), []byte("please drop me"), []byte("b")})
+	assert.NoError(t, err)
+	assert.EqualValues(t, [][]byte{[]byte("A"), {}, []byte("B")}, got)
+
+	transform.Close()
+	_, err = transform.Transform(ctx, [][]byte{[]byte("a")})
+	assert.ErrorIs(t, err, errTransformClosed)
+}
+
+func TestLineTransformer_FailsWhenTheCommandExits(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	transform, err := newLineTransformer(ctx, "read -r l; echo $l", logging.New(io.Discard, logging.Text, logging.Levels{}))
+	assert.NoError(t, err)
+
+	got, err := transform.Transform(ctx, [][]byte{[]byte("a")})
+	assert.NoError(t, err)
+	assert.EqualValues(t, [][]byte{[]byte("a")}, got)
+
+	_, err = transform.Transform(ctx, [][]byte{[]byte("b")})
+	assert.Error(t, err)
+}
+
+func TestLineTransformer_GivesUpOnCommandsThatDontAnswer(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// sed buffers what it writes to a pipe, so it answers no line until its
+	// input ends.
+	transform, err := newLineTransformer(ctx, "sed s/a/b/", logging.New(io.Discard, logging.Text, logging.Levels{}))
+	assert.NoError(t, err)
+	defer transform.Close()
+	transform.timeout = 50 * time.Millisecond
+
+	_, err = transform.Transform(ctx, [][]byte{[]byte("a")})
+	assert.ErrorIs(t, err, errTransformTimeout)
+
+	// Nor is an answer waited for once the reader stopped.
+	transform.timeout = time.Minute
+	canceled, cancelTransform := context.WithCancel(ctx)
+	cancelTransform()
+	_, err = transform.Transform(canceled, [][]byte{[]byte("a")})
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestLinesAhead_ReadsTheLinesAfterTheOneParsed(t *testing.T) {
+	file, _ := createTestFile(t, "zero\none\ntwo\nthree\n")
+	opts := parseOptions{input: file, scannerOptions: []reader.Option{reader.WithDelimiter([]byte{'\n'})}}
+
+	positions, lines := linesAhead(5, []byte("one"), opts)
+	assert.EqualValues(t, []int64{5, 9, 13}, positions)
+	assert.EqualValues(t, [][]byte{[]byte("one"), []byte("two"), []byte("three")}, lines)
+
+	// The backwards reader reads the lines before it next.
+	opts.backwards = true
+	positions, lines = linesAhead(9, []byte("two"), opts)
+	assert.EqualValues(t, []int64{9, 5, 0}, positions)
+	assert.EqualValues(t, [][]byte{[]byte("two"), []byte("one"), []byte("zero")}, lines)
+}
+
+func TestBuffer_ShowsRecordsUntransformedWhenTheTransformFails(t *testing.T) {
+	line := `{"time":1700000000000,"name":"Pelecard","msg":"hi"}` + "\n"
+	file, _ := createTestFile(t, strings.Repeat(line, 3))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	buffer, err := NewBuffer(80, 10, false, file, ctx)
+	assert.NoError(t, err)
+	assert.NoError(t, buffer.SetTransform("sed s/hi/bye/"))
+	buffer.transform.Load().timeout = 50 * time.Millisecond
+	var notices []tcell.Event
+	var mu sync.Mutex
+	buffer.SetPostEventFunc(func(ev tcell.Event) error {
+		mu.Lock()
+		defer mu.Unlock()
+		notices = append(notices, ev)
+		return nil
+	})
+
+	assert.NoError(t, buffer.SeekAndPopulate(0, io.SeekStart))
+	assert.Eventually(t, func() bool { return buffer.Status().Records == 3 }, 10*time.Second, 5*time.Millisecond)
+	assert.Nil(t, buffer.transform.Load())
+	buffer.records.WithLock(func(records *bufferRecordList) any {
+		assert.Contains(t, string(records.ScreenTopRecord().buf), `"msg":"hi"`)
+		return true
+	})
+
+	mu.Lock()
+	defer mu.Unlock()
+	found := false
+	for _, ev := range notices {
+		if ev, ok := ev.(*tcell.EventInterrupt); ok {
+			if n, ok := ev.Data().(*notice); ok && strings.HasPrefix(n.text, "transform command failed") {
+				found = true
+			}
+		}
+	}
+	assert.True(t, found)
+}
+
+func TestRunHeadless_TransformsRecords(t *testing.T) {
+	line := `{"time":1700000000000,"name":"Pelecard","msg":"hi"}`
+	dropped := `{"time":1700000000000,"name":"Pelecard","msg":"drop"}`
+	file, _ := createTestFile(t, line+"\n"+dropped+"\n"+line+"\n")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// The transform runs before jq, which only selects the records it
+	// renamed.
+	var out bytes.Buffer
+	config := &Config{Filter: defaultFilter, Transform: `while IFS= read -r l; do case "$l" in *drop*) echo;; *) echo "$l" | sed s/Pelecard/pelecard-Pelecard/;; esac; done`}
+	err := RunHeadless(ctx, file, nil, config, io.Discard, &out)
+	assert.NoError(t, err)
+	record := `{"msg":"hi","name":"pelecard-Pelecard","time":"2023-11-14T22:13:20Z"}` + "\n"
+	assert.EqualValues(t, record+record, out.String())
+}
+
+func TestBuffer_TransformsTheLinesAheadInOneBatch(t *testing.T) {
+	line := `{"time":1700000000000,"name":"Pelecard","msg":"hi"}`
+	file, _ := createTestFile(t, strings.Repeat(line+"\n", 3))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	buffer, err := NewBuffer(80, 10, false, file, ctx)
+	assert.NoError(t, err)
+	assert.NoError(t, buffer.SetTransform(testTransform))
+
+	buffer.parseLine(0, []byte(line), buffer.parseOptions())
+	// The lines after the one parsed were answered along with it.
+	transform := buffer.transform.Load()
+	for _, pos := range []int64{int64(len(line) + 1), int64(2 * (len(line) + 1))} {
+		answer, ok := transform.cache.get(pos, []byte(line))
+		assert.True(t, ok)
+		assert.EqualValues(t, strings.ToUpper(line), answer)
+	}
+}
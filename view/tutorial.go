@@ -0,0 +1,98 @@
+package view
+
+import "fmt"
+
+// topicIntros hold a short explanation shown above a topic's key bindings in
+// the tutorial.
+var topicIntros = map[string]string{
+	topicScrolling:  "Move through the log. Records are loaded as you scroll.",
+	topicFiltering:  "Narrow down the log to the records you care about.",
+	topicSearch:     "Jump between records that match a pattern.",
+	topicFollowMode: "Keep the newest records on screen as they are written.",
+	topicDisplay:    "Change how records are laid out on screen.",
+	topicSelection:  "Select lines with the keyboard or by dragging the mouse, and copy them.",
+	topicScripts:    "Actions your Lua scripts added.",
+	topicGeneral:    "Everything else. You can always bring up the help screen.",
+}
+
+// tutorial is the first run guided overlay. It has a page for every topic that
+// has key bindings in the keymap.
+type tutorial struct {
+	pages   []tutorialPage
+	current int
+}
+
+type tutorialPage struct {
+	topic    string
+	bindings []keyBinding
+}
+
+func newTutorial(km keymap) *tutorial {
+	t := &tutorial{}
+	for _, topic := range topics {
+		bindings := km.byTopic(topic)
+		if len(bindings) == 0 {
+			continue
+		}
+		t.pages = append(t.pages, tutorialPage{topic: topic, bindings: bindings})
+	}
+	return t
+}
+
+// next moves to the next page. It returns false if there are no more pages.
+func (t *tutorial) next() bool {
+	if t.current+1 >= len(t.pages) {
+		return false
+	}
+	t.current++
+	return true
+}
+
+// prev moves to the previous page, if there is one.
+func (t *tutorial) prev() {
+	if t.current > 0 {
+		t.current--
+	}
+}
+
+func (t *tutorial) overlay() *overlay {
+	page := t.pages[t.current]
+
+	lines := []string{topicIntros[page.topic], ""}
+	lines = append(lines, describeBindings(page.bindings)...)
+
+	footer := "Enter: next  Left: back  Esc: skip tutorial"
+	if t.current == len(t.pages)-1 {
+		footer = "Enter: start  Left: back  Esc: skip tutorial"
+	}
+
+	return &overlay{
+		title:  fmt.Sprintf("Welcome to gote (%d/%d): %s", t.current+1, len(t.pages), page.topic),
+		lines:  lines,
+		footer: footer,
+	}
+}
+
+// helpOverlay lists all the key bindings of the given keymap grouped by topic.
+func helpOverlay(km keymap) *overlay {
+	var lines []string
+	for _, topic := range topics {
+		bindings := km.byTopic(topic)
+		if len(bindings) == 0 {
+			continue
+		}
+		if len(lines) > 0 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, topic)
+		lines = append(lines, describeBindings(bindings)...)
+	}
+	lines = append(lines, "", "Commands")
+	lines = append(lines, describeCommands()...)
+
+	return &overlay{
+		title:  "Key bindings",
+		lines:  lines,
+		footer: "Esc: close",
+	}
+}
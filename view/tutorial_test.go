@@ -0,0 +1,41 @@
+package view
+
+import (
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTutorial_SkipsTopicsWithoutBindings(t *testing.T) {
+	km := keymap{
+		{key: tcell.KeyUp, action: actionScrollUp, topic: topicScrolling, description: "Up"},
+		{key: tcell.KeyRune, ch: 'q', action: actionQuit, topic: topicGeneral, description: "Quit"},
+	}
+
+	tut := newTutorial(km)
+	assert.Len(t, tut.pages, 2)
+	assert.EqualValues(t, topicScrolling, tut.pages[0].topic)
+	assert.EqualValues(t, topicGeneral, tut.pages[1].topic)
+}
+
+func TestTutorial_NavigatesPages(t *testing.T) {
+	tut := newTutorial(defaultKeymap)
+
+	tut.prev()
+	assert.EqualValues(t, 0, tut.current)
+
+	for i := 1; i < len(tut.pages); i++ {
+		assert.True(t, tut.next())
+	}
+	assert.False(t, tut.next())
+	assert.EqualValues(t, len(tut.pages)-1, tut.current)
+}
+
+func TestTutorial_OverlayListsBindings(t *testing.T) {
+	tut := newTutorial(defaultKeymap)
+
+	o := tut.overlay()
+	assert.Contains(t, o.title, topicScrolling)
+	assert.Contains(t, o.lines, "Up    Scroll up one line")
+}
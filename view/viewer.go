@@ -0,0 +1,129 @@
+package view
+
+import (
+	"context"
+	"io"
+	"os"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// Viewer is a pane of a tcell application that shows the records of a log
+// file the way gote does, and that reacts to keys and the mouse the way it
+// does. Its methods must be called from the goroutine that handles the
+// screen's events.
+type Viewer struct {
+	app    *Application
+	region *regionScreen
+}
+
+// DefaultConfig returns the configuration the gote command has when it is
+// given no options, except that the first run tutorial is never shown and
+// records are shown as they are, unfiltered.
+func DefaultConfig() *Config {
+	config, err := ParseFlags(nil, io.Discard)
+	if err != nil {
+		panic("invalid default configuration: " + err.Error())
+	}
+	config.NoTutorial = true
+	config.Filter = "."
+	return config
+}
+
+// NewViewer returns a viewer of the given file, which follows records as they
+// are appended to it if follow is true. If config is nil, DefaultConfig is
+// used. The records are filtered with config.Filter from the start.
+func NewViewer(file *os.File, follow bool, config *Config) *Viewer {
+	if config == nil {
+		config = DefaultConfig()
+	}
+	return &Viewer{app: NewApplication(file, follow, config)}
+}
+
+// Start draws the viewer in the given region of the screen, and starts reading
+// the file until the context is done. The screen must be initialized, and
+// shown by the caller after Start and HandleEvent.
+func (v *Viewer) Start(ctx context.Context, screen Screen, x, y, width, height int) error {
+	v.region = &regionScreen{Screen: screen, x: x, y: y, width: width, height: height}
+	return v.app.setup(ctx, v.region)
+}
+
+// Buffer returns the buffer the viewer reads the file with, which hooks can be
+// registered on. It is nil until Start is called, which starts reading, so
+// the records read before hooks are registered aren't given to them.
+func (v *Viewer) Buffer() *Buffer {
+	return v.app.buffer
+}
+
+// SetRegion moves the viewer to the given region of the screen, or resizes
+// it, and draws it there.
+func (v *Viewer) SetRegion(x, y, width, height int) {
+	v.region.x, v.region.y = x, y
+	if width != v.app.width || height != v.app.height {
+		v.region.width, v.region.height = width, height
+		v.app.resize(width, height)
+	}
+	v.app.render()
+}
+
+// HandleEvent handles an event the screen received. Key events are taken as
+// the viewer's input, so only the ones meant for it should be handed over.
+// Mouse events outside of its region, and resize events, which are handled by
+// calling SetRegion instead, are ignored. It returns false if the user asked
+// to quit the viewer.
+func (v *Viewer) HandleEvent(ev tcell.Event) bool {
+	switch ev := ev.(type) {
+	case *tcell.EventResize:
+		return true
+	case *tcell.EventMouse:
+		x, y := ev.Position()
+		if !v.region.contains(x, y) {
+			return true
+		}
+		return v.app.handleEvent(tcell.NewEventMouse(x-v.region.x, y-v.region.y, ev.Buttons(), ev.Modifiers()))
+	}
+	return v.app.handleEvent(ev)
+}
+
+// regionScreen is a region of a screen that is drawn on as if it was the whole
+// screen.
+type regionScreen struct {
+	Screen
+	x, y, width, height int
+}
+
+func (s *regionScreen) contains(x, y int) bool {
+	return x >= s.x && x < s.x+s.width && y >= s.y && y < s.y+s.height
+}
+
+func (s *regionScreen) Size() (int, int) {
+	return s.width, s.height
+}
+
+func (s *regionScreen) SetContent(x, y int, primary rune, combining []rune, style tcell.Style) {
+	if x < 0 || y < 0 || x >= s.width || y >= s.height {
+		return
+	}
+	s.Screen.SetContent(s.x+x, s.y+y, primary, combining, style)
+}
+
+func (s *regionScreen) GetContent(x, y int) (rune, []rune, tcell.Style, int) {
+	return s.Screen.GetContent(s.x+x, s.y+y)
+}
+
+func (s *regionScreen) ShowCursor(x, y int) {
+	s.Screen.ShowCursor(s.x+x, s.y+y)
+}
+
+// Clear clears only the region.
+func (s *regionScreen) Clear() {
+	s.Fill(' ', tcell.StyleDefault)
+}
+
+func (s *regionScreen) Fill(ch rune, style tcell.Style) {
+	for y := 0; y < s.height; y++ {
+		for x := 0; x < s.width; x++ {
+			s.SetContent(x, y, ch, nil, style)
+		}
+	}
+}
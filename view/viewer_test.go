@@ -0,0 +1,86 @@
+package view
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+// screenText returns the text of the given row of the screen, between the
+// given columns.
+func screenText(screen interface {
+	GetContent(x, y int) (rune, []rune, tcell.Style, int)
+}, y, from, to int) string {
+	var text strings.Builder
+	for x := from; x < to; x++ {
+		ch, _, _, _ := screen.GetContent(x, y)
+		text.WriteRune(ch)
+	}
+	return text.String()
+}
+
+func TestViewer_DrawsInItsRegion(t *testing.T) {
+	file, _ := createTestFile(t, `{"time":1700000000000,"name":"Pelecard","msg":"hi"}`+"\n")
+
+	ctx, cancelCtx := context.WithCancel(context.Background())
+	defer cancelCtx()
+	screen := tcell.NewSimulationScreen("UTF-8")
+	assert.NoError(t, screen.Init())
+	defer screen.Fini()
+	screen.SetSize(80, 25)
+	screen.SetContent(0, 0, '#', nil, tcell.StyleDefault)
+
+	v := NewViewer(file, false, nil)
+	assert.NoError(t, v.Start(ctx, screen, 10, 5, 40, 10))
+	assert.Eventually(t, func() bool {
+		v.HandleEvent(tcell.NewEventInterrupt(nil))
+		return strings.HasPrefix(screenText(screen, 5, 10, 50), `{"time":`)
+	}, time.Second, 5*time.Millisecond)
+
+	// The rest of the screen is left to the application embedding it.
+	assert.EqualValues(t, "#", screenText(screen, 0, 0, 1))
+	assert.EqualValues(t, strings.Repeat(" ", 10), screenText(screen, 5, 0, 10))
+
+	// Moving the viewer draws it in its new region.
+	v.SetRegion(0, 15, 40, 10)
+	assert.True(t, strings.HasPrefix(screenText(screen, 15, 0, 40), `{"time":`))
+
+	assert.False(t, v.HandleEvent(tcell.NewEventKey(tcell.KeyRune, 'q', tcell.ModNone)))
+}
+
+func TestViewer_FiltersRecordsWithItsConfig(t *testing.T) {
+	file, _ := createTestFile(t, `{"name":"api","msg":"first"}`+"\n"+`{"name":"db","msg":"second"}`+"\n")
+
+	ctx, cancelCtx := context.WithCancel(context.Background())
+	defer cancelCtx()
+	screen := tcell.NewSimulationScreen("UTF-8")
+	assert.NoError(t, screen.Init())
+	defer screen.Fini()
+	screen.SetSize(80, 25)
+
+	// By default, all of the records are shown as they are.
+	v := NewViewer(file, false, nil)
+	assert.NoError(t, v.Start(ctx, screen, 0, 0, 80, 10))
+	assert.Eventually(t, func() bool {
+		v.HandleEvent(tcell.NewEventInterrupt(nil))
+		return strings.HasPrefix(screenText(screen, 1, 0, 80), `{"name":"db","msg":"second"}`)
+	}, 5*time.Second, 5*time.Millisecond)
+	assert.True(t, strings.HasPrefix(screenText(screen, 0, 0, 80), `{"name":"api","msg":"first"}`))
+
+	config := DefaultConfig()
+	config.Filter = `select(.name == "db") | .msg`
+	v = NewViewer(file, false, config)
+	assert.NoError(t, v.Start(ctx, screen, 0, 10, 80, 10))
+	assert.Eventually(t, func() bool {
+		v.HandleEvent(tcell.NewEventInterrupt(nil))
+		return strings.HasPrefix(screenText(screen, 10, 0, 80), `"second"`)
+	}, 5*time.Second, 5*time.Millisecond)
+	assert.EqualValues(t, config.Filter, v.Buffer().Status().Filter)
+
+	config.Filter = "select("
+	assert.Error(t, NewViewer(file, false, config).Start(ctx, screen, 0, 0, 80, 10))
+}
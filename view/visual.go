@@ -0,0 +1,113 @@
+package view
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// startVisual starts selecting whole screen lines from the line under the
+// cursor, or from the top of the screen if there is no cursor.
+func (a *Application) startVisual() {
+	y := 0
+	if a.selection != nil {
+		y = a.selection.endY
+	}
+	y = min(max(y, 0), max(a.viewHeight()-1, 0))
+
+	a.visual = true
+	a.dragging = false
+	a.selection = &selection{startY: y}
+	a.extendVisual(y)
+}
+
+// extendVisual moves the end of the line selection to the given screen line,
+// keeping the selection within the screen.
+func (a *Application) extendVisual(y int) {
+	s := a.selection
+	s.endY = min(max(y, 0), max(a.viewHeight()-1, 0))
+
+	// Whole lines are selected, so the selection spans from the first column
+	// of its first line to the last column of its last line.
+	lastX := max(a.viewWidth()-1, 0)
+	if s.endY < s.startY {
+		s.startX, s.endX = lastX, 0
+	} else {
+		s.startX, s.endX = 0, lastX
+	}
+}
+
+// handleVisualKey handles the keys that behave differently while selecting
+// lines. It returns false if the key should be handled as usual.
+func (a *Application) handleVisualKey(ev *tcell.EventKey, act action) bool {
+	switch {
+	case act == actionScrollUp:
+		a.extendVisual(a.selection.endY - 1)
+	case act == actionScrollDown:
+		a.extendVisual(a.selection.endY + 1)
+	case act == actionPageUp:
+		a.extendVisual(0)
+	case act == actionPageDown:
+		a.extendVisual(a.viewHeight() - 1)
+	case act == actionVisualSelect || ev.Key() == tcell.KeyEscape:
+		a.clearSelection()
+	default:
+		return false
+	}
+	return true
+}
+
+// yank copies the selected text to the clipboard and reports the outcome in
+// the status bar.
+func (a *Application) yank() {
+	text := a.selectedText()
+	if text == "" {
+		a.message = "nothing selected"
+		return
+	}
+
+	if err := copyToClipboard(text, a.config.ClipboardCommand); err != nil {
+		a.notifyError("copy failed: " + err.Error())
+		return
+	}
+
+	lines := strings.Count(text, "\n") + 1
+	if lines == 1 {
+		a.notify("copied 1 line")
+	} else {
+		a.notify(fmt.Sprintf("copied %d lines", lines))
+	}
+	a.clearSelection()
+}
+
+// yankRecord copies the original line of the record under the cursor to the
+// clipboard, as it is in the input file rather than as it is displayed.
+func (a *Application) yankRecord() {
+	r := a.recordUnderCursor()
+	if r == nil || r.raw == nil {
+		a.message = "no record to copy"
+		return
+	}
+
+	raw, err := a.rawLine(r)
+	if err != nil {
+		a.notifyError("reading the whole record failed: " + err.Error())
+		return
+	}
+
+	if err := copyToClipboard(string(raw), a.config.ClipboardCommand); err != nil {
+		a.notifyError("copy failed: " + err.Error())
+		return
+	}
+	a.notify("copied the record")
+}
+
+// rawLine returns the original line of the given record, as it is in the input
+// file, including the part of a long line the record doesn't hold.
+func (a *Application) rawLine(r *record) ([]byte, error) {
+	if r.fullLen > 0 {
+		return a.buffer.ReadFullLine(r)
+	}
+	return r.raw, nil
+}
@@ -0,0 +1,62 @@
+package view
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVisual_SelectsWholeLinesInEitherDirection(t *testing.T) {
+	a := &Application{width: 41, height: 11}
+	a.selection = &selection{startX: 5, startY: 4, endX: 5, endY: 4}
+
+	a.startVisual()
+	assert.True(t, a.visual)
+	a.extendVisual(6)
+	x1, y1, x2, y2 := a.selection.ordered()
+	assert.EqualValues(t, []int{0, 4, 39, 6}, []int{x1, y1, x2, y2})
+
+	a.extendVisual(2)
+	x1, y1, x2, y2 = a.selection.ordered()
+	assert.EqualValues(t, []int{0, 2, 39, 4}, []int{x1, y1, x2, y2})
+}
+
+func TestVisual_StaysOnScreen(t *testing.T) {
+	a := &Application{width: 41, height: 11}
+
+	a.startVisual()
+	a.extendVisual(-3)
+	assert.EqualValues(t, 0, a.selection.endY)
+	a.extendVisual(50)
+	assert.EqualValues(t, 9, a.selection.endY)
+}
+
+func TestYankRecord_CopiesTheOriginalLine(t *testing.T) {
+	line := `{"time":1700000000000, "name":"Pelecard", "msg":"hi", "extra":1}`
+	file, _ := createTestFile(t, line+"\n")
+	path := filepath.Join(t.TempDir(), "clipboard")
+
+	ctx, cancelCtx := context.WithCancel(context.Background())
+	defer cancelCtx()
+	screen := tcell.NewSimulationScreen("UTF-8")
+	assert.NoError(t, screen.Init())
+	defer screen.Fini()
+	screen.SetSize(80, 25)
+
+	a := NewApplication(file, false, &Config{NoTutorial: true, ClipboardCommand: "tee " + path})
+	assert.NoError(t, a.setup(ctx, screen))
+	assert.Eventually(t, func() bool { return a.buffer.records.RecordAtScreenLine(0) != nil }, time.Second, 5*time.Millisecond)
+
+	// The record is displayed as the filter transformed it, but the line it
+	// was read from is copied.
+	a.handleEvent(tcell.NewEventKey(tcell.KeyRune, 'Y', tcell.ModNone))
+	assert.EqualValues(t, "copied the record", noticeText(a))
+	copied, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.EqualValues(t, line, string(copied))
+}
@@ -0,0 +1,88 @@
+package view
+
+import (
+	"context"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// followPollInterval is how often a followed file is checked for new lines
+// when there are no notifications of it changing. With notifications it is
+// only a safety net for ones that get lost.
+const followPollInterval = time.Second
+
+// fileWatcher waits for a followed file to be written to. It is notified of
+// writes by the OS where the file system supports it, and otherwise falls back
+// to polling.
+type fileWatcher struct {
+	// Signaled when the file was written to, or another file was created at
+	// its path, since the last wait. It is nil if
+	// notifications are not available and the watcher polls instead.
+	changed chan struct{}
+}
+
+// newFileWatcher watches the file with the given name until the context is
+// done. If notifications can't be set up, the returned watcher polls, and the
+// reason is returned along with it.
+//
+// The file's directory is watched too, so the watcher also wakes up when
+// another file is created or moved to its path, like when logs are rotated.
+func newFileWatcher(ctx context.Context, name string) (*fileWatcher, error) {
+	w := &fileWatcher{}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return w, err
+	}
+	name = filepath.Clean(name)
+	if err := watcher.Add(name); err != nil {
+		watcher.Close()
+		return w, err
+	}
+	// Changes to the path are only a hint, so the file's own notifications
+	// are enough if its directory can't be watched.
+	_ = watcher.Add(filepath.Dir(name))
+
+	w.changed = make(chan struct{}, 1)
+	go func() {
+		defer watcher.Close()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case ev, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				// The directory reports changes to the other files in it too.
+				if ev.Name != name || !ev.Has(fsnotify.Write) && !ev.Has(fsnotify.Create) {
+					continue
+				}
+				// Collapse changes that happen before the next wait into one.
+				select {
+				case w.changed <- struct{}{}:
+				default:
+				}
+			case _, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+			}
+		}
+	}()
+
+	return w, nil
+}
+
+// wait blocks until the file may have new data to read, or the context is
+// done.
+func (w *fileWatcher) wait(ctx context.Context) {
+	select {
+	case <-w.changed:
+	case <-time.After(followPollInterval):
+	case <-ctx.Done():
+	}
+}
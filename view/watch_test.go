@@ -0,0 +1,42 @@
+package view
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileWatcher_WakesUpOnWrite(t *testing.T) {
+	file, _ := createTestFile(t, "hello\n")
+
+	ctx, cancelCtx := context.WithCancel(context.Background())
+	defer cancelCtx()
+
+	w, err := newFileWatcher(ctx, file.Name())
+	assert.NoError(t, err)
+
+	done := make(chan struct{})
+	go func() {
+		w.wait(ctx)
+		close(done)
+	}()
+
+	appendToTestFile(t, file, "you\n")
+
+	select {
+	case <-done:
+	case <-time.After(followPollInterval / 2):
+		t.Fatal("watcher did not wake up before the poll interval")
+	}
+}
+
+func TestFileWatcher_PollsWithoutNotifications(t *testing.T) {
+	w, err := newFileWatcher(context.Background(), "does-not-exist")
+	assert.Error(t, err)
+
+	start := time.Now()
+	w.wait(context.Background())
+	assert.GreaterOrEqual(t, time.Since(start), followPollInterval)
+}